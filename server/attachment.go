@@ -0,0 +1,51 @@
+package server
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxAttachments is the maximum number of attachments of a ToDo
+const MaxAttachments = 20
+
+// Attachment is a file attached to a ToDo, its content is kept in a blob store
+type Attachment struct {
+	ID          string    `json:"id" schema:"readonly"`
+	Name        string    `json:"name" schema:"required,minLength=1,maxLength=255"`
+	ContentType string    `json:"contentType" schema:"required,minLength=1,maxLength=255"`
+	Size        int64     `json:"size" schema:"required,minimum=1"`
+	Created     time.Time `json:"created" schema:"readonly"`
+}
+
+// AttachmentLimits restricts the files users can attach to ToDos
+type AttachmentLimits struct {
+	// MaxSize is the maximum size of a file in bytes
+	MaxSize int64
+	// AllowedTypes are the accepted MIME types, a type such as image/* accepts all its subtypes
+	AllowedTypes []string
+}
+
+// Allows reports whether a file of the given MIME type is accepted
+func (l AttachmentLimits) Allows(contentType string) bool {
+	for _, t := range l.AllowedTypes {
+		if t == contentType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Attachment returns the attachment with the given ID, or nil if the ToDo has no such attachment
+func (t *ToDo) Attachment(id string) *Attachment {
+	for i := range t.Attachments {
+		if t.Attachments[i].ID == id {
+			return &t.Attachments[i]
+		}
+	}
+	return nil
+}
+
+// BlobKey returns the key of the content of an attachment of the ToDo in the blob store
+func (t *ToDo) BlobKey(attachmentID string) string {
+	return t.ID + "/" + attachmentID
+}
@@ -0,0 +1,209 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server/auth"
+	"github.com/pkg/errors"
+)
+
+const (
+	testUser   = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+	testClient = "client"
+	testKid    = "key"
+)
+
+func TestAuth(t *testing.T) {
+	t.Run("Principal", testPrincipal)
+	t.Run("VerifyToken", testVerifyToken)
+	t.Run("VerifyInvalidToken", testVerifyInvalidToken)
+	t.Run("AuthorizeConnection", testAuthorizeConnection)
+	t.Run("AuthorizeWithoutToken", testAuthorizeWithoutToken)
+}
+
+func testPrincipal(t *testing.T) {
+
+	for _, c := range []struct {
+		authorizer map[string]interface{}
+		want       string
+	}{
+		{map[string]interface{}{"principalId": testUser}, testUser},
+		{map[string]interface{}{"claims": map[string]interface{}{"sub": testUser}}, testUser},
+		{map[string]interface{}{"principalId": ""}, ""},
+		{nil, ""},
+	} {
+		if got := auth.Principal(c.authorizer); got != c.want {
+			t.Fatalf("Expected principal %q for %v, got %q", c.want, c.authorizer, got)
+		}
+	}
+}
+
+// pool is a user pool serving the keys its tokens are signed with
+type pool struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newPool(t *testing.T) *pool {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &pool{key: key}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/.well-known/jwks.json" {
+			http.NotFound(w, req)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": testKid,
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+
+	return p
+}
+
+// token returns a token signed by the pool, with the given claims overriding the ones of a
+// valid ID token
+func (p *pool) token(t *testing.T, kid string, overrides map[string]interface{}) string {
+
+	claims := map[string]interface{}{
+		"sub":       testUser,
+		"iss":       p.URL,
+		"aud":       testClient,
+		"token_use": "id",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range overrides {
+		claims[k] = v
+	}
+
+	encode := func(v interface{}) string {
+		js, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(js)
+	}
+
+	signed := encode(map[string]string{"alg": "RS256", "kid": kid}) + "." + encode(claims)
+
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (p *pool) verifier() *auth.Verifier {
+	return auth.NewVerifier(p.URL, testClient, p.Client())
+}
+
+func testVerifyToken(t *testing.T) {
+
+	p := newPool(t)
+	defer p.Close()
+
+	v := p.verifier()
+
+	for _, token := range []string{
+		p.token(t, testKid, nil),
+		p.token(t, testKid, map[string]interface{}{"token_use": "access", "aud": nil, "client_id": testClient}),
+	} {
+		sub, err := v.Verify(token, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sub != testUser {
+			t.Fatalf("Expected user %s, got %s", testUser, sub)
+		}
+	}
+}
+
+func testVerifyInvalidToken(t *testing.T) {
+
+	p := newPool(t)
+	defer p.Close()
+
+	other := newPool(t)
+	defer other.Close()
+
+	v := p.verifier()
+
+	valid := p.token(t, testKid, nil)
+	parts := strings.Split(valid, ".")
+
+	for name, token := range map[string]string{
+		"expired":        p.token(t, testKid, map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}),
+		"other client":   p.token(t, testKid, map[string]interface{}{"aud": "other"}),
+		"other issuer":   p.token(t, testKid, map[string]interface{}{"iss": other.URL}),
+		"refresh token":  p.token(t, testKid, map[string]interface{}{"token_use": "refresh"}),
+		"unknown key":    p.token(t, "other", nil),
+		"other pool key": other.token(t, testKid, map[string]interface{}{"iss": p.URL}),
+		"tampered":       parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"other"}`)) + "." + parts[2],
+		"malformed":      "not-a-token",
+		"empty":          "",
+	} {
+		if _, err := v.Verify(token, time.Now()); errors.Cause(err) != auth.ErrInvalidToken {
+			t.Fatalf("Expected %v for the %s token, got %v", auth.ErrInvalidToken, name, err)
+		}
+	}
+}
+
+func testAuthorizeConnection(t *testing.T) {
+
+	p := newPool(t)
+	defer p.Close()
+
+	req := events.APIGatewayCustomAuthorizerRequestTypeRequest{
+		MethodArn:             "arn:aws:execute-api:us-west-2:123456789012:api/dev/$connect",
+		QueryStringParameters: map[string]string{auth.TokenParameter: p.token(t, testKid, nil)},
+	}
+
+	resp, err := p.verifier().Authorize(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.PrincipalID != testUser {
+		t.Fatalf("Expected principal %s, got %s", testUser, resp.PrincipalID)
+	}
+
+	s := resp.PolicyDocument.Statement
+	if len(s) != 1 || s[0].Effect != "Allow" || s[0].Resource[0] != req.MethodArn {
+		t.Fatalf("Expected the connection to be allowed, got %+v", resp.PolicyDocument)
+	}
+}
+
+func testAuthorizeWithoutToken(t *testing.T) {
+
+	p := newPool(t)
+	defer p.Close()
+
+	_, err := p.verifier().Authorize(events.APIGatewayCustomAuthorizerRequestTypeRequest{})
+	if err == nil || err.Error() != "Unauthorized" {
+		t.Fatalf("Expected Unauthorized, got %v", err)
+	}
+}
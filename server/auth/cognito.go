@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidToken is returned when a token is malformed, expired, or not signed by the user pool
+var ErrInvalidToken = errors.New("invalid token")
+
+// errUnauthorized is the error a Lambda authorizer returns for API Gateway to respond with 401
+var errUnauthorized = errors.New("Unauthorized")
+
+// TokenParameter is the query string parameter WebSocket clients send their token in, browsers
+// not being able to set the headers of a WebSocket request
+const TokenParameter = "token"
+
+// Verifier verifies the ID and access tokens issued by a Cognito user pool to one of its app
+// clients. The signing keys of the user pool are fetched the first time a token signed with an
+// unknown key is verified.
+type Verifier struct {
+	issuer   string
+	clientID string
+	client   *http.Client
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+}
+
+// NewVerifier returns a verifier of the tokens of a user pool, whose issuer is
+// https://cognito-idp.<region>.amazonaws.com/<user pool ID>, issued to the given app client
+func NewVerifier(issuer, clientID string, client *http.Client) *Verifier {
+	return &Verifier{
+		issuer:   issuer,
+		clientID: clientID,
+		client:   client,
+		keys:     map[string]*rsa.PublicKey{},
+	}
+}
+
+// claims are the claims of a Cognito token checked by the verifier
+type claims struct {
+	Subject  string `json:"sub"`
+	Issuer   string `json:"iss"`
+	Expires  int64  `json:"exp"`
+	TokenUse string `json:"token_use"`
+	// Audience is the app client of an ID token
+	Audience string `json:"aud"`
+	// ClientID is the app client of an access token
+	ClientID string `json:"client_id"`
+}
+
+// Verify checks the signature, issuer, app client and expiry of a token, and returns the ID of
+// the user it was issued to
+func (v *Verifier) Verify(token string, now time.Time) (string, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.Wrap(ErrInvalidToken, "not a JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodePart(parts[0], &header); err != nil {
+		return "", err
+	}
+
+	if header.Alg != "RS256" {
+		return "", errors.Wrapf(ErrInvalidToken, "unexpected algorithm %s", header.Alg)
+	}
+
+	key, err := v.key(header.Kid)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.Wrap(ErrInvalidToken, "malformed signature")
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return "", errors.Wrap(ErrInvalidToken, "bad signature")
+	}
+
+	var c claims
+	if err := decodePart(parts[1], &c); err != nil {
+		return "", err
+	}
+
+	client := c.Audience
+	if c.TokenUse == "access" {
+		client = c.ClientID
+	}
+
+	switch {
+	case c.Issuer != v.issuer:
+		return "", errors.Wrapf(ErrInvalidToken, "unexpected issuer %s", c.Issuer)
+	case c.TokenUse != "id" && c.TokenUse != "access":
+		return "", errors.Wrapf(ErrInvalidToken, "unexpected token use %s", c.TokenUse)
+	case client != v.clientID:
+		return "", errors.Wrapf(ErrInvalidToken, "issued to client %s", client)
+	case now.Unix() >= c.Expires:
+		return "", errors.Wrap(ErrInvalidToken, "expired")
+	case c.Subject == "":
+		return "", errors.Wrap(ErrInvalidToken, "no subject")
+	}
+
+	return c.Subject, nil
+}
+
+// decodePart decodes the header or the claims of a token
+func decodePart(part string, v interface{}) error {
+
+	js, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.Wrap(ErrInvalidToken, "malformed encoding")
+	}
+
+	if err := json.Unmarshal(js, v); err != nil {
+		return errors.Wrap(ErrInvalidToken, "malformed JSON")
+	}
+
+	return nil
+}
+
+// key returns a signing key of the user pool, fetching the keys if it is not known yet
+func (v *Verifier) key(kid string) (*rsa.PublicKey, error) {
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if k, ok := v.keys[kid]; ok {
+		return k, nil
+	}
+
+	if err := v.fetchKeys(); err != nil {
+		return nil, err
+	}
+
+	if k, ok := v.keys[kid]; ok {
+		return k, nil
+	}
+
+	return nil, errors.Wrapf(ErrInvalidToken, "unknown key %s", kid)
+}
+
+// fetchKeys reads the JSON Web Key Set of the user pool
+func (v *Verifier) fetchKeys() error {
+
+	resp, err := v.client.Get(v.issuer + "/.well-known/jwks.json")
+	if err != nil {
+		return errors.Wrap(err, "Could not fetch the keys of the user pool")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("Could not fetch the keys of the user pool: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return errors.Wrap(err, "Could not decode the keys of the user pool")
+	}
+
+	for _, k := range set.Keys {
+
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return errors.Wrapf(err, "Could not decode key %s", k.Kid)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return errors.Wrapf(err, "Could not decode key %s", k.Kid)
+		}
+
+		v.keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return nil
+}
+
+// Authorize is a Lambda REQUEST authorizer allowing the requests carrying a valid token in
+// their TokenParameter query string parameter. Cognito authorizers are not supported by
+// WebSocket APIs, which use it to authenticate connections.
+func (v *Verifier) Authorize(req events.APIGatewayCustomAuthorizerRequestTypeRequest) (events.APIGatewayCustomAuthorizerResponse, error) {
+
+	sub, err := v.Verify(req.QueryStringParameters[TokenParameter], time.Now())
+	if errors.Cause(err) == ErrInvalidToken {
+		return events.APIGatewayCustomAuthorizerResponse{}, errUnauthorized
+	} else if err != nil {
+		return events.APIGatewayCustomAuthorizerResponse{}, err
+	}
+
+	return events.APIGatewayCustomAuthorizerResponse{
+		PrincipalID: sub,
+		PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+			Version: "2012-10-17",
+			Statement: []events.IAMPolicyStatement{
+				{
+					Action:   []string{"execute-api:Invoke"},
+					Effect:   "Allow",
+					Resource: []string{req.MethodArn},
+				},
+			},
+		},
+	}, nil
+}
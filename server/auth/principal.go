@@ -0,0 +1,22 @@
+// Package auth identifies the users of the API from the context of the API Gateway authorizers,
+// and verifies the Cognito tokens of the clients which cannot use a Cognito authorizer
+package auth
+
+// Principal returns the ID of the user authenticated by an API Gateway authorizer, given the
+// authorizer context of the request, or an empty string if the request is not authenticated.
+// Both Lambda authorizers (principalId) and Cognito user pool authorizers (claims.sub) are
+// supported.
+func Principal(authorizer map[string]interface{}) string {
+
+	if id, ok := authorizer["principalId"].(string); ok && id != "" {
+		return id
+	}
+
+	if claims, ok := authorizer["claims"].(map[string]interface{}); ok {
+		if sub, ok := claims["sub"].(string); ok {
+			return sub
+		}
+	}
+
+	return ""
+}
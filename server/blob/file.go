@@ -0,0 +1,202 @@
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FileStore stores blobs in a local directory, for development. Like presigned S3 URLs, the URLs
+// it issues are signed and expire, and they are served by the store itself.
+type FileStore struct {
+	dir     string
+	baseURL string
+	secret  []byte
+	expiry  time.Duration
+}
+
+// NewFileStore returns a blob store writing to dir, issuing URLs under baseURL, which must be
+// routed to the ServeHTTP method of the store
+func NewFileStore(dir, baseURL string, secret []byte, expiry time.Duration) *FileStore {
+	return &FileStore{dir, strings.TrimSuffix(baseURL, "/"), secret, expiry}
+}
+
+// UploadURL returns a signed URL accepting a PUT request with the given content type and length
+func (s *FileStore) UploadURL(key, contentType string, size int64) (string, error) {
+	return s.sign(http.MethodPut, key, url.Values{
+		"type": {contentType},
+		"size": {strconv.FormatInt(size, 10)},
+	})
+}
+
+// DownloadURL returns a signed URL serving the blob as an attachment
+func (s *FileStore) DownloadURL(key, name string) (string, error) {
+	return s.sign(http.MethodGet, key, url.Values{"name": {name}})
+}
+
+// Put writes a blob
+func (s *FileStore) Put(key, contentType string, content []byte) error {
+
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return errors.Wrapf(err, "Could not create directory of blob %s", key)
+	}
+
+	if err := ioutil.WriteFile(p, content, 0600); err != nil {
+		return errors.Wrapf(err, "Could not write blob %s", key)
+	}
+
+	return nil
+}
+
+// Delete removes a blob, blobs which were never uploaded are ignored
+func (s *FileStore) Delete(key string) error {
+
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "Could not delete blob %s", key)
+	}
+
+	return nil
+}
+
+// ServeHTTP uploads and downloads blobs with the URLs issued by the store
+func (s *FileStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	q := r.URL.Query()
+
+	if !s.valid(r.Method, key, q) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+
+	p, err := s.path(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.put(w, r, p, q)
+	case http.MethodGet:
+		w.Header().Set("Content-Disposition", contentDisposition(q.Get("name")))
+		http.ServeFile(w, r, p)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// put writes the body of an upload request, which must match the signed type and size
+func (s *FileStore) put(w http.ResponseWriter, r *http.Request, p string, q url.Values) {
+
+	size, _ := strconv.ParseInt(q.Get("size"), 10, 64)
+
+	if r.Header.Get("Content-Type") != q.Get("type") {
+		http.Error(w, "content type does not match the signed type", http.StatusForbidden)
+		return
+	}
+
+	if r.ContentLength != size {
+		http.Error(w, "content length does not match the signed size", http.StatusForbidden)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	if n, err := io.Copy(f, io.LimitReader(r.Body, size)); err != nil || n != size {
+		os.Remove(p)
+		http.Error(w, "incomplete upload", http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// path returns the file of a blob, keys cannot point outside of the directory of the store
+func (s *FileStore) path(key string) (string, error) {
+
+	if key == "" || path.Clean("/"+key) != "/"+key {
+		return "", errors.Errorf("Invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// sign returns the URL of a request on a blob, with an expiry and a signature of the parameters
+func (s *FileStore) sign(method, key string, q url.Values) (string, error) {
+
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	q.Set("expires", strconv.FormatInt(time.Now().Add(s.expiry).Unix(), 10))
+	q.Set("signature", s.signature(method, key, q))
+
+	return s.baseURL + "/" + key + "?" + q.Encode(), nil
+}
+
+// valid reports whether the parameters of a request were signed by the store and did not expire
+func (s *FileStore) valid(method, key string, q url.Values) bool {
+
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+
+	sig, err := hex.DecodeString(q.Get("signature"))
+	if err != nil {
+		return false
+	}
+
+	want, _ := hex.DecodeString(s.signature(method, key, q))
+
+	return hmac.Equal(sig, want)
+}
+
+// signature returns the HMAC of a request on a blob, covering every parameter but the signature
+func (s *FileStore) signature(method, key string, q url.Values) string {
+
+	signed := url.Values{}
+	for k, v := range q {
+		if k != "signature" {
+			signed[k] = v
+		}
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + signed.Encode()))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
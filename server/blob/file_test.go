@@ -0,0 +1,193 @@
+package blob_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server/blob"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Run("UploadAndDownload", testFileUploadAndDownload)
+	t.Run("UploadWrongSize", testFileUploadWrongSize)
+	t.Run("TamperedURL", testFileTamperedURL)
+	t.Run("ExpiredURL", testFileExpiredURL)
+	t.Run("InvalidKey", testFileInvalidKey)
+	t.Run("PutAndDownload", testFilePutAndDownload)
+}
+
+// fileStore returns a store in a temporary directory served by a test server
+func fileStore(t *testing.T, expiry time.Duration) (*blob.FileStore, func()) {
+
+	dir, err := ioutil.TempDir("", "blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var store *blob.FileStore
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store.ServeHTTP(w, r)
+	}))
+	store = blob.NewFileStore(dir, srv.URL, []byte("secret"), expiry)
+
+	return store, func() {
+		srv.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func put(t *testing.T, url, contentType, body string) int {
+
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func testFileUploadAndDownload(t *testing.T) {
+
+	store, done := fileStore(t, time.Minute)
+	defer done()
+
+	url, err := store.UploadURL("todo/a1", "text/plain", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := put(t, url, "text/plain", "hello"); code != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, code)
+	}
+
+	url, err = store.DownloadURL("todo/a1", "hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Fatalf("Expected the uploaded blob, got %d %q", resp.StatusCode, body)
+	}
+
+	if cd := resp.Header.Get("Content-Disposition"); cd != `attachment; filename=hello.txt` {
+		t.Fatalf("Expected the blob to be served as hello.txt, got %s", cd)
+	}
+
+	if err := store.Delete("todo/a1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Delete("todo/a1"); err != nil {
+		t.Fatalf("Expected deleting a missing blob to succeed, got %v", err)
+	}
+}
+
+func testFileUploadWrongSize(t *testing.T) {
+
+	store, done := fileStore(t, time.Minute)
+	defer done()
+
+	url, err := store.UploadURL("todo/a1", "text/plain", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := put(t, url, "text/plain", "hello world"); code != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, code)
+	}
+
+	if code := put(t, url, "image/png", "hello"); code != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, code)
+	}
+}
+
+func testFileTamperedURL(t *testing.T) {
+
+	store, done := fileStore(t, time.Minute)
+	defer done()
+
+	url, err := store.UploadURL("todo/a1", "text/plain", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url = strings.Replace(url, "size=5", "size=11", 1)
+
+	if code := put(t, url, "text/plain", "hello world"); code != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, code)
+	}
+}
+
+func testFileExpiredURL(t *testing.T) {
+
+	store, done := fileStore(t, -time.Minute)
+	defer done()
+
+	url, err := store.UploadURL("todo/a1", "text/plain", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := put(t, url, "text/plain", "hello"); code != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, code)
+	}
+}
+
+func testFileInvalidKey(t *testing.T) {
+
+	store, done := fileStore(t, time.Minute)
+	defer done()
+
+	if _, err := store.UploadURL("../etc/passwd", "text/plain", 5); err == nil {
+		t.Fatal("Expected a key outside of the store to be rejected")
+	}
+}
+
+func testFilePutAndDownload(t *testing.T) {
+
+	store, done := fileStore(t, time.Minute)
+	defer done()
+
+	if err := store.Put("todo/a1", "text/plain", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := store.DownloadURL("todo/a1", "hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Fatalf("Expected the blob put by the server, got %d %q", resp.StatusCode, body)
+	}
+
+	if err := store.Put("../etc/passwd", "text/plain", []byte("hello")); err == nil {
+		t.Fatal("Expected a key outside of the store to be rejected")
+	}
+}
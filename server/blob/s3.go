@@ -0,0 +1,100 @@
+package blob
+
+import (
+	"bytes"
+	"mime"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+)
+
+// S3Store stores blobs in an S3 bucket, which clients access with presigned URLs
+type S3Store struct {
+	s3     s3iface.S3API
+	bucket string
+	expiry time.Duration
+}
+
+// NewS3Store returns a blob store using the given bucket, issuing URLs valid for expiry
+func NewS3Store(s3 s3iface.S3API, bucket string, expiry time.Duration) *S3Store {
+	return &S3Store{s3, bucket, expiry}
+}
+
+// UploadURL returns a presigned URL for a PUT request, which S3 only accepts with the given
+// content type and length
+func (s *S3Store) UploadURL(key, contentType string, size int64) (string, error) {
+
+	req, _ := s.s3.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	})
+
+	url, err := req.Presign(s.expiry)
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not presign upload of blob %s", key)
+	}
+
+	return url, nil
+}
+
+// DownloadURL returns a presigned URL for a GET request, serving the blob as an attachment
+func (s *S3Store) DownloadURL(key, name string) (string, error) {
+
+	req, _ := s.s3.GetObjectRequest(&s3.GetObjectInput{
+		Bucket:                     aws.String(s.bucket),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(contentDisposition(name)),
+	})
+
+	url, err := req.Presign(s.expiry)
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not presign download of blob %s", key)
+	}
+
+	return url, nil
+}
+
+// Put uploads a blob
+func (s *S3Store) Put(key, contentType string, content []byte) error {
+
+	_, err := s.s3.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        bytes.NewReader(content),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Could not upload blob %s", key)
+	}
+
+	return nil
+}
+
+// Delete removes a blob, blobs which were never uploaded are ignored
+func (s *S3Store) Delete(key string) error {
+
+	_, err := s.s3.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil
+		}
+		return errors.Wrapf(err, "Could not delete blob %s", key)
+	}
+
+	return nil
+}
+
+// contentDisposition returns the Content-Disposition header making browsers save a blob with
+// the given file name
+func contentDisposition(name string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name})
+}
@@ -0,0 +1,102 @@
+package blob_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/massimoselvi/serverless-todo-api-go/server/blob"
+)
+
+func TestS3Store(t *testing.T) {
+	t.Run("UploadURL", testS3UploadURL)
+	t.Run("DownloadURL", testS3DownloadURL)
+	t.Run("DeleteMissing", testS3DeleteMissing)
+}
+
+// S3Mock presigns requests with a real client and mocks the calls reaching S3
+type S3Mock struct {
+	s3iface.S3API
+	DeleteObjectFn func(*s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+}
+
+func (m *S3Mock) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	return m.DeleteObjectFn(input)
+}
+
+func s3Client() s3iface.S3API {
+	s := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("us-west-2").
+		WithCredentials(credentials.NewStaticCredentials("AKID", "SECRET", ""))))
+	return s3.New(s)
+}
+
+func testS3UploadURL(t *testing.T) {
+
+	store := blob.NewS3Store(s3Client(), "attachments", 15*time.Minute)
+
+	u, err := store.UploadURL("todo/a1", "image/png", 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(p.Path, "/todo/a1") {
+		t.Fatalf("Expected a URL of blob todo/a1, got %s", u)
+	}
+
+	q := p.Query()
+	if q.Get("X-Amz-Expires") != "900" {
+		t.Fatalf("Expected the URL to expire in 900 seconds, got %s", q.Get("X-Amz-Expires"))
+	}
+
+	if signed := q.Get("X-Amz-SignedHeaders"); !strings.Contains(signed, "content-type") || !strings.Contains(signed, "content-length") {
+		t.Fatalf("Expected the content type and length to be signed, got %s", signed)
+	}
+}
+
+func testS3DownloadURL(t *testing.T) {
+
+	store := blob.NewS3Store(s3Client(), "attachments", 15*time.Minute)
+
+	u, err := store.DownloadURL("todo/a1", "report.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cd := p.Query().Get("response-content-disposition"); cd != "attachment; filename=report.pdf" {
+		t.Fatalf("Expected the blob to be served as report.pdf, got %s", cd)
+	}
+}
+
+func testS3DeleteMissing(t *testing.T) {
+
+	m := &S3Mock{
+		DeleteObjectFn: func(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+			if *input.Bucket != "attachments" || *input.Key != "todo/a1" {
+				t.Fatalf("Expected blob todo/a1 of bucket attachments, got %s/%s", *input.Bucket, *input.Key)
+			}
+			return nil, awserr.New(s3.ErrCodeNoSuchKey, "missing", nil)
+		},
+	}
+
+	if err := blob.NewS3Store(m, "attachments", time.Minute).Delete("todo/a1"); err != nil {
+		t.Fatalf("Expected deleting a missing blob to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import "sort"
+
+// ChecklistItem is a step of a ToDo
+type ChecklistItem struct {
+	ID        string `json:"id" schema:"format=uuid"`
+	Title     string `json:"title" schema:"required,minLength=1,maxLength=500"`
+	Completed bool   `json:"completed"`
+	Order     int    `json:"order" schema:"minimum=0"`
+}
+
+// Progress counts the completed checklist items of a ToDo
+type Progress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// Progress returns the number of completed checklist items, or nil if the ToDo has none
+func (t *ToDo) Progress() *Progress {
+
+	if len(t.Items) == 0 {
+		return nil
+	}
+
+	p := &Progress{Total: len(t.Items)}
+	for _, i := range t.Items {
+		if i.Completed {
+			p.Completed++
+		}
+	}
+
+	return p
+}
+
+// Item returns the checklist item with the given ID, or nil if the ToDo has no such item
+func (t *ToDo) Item(id string) *ChecklistItem {
+	for i := range t.Items {
+		if t.Items[i].ID == id {
+			return &t.Items[i]
+		}
+	}
+	return nil
+}
+
+// SortItems sorts the checklist items by their order and renumbers them from 0
+func (t *ToDo) SortItems() {
+
+	sort.SliceStable(t.Items, func(i, j int) bool {
+		return t.Items[i].Order < t.Items[j].Order
+	})
+
+	for i := range t.Items {
+		t.Items[i].Order = i
+	}
+}
+
+// UpdateCompletion completes a ToDo once all its checklist items are completed. A completed
+// ToDo is never reopened, users can complete it while some items are still open.
+func (t *ToDo) UpdateCompletion() {
+	if p := t.Progress(); p != nil && p.Completed == p.Total {
+		t.Completed = true
+	}
+}
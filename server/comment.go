@@ -0,0 +1,15 @@
+package server
+
+import "time"
+
+// Comment is a message left on a ToDo. Deleted comments are kept, without their body, so
+// that the thread keeps its shape.
+type Comment struct {
+	ToDoID  string     `json:"todoId" schema:"readonly"`
+	ID      string     `json:"id" schema:"readonly"`
+	Author  string     `json:"author" schema:"readonly"`
+	Body    string     `json:"body" schema:"required,minLength=1,maxLength=5000"`
+	Created time.Time  `json:"created" schema:"readonly"`
+	Edited  *time.Time `json:"edited,omitempty" schema:"readonly"`
+	Deleted bool       `json:"deleted,omitempty" schema:"readonly"`
+}
@@ -0,0 +1,77 @@
+// Package databasetest provides in-memory repositories for the tests of the packages using the
+// database interfaces
+package databasetest
+
+import (
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+)
+
+// ProfileRepoMock is an in-memory profile repository
+type ProfileRepoMock struct {
+	Profiles map[string]*server.Profile
+	// Claims are the users who claimed each email address
+	Claims map[string]string
+}
+
+// NewProfileRepoMock returns a profile repository storing the given profiles
+func NewProfileRepoMock(profiles ...server.Profile) *ProfileRepoMock {
+	m := &ProfileRepoMock{Profiles: map[string]*server.Profile{}}
+	for i := range profiles {
+		m.Profiles[profiles[i].User] = &profiles[i]
+	}
+	return m
+}
+
+// Get returns the profile of a user
+func (m *ProfileRepoMock) Get(user string) (*server.Profile, error) {
+	return m.Profiles[user], nil
+}
+
+// GetByEmail returns the profile with the given verified email address
+func (m *ProfileRepoMock) GetByEmail(email string) (*server.Profile, error) {
+	for _, p := range m.Profiles {
+		if p.Email == email && p.EmailVerified {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// ClaimEmail reserves an email address for a user, it returns ErrExists if another user
+// claimed it
+func (m *ProfileRepoMock) ClaimEmail(email, user string) error {
+	if m.Claims == nil {
+		m.Claims = map[string]string{}
+	}
+	if owner, ok := m.Claims[email]; ok && owner != user {
+		return database.ErrExists
+	}
+	m.Claims[email] = user
+	return nil
+}
+
+// ReleaseEmail removes the claim of a user on an email address
+func (m *ProfileRepoMock) ReleaseEmail(email, user string) error {
+	if m.Claims[email] == user {
+		delete(m.Claims, email)
+	}
+	return nil
+}
+
+// GetSubscribed returns the profiles of the users receiving a digest
+func (m *ProfileRepoMock) GetSubscribed() ([]server.Profile, error) {
+	profiles := []server.Profile{}
+	for _, p := range m.Profiles {
+		if p.Digest != "" {
+			profiles = append(profiles, *p)
+		}
+	}
+	return profiles, nil
+}
+
+// Save stores the profile of a user
+func (m *ProfileRepoMock) Save(profile *server.Profile) error {
+	m.Profiles[profile.User] = profile
+	return nil
+}
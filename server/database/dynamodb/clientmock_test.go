@@ -8,14 +8,22 @@ import (
 // ClientMock is used to mock a client that uses makes call to DynamoDBAPI
 type ClientMock struct {
 	dynamodbiface.DynamoDBAPI
-	GetItemFn         func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
-	ScanFn            func(*dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
-	PutItemFn         func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
-	DeleteItemFn      func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
-	GetItemInvoked    bool
-	ScanInvoked       bool
-	PutItemInvoked    bool
-	DeleteItemInvoked bool
+	GetItemFn                 func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	ScanFn                    func(*dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	PutItemFn                 func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	DeleteItemFn              func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	UpdateItemFn              func(*dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	QueryFn                   func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	TransactWriteItemsFn      func(*dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
+	BatchWriteItemFn          func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	GetItemInvoked            bool
+	ScanInvoked               bool
+	PutItemInvoked            bool
+	DeleteItemInvoked         bool
+	UpdateItemInvoked         bool
+	QueryInvoked              bool
+	TransactWriteItemsInvoked bool
+	BatchWriteItemInvoked     bool
 }
 
 // GetItem returns a set of attributes for the item with the given primary key
@@ -42,3 +50,27 @@ func (m *ClientMock) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.Dele
 	return m.DeleteItemFn(input)
 
 }
+
+// UpdateItem edits an existing item's attributes, or adds a new item to the table if it does not already exist
+func (m *ClientMock) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	m.UpdateItemInvoked = true
+	return m.UpdateItemFn(input)
+}
+
+// Query finds items based on primary key values
+func (m *ClientMock) Query(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	m.QueryInvoked = true
+	return m.QueryFn(input)
+}
+
+// TransactWriteItems groups up to 25 writes in a single all-or-nothing operation
+func (m *ClientMock) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	m.TransactWriteItemsInvoked = true
+	return m.TransactWriteItemsFn(input)
+}
+
+// BatchWriteItem puts or deletes up to 25 items, returning the items it did not process
+func (m *ClientMock) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	m.BatchWriteItemInvoked = true
+	return m.BatchWriteItemFn(input)
+}
@@ -0,0 +1,155 @@
+package dynamodb
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// commentsTableName is the table storing comments, partitioned by todoId and sorted by id so
+// that the thread of a ToDo is read with a single query
+const commentsTableName = "comments"
+
+// CommentRepo represents a DynamoDB repository for managing the comments of ToDos
+type CommentRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewCommentRepo returns a new comment repository using the given DynamoDB client
+func NewCommentRepo(db dynamodbiface.DynamoDBAPI) *CommentRepo {
+	return &CommentRepo{db}
+}
+
+// mapComment returns the key of a comment
+func mapComment(todoID, id string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"todoId": {S: aws.String(todoID)},
+		"id":     {S: aws.String(id)},
+	}
+}
+
+// Get returns a comment of a ToDo by its ID
+func (r *CommentRepo) Get(todoID, id string) (*server.Comment, error) {
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(commentsTableName),
+		Key:       mapComment(todoID, id),
+	}
+
+	result, err := r.db.GetItem(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get comment %s from database", id)
+	}
+
+	c := &server.Comment{}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not unmarshal comment %s", id)
+	}
+
+	if c.ID == "" {
+		return nil, nil
+	}
+
+	return c, nil
+}
+
+// List returns a page of the comments of a ToDo, the cursor is the ID of the last comment of
+// the previous page
+func (r *CommentRepo) List(todoID, cursor string, limit int) ([]server.Comment, string, error) {
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(commentsTableName),
+		KeyConditionExpression: aws.String("todoId = :todoId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":todoId": {S: aws.String(todoID)},
+		},
+		Limit: aws.Int64(int64(limit)),
+	}
+
+	if cursor != "" {
+		input.ExclusiveStartKey = mapComment(todoID, cursor)
+	}
+
+	result, err := r.db.Query(input)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "Could not get comments of ToDo %s from database", todoID)
+	}
+
+	c := []server.Comment{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &c); err != nil {
+		return nil, "", errors.Wrapf(err, "Could not unmarshal comments of ToDo %s", todoID)
+	}
+
+	next := ""
+	if id, ok := result.LastEvaluatedKey["id"]; ok && id.S != nil {
+		next = *id.S
+	}
+
+	return c, next, nil
+}
+
+// Save creates or updates a comment
+func (r *CommentRepo) Save(comment *server.Comment) error {
+
+	if comment.ID == "" {
+		comment.Created = time.Now()
+		comment.ID = timeID(comment.Created)
+	}
+
+	c, err := dynamodbattribute.MarshalMap(comment)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal comment %s", comment.ID)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(commentsTableName),
+		Item:      c,
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		return errors.Wrapf(err, "Could not save comment %s to database", comment.ID)
+	}
+
+	return nil
+}
+
+// DeleteAll deletes every comment of a ToDo
+func (r *CommentRepo) DeleteAll(todoID string) error {
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(commentsTableName),
+		KeyConditionExpression: aws.String("todoId = :todoId"),
+		ProjectionExpression:   aws.String("todoId, id"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":todoId": {S: aws.String(todoID)},
+		},
+	}
+
+	for {
+		result, err := r.db.Query(input)
+		if err != nil {
+			return errors.Wrapf(err, "Could not get comments of ToDo %s from database", todoID)
+		}
+
+		writes := make([]*dynamodb.WriteRequest, len(result.Items))
+		for i, key := range result.Items {
+			writes[i] = &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: key}}
+		}
+
+		if err := batchWrite(r.db, commentsTableName, writes); err != nil {
+			return errors.Wrapf(err, "Could not delete comments of ToDo %s", todoID)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,133 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestCommentRepo(t *testing.T) {
+	t.Run("ListComments", testListComments)
+	t.Run("CreateComment", testCreateComment)
+	t.Run("DeleteAllComments", testDeleteAllComments)
+}
+
+func testListComments(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.TableName != "comments" || *input.ExpressionAttributeValues[":todoId"].S != testUUID {
+			t.Fatal("Expected a query on the partition of the ToDo")
+		}
+
+		if start := input.ExclusiveStartKey; *start["todoId"].S != testUUID || *start["id"].S != "cursor" {
+			t.Fatal("Expected the query to start after the cursor")
+		}
+
+		item, err := dynamodbattribute.MarshalMap(server.Comment{ToDoID: testUUID, ID: "next", Body: "Hello"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &awsdynamodb.QueryOutput{
+			Items:            []map[string]*awsdynamodb.AttributeValue{item},
+			LastEvaluatedKey: item,
+		}, nil
+	}
+
+	repo := dynamodb.NewCommentRepo(m)
+
+	comments, next, err := repo.List(testUUID, "cursor", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(comments) != 1 || next != "next" {
+		t.Fatalf("Expected a comment and the next cursor, got %d comments and %q", len(comments), next)
+	}
+}
+
+func testCreateComment(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.PutItemFn = func(*awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewCommentRepo(m)
+
+	first := &server.Comment{ToDoID: testUUID, Body: "First"}
+	if err := repo.Save(first); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &server.Comment{ToDoID: testUUID, Body: "Second"}
+	if err := repo.Save(second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Created.IsZero() || !strings.HasPrefix(first.ID, first.Created.UTC().Format("20060102T")) {
+		t.Fatalf("Expected ID prefixed with the creation time, got %s", first.ID)
+	}
+
+	if first.ID >= second.ID {
+		t.Fatalf("Expected IDs ordered by creation, got %s and %s", first.ID, second.ID)
+	}
+}
+
+func testDeleteAllComments(t *testing.T) {
+
+	m := &ClientMock{}
+
+	pages := 0
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.ExpressionAttributeValues[":todoId"].S != testUUID {
+			t.Fatal("Expected a query on the partition of the ToDo")
+		}
+
+		out := &awsdynamodb.QueryOutput{}
+		for i := 0; i < 30; i++ {
+			out.Items = append(out.Items, map[string]*awsdynamodb.AttributeValue{
+				"todoId": {S: aws.String(testUUID)},
+				"id":     {S: aws.String(fmt.Sprintf("c%d-%02d", pages, i))},
+			})
+		}
+
+		pages++
+		if pages == 1 {
+			out.LastEvaluatedKey = out.Items[len(out.Items)-1]
+		}
+
+		return out, nil
+	}
+
+	deleted := 0
+	m.BatchWriteItemFn = func(input *awsdynamodb.BatchWriteItemInput) (*awsdynamodb.BatchWriteItemOutput, error) {
+
+		writes := input.RequestItems["comments"]
+		if len(writes) > 25 {
+			t.Fatalf("Expected at most 25 writes per batch, got %d", len(writes))
+		}
+
+		deleted += len(writes)
+		return &awsdynamodb.BatchWriteItemOutput{}, nil
+	}
+
+	if err := dynamodb.NewCommentRepo(m).DeleteAll(testUUID); err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted != 60 {
+		t.Fatalf("Expected the 60 comments of both pages to be deleted, got %d", deleted)
+	}
+}
@@ -0,0 +1,104 @@
+package dynamodb
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// connectionsTableName is the table storing the WebSocket connections of users, connections
+// whose disconnect was missed are removed by the DynamoDB TTL on the expiresAt attribute
+const connectionsTableName = "connections"
+
+// connectionLifetime outlives the longest connection API Gateway keeps open
+const connectionLifetime = 3 * time.Hour
+
+// ConnectionRepo represents a DynamoDB repository for managing WebSocket connections
+type ConnectionRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewConnectionRepo returns a new connection repository using the given DynamoDB client
+func NewConnectionRepo(db dynamodbiface.DynamoDBAPI) *ConnectionRepo {
+	return &ConnectionRepo{db}
+}
+
+// GetByOwner returns the connections of a user
+func (r *ConnectionRepo) GetByOwner(owner string) ([]database.Connection, error) {
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(connectionsTableName),
+		IndexName:              aws.String(ownerIndexName),
+		KeyConditionExpression: aws.String("#owner = :owner"),
+		// owner is a reserved word
+		ExpressionAttributeNames: map[string]*string{"#owner": aws.String("owner")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {S: aws.String(owner)},
+		},
+	}
+
+	c := []database.Connection{}
+
+	for {
+		result, err := r.db.Query(input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not get connections of %s from database", owner)
+		}
+
+		page := []database.Connection{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, errors.Wrapf(err, "Could not unmarshal connections of %s", owner)
+		}
+		c = append(c, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return c, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// Save stores a connection until it outlives any connection API Gateway keeps open
+func (r *ConnectionRepo) Save(conn *database.Connection) error {
+
+	if conn.Connected.IsZero() {
+		conn.Connected = time.Now()
+	}
+	conn.ExpiresAt = conn.Connected.Add(connectionLifetime)
+
+	c, err := dynamodbattribute.MarshalMap(conn)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal connection %s", conn.ID)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(connectionsTableName),
+		Item:      c,
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		return errors.Wrapf(err, "Could not save connection %s to database", conn.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a connection
+func (r *ConnectionRepo) Delete(id string) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(connectionsTableName),
+		Key:       mapID(id),
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil {
+		return errors.Wrapf(err, "Could not delete connection %s from database", id)
+	}
+
+	return nil
+}
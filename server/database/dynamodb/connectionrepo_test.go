@@ -0,0 +1,42 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestConnectionRepo(t *testing.T) {
+	t.Run("SaveConnectionExpires", testSaveConnectionExpires)
+}
+
+func testSaveConnectionExpires(t *testing.T) {
+
+	m := &ClientMock{}
+	connected := time.Now()
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if *input.TableName != "connections" || *input.Item["owner"].S != testActor {
+			t.Fatal("Expected the connection of the user to be saved")
+		}
+
+		if input.Item["expiresAt"] == nil || input.Item["expiresAt"].N == nil {
+			t.Fatal("Expected the connection to expire")
+		}
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	conn := &database.Connection{ID: "conn", Owner: testActor, Connected: connected}
+	if err := dynamodb.NewConnectionRepo(m).Save(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	if !conn.ExpiresAt.After(connected.Add(2 * time.Hour)) {
+		t.Fatalf("Expected the connection to outlive API Gateway connections, expires at %s", conn.ExpiresAt)
+	}
+}
@@ -0,0 +1,170 @@
+package dynamodb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// deliveriesTableName is the table storing the deliveries of each webhook, keyed by webhookId
+// and id
+const deliveriesTableName = "webhook_deliveries"
+
+// retryIndexName is the global secondary index of the deliveries table keyed by queue and due.
+// Only pending deliveries have these attributes, so the index only holds the deliveries to retry.
+const retryIndexName = "retry-index"
+
+// retryQueue is the queue of the pending deliveries in the retry index
+const retryQueue = "retry"
+
+// deadLettersTableName is the table keeping the deliveries which failed every attempt
+const deadLettersTableName = "webhook_dead_letters"
+
+// deliveryItem is a delivery along with its position in the retry index
+type deliveryItem struct {
+	server.Delivery
+	Queue string `json:"queue,omitempty"`
+	// Due is the Unix time of the next attempt
+	Due int64 `json:"due,omitempty"`
+}
+
+// DeliveryRepo represents a DynamoDB repository for the deliveries of events to webhooks
+type DeliveryRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewDeliveryRepo returns a new delivery repository using the given DynamoDB client
+func NewDeliveryRepo(db dynamodbiface.DynamoDBAPI) *DeliveryRepo {
+	return &DeliveryRepo{db}
+}
+
+func mapDelivery(webhookID, id string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"webhookId": {S: aws.String(webhookID)},
+		"id":        {S: aws.String(id)},
+	}
+}
+
+// List returns a page of the deliveries of a webhook, newest first, the cursor is the ID of the
+// last delivery of the previous page
+func (r *DeliveryRepo) List(webhookID, cursor string, limit int) ([]server.Delivery, string, error) {
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(deliveriesTableName),
+		KeyConditionExpression: aws.String("webhookId = :webhookId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":webhookId": {S: aws.String(webhookID)},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int64(int64(limit)),
+	}
+
+	if cursor != "" {
+		input.ExclusiveStartKey = mapDelivery(webhookID, cursor)
+	}
+
+	result, err := r.db.Query(input)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "Could not get deliveries of webhook %s from database", webhookID)
+	}
+
+	d := []server.Delivery{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &d); err != nil {
+		return nil, "", errors.Wrapf(err, "Could not unmarshal deliveries of webhook %s", webhookID)
+	}
+
+	next := ""
+	if id, ok := result.LastEvaluatedKey["id"]; ok && id.S != nil {
+		next = *id.S
+	}
+
+	return d, next, nil
+}
+
+// Due returns up to limit pending deliveries whose next attempt is due at now
+func (r *DeliveryRepo) Due(now time.Time, limit int) ([]server.Delivery, error) {
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(deliveriesTableName),
+		IndexName:              aws.String(retryIndexName),
+		KeyConditionExpression: aws.String("#queue = :queue AND #due <= :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#queue": aws.String("queue"),
+			"#due":   aws.String("due"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":queue": {S: aws.String(retryQueue)},
+			":now":   {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+		},
+		Limit: aws.Int64(int64(limit)),
+	}
+
+	result, err := r.db.Query(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not get due deliveries from database")
+	}
+
+	d := []server.Delivery{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &d); err != nil {
+		return nil, errors.Wrap(err, "Could not unmarshal due deliveries")
+	}
+
+	return d, nil
+}
+
+// Save creates or updates a delivery, pending deliveries are added to the retry index
+func (r *DeliveryRepo) Save(delivery *server.Delivery) error {
+
+	if delivery.ID == "" {
+		delivery.Created = time.Now()
+		delivery.ID = timeID(delivery.Created)
+	}
+
+	item := deliveryItem{Delivery: *delivery}
+	if delivery.Status == server.DeliveryPending && delivery.NextAttempt != nil {
+		item.Queue = retryQueue
+		item.Due = delivery.NextAttempt.Unix()
+	}
+
+	return putDelivery(r.db, deliveriesTableName, item)
+}
+
+// DeadLetterRepo represents a DynamoDB repository for the deliveries which failed every attempt
+type DeadLetterRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewDeadLetterRepo returns a new dead-letter repository using the given DynamoDB client
+func NewDeadLetterRepo(db dynamodbiface.DynamoDBAPI) *DeadLetterRepo {
+	return &DeadLetterRepo{db}
+}
+
+// Save keeps a delivery which failed every attempt
+func (r *DeadLetterRepo) Save(delivery *server.Delivery) error {
+	return putDelivery(r.db, deadLettersTableName, deliveryItem{Delivery: *delivery})
+}
+
+func putDelivery(db dynamodbiface.DynamoDBAPI, table string, item deliveryItem) error {
+
+	d, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal delivery %s", item.ID)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(table),
+		Item:      d,
+	}
+
+	if _, err := db.PutItem(input); err != nil {
+		return errors.Wrapf(err, "Could not save delivery %s to %s", item.ID, table)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package dynamodb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// digestsSentTableName is the table storing a marker for each digest sent, markers are removed
+// by the DynamoDB TTL on the expiresAt attribute
+const digestsSentTableName = "digests_sent"
+
+// DigestRepo represents a DynamoDB repository for sending each digest of users once
+type DigestRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewDigestRepo returns a new digest repository using the given DynamoDB client
+func NewDigestRepo(db dynamodbiface.DynamoDBAPI) *DigestRepo {
+	return &DigestRepo{db}
+}
+
+// MarkSent marks the digest of a user for a period sent, it returns ErrExists if it already was
+func (r *DigestRepo) MarkSent(user, period string) error {
+
+	item := mapID(user + "#" + period)
+	item["expiresAt"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(time.Now().Add(sentRetention).Unix(), 10))}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(digestsSentTableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		if isConditionFailed(err) {
+			return errors.Wrapf(database.ErrExists, "digest of %s for %s", user, period)
+		}
+		return errors.Wrapf(err, "Could not mark digest of %s sent", user)
+	}
+
+	return nil
+}
+
+// Unmark removes the sent marker of a digest which could not be sent
+func (r *DigestRepo) Unmark(user, period string) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(digestsSentTableName),
+		Key:       mapID(user + "#" + period),
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil {
+		return errors.Wrapf(err, "Could not unmark digest of %s", user)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/pkg/errors"
+)
+
+func TestDigestRepo(t *testing.T) {
+	t.Run("MarkDigestSentTwice", testMarkDigestSentTwice)
+	t.Run("GetSubscribedProfiles", testGetSubscribedProfiles)
+}
+
+func testMarkDigestSentTwice(t *testing.T) {
+
+	m := &ClientMock{}
+	sent := map[string]bool{}
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if *input.TableName != "digests_sent" || input.ConditionExpression == nil {
+			t.Fatal("Expected the marker to be saved only if it does not exist")
+		}
+
+		id := *input.Item["id"].S
+		if sent[id] {
+			return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+		}
+		sent[id] = true
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewDigestRepo(m)
+
+	if err := repo.MarkSent(testActor, "2019-08-05"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.MarkSent(testActor, "2019-08-05"); errors.Cause(err) != database.ErrExists {
+		t.Fatalf("Expected ErrExists, got %v", err)
+	}
+
+	if err := repo.MarkSent(testActor, "2019-08-06"); err != nil {
+		t.Fatalf("Expected the digest of the next day to be sent, got %v", err)
+	}
+}
+
+func testGetSubscribedProfiles(t *testing.T) {
+
+	m := &ClientMock{}
+	pages := 0
+
+	m.ScanFn = func(input *awsdynamodb.ScanInput) (*awsdynamodb.ScanOutput, error) {
+
+		pages++
+		out := &awsdynamodb.ScanOutput{
+			Items: []map[string]*awsdynamodb.AttributeValue{{
+				"user":   {S: aws.String(testActor)},
+				"digest": {S: aws.String("daily")},
+			}},
+		}
+		if pages == 1 {
+			out.LastEvaluatedKey = map[string]*awsdynamodb.AttributeValue{"user": {S: aws.String(testActor)}}
+		}
+
+		return out, nil
+	}
+
+	profiles, err := dynamodb.NewProfileRepo(m).GetSubscribed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(profiles) != 2 || profiles[0].Digest != "daily" {
+		t.Fatalf("Expected the subscribed profiles of every page, got %+v", profiles)
+	}
+}
@@ -1,10 +1,21 @@
 package dynamodb
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	uuid "github.com/satori/go.uuid"
 )
 
+// timeIDLayout prefixes IDs with their creation time, so they sort by creation
+const timeIDLayout = "20060102T150405.000000000Z"
+
+// timeID returns a unique ID sorting by the given creation time
+func timeID(created time.Time) string {
+	return created.UTC().Format(timeIDLayout) + "-" + uuid.NewV4().String()[:8]
+}
+
 // mapID return a AttributeValue map with id set
 func mapID(id string) map[string]*dynamodb.AttributeValue {
 	return map[string]*dynamodb.AttributeValue{
@@ -13,3 +24,12 @@ func mapID(id string) map[string]*dynamodb.AttributeValue {
 		},
 	}
 }
+
+// mapKey return a AttributeValue map with key set
+func mapKey(key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"key": {
+			S: aws.String(key),
+		},
+	}
+}
@@ -0,0 +1,125 @@
+package dynamodb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// idempotencyTableName is the table storing idempotency keys, expired keys are removed by
+// the DynamoDB TTL on the expiresAt attribute
+const idempotencyTableName = "idempotency_keys"
+
+// IdempotencyRepo represents a DynamoDB repository for managing idempotency keys
+type IdempotencyRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewIdempotencyRepo returns a new idempotency key repository using the given DynamoDB client
+func NewIdempotencyRepo(db dynamodbiface.DynamoDBAPI) *IdempotencyRepo {
+	return &IdempotencyRepo{db}
+}
+
+// Get returns the record stored for an idempotency key
+func (r *IdempotencyRepo) Get(key string) (*database.IdempotencyRecord, error) {
+
+	input := &dynamodb.GetItemInput{
+		TableName:      aws.String(idempotencyTableName),
+		Key:            mapKey(key),
+		ConsistentRead: aws.Bool(true),
+	}
+
+	result, err := r.db.GetItem(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get idempotency key %s from database", key)
+	}
+
+	rec := &database.IdempotencyRecord{}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, rec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not unmarshal idempotency key %s", key)
+	}
+
+	// The TTL removes expired items lazily, until then they are treated as missing
+	if rec.Key == "" || !rec.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+
+	return rec, nil
+}
+
+// Reserve saves a record only if its key is not already stored or has expired
+func (r *IdempotencyRepo) Reserve(record *database.IdempotencyRecord) error {
+	return r.put(record, true)
+}
+
+// Save creates or replaces the record of an idempotency key
+func (r *IdempotencyRepo) Save(record *database.IdempotencyRecord) error {
+	return r.put(record, false)
+}
+
+func (r *IdempotencyRepo) put(record *database.IdempotencyRecord, reserve bool) error {
+
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal idempotency key %s", record.Key)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(idempotencyTableName),
+		Item:      item,
+	}
+
+	if reserve {
+		input.ConditionExpression = aws.String("attribute_not_exists(#key) OR expiresAt < :now")
+		input.ExpressionAttributeNames = map[string]*string{"#key": aws.String("key")}
+		input.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(strconv.FormatInt(time.Now().Unix(), 10))},
+		}
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		if isConditionFailed(err) {
+			return errors.Wrapf(database.ErrExists, "idempotency key %s", record.Key)
+		}
+		return errors.Wrapf(err, "Could not save idempotency key %s to database", record.Key)
+	}
+
+	return nil
+}
+
+// Delete removes an idempotency key
+func (r *IdempotencyRepo) Delete(key string) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(idempotencyTableName),
+		Key:       mapKey(key),
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil {
+		return errors.Wrapf(err, "Could not delete idempotency key %s from database", key)
+	}
+
+	return nil
+}
+
+// isConditionFailed reports whether err was caused by a failed condition expression
+func isConditionFailed(err error) bool {
+	aerr, ok := errors.Cause(err).(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+// isTransactionCanceled reports whether err was caused by a transaction canceled because one
+// of its conditions failed
+func isTransactionCanceled(err error) bool {
+	aerr, ok := errors.Cause(err).(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeTransactionCanceledException
+}
@@ -0,0 +1,157 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/pkg/errors"
+)
+
+const testIdempotencyKey = "user#5f6c1d6e-1b7e-4d0a-9a3c-0c2f5a0b9e11"
+
+func TestIdempotencyRepo(t *testing.T) {
+	t.Run("ReserveKey", testReserveKey)
+	t.Run("ReserveKeyExists", testReserveKeyExists)
+	t.Run("GetKeyFound", testGetKeyFound)
+	t.Run("GetKeyNotFound", testGetKeyNotFound)
+	t.Run("GetKeyExpired", testGetKeyExpired)
+}
+
+func testReserveKey(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if input.ConditionExpression == nil || *input.ConditionExpression != "attribute_not_exists(#key) OR expiresAt < :now" {
+			t.Fatal("Expected expired keys to be reserved again")
+		}
+
+		if input.ExpressionAttributeValues[":now"] == nil || input.ExpressionAttributeValues[":now"].N == nil {
+			t.Fatal("Expected the current time as a number")
+		}
+
+		if input.Item["expiresAt"] == nil || input.Item["expiresAt"].N == nil {
+			t.Fatal("Expected expiresAt to be stored as a number")
+		}
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewIdempotencyRepo(m)
+
+	err := repo.Reserve(&database.IdempotencyRecord{
+		Key:       testIdempotencyKey,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.PutItemInvoked {
+		t.Fatal("PutItem not invoked")
+	}
+}
+
+func testReserveKeyExists(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.PutItemFn = func(*awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+		return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+
+	repo := dynamodb.NewIdempotencyRepo(m)
+
+	err := repo.Reserve(&database.IdempotencyRecord{Key: testIdempotencyKey})
+	if errors.Cause(err) != database.ErrExists {
+		t.Fatalf("Expected %v, got %v", database.ErrExists, err)
+	}
+}
+
+func testGetKeyFound(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.GetItemFn = func(*awsdynamodb.GetItemInput) (*awsdynamodb.GetItemOutput, error) {
+
+		item, err := dynamodbattribute.MarshalMap(&database.IdempotencyRecord{
+			Key:        testIdempotencyKey,
+			StatusCode: 200,
+			Body:       "{}",
+			ExpiresAt:  time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &awsdynamodb.GetItemOutput{Item: item}, nil
+	}
+
+	repo := dynamodb.NewIdempotencyRepo(m)
+
+	rec, err := repo.Get(testIdempotencyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec == nil || !rec.Completed() {
+		t.Fatal("Expected a completed record")
+	}
+}
+
+func testGetKeyNotFound(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.GetItemFn = func(*awsdynamodb.GetItemInput) (*awsdynamodb.GetItemOutput, error) {
+		return &awsdynamodb.GetItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewIdempotencyRepo(m)
+
+	rec, err := repo.Get(testIdempotencyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec != nil {
+		t.Fatal("Expected record to be nil")
+	}
+}
+
+func testGetKeyExpired(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.GetItemFn = func(*awsdynamodb.GetItemInput) (*awsdynamodb.GetItemOutput, error) {
+
+		item, err := dynamodbattribute.MarshalMap(&database.IdempotencyRecord{
+			Key:        testIdempotencyKey,
+			StatusCode: 200,
+			Body:       "{}",
+			ExpiresAt:  time.Now().Add(-time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &awsdynamodb.GetItemOutput{Item: item}, nil
+	}
+
+	repo := dynamodb.NewIdempotencyRepo(m)
+
+	rec, err := repo.Get(testIdempotencyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec != nil {
+		t.Fatal("Expected expired record to be nil")
+	}
+}
@@ -0,0 +1,121 @@
+package dynamodb
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const labelsTableName = "labels"
+
+// LabelRepo represents a DynamoDB repository for managing labels
+type LabelRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewLabelRepo returns a new label repository using the given DynamoDB client
+func NewLabelRepo(db dynamodbiface.DynamoDBAPI) *LabelRepo {
+	return &LabelRepo{db}
+}
+
+// Get returns a label by its ID
+func (r *LabelRepo) Get(id string) (*server.Label, error) {
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(labelsTableName),
+		Key:       mapID(id),
+	}
+
+	result, err := r.db.GetItem(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get label %s from database", id)
+	}
+
+	l := &server.Label{}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, l)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not unmarshal label %s", id)
+	}
+
+	if l.ID == "" {
+		return nil, nil
+	}
+
+	return l, nil
+}
+
+// GetAll returns all labels
+func (r *LabelRepo) GetAll() ([]server.Label, error) {
+
+	input := &dynamodb.ScanInput{
+		TableName: aws.String(labelsTableName),
+	}
+
+	l := []server.Label{}
+
+	for {
+		result, err := r.db.Scan(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get labels from database")
+		}
+
+		page := []server.Label{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, errors.Wrap(err, "Could not unmarshal labels")
+		}
+		l = append(l, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return l, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// Save creates or updates a label
+func (r *LabelRepo) Save(label *server.Label) error {
+
+	if label.ID == "" {
+		label.ID = uuid.NewV4().String()
+	}
+
+	label.ModTime = time.Now()
+
+	l, err := dynamodbattribute.MarshalMap(label)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal label %s", label.ID)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(labelsTableName),
+		Item:      l,
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		return errors.Wrapf(err, "Could not save label %s to database", label.ID)
+	}
+
+	return nil
+}
+
+// Delete permanently removes a label
+func (r *LabelRepo) Delete(id string) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(labelsTableName),
+		Key:       mapID(id),
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil {
+		return errors.Wrapf(err, "Could not delete label %s from database", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestLabelRepo(t *testing.T) {
+	t.Run("SaveLabelWithMigration", testSaveLabelWithMigration)
+}
+
+func testSaveLabelWithMigration(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if *input.TableName != "labels" {
+			t.Fatalf("Expected labels table, got %s", *input.TableName)
+		}
+
+		if _, ok := input.Item["usageCount"]; ok {
+			t.Fatal("Expected usage count not to be stored")
+		}
+
+		var l server.Label
+		if err := dynamodbattribute.UnmarshalMap(input.Item, &l); err != nil {
+			t.Fatal(err)
+		}
+
+		if l.Migration == nil || l.Migration.Updated != 2 {
+			t.Fatalf("Expected the migration to be stored, got %+v", l.Migration)
+		}
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewLabelRepo(m)
+
+	label := &server.Label{
+		Name:       "urgent",
+		UsageCount: 3,
+		Migration:  &server.LabelMigration{From: "urgent", To: "asap", Updated: 2},
+	}
+
+	if err := repo.Save(label); err != nil {
+		t.Fatal(err)
+	}
+
+	if label.ID == "" {
+		t.Fatal("Expected ID to be set")
+	}
+}
@@ -0,0 +1,121 @@
+package dynamodb
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const listsTableName = "lists"
+
+// ListRepo represents a DynamoDB repository for managing lists
+type ListRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewListRepo returns a new list repository using the given DynamoDB client
+func NewListRepo(db dynamodbiface.DynamoDBAPI) *ListRepo {
+	return &ListRepo{db}
+}
+
+// Get returns a list by its ID
+func (r *ListRepo) Get(id string) (*server.List, error) {
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(listsTableName),
+		Key:       mapID(id),
+	}
+
+	result, err := r.db.GetItem(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get list %s from database", id)
+	}
+
+	l := &server.List{}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, l)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not unmarshal list %s", id)
+	}
+
+	if l.ID == "" {
+		return nil, nil
+	}
+
+	return l, nil
+}
+
+// GetAll returns all lists
+func (r *ListRepo) GetAll() ([]server.List, error) {
+
+	input := &dynamodb.ScanInput{
+		TableName: aws.String(listsTableName),
+	}
+
+	l := []server.List{}
+
+	for {
+		result, err := r.db.Scan(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get lists from database")
+		}
+
+		page := []server.List{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, errors.Wrap(err, "Could not unmarshal lists")
+		}
+		l = append(l, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return l, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// Save creates or updates a list
+func (r *ListRepo) Save(list *server.List) error {
+
+	if list.ID == "" {
+		list.ID = uuid.NewV4().String()
+	}
+
+	list.ModTime = time.Now()
+
+	l, err := dynamodbattribute.MarshalMap(list)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal list %s", list.ID)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(listsTableName),
+		Item:      l,
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		return errors.Wrapf(err, "Could not save list %s to database", list.ID)
+	}
+
+	return nil
+}
+
+// Delete permanently removes a list
+func (r *ListRepo) Delete(id string) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(listsTableName),
+		Key:       mapID(id),
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil {
+		return errors.Wrapf(err, "Could not delete list %s from database", id)
+	}
+
+	return nil
+}
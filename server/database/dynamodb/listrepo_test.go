@@ -0,0 +1,146 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestListRepo(t *testing.T) {
+	t.Run("GetList", testGetList)
+	t.Run("GetListNotFound", testGetListNotFound)
+	t.Run("GetAllLists", testGetAllLists)
+	t.Run("CreateList", testCreateList)
+	t.Run("DeleteList", testDeleteList)
+}
+
+func testGetList(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.GetItemFn = func(input *awsdynamodb.GetItemInput) (*awsdynamodb.GetItemOutput, error) {
+
+		if *input.TableName != "lists" {
+			t.Fatalf("Expected lists table, got %s", *input.TableName)
+		}
+
+		item, err := dynamodbattribute.MarshalMap(server.List{ID: testUUID, Name: "Work"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &awsdynamodb.GetItemOutput{Item: item}, nil
+	}
+
+	repo := dynamodb.NewListRepo(m)
+
+	list, err := repo.Get(testUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if list == nil || list.Name != "Work" {
+		t.Fatalf("Expected the Work list, got %+v", list)
+	}
+}
+
+func testGetListNotFound(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.GetItemFn = func(*awsdynamodb.GetItemInput) (*awsdynamodb.GetItemOutput, error) {
+		return &awsdynamodb.GetItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewListRepo(m)
+
+	list, err := repo.Get(testUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if list != nil {
+		t.Fatalf("Expected no list, got %+v", list)
+	}
+}
+
+func testGetAllLists(t *testing.T) {
+
+	m := &ClientMock{}
+
+	pages := 0
+
+	m.ScanFn = func(*awsdynamodb.ScanInput) (*awsdynamodb.ScanOutput, error) {
+
+		pages++
+
+		item, err := dynamodbattribute.MarshalMap(server.List{ID: testUUID, Name: "Work"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := &awsdynamodb.ScanOutput{Items: []map[string]*awsdynamodb.AttributeValue{item}}
+		if pages == 1 {
+			out.LastEvaluatedKey = item
+		}
+
+		return out, nil
+	}
+
+	repo := dynamodb.NewListRepo(m)
+
+	lists, err := repo.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lists) != 2 {
+		t.Fatalf("Expected 2 lists from 2 pages, got %d", len(lists))
+	}
+}
+
+func testCreateList(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.PutItemFn = func(*awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewListRepo(m)
+
+	list := &server.List{Name: "Work"}
+	if err := repo.Save(list); err != nil {
+		t.Fatal(err)
+	}
+
+	if list.ID == "" || list.ModTime.IsZero() {
+		t.Fatalf("Expected ID and modTime to be set, got %+v", list)
+	}
+
+	if !m.PutItemInvoked {
+		t.Fatal("PutItem not invoked")
+	}
+}
+
+func testDeleteList(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.DeleteItemFn = func(*awsdynamodb.DeleteItemInput) (*awsdynamodb.DeleteItemOutput, error) {
+		return &awsdynamodb.DeleteItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewListRepo(m)
+
+	if err := repo.Delete(testUUID); err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.DeleteItemInvoked {
+		t.Fatal("DeleteItem not invoked")
+	}
+}
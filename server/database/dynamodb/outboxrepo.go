@@ -0,0 +1,155 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// outboxTableName is the table storing the events of the changes of ToDos until they are
+// published, published events are removed by the DynamoDB TTL on the expiresAt attribute
+const outboxTableName = "outbox"
+
+// publishedRetention is how long published events are kept to deduplicate them
+const publishedRetention = 7 * 24 * time.Hour
+
+// OutboxRepo represents a DynamoDB repository for relaying the events of the outbox
+type OutboxRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewOutboxRepo returns a new outbox repository using the given DynamoDB client
+func NewOutboxRepo(db dynamodbiface.DynamoDBAPI) *OutboxRepo {
+	return &OutboxRepo{db}
+}
+
+// Pending returns up to limit events which were not published yet, oldest first
+func (r *OutboxRepo) Pending(limit int) ([]server.Event, error) {
+
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(outboxTableName),
+		FilterExpression: aws.String("attribute_not_exists(published)"),
+		ConsistentRead:   aws.Bool(true),
+	}
+
+	records := []database.OutboxRecord{}
+
+	for len(records) < limit {
+		result, err := r.db.Scan(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get pending events from database")
+		}
+
+		page := []database.OutboxRecord{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, errors.Wrap(err, "Could not unmarshal pending events")
+		}
+		records = append(records, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Created.Before(records[j].Created)
+	})
+
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	events := make([]server.Event, len(records))
+	for i, rec := range records {
+		if err := json.Unmarshal([]byte(rec.Event), &events[i]); err != nil {
+			return nil, errors.Wrapf(err, "Could not unmarshal event %s", rec.ID)
+		}
+	}
+
+	return events, nil
+}
+
+// Claim reserves an event until the lease expires, unless it was published or another relay
+// holds an unexpired claim on it. Events which expired from the outbox cannot be claimed.
+func (r *OutboxRepo) Claim(id string, lease time.Duration) (bool, error) {
+
+	now := time.Now()
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(outboxTableName),
+		Key:                 mapID(id),
+		UpdateExpression:    aws.String("SET claimedUntil = :claimedUntil"),
+		ConditionExpression: aws.String("attribute_exists(id) AND attribute_not_exists(published) AND (attribute_not_exists(claimedUntil) OR claimedUntil < :now)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":claimedUntil": {N: aws.String(strconv.FormatInt(now.Add(lease).Unix(), 10))},
+			":now":          {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+		},
+	}
+
+	if _, err := r.db.UpdateItem(input); err != nil {
+		if isConditionFailed(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "Could not claim event %s", id)
+	}
+
+	return true, nil
+}
+
+// MarkPublished marks an event published and sets it to expire after the retention period
+func (r *OutboxRepo) MarkPublished(id string) error {
+
+	expires := time.Now().Add(publishedRetention).Unix()
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(outboxTableName),
+		Key:              mapID(id),
+		UpdateExpression: aws.String("SET published = :published, expiresAt = :expiresAt"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":published": {BOOL: aws.Bool(true)},
+			":expiresAt": {N: aws.String(strconv.FormatInt(expires, 10))},
+		},
+	}
+
+	if _, err := r.db.UpdateItem(input); err != nil {
+		return errors.Wrapf(err, "Could not mark event %s published", id)
+	}
+
+	return nil
+}
+
+// outboxPut returns the write of an event to the outbox, part of the transaction of a change
+func outboxPut(event server.Event) (*dynamodb.TransactWriteItem, error) {
+
+	js, err := json.Marshal(event)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not marshal event %s", event.ID)
+	}
+
+	item, err := dynamodbattribute.MarshalMap(database.OutboxRecord{
+		ID:      event.ID,
+		Event:   string(js),
+		Created: event.Time,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not marshal event %s", event.ID)
+	}
+
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			TableName:           aws.String(outboxTableName),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		},
+	}, nil
+}
@@ -0,0 +1,212 @@
+package dynamodb_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+const testActor = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+
+func TestOutbox(t *testing.T) {
+	t.Run("SaveWithEvent", testSaveWithEvent)
+	t.Run("DeleteWithEvent", testDeleteWithEvent)
+	t.Run("PendingOldestFirst", testPendingOldestFirst)
+	t.Run("MarkPublished", testMarkPublished)
+	t.Run("ClaimEvent", testClaimEvent)
+	t.Run("ClaimEventClaimed", testClaimEventClaimed)
+}
+
+// outboxEvent returns the event recorded by a write to the outbox
+func outboxEvent(t *testing.T, item *awsdynamodb.TransactWriteItem) server.Event {
+
+	if item.Put == nil || *item.Put.TableName != "outbox" || *item.Put.ConditionExpression != "attribute_not_exists(id)" {
+		t.Fatalf("Expected a new record of the outbox, got %s", item)
+	}
+
+	var rec database.OutboxRecord
+	if err := dynamodbattribute.UnmarshalMap(item.Put.Item, &rec); err != nil {
+		t.Fatal(err)
+	}
+
+	var e server.Event
+	if err := json.Unmarshal([]byte(rec.Event), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.ID != e.ID {
+		t.Fatalf("Expected the record to be keyed by the event ID %s, got %s", e.ID, rec.ID)
+	}
+
+	return e
+}
+
+func testSaveWithEvent(t *testing.T) {
+
+	m := &ClientMock{}
+
+	var items []*awsdynamodb.TransactWriteItem
+	m.TransactWriteItemsFn = func(input *awsdynamodb.TransactWriteItemsInput) (*awsdynamodb.TransactWriteItemsOutput, error) {
+		items = input.TransactItems
+		return &awsdynamodb.TransactWriteItemsOutput{}, nil
+	}
+
+	todo := &server.ToDo{Title: "New"}
+	err := dynamodb.NewToDoRepo(m).SaveWithEvent(todo, func(after *server.ToDo) server.Event {
+		return server.NewToDoEvent(testActor, nil, after)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.PutItemInvoked || len(items) != 2 {
+		t.Fatal("Expected the ToDo and the event to be written in a single transaction")
+	}
+
+	if *items[0].Put.TableName != "todos" {
+		t.Fatalf("Expected the ToDo to be written first, got %s", items[0])
+	}
+
+	e := outboxEvent(t, items[1])
+	if e.Type != server.EventToDoCreated || e.ToDoID != todo.ID || e.After.ModTime.IsZero() {
+		t.Fatalf("Expected the event of the saved ToDo, got %+v", e)
+	}
+}
+
+func testDeleteWithEvent(t *testing.T) {
+
+	m := &ClientMock{}
+
+	var items []*awsdynamodb.TransactWriteItem
+	m.TransactWriteItemsFn = func(input *awsdynamodb.TransactWriteItemsInput) (*awsdynamodb.TransactWriteItemsOutput, error) {
+		items = input.TransactItems
+		return &awsdynamodb.TransactWriteItemsOutput{}, nil
+	}
+
+	e := server.NewToDoEvent(testActor, &server.ToDo{ID: testUUID}, nil)
+	if err := dynamodb.NewToDoRepo(m).DeleteWithEvent(testUUID, e); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.DeleteItemInvoked || len(items) != 2 || items[0].Delete == nil || *items[0].Delete.Key["id"].S != testUUID {
+		t.Fatal("Expected the ToDo to be deleted in the transaction writing the event")
+	}
+
+	if outboxEvent(t, items[1]).ID != e.ID {
+		t.Fatal("Expected the deletion event to be written")
+	}
+}
+
+func testPendingOldestFirst(t *testing.T) {
+
+	m := &ClientMock{}
+
+	now := time.Now()
+	pages := [][]server.Event{
+		{{ID: "b", Time: now}},
+		{{ID: "a", Time: now.Add(-time.Minute)}},
+	}
+
+	m.ScanFn = func(input *awsdynamodb.ScanInput) (*awsdynamodb.ScanOutput, error) {
+
+		if *input.TableName != "outbox" || *input.FilterExpression != "attribute_not_exists(published)" {
+			t.Fatal("Expected a scan of the unpublished events of the outbox")
+		}
+
+		page := pages[0]
+		pages = pages[1:]
+
+		out := &awsdynamodb.ScanOutput{}
+		for _, e := range page {
+			js, _ := json.Marshal(e)
+			item, err := dynamodbattribute.MarshalMap(database.OutboxRecord{ID: e.ID, Event: string(js), Created: e.Time})
+			if err != nil {
+				t.Fatal(err)
+			}
+			out.Items = append(out.Items, item)
+		}
+
+		if len(pages) > 0 {
+			out.LastEvaluatedKey = out.Items[0]
+		}
+
+		return out, nil
+	}
+
+	events, err := dynamodb.NewOutboxRepo(m).Pending(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].ID != "a" || events[1].ID != "b" {
+		t.Fatalf("Expected the events of both pages oldest first, got %+v", events)
+	}
+}
+
+func testMarkPublished(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(input *awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+		if *input.TableName != "outbox" || *input.Key["id"].S != "e1" || !*input.ExpressionAttributeValues[":published"].BOOL {
+			t.Fatalf("Expected event e1 to be marked published, got %s", input)
+		}
+		if input.ExpressionAttributeValues[":expiresAt"].N == nil {
+			t.Fatal("Expected the published event to expire")
+		}
+		return &awsdynamodb.UpdateItemOutput{}, nil
+	}
+
+	if err := dynamodb.NewOutboxRepo(m).MarkPublished("e1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testClaimEvent(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(input *awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+		if *input.TableName != "outbox" || *input.Key["id"].S != "e1" {
+			t.Fatalf("Expected event e1 to be claimed, got %s", input)
+		}
+		if input.ConditionExpression == nil || input.ExpressionAttributeValues[":claimedUntil"].N == nil {
+			t.Fatal("Expected a conditional claim with an expiry")
+		}
+		return &awsdynamodb.UpdateItemOutput{}, nil
+	}
+
+	claimed, err := dynamodb.NewOutboxRepo(m).Claim("e1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !claimed {
+		t.Fatal("Expected the event to be claimed")
+	}
+}
+
+func testClaimEventClaimed(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(input *awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+		return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "claimed", nil)
+	}
+
+	claimed, err := dynamodb.NewOutboxRepo(m).Claim("e1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claimed {
+		t.Fatal("Expected an event claimed by another relay not to be claimed again")
+	}
+}
@@ -0,0 +1,190 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// profilesTableName is the table storing the profile of each user, keyed by user
+const profilesTableName = "profiles"
+
+// emailsTableName is the table storing the user who claimed each email address, keyed by email,
+// so that an address verifies a single profile
+const emailsTableName = "emails"
+
+// ProfileRepo represents a DynamoDB repository for managing the profiles of users
+type ProfileRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewProfileRepo returns a new profile repository using the given DynamoDB client
+func NewProfileRepo(db dynamodbiface.DynamoDBAPI) *ProfileRepo {
+	return &ProfileRepo{db}
+}
+
+// Get returns the profile of a user, or nil if they have none
+func (r *ProfileRepo) Get(user string) (*server.Profile, error) {
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(profilesTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"user": {S: aws.String(user)},
+		},
+	}
+
+	result, err := r.db.GetItem(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get profile of %s from database", user)
+	}
+
+	p := &server.Profile{}
+	if err := dynamodbattribute.UnmarshalMap(result.Item, p); err != nil {
+		return nil, errors.Wrapf(err, "Could not unmarshal profile of %s", user)
+	}
+
+	if p.User == "" {
+		return nil, nil
+	}
+
+	return p, nil
+}
+
+// GetByEmail returns the profile with the given verified email address, or nil if there is
+// none: the address is not claimed, or the user who claimed it has changed their address since
+func (r *ProfileRepo) GetByEmail(email string) (*server.Profile, error) {
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(emailsTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"email": {S: aws.String(email)},
+		},
+	}
+
+	result, err := r.db.GetItem(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get claim of %s from database", email)
+	}
+
+	user := result.Item["user"]
+	if user == nil || user.S == nil {
+		return nil, nil
+	}
+
+	p, err := r.Get(*user.S)
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	if p.Email != email || !p.EmailVerified {
+		return nil, nil
+	}
+
+	return p, nil
+}
+
+// ClaimEmail reserves an email address for a user, it returns ErrExists if another user
+// claimed it
+func (r *ProfileRepo) ClaimEmail(email, user string) error {
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(emailsTableName),
+		Item: map[string]*dynamodb.AttributeValue{
+			"email": {S: aws.String(email)},
+			"user":  {S: aws.String(user)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(email) OR #user = :user"),
+		ExpressionAttributeNames: map[string]*string{
+			"#user": aws.String("user"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":user": {S: aws.String(user)},
+		},
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		if isConditionFailed(err) {
+			return errors.Wrapf(database.ErrExists, "email address %s", email)
+		}
+		return errors.Wrapf(err, "Could not claim email address %s for %s", email, user)
+	}
+
+	return nil
+}
+
+// ReleaseEmail removes the claim of a user on an email address, claims of other users are kept
+func (r *ProfileRepo) ReleaseEmail(email, user string) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(emailsTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"email": {S: aws.String(email)},
+		},
+		ConditionExpression: aws.String("#user = :user"),
+		ExpressionAttributeNames: map[string]*string{
+			"#user": aws.String("user"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":user": {S: aws.String(user)},
+		},
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil && !isConditionFailed(err) {
+		return errors.Wrapf(err, "Could not release email address %s of %s", email, user)
+	}
+
+	return nil
+}
+
+// GetSubscribed returns the profiles of the users receiving a digest
+func (r *ProfileRepo) GetSubscribed() ([]server.Profile, error) {
+
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(profilesTableName),
+		FilterExpression: aws.String("attribute_exists(digest)"),
+	}
+
+	p := []server.Profile{}
+
+	for {
+		result, err := r.db.Scan(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get subscribed profiles from database")
+		}
+
+		page := []server.Profile{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, errors.Wrap(err, "Could not unmarshal subscribed profiles")
+		}
+		p = append(p, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return p, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// Save creates or replaces the profile of a user
+func (r *ProfileRepo) Save(profile *server.Profile) error {
+
+	p, err := dynamodbattribute.MarshalMap(profile)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal profile of %s", profile.User)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(profilesTableName),
+		Item:      p,
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		return errors.Wrapf(err, "Could not save profile of %s to database", profile.User)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/pkg/errors"
+)
+
+func TestProfileRepo(t *testing.T) {
+	t.Run("GetByVerifiedEmail", testGetByVerifiedEmail)
+	t.Run("ClaimEmailTwice", testClaimEmailTwice)
+}
+
+// profileClient stores the claim of user@example.com by testActor, and the given profile
+func profileClient(t *testing.T, profile server.Profile) *ClientMock {
+
+	m := &ClientMock{}
+
+	m.GetItemFn = func(input *awsdynamodb.GetItemInput) (*awsdynamodb.GetItemOutput, error) {
+
+		if *input.TableName == "emails" {
+			if *input.Key["email"].S != "user@example.com" {
+				return &awsdynamodb.GetItemOutput{}, nil
+			}
+			return &awsdynamodb.GetItemOutput{Item: map[string]*awsdynamodb.AttributeValue{
+				"email": {S: aws.String("user@example.com")},
+				"user":  {S: aws.String(testActor)},
+			}}, nil
+		}
+
+		item, err := dynamodbattribute.MarshalMap(profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &awsdynamodb.GetItemOutput{Item: item}, nil
+	}
+
+	return m
+}
+
+func testGetByVerifiedEmail(t *testing.T) {
+
+	cases := []struct {
+		profile server.Profile
+		email   string
+		found   bool
+	}{
+		{server.Profile{User: testActor, Email: "user@example.com", EmailVerified: true}, "user@example.com", true},
+		{server.Profile{User: testActor, Email: "user@example.com"}, "user@example.com", false},
+		// The user changed their address since claiming it
+		{server.Profile{User: testActor, Email: "new@example.com", EmailVerified: true}, "user@example.com", false},
+		{server.Profile{User: testActor, Email: "user@example.com", EmailVerified: true}, "other@example.com", false},
+	}
+
+	for _, c := range cases {
+
+		p, err := dynamodb.NewProfileRepo(profileClient(t, c.profile)).GetByEmail(c.email)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (p != nil) != c.found {
+			t.Fatalf("Expected found %v for %s with profile %+v, got %+v", c.found, c.email, c.profile, p)
+		}
+	}
+}
+
+func testClaimEmailTwice(t *testing.T) {
+
+	m := &ClientMock{}
+
+	claims := map[string]string{}
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if *input.TableName != "emails" || input.ConditionExpression == nil {
+			t.Fatal("Expected a conditional put of the claim")
+		}
+
+		email, user := *input.Item["email"].S, *input.Item["user"].S
+		if owner, ok := claims[email]; ok && owner != user {
+			return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+		}
+		claims[email] = user
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewProfileRepo(m)
+
+	if err := repo.ClaimEmail("user@example.com", testActor); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.ClaimEmail("user@example.com", testActor); err != nil {
+		t.Fatalf("Expected the user to claim their address again, got %v", err)
+	}
+
+	if err := repo.ClaimEmail("user@example.com", "other-user"); errors.Cause(err) != database.ErrExists {
+		t.Fatalf("Expected %v, got %v", database.ErrExists, err)
+	}
+}
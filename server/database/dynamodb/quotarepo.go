@@ -0,0 +1,77 @@
+package dynamodb
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// quotasTableName is the table storing the number of ToDos each user owns
+const quotasTableName = "quotas"
+
+// QuotaRepo represents a DynamoDB repository counting the ToDos each user owns
+type QuotaRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewQuotaRepo returns a new quota repository using the given DynamoDB client
+func NewQuotaRepo(db dynamodbiface.DynamoDBAPI) *QuotaRepo {
+	return &QuotaRepo{db}
+}
+
+// Reserve adds one to the count of owner in a single conditional update, so that concurrent
+// requests cannot exceed max
+func (r *QuotaRepo) Reserve(owner string, max int) error {
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(quotasTableName),
+		Key:              mapID(owner),
+		UpdateExpression: aws.String("ADD #count :one"),
+		// count is a reserved word
+		ExpressionAttributeNames: map[string]*string{"#count": aws.String("count")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":one": {N: aws.String("1")},
+		},
+	}
+
+	if max > 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(#count) OR #count < :max")
+		input.ExpressionAttributeValues[":max"] = &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(max))}
+	}
+
+	if _, err := r.db.UpdateItem(input); err != nil {
+		if isConditionFailed(err) {
+			return errors.Wrapf(database.ErrLimitReached, "quota of %s", owner)
+		}
+		return errors.Wrapf(err, "Could not reserve quota of %s in database", owner)
+	}
+
+	return nil
+}
+
+// Release subtracts one from the count of owner. The count does not go below zero, as the
+// ToDos created before the quota was enforced were not counted.
+func (r *QuotaRepo) Release(owner string) error {
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                aws.String(quotasTableName),
+		Key:                      mapID(owner),
+		UpdateExpression:         aws.String("ADD #count :minusOne"),
+		ConditionExpression:      aws.String("#count > :zero"),
+		ExpressionAttributeNames: map[string]*string{"#count": aws.String("count")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":minusOne": {N: aws.String("-1")},
+			":zero":     {N: aws.String("0")},
+		},
+	}
+
+	if _, err := r.db.UpdateItem(input); err != nil && !isConditionFailed(err) {
+		return errors.Wrapf(err, "Could not release quota of %s in database", owner)
+	}
+
+	return nil
+}
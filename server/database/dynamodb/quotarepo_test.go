@@ -0,0 +1,89 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/pkg/errors"
+)
+
+func TestQuotaRepo(t *testing.T) {
+	t.Run("ReserveQuota", testReserveQuota)
+	t.Run("ReserveQuotaWithoutLimit", testReserveQuotaWithoutLimit)
+	t.Run("ReserveQuotaLimitReached", testReserveQuotaLimitReached)
+	t.Run("ReleaseQuotaAtZero", testReleaseQuotaAtZero)
+}
+
+func testReserveQuota(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(input *awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+
+		if *input.UpdateExpression != "ADD #count :one" {
+			t.Fatalf("Unexpected update %s", *input.UpdateExpression)
+		}
+
+		if *input.ConditionExpression != "attribute_not_exists(#count) OR #count < :max" {
+			t.Fatalf("Unexpected condition %s", *input.ConditionExpression)
+		}
+
+		if *input.ExpressionAttributeValues[":max"].N != "10" {
+			t.Fatalf("Expected max 10, got %s", *input.ExpressionAttributeValues[":max"].N)
+		}
+
+		return &awsdynamodb.UpdateItemOutput{}, nil
+	}
+
+	if err := dynamodb.NewQuotaRepo(m).Reserve("user-1", 10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testReserveQuotaWithoutLimit(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(input *awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+
+		if input.ConditionExpression != nil {
+			t.Fatalf("Unexpected condition %s", *input.ConditionExpression)
+		}
+
+		return &awsdynamodb.UpdateItemOutput{}, nil
+	}
+
+	if err := dynamodb.NewQuotaRepo(m).Reserve("user-1", 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testReserveQuotaLimitReached(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(*awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+		return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+
+	err := dynamodb.NewQuotaRepo(m).Reserve("user-1", 10)
+	if errors.Cause(err) != database.ErrLimitReached {
+		t.Fatalf("Expected %v, got %v", database.ErrLimitReached, err)
+	}
+}
+
+func testReleaseQuotaAtZero(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(*awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+		return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+
+	if err := dynamodb.NewQuotaRepo(m).Release("user-1"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,105 @@
+package dynamodb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// rateLimitsTableName is the table storing token buckets, full buckets are removed by the
+// DynamoDB TTL on the expiresAt attribute
+const rateLimitsTableName = "rate_limits"
+
+// RateLimitRepo represents a DynamoDB repository for managing rate limit buckets
+type RateLimitRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewRateLimitRepo returns a new rate limit repository using the given DynamoDB client
+func NewRateLimitRepo(db dynamodbiface.DynamoDBAPI) *RateLimitRepo {
+	return &RateLimitRepo{db}
+}
+
+// Take takes a token from the bucket of key in a single conditional update, so that concurrent
+// requests cannot take more tokens than the bucket holds.
+//
+// A bucket is stored as fullAt, the time it is full again: it holds capacity tokens minus one
+// for each interval until fullAt. Taking a token moves fullAt one interval later, which refills
+// the tokens for the time elapsed since the last request without reading the bucket first, and
+// the bucket has a token left as long as fullAt is at most capacity-1 intervals from now. A
+// bucket that is already full, or missing, is started again from now instead, as the tokens
+// cannot go above capacity.
+func (r *RateLimitRepo) Take(key string, capacity int, interval time.Duration, now time.Time) (time.Time, error) {
+
+	latest := now.Add(time.Duration(capacity-1) * interval)
+	expiresAt := now.Add(time.Duration(capacity)*interval + time.Second)
+
+	values := map[string]*dynamodb.AttributeValue{
+		":now":       {N: aws.String(nanos(now))},
+		":expiresAt": {N: aws.String(strconv.FormatInt(expiresAt.Unix(), 10))},
+	}
+
+	take := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(rateLimitsTableName),
+		Key:                 mapKey(key),
+		UpdateExpression:    aws.String("SET fullAt = fullAt + :interval, expiresAt = :expiresAt"),
+		ConditionExpression: aws.String("fullAt BETWEEN :now AND :latest"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now":       values[":now"],
+			":expiresAt": values[":expiresAt"],
+			":interval":  {N: aws.String(strconv.FormatInt(int64(interval), 10))},
+			":latest":    {N: aws.String(nanos(latest))},
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueUpdatedNew),
+	}
+
+	start := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(rateLimitsTableName),
+		Key:                 mapKey(key),
+		UpdateExpression:    aws.String("SET fullAt = :next, expiresAt = :expiresAt"),
+		ConditionExpression: aws.String("attribute_not_exists(fullAt) OR fullAt < :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now":       values[":now"],
+			":expiresAt": values[":expiresAt"],
+			":next":      {N: aws.String(nanos(now.Add(interval)))},
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueUpdatedNew),
+	}
+
+	// The bucket is full or missing when taking fails its condition, unless it is empty. A
+	// request racing another one to start a full bucket is rejected too, which can only
+	// underestimate the tokens left.
+	for _, input := range []*dynamodb.UpdateItemInput{take, start} {
+
+		result, err := r.db.UpdateItem(input)
+		if isConditionFailed(err) {
+			continue
+		} else if err != nil {
+			return time.Time{}, errors.Wrapf(err, "Could not take a token from rate limit %s in database", key)
+		}
+
+		fullAt, ok := result.Attributes["fullAt"]
+		if !ok || fullAt.N == nil {
+			return time.Time{}, errors.Errorf("Could not read rate limit %s", key)
+		}
+
+		n, err := strconv.ParseInt(*fullAt.N, 10, 64)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "Could not read rate limit %s", key)
+		}
+
+		return time.Unix(0, n), nil
+	}
+
+	return time.Time{}, errors.Wrapf(database.ErrLimitReached, "rate limit %s", key)
+}
+
+// nanos returns t as the number of nanoseconds since the Unix epoch
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,117 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/pkg/errors"
+)
+
+func TestRateLimitRepo(t *testing.T) {
+	t.Run("TakeToken", testTakeToken)
+	t.Run("TakeTokenFullBucket", testTakeTokenFullBucket)
+	t.Run("TakeTokenLimitReached", testTakeTokenLimitReached)
+}
+
+func conditionFailed() error {
+	return awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+}
+
+func testTakeToken(t *testing.T) {
+
+	now := time.Unix(1562917200, 0)
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(input *awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+
+		if *input.UpdateExpression != "SET fullAt = fullAt + :interval, expiresAt = :expiresAt" {
+			t.Fatalf("Unexpected update %s", *input.UpdateExpression)
+		}
+
+		if *input.ConditionExpression != "fullAt BETWEEN :now AND :latest" {
+			t.Fatalf("Unexpected condition %s", *input.ConditionExpression)
+		}
+
+		if *input.ExpressionAttributeValues[":latest"].N != "1562917209000000000" {
+			t.Fatalf("Expected latest 9 intervals from now, got %s", *input.ExpressionAttributeValues[":latest"].N)
+		}
+
+		if *input.ExpressionAttributeValues[":interval"].N != "1000000000" {
+			t.Fatalf("Expected interval of 1s, got %s", *input.ExpressionAttributeValues[":interval"].N)
+		}
+
+		return &awsdynamodb.UpdateItemOutput{
+			Attributes: map[string]*awsdynamodb.AttributeValue{
+				"fullAt": {N: aws.String("1562917204000000000")},
+			},
+		}, nil
+	}
+
+	repo := dynamodb.NewRateLimitRepo(m)
+
+	fullAt, err := repo.Take("user#1", 10, time.Second, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fullAt.Equal(now.Add(4 * time.Second)) {
+		t.Fatalf("Expected bucket full in 4s, got %s", fullAt)
+	}
+}
+
+func testTakeTokenFullBucket(t *testing.T) {
+
+	now := time.Unix(1562917200, 0)
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(input *awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+
+		if *input.ConditionExpression != "attribute_not_exists(fullAt) OR fullAt < :now" {
+			return nil, conditionFailed()
+		}
+
+		if *input.ExpressionAttributeValues[":next"].N != "1562917201000000000" {
+			t.Fatalf("Expected bucket started from now, got %s", *input.ExpressionAttributeValues[":next"].N)
+		}
+
+		return &awsdynamodb.UpdateItemOutput{
+			Attributes: map[string]*awsdynamodb.AttributeValue{
+				"fullAt": input.ExpressionAttributeValues[":next"],
+			},
+		}, nil
+	}
+
+	repo := dynamodb.NewRateLimitRepo(m)
+
+	fullAt, err := repo.Take("user#1", 10, time.Second, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fullAt.Equal(now.Add(time.Second)) {
+		t.Fatalf("Expected bucket full in 1s, got %s", fullAt)
+	}
+}
+
+func testTakeTokenLimitReached(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.UpdateItemFn = func(*awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+		return nil, conditionFailed()
+	}
+
+	repo := dynamodb.NewRateLimitRepo(m)
+
+	_, err := repo.Take("user#1", 10, time.Second, time.Now())
+	if errors.Cause(err) != database.ErrLimitReached {
+		t.Fatalf("Expected %v, got %v", database.ErrLimitReached, err)
+	}
+}
@@ -0,0 +1,159 @@
+package dynamodb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// reminderIndexName is the sparse global secondary index of the todos table keyed by
+// reminderQueue and remindAt, which only holds the ToDos with a reminder to send
+const reminderIndexName = "reminder-index"
+
+// reminderQueue is the partition of the reminder index
+const reminderQueue = "pending"
+
+// remindersSentTableName is the table storing a marker for each reminder sent, markers are
+// removed by the DynamoDB TTL on the expiresAt attribute
+const remindersSentTableName = "reminders_sent"
+
+// sentRetention is how long the marker of a sent reminder is kept
+const sentRetention = 30 * 24 * time.Hour
+
+// indexReminder adds the item of a ToDo to the reminder index at the time of its next reminder,
+// or leaves it out of the index if there is none
+func indexReminder(item map[string]*dynamodb.AttributeValue, next *time.Time) {
+
+	if next == nil {
+		return
+	}
+
+	item["reminderQueue"] = &dynamodb.AttributeValue{S: aws.String(reminderQueue)}
+	item["remindAt"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(next.UnixNano(), 10))}
+}
+
+// reminderItem is a ToDo along with the time of its next reminder, in Unix nanoseconds
+type reminderItem struct {
+	server.ToDo
+	RemindAt int64 `json:"remindAt"`
+}
+
+// ReminderRepo represents a DynamoDB repository for sending the reminders of ToDos once
+type ReminderRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewReminderRepo returns a new reminder repository using the given DynamoDB client
+func NewReminderRepo(db dynamodbiface.DynamoDBAPI) *ReminderRepo {
+	return &ReminderRepo{db}
+}
+
+// Due returns up to limit reminders due at now, oldest first
+func (r *ReminderRepo) Due(now time.Time, limit int) ([]database.DueReminder, error) {
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(todosTableName),
+		IndexName:              aws.String(reminderIndexName),
+		KeyConditionExpression: aws.String("reminderQueue = :queue AND remindAt <= :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":queue": {S: aws.String(reminderQueue)},
+			":now":   {N: aws.String(strconv.FormatInt(now.UnixNano(), 10))},
+		},
+		Limit: aws.Int64(int64(limit)),
+	}
+
+	result, err := r.db.Query(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not get due reminders from database")
+	}
+
+	items := []reminderItem{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &items); err != nil {
+		return nil, errors.Wrap(err, "Could not unmarshal due reminders")
+	}
+
+	due := make([]database.DueReminder, len(items))
+	for i, item := range items {
+		due[i] = database.DueReminder{ToDo: item.ToDo, At: time.Unix(0, item.RemindAt)}
+	}
+
+	return due, nil
+}
+
+// sentKey returns the key of the marker of a reminder
+func sentKey(todoID string, at time.Time) map[string]*dynamodb.AttributeValue {
+	return mapID(todoID + "#" + strconv.FormatInt(at.UnixNano(), 10))
+}
+
+// MarkSent marks a reminder sent, it returns ErrExists if it already was
+func (r *ReminderRepo) MarkSent(todoID string, at time.Time) error {
+
+	item := sentKey(todoID, at)
+	item["expiresAt"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(at.Add(sentRetention).Unix(), 10))}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(remindersSentTableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		if isConditionFailed(err) {
+			return errors.Wrapf(database.ErrExists, "reminder of ToDo %s at %s", todoID, at)
+		}
+		return errors.Wrapf(err, "Could not mark reminder of ToDo %s sent", todoID)
+	}
+
+	return nil
+}
+
+// Unmark removes the sent marker of a reminder which could not be sent
+func (r *ReminderRepo) Unmark(todoID string, at time.Time) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(remindersSentTableName),
+		Key:       sentKey(todoID, at),
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil {
+		return errors.Wrapf(err, "Could not unmark reminder of ToDo %s", todoID)
+	}
+
+	return nil
+}
+
+// Advance indexes a ToDo at its reminder following at, or removes it from the index if there is
+// none. Nothing is done if the reminder of the ToDo was changed since at was due.
+func (r *ReminderRepo) Advance(todo *server.ToDo, at time.Time) error {
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(todosTableName),
+		Key:                 mapID(todo.ID),
+		UpdateExpression:    aws.String("REMOVE reminderQueue, remindAt"),
+		ConditionExpression: aws.String("remindAt = :at"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":at": {N: aws.String(strconv.FormatInt(at.UnixNano(), 10))},
+		},
+	}
+
+	if next := todo.NextReminder(at); next != nil {
+		input.UpdateExpression = aws.String("SET remindAt = :next")
+		input.ExpressionAttributeValues[":next"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(next.UnixNano(), 10))}
+	}
+
+	if _, err := r.db.UpdateItem(input); err != nil {
+		if isConditionFailed(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "Could not advance the reminders of ToDo %s", todo.ID)
+	}
+
+	return nil
+}
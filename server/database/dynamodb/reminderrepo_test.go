@@ -0,0 +1,127 @@
+package dynamodb_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/pkg/errors"
+)
+
+func TestReminderRepo(t *testing.T) {
+	t.Run("SaveIndexesNextReminder", testSaveIndexesNextReminder)
+	t.Run("SaveCompletedNotIndexed", testSaveCompletedNotIndexed)
+	t.Run("MarkSentTwice", testMarkSentTwice)
+	t.Run("AdvanceToNextReminder", testAdvanceToNextReminder)
+}
+
+func testSaveIndexesNextReminder(t *testing.T) {
+
+	m := &ClientMock{}
+	past := time.Now().Add(-time.Hour)
+	next := time.Now().Add(time.Hour)
+	later := next.Add(time.Hour)
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if *input.Item["reminderQueue"].S != "pending" {
+			t.Fatal("Expected the ToDo to be added to the reminder index")
+		}
+
+		if *input.Item["remindAt"].N != strconv.FormatInt(next.UnixNano(), 10) {
+			t.Fatalf("Expected the ToDo to be indexed at its next reminder, got %s", *input.Item["remindAt"].N)
+		}
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	todo := &server.ToDo{Title: "New ToDo", Reminders: []time.Time{later, past, next}}
+	if err := dynamodb.NewToDoRepo(m).Save(todo); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testSaveCompletedNotIndexed(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if input.Item["reminderQueue"] != nil || input.Item["remindAt"] != nil {
+			t.Fatal("Expected a completed ToDo to be left out of the reminder index")
+		}
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	todo := &server.ToDo{Title: "New ToDo", Completed: true, Reminders: []time.Time{time.Now().Add(time.Hour)}}
+	if err := dynamodb.NewToDoRepo(m).Save(todo); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testMarkSentTwice(t *testing.T) {
+
+	m := &ClientMock{}
+	sent := map[string]bool{}
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if *input.TableName != "reminders_sent" || input.ConditionExpression == nil {
+			t.Fatal("Expected the marker to be saved only if it does not exist")
+		}
+
+		id := *input.Item["id"].S
+		if sent[id] {
+			return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+		}
+		sent[id] = true
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	repo := dynamodb.NewReminderRepo(m)
+	at := time.Now()
+
+	if err := repo.MarkSent(testUUID, at); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.MarkSent(testUUID, at); errors.Cause(err) != database.ErrExists {
+		t.Fatalf("Expected ErrExists, got %v", err)
+	}
+
+	if err := repo.MarkSent(testUUID, at.Add(time.Minute)); err != nil {
+		t.Fatalf("Expected the other reminders of the ToDo to be sent, got %v", err)
+	}
+}
+
+func testAdvanceToNextReminder(t *testing.T) {
+
+	m := &ClientMock{}
+	at := time.Now()
+	next := at.Add(time.Hour)
+
+	m.UpdateItemFn = func(input *awsdynamodb.UpdateItemInput) (*awsdynamodb.UpdateItemOutput, error) {
+
+		if *input.ExpressionAttributeValues[":at"].N != strconv.FormatInt(at.UnixNano(), 10) {
+			t.Fatal("Expected the reminder to be advanced only if it was not changed")
+		}
+
+		if *input.ExpressionAttributeValues[":next"].N != strconv.FormatInt(next.UnixNano(), 10) {
+			t.Fatal("Expected the ToDo to be indexed at its next reminder")
+		}
+
+		return nil, awserr.New(awsdynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+
+	todo := &server.ToDo{ID: testUUID, Reminders: []time.Time{at, next}}
+	if err := dynamodb.NewReminderRepo(m).Advance(todo, at); err != nil {
+		t.Fatalf("Expected a changed reminder to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+)
+
+// searchIndexTableName is the table mapping each word, the hash key, to the ToDos containing it,
+// the range key
+const searchIndexTableName = "search_index"
+
+// maxBatchWrites is the maximum number of writes of a BatchWriteItem request
+const maxBatchWrites = 25
+
+// maxBatchAttempts is the number of attempts made to write the unprocessed items of a batch
+const maxBatchAttempts = 5
+
+// SearchIndexRepo represents a DynamoDB repository for the words the ToDos can be searched by
+type SearchIndexRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewSearchIndexRepo returns a new search index repository using the given DynamoDB client
+func NewSearchIndexRepo(db dynamodbiface.DynamoDBAPI) *SearchIndexRepo {
+	return &SearchIndexRepo{db}
+}
+
+// Index adds and removes words of a ToDo from the index
+func (r *SearchIndexRepo) Index(todoID, owner string, add, remove []string) error {
+
+	writes := []*dynamodb.WriteRequest{}
+
+	for _, w := range add {
+		writes = append(writes, &dynamodb.WriteRequest{
+			PutRequest: &dynamodb.PutRequest{
+				Item: map[string]*dynamodb.AttributeValue{
+					"word":   {S: aws.String(w)},
+					"todoId": {S: aws.String(todoID)},
+					"owner":  {S: aws.String(owner)},
+				},
+			},
+		})
+	}
+
+	for _, w := range remove {
+		writes = append(writes, &dynamodb.WriteRequest{
+			DeleteRequest: &dynamodb.DeleteRequest{
+				Key: map[string]*dynamodb.AttributeValue{
+					"word":   {S: aws.String(w)},
+					"todoId": {S: aws.String(todoID)},
+				},
+			},
+		})
+	}
+
+	if err := batchWrite(r.db, searchIndexTableName, writes); err != nil {
+		return errors.Wrapf(err, "Could not index ToDo %s", todoID)
+	}
+
+	return nil
+}
+
+// batchWrite writes items of a table in batches of maxBatchWrites
+func batchWrite(db dynamodbiface.DynamoDBAPI, table string, writes []*dynamodb.WriteRequest) error {
+
+	for len(writes) > 0 {
+
+		n := len(writes)
+		if n > maxBatchWrites {
+			n = maxBatchWrites
+		}
+
+		if err := writeBatch(db, table, writes[:n]); err != nil {
+			return err
+		}
+
+		writes = writes[n:]
+	}
+
+	return nil
+}
+
+// writeBatch writes a batch, retrying the items DynamoDB did not process
+func writeBatch(db dynamodbiface.DynamoDBAPI, table string, writes []*dynamodb.WriteRequest) error {
+
+	items := map[string][]*dynamodb.WriteRequest{table: writes}
+
+	for attempt := 0; attempt < maxBatchAttempts; attempt++ {
+
+		result, err := db.BatchWriteItem(&dynamodb.BatchWriteItemInput{RequestItems: items})
+		if err != nil {
+			return err
+		}
+
+		if len(result.UnprocessedItems) == 0 {
+			return nil
+		}
+		items = result.UnprocessedItems
+	}
+
+	return errors.Errorf("%d items still unprocessed after %d attempts", len(items[table]), maxBatchAttempts)
+}
@@ -0,0 +1,50 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"testing"
+
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestSearchIndexRepo(t *testing.T) {
+	t.Run("IndexBatches", testIndexBatches)
+}
+
+func testIndexBatches(t *testing.T) {
+
+	m := &ClientMock{}
+
+	add := make([]string, 30)
+	for i := range add {
+		add[i] = fmt.Sprintf("word%d", i)
+	}
+
+	var batches []int
+	retried := false
+
+	m.BatchWriteItemFn = func(input *awsdynamodb.BatchWriteItemInput) (*awsdynamodb.BatchWriteItemOutput, error) {
+
+		writes := input.RequestItems["search_index"]
+		batches = append(batches, len(writes))
+
+		// The first write of the first batch is not processed once
+		if !retried {
+			retried = true
+			return &awsdynamodb.BatchWriteItemOutput{
+				UnprocessedItems: map[string][]*awsdynamodb.WriteRequest{"search_index": writes[:1]},
+			}, nil
+		}
+
+		return &awsdynamodb.BatchWriteItemOutput{}, nil
+	}
+
+	if err := dynamodb.NewSearchIndexRepo(m).Index(testUUID, testActor, add, []string{"old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(batches) != "[25 1 6]" {
+		t.Fatalf("Expected batches of up to 25 writes and the unprocessed write retried, got %v", batches)
+	}
+}
@@ -0,0 +1,108 @@
+package dynamodb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+)
+
+// sharesTableName is the table storing the ToDos shared with each user, partitioned by member
+// and sorted by todoId
+const sharesTableName = "shares"
+
+// changedIndexName is the local secondary index of the shares table sorted by changed, the Unix
+// time in nanoseconds the shared ToDo last changed at
+const changedIndexName = "changed-index"
+
+// ShareRepo represents a DynamoDB repository for the ToDos shared with each user
+type ShareRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewShareRepo returns a new share repository using the given DynamoDB client
+func NewShareRepo(db dynamodbiface.DynamoDBAPI) *ShareRepo {
+	return &ShareRepo{db}
+}
+
+// mapShare returns the key of a ToDo shared with a member
+func mapShare(member, todoID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"member": {S: aws.String(member)},
+		"todoId": {S: aws.String(todoID)},
+	}
+}
+
+// Share records that a ToDo shared with a member changed at the given time
+func (r *ShareRepo) Share(member, todoID string, changed time.Time) error {
+
+	item := mapShare(member, todoID)
+	item["changed"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(changed.UnixNano(), 10))}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(sharesTableName),
+		Item:      item,
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		return errors.Wrapf(err, "Could not share ToDo %s with %s", todoID, member)
+	}
+
+	return nil
+}
+
+// Unshare removes a ToDo from the ToDos shared with a member
+func (r *ShareRepo) Unshare(member, todoID string) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(sharesTableName),
+		Key:       mapShare(member, todoID),
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil {
+		return errors.Wrapf(err, "Could not unshare ToDo %s with %s", todoID, member)
+	}
+
+	return nil
+}
+
+// ChangedSince returns the IDs of the ToDos shared with a member which changed after since
+func (r *ShareRepo) ChangedSince(member string, since time.Time) ([]string, error) {
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(sharesTableName),
+		IndexName:              aws.String(changedIndexName),
+		KeyConditionExpression: aws.String("#member = :member AND changed > :since"),
+		ProjectionExpression:   aws.String("todoId"),
+		ExpressionAttributeNames: map[string]*string{
+			"#member": aws.String("member"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":member": {S: aws.String(member)},
+			":since":  {N: aws.String(strconv.FormatInt(since.UnixNano(), 10))},
+		},
+	}
+
+	ids := []string{}
+
+	for {
+		result, err := r.db.Query(input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not get ToDos shared with %s from database", member)
+		}
+
+		for _, item := range result.Items {
+			if id := item["todoId"]; id != nil && id.S != nil {
+				ids = append(ids, *id.S)
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return ids, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,70 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestShareRepo(t *testing.T) {
+	t.Run("ShareToDo", testShareToDo)
+	t.Run("SharedChangedSince", testSharedChangedSince)
+}
+
+func testShareToDo(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+		if *input.TableName != "shares" || *input.Item["member"].S != testActor || *input.Item["todoId"].S != testUUID {
+			t.Fatalf("Expected the share of the ToDo with the member, got %v", input.Item)
+		}
+		if input.Item["changed"] == nil {
+			t.Fatal("Expected the time the share changed")
+		}
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	if err := dynamodb.NewShareRepo(m).Share(testActor, testUUID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.PutItemInvoked {
+		t.Fatal("Expected the share to be saved")
+	}
+}
+
+func testSharedChangedSince(t *testing.T) {
+
+	m := &ClientMock{}
+
+	pages := 0
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.IndexName != "changed-index" || *input.ExpressionAttributeValues[":member"].S != testActor {
+			t.Fatal("Expected a query of the shares of the member by change time")
+		}
+
+		pages++
+		output := &awsdynamodb.QueryOutput{Items: []map[string]*awsdynamodb.AttributeValue{
+			{"todoId": {S: aws.String(testUUID)}},
+		}}
+		if pages == 1 {
+			output.LastEvaluatedKey = map[string]*awsdynamodb.AttributeValue{"todoId": {S: aws.String(testUUID)}}
+		}
+
+		return output, nil
+	}
+
+	ids, err := dynamodb.NewShareRepo(m).ChangedSince(testActor, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pages != 2 || len(ids) != 2 {
+		t.Fatalf("Expected the IDs of every page, got %v in %d pages", ids, pages)
+	}
+}
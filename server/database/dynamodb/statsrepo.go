@@ -0,0 +1,111 @@
+package dynamodb
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// statsTableName is the table storing the counters of each user under owner#<user>, along with
+// markers of the applied changes under change#<id>, which expire with the DynamoDB TTL on the
+// expiresAt attribute
+const statsTableName = "stats"
+
+// changeRetention is how long the markers of applied changes are kept, longer than a stream
+// keeps its records
+const changeRetention = 48 * time.Hour
+
+// StatsRepo represents a DynamoDB repository for the counters of the ToDos of each user
+type StatsRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewStatsRepo returns a new stats repository using the given DynamoDB client
+func NewStatsRepo(db dynamodbiface.DynamoDBAPI) *StatsRepo {
+	return &StatsRepo{db}
+}
+
+// statsItem is the item storing the counters of a user
+type statsItem struct {
+	ID string `json:"id"`
+	server.Stats
+}
+
+// Get returns the counters of a user
+func (r *StatsRepo) Get(owner string) (*server.Stats, error) {
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(statsTableName),
+		Key:       mapID("owner#" + owner),
+	}
+
+	result, err := r.db.GetItem(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get stats of %s from database", owner)
+	}
+
+	item := statsItem{}
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &item); err != nil {
+		return nil, errors.Wrapf(err, "Could not unmarshal stats of %s", owner)
+	}
+
+	item.Owner = owner
+
+	return &item.Stats, nil
+}
+
+// Add adds delta to the counters of its owner along with the marker of the change, in a single
+// transaction which is canceled if the change was already applied
+func (r *StatsRepo) Add(changeID string, delta server.Stats) error {
+
+	expires := time.Now().Add(changeRetention).Unix()
+
+	items := []*dynamodb.TransactWriteItem{
+		{
+			Put: &dynamodb.Put{
+				TableName: aws.String(statsTableName),
+				Item: map[string]*dynamodb.AttributeValue{
+					"id":        {S: aws.String("change#" + changeID)},
+					"expiresAt": {N: aws.String(strconv.FormatInt(expires, 10))},
+				},
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			},
+		},
+		{
+			Update: &dynamodb.Update{
+				TableName:        aws.String(statsTableName),
+				Key:              mapID("owner#" + delta.Owner),
+				UpdateExpression: aws.String("ADD #total :total, completed :completed"),
+				// total is a reserved word
+				ExpressionAttributeNames: map[string]*string{"#total": aws.String("total")},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":total":     {N: aws.String(strconv.Itoa(delta.Total))},
+					":completed": {N: aws.String(strconv.Itoa(delta.Completed))},
+				},
+			},
+		},
+	}
+
+	_, err := r.db.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		if isConditionCanceled(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "Could not add change %s to stats of %s", changeID, delta.Owner)
+	}
+
+	return nil
+}
+
+// isConditionCanceled reports whether err was caused by a transaction canceled because one of
+// its conditions failed, rather than by a conflicting transaction
+func isConditionCanceled(err error) bool {
+	return isTransactionCanceled(err) && strings.Contains(errors.Cause(err).Error(), "ConditionalCheckFailed")
+}
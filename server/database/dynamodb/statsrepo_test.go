@@ -0,0 +1,66 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestStatsRepo(t *testing.T) {
+	t.Run("Add", testStatsAdd)
+	t.Run("AddApplied", testStatsAddApplied)
+	t.Run("AddConflict", testStatsAddConflict)
+}
+
+func testStatsAdd(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.TransactWriteItemsFn = func(input *awsdynamodb.TransactWriteItemsInput) (*awsdynamodb.TransactWriteItemsOutput, error) {
+
+		marker, update := input.TransactItems[0].Put, input.TransactItems[1].Update
+
+		if *marker.Item["id"].S != "change#r1" || *marker.ConditionExpression != "attribute_not_exists(id)" {
+			t.Fatalf("Expected the marker of change r1, got %s", marker)
+		}
+
+		if *update.Key["id"].S != "owner#"+testActor || *update.ExpressionAttributeValues[":total"].N != "-1" || *update.ExpressionAttributeValues[":completed"].N != "0" {
+			t.Fatalf("Expected the counters of the owner to be decremented, got %s", update)
+		}
+
+		return &awsdynamodb.TransactWriteItemsOutput{}, nil
+	}
+
+	if err := dynamodb.NewStatsRepo(m).Add("r1", server.Stats{Owner: testActor, Total: -1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testStatsAddApplied(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.TransactWriteItemsFn = func(input *awsdynamodb.TransactWriteItemsInput) (*awsdynamodb.TransactWriteItemsOutput, error) {
+		return nil, awserr.New(awsdynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None]", nil)
+	}
+
+	if err := dynamodb.NewStatsRepo(m).Add("r1", server.Stats{Owner: testActor, Total: 1}); err != nil {
+		t.Fatalf("Expected an applied change to be ignored, got %v", err)
+	}
+}
+
+func testStatsAddConflict(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.TransactWriteItemsFn = func(input *awsdynamodb.TransactWriteItemsInput) (*awsdynamodb.TransactWriteItemsOutput, error) {
+		return nil, awserr.New(awsdynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled, please refer cancellation reasons for specific reasons [None, TransactionConflict]", nil)
+	}
+
+	if err := dynamodb.NewStatsRepo(m).Add("r1", server.Stats{Owner: testActor, Total: 1}); err == nil {
+		t.Fatal("Expected a conflicting transaction to fail the change")
+	}
+}
@@ -0,0 +1,38 @@
+package dynamodb
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// UnmarshalImage returns the ToDo of an image of a stream record, or nil if the record has no
+// such image, as for the old image of an inserted item
+func UnmarshalImage(image map[string]events.DynamoDBAttributeValue) (*server.ToDo, error) {
+
+	if len(image) == 0 {
+		return nil, nil
+	}
+
+	// Stream attributes encode to the DynamoDB JSON format, which decodes to SDK attributes
+	js, err := json.Marshal(image)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not marshal stream image")
+	}
+
+	item := map[string]*dynamodb.AttributeValue{}
+	if err := json.Unmarshal(js, &item); err != nil {
+		return nil, errors.Wrap(err, "Could not unmarshal stream image")
+	}
+
+	t := &server.ToDo{}
+	if err := dynamodbattribute.UnmarshalMap(item, t); err != nil {
+		return nil, errors.Wrap(err, "Could not unmarshal ToDo of stream image")
+	}
+
+	return t, nil
+}
@@ -0,0 +1,63 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestUnmarshalImage(t *testing.T) {
+	t.Run("ToDo", testUnmarshalImageToDo)
+	t.Run("Missing", testUnmarshalImageMissing)
+}
+
+func testUnmarshalImageToDo(t *testing.T) {
+
+	image := map[string]events.DynamoDBAttributeValue{
+		"id":        events.NewStringAttribute(testUUID),
+		"title":     events.NewStringAttribute("Buy milk"),
+		"completed": events.NewBooleanAttribute(true),
+		"modTime":   events.NewStringAttribute("2019-07-01T10:00:00Z"),
+		"members": events.NewMapAttribute(map[string]events.DynamoDBAttributeValue{
+			"u1": events.NewStringAttribute("editor"),
+		}),
+		"tags": events.NewListAttribute([]events.DynamoDBAttributeValue{
+			events.NewStringAttribute("errands"),
+		}),
+		"items": events.NewListAttribute([]events.DynamoDBAttributeValue{
+			events.NewMapAttribute(map[string]events.DynamoDBAttributeValue{
+				"id":        events.NewStringAttribute("i1"),
+				"title":     events.NewStringAttribute("Skimmed"),
+				"completed": events.NewBooleanAttribute(false),
+			}),
+		}),
+		"parentId": events.NewNullAttribute(),
+	}
+
+	todo, err := dynamodb.UnmarshalImage(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if todo.ID != testUUID || todo.Title != "Buy milk" || !todo.Completed || todo.ModTime.IsZero() {
+		t.Fatalf("Expected the scalar fields of the ToDo, got %+v", todo)
+	}
+
+	if todo.Members["u1"] != server.RoleEditor || len(todo.Tags) != 1 || len(todo.Items) != 1 || todo.Items[0].Title != "Skimmed" {
+		t.Fatalf("Expected the nested fields of the ToDo, got %+v", todo)
+	}
+}
+
+func testUnmarshalImageMissing(t *testing.T) {
+
+	todo, err := dynamodb.UnmarshalImage(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if todo != nil {
+		t.Fatalf("Expected no ToDo without an image, got %+v", todo)
+	}
+}
@@ -8,12 +8,20 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 )
 
 const todosTableName = "todos"
 
+// parentIndexName is the global secondary index of the todos table keyed by parentId. ToDos
+// without a parent are not in the index.
+const parentIndexName = "parentId-index"
+
+// listIndexName is the global secondary index of the todos table keyed by listId
+const listIndexName = "listId-index"
+
 // ToDoRepo represents a boltdb repository for managing todos
 type ToDoRepo struct {
 	db dynamodbiface.DynamoDBAPI
@@ -51,31 +59,144 @@ func (r *ToDoRepo) Get(id string) (*server.ToDo, error) {
 	return t, nil
 }
 
-// GetAll returns all ToDos
+// GetAll returns all ToDos, reading every page of the scan
 func (r *ToDoRepo) GetAll() ([]server.ToDo, error) {
 
 	input := &dynamodb.ScanInput{
 		TableName: aws.String(todosTableName),
 	}
 
-	result, err := r.db.Scan(input)
+	t := []server.ToDo{}
+
+	for {
+		result, err := r.db.Scan(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get ToDos from database")
+		}
+
+		page := []server.ToDo{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, errors.Wrap(err, "Could not unmarshal ToDos")
+		}
+		t = append(t, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return t, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// GetChildren returns the ToDos whose parent is the given ToDo
+func (r *ToDoRepo) GetChildren(parentID string) ([]server.ToDo, error) {
+
+	t, err := r.query(parentIndexName, "parentId", parentID)
 	if err != nil {
-		return nil, errors.Wrap(err, "Could not get ToDos from database")
+		return nil, errors.Wrapf(err, "Could not get children of ToDo %s", parentID)
 	}
 
-	t := []server.ToDo{}
+	return t, nil
+}
+
+// GetByList returns the ToDos in the given list
+func (r *ToDoRepo) GetByList(listID string) ([]server.ToDo, error) {
+
+	t, err := r.query(listIndexName, "listId", listID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get ToDos of list %s", listID)
+	}
+
+	return t, nil
+}
+
+// GetByOwner returns the ToDos owned by the given user, least recently modified first
+func (r *ToDoRepo) GetByOwner(owner string) ([]server.ToDo, error) {
+
+	t, err := r.query(ownerIndexName, "owner", owner)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get ToDos of user %s", owner)
+	}
+
+	return t, nil
+}
+
+// ModifiedSince returns the ToDos owned by the given user which were modified after since
+func (r *ToDoRepo) ModifiedSince(owner string, since time.Time) ([]server.ToDo, error) {
 
-	// Unmarshal the Items field in the result value to the Item Go type.
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &t)
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(todosTableName),
+		IndexName:              aws.String(ownerIndexName),
+		KeyConditionExpression: aws.String("#owner = :owner AND modTime > :since"),
+		ExpressionAttributeNames: map[string]*string{
+			"#owner": aws.String("owner"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {S: aws.String(owner)},
+			// modTime is stored in the RFC 3339 format of the times marshalled by the SDK
+			":since": {S: aws.String(since.UTC().Format(time.RFC3339Nano))},
+		},
+	}
+
+	t, err := r.queryAll(input)
 	if err != nil {
-		return nil, errors.Wrap(err, "Could not unmarshal ToDos")
+		return nil, errors.Wrapf(err, "Could not get ToDos of user %s modified since %s", owner, since)
 	}
 
 	return t, nil
 }
 
+// query returns all the ToDos of an index having the given key
+func (r *ToDoRepo) query(index, key, value string) ([]server.ToDo, error) {
+
+	return r.queryAll(&dynamodb.QueryInput{
+		TableName:              aws.String(todosTableName),
+		IndexName:              aws.String(index),
+		KeyConditionExpression: aws.String("#" + key + " = :" + key),
+		ExpressionAttributeNames: map[string]*string{
+			"#" + key: aws.String(key),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":" + key: {S: aws.String(value)},
+		},
+	})
+}
+
+// queryAll returns the ToDos of every page of a query
+func (r *ToDoRepo) queryAll(input *dynamodb.QueryInput) ([]server.ToDo, error) {
+
+	t := []server.ToDo{}
+
+	for {
+		result, err := r.db.Query(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not query database")
+		}
+
+		page := []server.ToDo{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, errors.Wrap(err, "Could not unmarshal ToDos")
+		}
+		t = append(t, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return t, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
 // Save creates or updates a ToDo
 func (r *ToDoRepo) Save(todo *server.ToDo) error {
+	return r.save(todo, nil)
+}
+
+// SaveWithEvent creates or updates a ToDo and records the event of the change in the outbox, in
+// a single transaction
+func (r *ToDoRepo) SaveWithEvent(todo *server.ToDo, event func(after *server.ToDo) server.Event) error {
+	return r.save(todo, event)
+}
+
+func (r *ToDoRepo) save(todo *server.ToDo, event func(after *server.ToDo) server.Event) error {
 
 	if todo.ID == "" {
 		todo.ID = uuid.NewV4().String()
@@ -88,18 +209,104 @@ func (r *ToDoRepo) Save(todo *server.ToDo) error {
 		return errors.Wrapf(err, "Could not unmarshal ToDo %s", todo.ID)
 	}
 
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(todosTableName),
-		Item:      t,
+	indexReminder(t, todo.NextReminder(todo.ModTime))
+
+	if event == nil {
+		input := &dynamodb.PutItemInput{
+			TableName: aws.String(todosTableName),
+			Item:      t,
+		}
+
+		if _, err := r.db.PutItem(input); err != nil {
+			return errors.Wrapf(err, "Could not save ToDo %s to database", todo.ID)
+		}
+
+		return nil
 	}
 
-	if _, err := r.db.PutItem(input); err != nil {
+	outbox, err := outboxPut(event(todo))
+	if err != nil {
+		return err
+	}
+
+	items := []*dynamodb.TransactWriteItem{
+		{Put: &dynamodb.Put{TableName: aws.String(todosTableName), Item: t}},
+		outbox,
+	}
+
+	if _, err := r.db.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: items}); err != nil {
 		return errors.Wrapf(err, "Could not save ToDo %s to database", todo.ID)
 	}
 
 	return nil
 }
 
+// Move sets the list of a ToDo in a single transaction, which fails if the ToDo is no longer in
+// the from list or the to list was deleted
+func (r *ToDoRepo) Move(id, from, to string) error {
+	return r.move(id, from, to, nil)
+}
+
+// MoveWithEvent sets the list of a ToDo like Move, recording the event of the change in the
+// outbox in the same transaction
+func (r *ToDoRepo) MoveWithEvent(id, from, to string, event server.Event) error {
+	return r.move(id, from, to, &event)
+}
+
+func (r *ToDoRepo) move(id, from, to string, event *server.Event) error {
+
+	update := &dynamodb.Update{
+		TableName:                aws.String(todosTableName),
+		Key:                      mapID(id),
+		ExpressionAttributeNames: map[string]*string{"#listId": aws.String("listId")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {S: aws.String(time.Now().Format(time.RFC3339Nano))},
+		},
+		UpdateExpression:    aws.String("SET modTime = :now REMOVE #listId"),
+		ConditionExpression: aws.String("attribute_exists(id) AND attribute_not_exists(#listId)"),
+	}
+
+	if to != "" {
+		update.UpdateExpression = aws.String("SET modTime = :now, #listId = :to")
+		update.ExpressionAttributeValues[":to"] = &dynamodb.AttributeValue{S: aws.String(to)}
+	}
+
+	if from != "" {
+		update.ConditionExpression = aws.String("#listId = :from")
+		update.ExpressionAttributeValues[":from"] = &dynamodb.AttributeValue{S: aws.String(from)}
+	}
+
+	items := []*dynamodb.TransactWriteItem{{Update: update}}
+
+	if to != "" {
+		items = append(items, &dynamodb.TransactWriteItem{
+			ConditionCheck: &dynamodb.ConditionCheck{
+				TableName:           aws.String(listsTableName),
+				Key:                 mapID(to),
+				ConditionExpression: aws.String("attribute_exists(id)"),
+			},
+		})
+	}
+
+	if event != nil {
+		outbox, err := outboxPut(*event)
+		if err != nil {
+			return err
+		}
+		items = append(items, outbox)
+	}
+
+	_, err := r.db.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		if isTransactionCanceled(err) {
+			return errors.Wrapf(database.ErrStale, "ToDo %s", id)
+		}
+		return errors.Wrapf(err, "Could not move ToDo %s to list %s", id, to)
+	}
+
+	return nil
+}
+
 // Delete permanently removes a ToDo
 func (r *ToDoRepo) Delete(id string) error {
 
@@ -114,3 +321,24 @@ func (r *ToDoRepo) Delete(id string) error {
 
 	return nil
 }
+
+// DeleteWithEvent permanently removes a ToDo and records the event of the deletion in the
+// outbox, in a single transaction
+func (r *ToDoRepo) DeleteWithEvent(id string, event server.Event) error {
+
+	outbox, err := outboxPut(event)
+	if err != nil {
+		return err
+	}
+
+	items := []*dynamodb.TransactWriteItem{
+		{Delete: &dynamodb.Delete{TableName: aws.String(todosTableName), Key: mapID(id)}},
+		outbox,
+	}
+
+	if _, err := r.db.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: items}); err != nil {
+		return errors.Wrapf(err, "Could not delete ToDo %s to database", id)
+	}
+
+	return nil
+}
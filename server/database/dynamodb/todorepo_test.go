@@ -5,10 +5,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
 	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	pkgerrors "github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -19,10 +22,19 @@ func TestToDoRepo(t *testing.T) {
 	t.Run("GetToDoNotFound", testGetToDoNotFound)
 	t.Run("GetToDoError", testGetToDoError)
 	t.Run("GetAllToDos", testGetAllToDos)
+	t.Run("GetAllToDosPages", testGetAllToDosPages)
 	t.Run("GetAllToDosError", testGetAllToDosError)
+	t.Run("GetChildren", testGetChildren)
+	t.Run("GetChildrenError", testGetChildrenError)
 	t.Run("CreateToDo", testCreateToDo)
 	t.Run("CreateToDoError", testCreateToDoError)
 	t.Run("UpdateToDo", testUpdateToDo)
+	t.Run("GetByList", testGetByList)
+	t.Run("GetByOwner", testGetByOwner)
+	t.Run("ModifiedSince", testModifiedSince)
+	t.Run("MoveToDo", testMoveToDo)
+	t.Run("MoveToDoOutOfList", testMoveToDoOutOfList)
+	t.Run("MoveToDoStale", testMoveToDoStale)
 	t.Run("DeleteToDo", testDeleteToDo)
 	t.Run("DeleteToDoError", testDeleteToDoError)
 }
@@ -174,6 +186,45 @@ func testGetAllToDos(t *testing.T) {
 	}
 }
 
+func testGetAllToDosPages(t *testing.T) {
+
+	m := &ClientMock{}
+
+	pages := 0
+
+	m.ScanFn = func(input *awsdynamodb.ScanInput) (*awsdynamodb.ScanOutput, error) {
+
+		pages++
+
+		if pages == 2 && input.ExclusiveStartKey == nil {
+			t.Fatal("Expected the second page to start after the first one")
+		}
+
+		item, err := dynamodbattribute.MarshalMap(server.ToDo{ID: testUUID, Title: "Test ToDo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := &awsdynamodb.ScanOutput{Items: []map[string]*awsdynamodb.AttributeValue{item}}
+		if pages == 1 {
+			out.LastEvaluatedKey = item
+		}
+
+		return out, nil
+	}
+
+	repo := dynamodb.NewToDoRepo(m)
+
+	toDos, err := repo.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(toDos) != 2 {
+		t.Fatalf("Expected 2 ToDos from 2 pages, got %d", len(toDos))
+	}
+}
+
 func testGetAllToDosError(t *testing.T) {
 
 	m := &ClientMock{}
@@ -195,6 +246,267 @@ func testGetAllToDosError(t *testing.T) {
 
 }
 
+func testGetChildren(t *testing.T) {
+
+	m := &ClientMock{}
+
+	pages := 0
+
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.IndexName != "parentId-index" {
+			t.Fatalf("Expected query on parentId-index, got %s", *input.IndexName)
+		}
+
+		if *input.ExpressionAttributeValues[":parentId"].S != testUUID {
+			t.Fatal("Expected query on the parent ID")
+		}
+
+		pages++
+
+		item, err := dynamodbattribute.MarshalMap(server.ToDo{
+			ID:       uuid.NewV4().String(),
+			Title:    "Child ToDo",
+			ParentID: testUUID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := &awsdynamodb.QueryOutput{
+			Items: []map[string]*awsdynamodb.AttributeValue{item},
+		}
+
+		// Return a second page
+		if pages == 1 {
+			out.LastEvaluatedKey = item
+		}
+
+		return out, nil
+	}
+
+	repo := dynamodb.NewToDoRepo(m)
+
+	children, err := repo.GetChildren(testUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(children) != 2 {
+		t.Fatalf("Expected 2 children from 2 pages, got %d", len(children))
+	}
+
+	if !m.QueryInvoked {
+		t.Fatal("Query not invoked")
+	}
+}
+
+func testGetChildrenError(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.QueryFn = func(*awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+		return nil, errors.New("DB Error")
+	}
+
+	repo := dynamodb.NewToDoRepo(m)
+
+	_, err := repo.GetChildren(testUUID)
+	if err == nil {
+		t.Fatal("Expected Error")
+	}
+
+	if !m.QueryInvoked {
+		t.Fatal("Query not invoked")
+	}
+}
+
+func testGetByList(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.IndexName != "listId-index" {
+			t.Fatalf("Expected query on listId-index, got %s", *input.IndexName)
+		}
+
+		if *input.ExpressionAttributeValues[":listId"].S != testUUID {
+			t.Fatal("Expected query on the list ID")
+		}
+
+		item, err := dynamodbattribute.MarshalMap(server.ToDo{ID: uuid.NewV4().String(), Title: "Listed ToDo", ListID: testUUID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &awsdynamodb.QueryOutput{Items: []map[string]*awsdynamodb.AttributeValue{item}}, nil
+	}
+
+	repo := dynamodb.NewToDoRepo(m)
+
+	todos, err := repo.GetByList(testUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos) != 1 || todos[0].ListID != testUUID {
+		t.Fatalf("Expected the ToDo of the list, got %+v", todos)
+	}
+}
+
+func testGetByOwner(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.IndexName != "owner-index" {
+			t.Fatalf("Expected query on owner-index, got %s", *input.IndexName)
+		}
+
+		if *input.ExpressionAttributeValues[":owner"].S != "test-user" {
+			t.Fatal("Expected query on the owner")
+		}
+
+		item, err := dynamodbattribute.MarshalMap(server.ToDo{ID: uuid.NewV4().String(), Title: "Owned ToDo", Owner: "test-user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &awsdynamodb.QueryOutput{Items: []map[string]*awsdynamodb.AttributeValue{item}}, nil
+	}
+
+	repo := dynamodb.NewToDoRepo(m)
+
+	todos, err := repo.GetByOwner("test-user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos) != 1 || todos[0].Owner != "test-user" {
+		t.Fatalf("Expected the ToDo of the owner, got %+v", todos)
+	}
+}
+
+func testModifiedSince(t *testing.T) {
+
+	m := &ClientMock{}
+	since := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	pages := 0
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.IndexName != "owner-index" || *input.ExpressionAttributeValues[":owner"].S != "test-user" {
+			t.Fatal("Expected query on the owner-index of the owner")
+		}
+
+		if *input.ExpressionAttributeValues[":since"].S != "2019-07-01T12:00:00Z" {
+			t.Fatalf("Expected query of the ToDos modified since the time, got %s", *input.ExpressionAttributeValues[":since"].S)
+		}
+
+		item, err := dynamodbattribute.MarshalMap(server.ToDo{ID: uuid.NewV4().String(), Owner: "test-user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pages++
+		output := &awsdynamodb.QueryOutput{Items: []map[string]*awsdynamodb.AttributeValue{item}}
+		if pages == 1 {
+			output.LastEvaluatedKey = item
+		}
+
+		return output, nil
+	}
+
+	todos, err := dynamodb.NewToDoRepo(m).ModifiedSince("test-user", since)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos) != 2 {
+		t.Fatalf("Expected the ToDos of every page, got %+v", todos)
+	}
+}
+
+func testMoveToDo(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.TransactWriteItemsFn = func(input *awsdynamodb.TransactWriteItemsInput) (*awsdynamodb.TransactWriteItemsOutput, error) {
+
+		if len(input.TransactItems) != 2 {
+			t.Fatalf("Expected an update and a condition check, got %d items", len(input.TransactItems))
+		}
+
+		update := input.TransactItems[0].Update
+		if *update.ConditionExpression != "#listId = :from" || *update.ExpressionAttributeValues[":from"].S != "inbox" {
+			t.Fatalf("Unexpected condition %s", *update.ConditionExpression)
+		}
+
+		if *update.ExpressionAttributeValues[":to"].S != "work" {
+			t.Fatal("Expected ToDo to be moved to the work list")
+		}
+
+		check := input.TransactItems[1].ConditionCheck
+		if *check.TableName != "lists" || *check.Key["id"].S != "work" {
+			t.Fatal("Expected a check that the work list exists")
+		}
+
+		return &awsdynamodb.TransactWriteItemsOutput{}, nil
+	}
+
+	repo := dynamodb.NewToDoRepo(m)
+
+	if err := repo.Move(testUUID, "inbox", "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.TransactWriteItemsInvoked {
+		t.Fatal("TransactWriteItems not invoked")
+	}
+}
+
+func testMoveToDoOutOfList(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.TransactWriteItemsFn = func(input *awsdynamodb.TransactWriteItemsInput) (*awsdynamodb.TransactWriteItemsOutput, error) {
+
+		if len(input.TransactItems) != 1 {
+			t.Fatalf("Expected a single update, got %d items", len(input.TransactItems))
+		}
+
+		if *input.TransactItems[0].Update.UpdateExpression != "SET modTime = :now REMOVE #listId" {
+			t.Fatalf("Unexpected update %s", *input.TransactItems[0].Update.UpdateExpression)
+		}
+
+		return &awsdynamodb.TransactWriteItemsOutput{}, nil
+	}
+
+	repo := dynamodb.NewToDoRepo(m)
+
+	if err := repo.Move(testUUID, "inbox", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testMoveToDoStale(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.TransactWriteItemsFn = func(*awsdynamodb.TransactWriteItemsInput) (*awsdynamodb.TransactWriteItemsOutput, error) {
+		return nil, awserr.New(awsdynamodb.ErrCodeTransactionCanceledException, "condition failed", nil)
+	}
+
+	repo := dynamodb.NewToDoRepo(m)
+
+	err := repo.Move(testUUID, "", "work")
+	if pkgerrors.Cause(err) != database.ErrStale {
+		t.Fatalf("Expected ErrStale, got %v", err)
+	}
+}
+
 func testCreateToDo(t *testing.T) {
 
 	m := &ClientMock{}
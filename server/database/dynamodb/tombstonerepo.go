@@ -0,0 +1,117 @@
+package dynamodb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// tombstonesTableName is the table storing the tombstones of the ToDos users can no longer read,
+// partitioned by reader and sorted by key, so that the tombstones of a user recorded since a
+// time are read with a query. They are removed by the DynamoDB TTL on the expiresAt attribute
+// once the retention window is over.
+const tombstonesTableName = "tombstones"
+
+// TombstoneRetention is how long the tombstones of deleted ToDos are kept
+const TombstoneRetention = 30 * 24 * time.Hour
+
+// tombstoneItem is the tombstone of a ToDo for one of its readers
+type tombstoneItem struct {
+	server.Tombstone
+	Reader string `json:"reader"`
+	// Key is the Unix time of the tombstone in nanoseconds, zero padded so that keys sort by
+	// time, followed by the ID of the ToDo
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expiresAt" dynamodbav:"expiresAt,unixtime"`
+}
+
+// tombstoneKey returns the sort key of the tombstones recorded at t, followed by suffix
+func tombstoneKey(t time.Time, suffix string) string {
+	return fmt.Sprintf("%020d%s", t.UnixNano(), suffix)
+}
+
+// TombstoneRepo represents a DynamoDB repository for the tombstones of ToDos
+type TombstoneRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewTombstoneRepo returns a new tombstone repository using the given DynamoDB client
+func NewTombstoneRepo(db dynamodbiface.DynamoDBAPI) *TombstoneRepo {
+	return &TombstoneRepo{db}
+}
+
+// Since returns the tombstones of a user, and of the ToDos every user could read, recorded
+// after since
+func (r *TombstoneRepo) Since(user string, since time.Time) ([]server.Tombstone, error) {
+
+	t := []server.Tombstone{}
+
+	for _, reader := range []string{user, database.AnyReader} {
+
+		input := &dynamodb.QueryInput{
+			TableName:              aws.String(tombstonesTableName),
+			KeyConditionExpression: aws.String("reader = :reader AND #key > :since"),
+			ExpressionAttributeNames: map[string]*string{
+				"#key": aws.String("key"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":reader": {S: aws.String(reader)},
+				":since":  {S: aws.String(tombstoneKey(since, ""))},
+			},
+		}
+
+		for {
+			result, err := r.db.Query(input)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Could not get tombstones of %s from database", user)
+			}
+
+			page := []server.Tombstone{}
+			if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+				return nil, errors.Wrapf(err, "Could not unmarshal tombstones of %s", user)
+			}
+			t = append(t, page...)
+
+			if len(result.LastEvaluatedKey) == 0 {
+				break
+			}
+			input.ExclusiveStartKey = result.LastEvaluatedKey
+		}
+	}
+
+	return t, nil
+}
+
+// Add records a tombstone for each of the given readers
+func (r *TombstoneRepo) Add(readers []string, tombstone server.Tombstone) error {
+
+	writes := make([]*dynamodb.WriteRequest, len(readers))
+
+	for i, reader := range readers {
+
+		item, err := dynamodbattribute.MarshalMap(tombstoneItem{
+			Tombstone: tombstone,
+			Reader:    reader,
+			Key:       tombstoneKey(tombstone.Deleted, "#"+tombstone.ID),
+			ExpiresAt: tombstone.Deleted.Add(TombstoneRetention),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Could not marshal tombstone of ToDo %s", tombstone.ID)
+		}
+
+		writes[i] = &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}}
+	}
+
+	if err := batchWrite(r.db, tombstonesTableName, writes); err != nil {
+		return errors.Wrapf(err, "Could not save tombstones of ToDo %s to database", tombstone.ID)
+	}
+
+	return nil
+}
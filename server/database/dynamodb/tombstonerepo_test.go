@@ -0,0 +1,80 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestTombstoneRepo(t *testing.T) {
+	t.Run("AddTombstoneForReaders", testAddTombstoneForReaders)
+	t.Run("TombstonesSince", testTombstonesSince)
+}
+
+func testAddTombstoneForReaders(t *testing.T) {
+
+	m := &ClientMock{}
+
+	readers := map[string]bool{}
+	m.BatchWriteItemFn = func(input *awsdynamodb.BatchWriteItemInput) (*awsdynamodb.BatchWriteItemOutput, error) {
+		for _, w := range input.RequestItems["tombstones"] {
+			item := w.PutRequest.Item
+			if item["expiresAt"] == nil || item["key"] == nil {
+				t.Fatal("Expected the tombstone to be keyed by time and to expire")
+			}
+			readers[*item["reader"].S] = true
+		}
+		return &awsdynamodb.BatchWriteItemOutput{}, nil
+	}
+
+	tombstone := server.Tombstone{ID: testUUID, Deleted: time.Now()}
+	if err := dynamodb.NewTombstoneRepo(m).Add([]string{testActor, "member"}, tombstone); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(readers) != 2 || !readers[testActor] || !readers["member"] {
+		t.Fatalf("Expected a tombstone for the owner and the member, got %v", readers)
+	}
+}
+
+func testTombstonesSince(t *testing.T) {
+
+	m := &ClientMock{}
+	since := time.Now().Add(-time.Hour)
+
+	queried := map[string]bool{}
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.TableName != "tombstones" {
+			t.Fatalf("Expected a query of the tombstones, got %s", *input.TableName)
+		}
+
+		reader := *input.ExpressionAttributeValues[":reader"].S
+		queried[reader] = true
+
+		item, err := dynamodbattribute.MarshalMap(server.Tombstone{ID: reader, Deleted: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &awsdynamodb.QueryOutput{Items: []map[string]*awsdynamodb.AttributeValue{item}}, nil
+	}
+
+	tombstones, err := dynamodb.NewTombstoneRepo(m).Since(testActor, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !queried[testActor] || !queried[database.AnyReader] {
+		t.Fatalf("Expected a query of the user and of any reader, got %v", queried)
+	}
+
+	if len(tombstones) != 2 {
+		t.Fatalf("Expected the tombstones of both queries, got %+v", tombstones)
+	}
+}
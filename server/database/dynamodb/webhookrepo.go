@@ -0,0 +1,129 @@
+package dynamodb
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const webhooksTableName = "webhooks"
+
+// ownerIndexName is the global secondary index keyed by owner of the webhooks, connections and
+// todos tables. The one of the todos table is sorted by modTime.
+const ownerIndexName = "owner-index"
+
+// WebhookRepo represents a DynamoDB repository for managing webhooks
+type WebhookRepo struct {
+	db dynamodbiface.DynamoDBAPI
+}
+
+// NewWebhookRepo returns a new webhook repository using the given DynamoDB client
+func NewWebhookRepo(db dynamodbiface.DynamoDBAPI) *WebhookRepo {
+	return &WebhookRepo{db}
+}
+
+// Get returns a webhook by its ID
+func (r *WebhookRepo) Get(id string) (*server.Webhook, error) {
+
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(webhooksTableName),
+		Key:       mapID(id),
+	}
+
+	result, err := r.db.GetItem(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get webhook %s from database", id)
+	}
+
+	w := &server.Webhook{}
+	if err := dynamodbattribute.UnmarshalMap(result.Item, w); err != nil {
+		return nil, errors.Wrapf(err, "Could not unmarshal webhook %s", id)
+	}
+
+	if w.ID == "" {
+		return nil, nil
+	}
+
+	return w, nil
+}
+
+// GetByOwner returns the webhooks of a user
+func (r *WebhookRepo) GetByOwner(owner string) ([]server.Webhook, error) {
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(webhooksTableName),
+		IndexName:              aws.String(ownerIndexName),
+		KeyConditionExpression: aws.String("#owner = :owner"),
+		// owner is a reserved word
+		ExpressionAttributeNames: map[string]*string{"#owner": aws.String("owner")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {S: aws.String(owner)},
+		},
+	}
+
+	w := []server.Webhook{}
+
+	for {
+		result, err := r.db.Query(input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not get webhooks of %s from database", owner)
+		}
+
+		page := []server.Webhook{}
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, errors.Wrapf(err, "Could not unmarshal webhooks of %s", owner)
+		}
+		w = append(w, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return w, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// Save creates or updates a webhook
+func (r *WebhookRepo) Save(webhook *server.Webhook) error {
+
+	if webhook.ID == "" {
+		webhook.ID = uuid.NewV4().String()
+		webhook.Created = time.Now()
+	}
+
+	w, err := dynamodbattribute.MarshalMap(webhook)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal webhook %s", webhook.ID)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(webhooksTableName),
+		Item:      w,
+	}
+
+	if _, err := r.db.PutItem(input); err != nil {
+		return errors.Wrapf(err, "Could not save webhook %s to database", webhook.ID)
+	}
+
+	return nil
+}
+
+// Delete permanently removes a webhook
+func (r *WebhookRepo) Delete(id string) error {
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(webhooksTableName),
+		Key:       mapID(id),
+	}
+
+	if _, err := r.db.DeleteItem(input); err != nil {
+		return errors.Wrapf(err, "Could not delete webhook %s from database", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+)
+
+func TestWebhookRepo(t *testing.T) {
+	t.Run("GetWebhooksByOwner", testGetWebhooksByOwner)
+	t.Run("SavePendingDelivery", testSavePendingDelivery)
+	t.Run("SaveSucceededDelivery", testSaveSucceededDelivery)
+	t.Run("ListDeliveriesNewestFirst", testListDeliveriesNewestFirst)
+}
+
+func testGetWebhooksByOwner(t *testing.T) {
+
+	m := &ClientMock{}
+	pages := 0
+
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if *input.TableName != "webhooks" || *input.IndexName != "owner-index" || *input.ExpressionAttributeValues[":owner"].S != testActor {
+			t.Fatal("Expected a query on the owner index")
+		}
+
+		item, err := dynamodbattribute.MarshalMap(server.Webhook{ID: testUUID, URL: "https://hooks.test", Owner: testActor})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pages++
+		out := &awsdynamodb.QueryOutput{Items: []map[string]*awsdynamodb.AttributeValue{item}}
+		if pages == 1 {
+			out.LastEvaluatedKey = item
+		}
+		return out, nil
+	}
+
+	hooks, err := dynamodb.NewWebhookRepo(m).GetByOwner(testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hooks) != 2 {
+		t.Fatalf("Expected the webhooks of both pages, got %d", len(hooks))
+	}
+}
+
+func testSavePendingDelivery(t *testing.T) {
+
+	m := &ClientMock{}
+	next := time.Now().Add(time.Minute)
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if *input.TableName != "webhook_deliveries" {
+			t.Fatalf("Expected the deliveries table, got %s", *input.TableName)
+		}
+
+		if input.Item["queue"] == nil || *input.Item["queue"].S != "retry" || input.Item["due"] == nil {
+			t.Fatal("Expected a pending delivery in the retry index")
+		}
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	d := &server.Delivery{WebhookID: testUUID, Status: server.DeliveryPending, NextAttempt: &next}
+	if err := dynamodb.NewDeliveryRepo(m).Save(d); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.ID == "" || d.Created.IsZero() {
+		t.Fatal("Expected the delivery to get an ID and a creation time")
+	}
+}
+
+func testSaveSucceededDelivery(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.PutItemFn = func(input *awsdynamodb.PutItemInput) (*awsdynamodb.PutItemOutput, error) {
+
+		if _, ok := input.Item["queue"]; ok {
+			t.Fatal("Expected a succeeded delivery to leave the retry index")
+		}
+
+		return &awsdynamodb.PutItemOutput{}, nil
+	}
+
+	d := &server.Delivery{WebhookID: testUUID, ID: "delivery", Status: server.DeliverySucceeded}
+	if err := dynamodb.NewDeliveryRepo(m).Save(d); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testListDeliveriesNewestFirst(t *testing.T) {
+
+	m := &ClientMock{}
+
+	m.QueryFn = func(input *awsdynamodb.QueryInput) (*awsdynamodb.QueryOutput, error) {
+
+		if input.ScanIndexForward == nil || *input.ScanIndexForward {
+			t.Fatal("Expected the deliveries to be queried newest first")
+		}
+
+		return &awsdynamodb.QueryOutput{}, nil
+	}
+
+	deliveries, next, err := dynamodb.NewDeliveryRepo(m).List(testUUID, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deliveries) != 0 || next != "" {
+		t.Fatalf("Expected no deliveries, got %d and %q", len(deliveries), next)
+	}
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"time"
+
 	"github.com/massimoselvi/serverless-todo-api-go/server"
 )
 
@@ -8,6 +10,209 @@ import (
 type ToDoRepo interface {
 	Get(id string) (*server.ToDo, error)
 	GetAll() ([]server.ToDo, error)
+	GetChildren(parentID string) ([]server.ToDo, error)
+	GetByList(listID string) ([]server.ToDo, error)
+	// GetByOwner returns the ToDos a user owns, least recently modified first
+	GetByOwner(owner string) ([]server.ToDo, error)
+	// ModifiedSince returns the ToDos a user owns which were modified after since
+	ModifiedSince(owner string, since time.Time) ([]server.ToDo, error)
 	Save(todo *server.ToDo) error
+	// Move sets the list of a ToDo only if it is still in the from list and the to list
+	// exists, otherwise it returns ErrStale. An empty list ID means no list.
+	Move(id, from, to string) error
+	Delete(id string) error
+}
+
+// AnyReader is the reader of the tombstones of ToDos created before owners were introduced,
+// which every user could read
+const AnyReader = "*"
+
+// TombstoneRepo is an interface for storing the tombstones of the ToDos users can no longer
+// read, because they were deleted or are no longer shared with them. Tombstones are kept for a
+// while for clients syncing their changes.
+type TombstoneRepo interface {
+	// Since returns the tombstones of a user, or of AnyReader, recorded after since
+	Since(user string, since time.Time) ([]server.Tombstone, error)
+	// Add records a tombstone for each of the given readers
+	Add(readers []string, tombstone server.Tombstone) error
+}
+
+// ShareRepo is an interface for storing the ToDos shared with each user, so that their changes
+// can be synced without reading the ToDos of other users
+type ShareRepo interface {
+	// Share records that a ToDo shared with a member changed at the given time
+	Share(member, todoID string, changed time.Time) error
+	// Unshare removes a ToDo from the ToDos shared with a member
+	Unshare(member, todoID string) error
+	// ChangedSince returns the IDs of the ToDos shared with a member which changed after since
+	ChangedSince(member string, since time.Time) ([]string, error)
+}
+
+// OutboxToDoRepo is a ToDo repository recording the events of changes in an outbox, in the same
+// transaction as the changes, so that no event is lost
+type OutboxToDoRepo interface {
+	ToDoRepo
+	// SaveWithEvent creates or updates a ToDo along with the event returned by event, which is
+	// called once the ID and modification time of the ToDo are set
+	SaveWithEvent(todo *server.ToDo, event func(after *server.ToDo) server.Event) error
+	MoveWithEvent(id, from, to string, event server.Event) error
+	DeleteWithEvent(id string, event server.Event) error
+}
+
+// OutboxRepo is an interface for relaying the events recorded in the outbox
+type OutboxRepo interface {
+	// Pending returns up to limit events which were not published yet, oldest first
+	Pending(limit int) ([]server.Event, error)
+	// Claim reserves an event for a relay to publish it until the lease expires. It reports false
+	// if the event was already published or is claimed by another relay.
+	Claim(id string, lease time.Duration) (bool, error)
+	// MarkPublished marks an event published, it is then kept for a while to deduplicate it
+	MarkPublished(id string) error
+}
+
+// StatsRepo is an interface for storing the counters of the ToDos of each user
+type StatsRepo interface {
+	Get(owner string) (*server.Stats, error)
+	// Add adds delta to the counters of its owner, only once for a given change ID
+	Add(changeID string, delta server.Stats) error
+}
+
+// SearchIndexRepo is an interface for storing the words the ToDos can be searched by
+type SearchIndexRepo interface {
+	// Index adds and removes words of a ToDo from the index
+	Index(todoID, owner string, add, remove []string) error
+}
+
+// WebhookRepo is an interface for storing the webhooks users subscribe to events with
+type WebhookRepo interface {
+	Get(id string) (*server.Webhook, error)
+	GetByOwner(owner string) ([]server.Webhook, error)
+	Save(webhook *server.Webhook) error
+	Delete(id string) error
+}
+
+// DeliveryRepo is an interface for storing the deliveries of events to webhooks
+type DeliveryRepo interface {
+	// List returns up to limit deliveries of a webhook, newest first, following the cursor, and
+	// the cursor of the next page which is empty after the last page
+	List(webhookID, cursor string, limit int) ([]server.Delivery, string, error)
+	// Due returns up to limit pending deliveries whose next attempt is due at now
+	Due(now time.Time, limit int) ([]server.Delivery, error)
+	// Save creates a delivery, giving it an ID ordered by creation time, or updates it
+	Save(delivery *server.Delivery) error
+}
+
+// DeadLetterRepo is an interface for keeping the deliveries which failed every attempt
+type DeadLetterRepo interface {
+	Save(delivery *server.Delivery) error
+}
+
+// ConnectionRepo is an interface for storing the WebSocket connections of users
+type ConnectionRepo interface {
+	GetByOwner(owner string) ([]Connection, error)
+	Save(conn *Connection) error
+	Delete(id string) error
+}
+
+// ReminderRepo is an interface for sending each reminder of the ToDos once
+type ReminderRepo interface {
+	// Due returns up to limit reminders due at now, each ToDo has at most one due reminder
+	Due(now time.Time, limit int) ([]DueReminder, error)
+	// MarkSent marks a reminder sent, it returns ErrExists if it already was
+	MarkSent(todoID string, at time.Time) error
+	// Unmark removes the sent marker of a reminder which could not be sent
+	Unmark(todoID string, at time.Time) error
+	// Advance makes the reminder of a ToDo following at due, unless the ToDo changed since
+	Advance(todo *server.ToDo, at time.Time) error
+}
+
+// ProfileRepo is an interface for storing the contact details of users
+type ProfileRepo interface {
+	Get(user string) (*server.Profile, error)
+	// GetByEmail returns the profile with the given verified email address, or nil if there is
+	// none
+	GetByEmail(email string) (*server.Profile, error)
+	// ClaimEmail reserves an email address for a user, so that each address verifies a single
+	// profile. It returns ErrExists if another user claimed it.
+	ClaimEmail(email, user string) error
+	// ReleaseEmail removes the claim of a user on an email address
+	ReleaseEmail(email, user string) error
+	// GetSubscribed returns the profiles of the users receiving a digest
+	GetSubscribed() ([]server.Profile, error)
+	Save(profile *server.Profile) error
+}
+
+// DigestRepo is an interface for sending each digest of the ToDos of users once
+type DigestRepo interface {
+	// MarkSent marks the digest of a user for a period sent, it returns ErrExists if it already was
+	MarkSent(user, period string) error
+	// Unmark removes the sent marker of a digest which could not be sent
+	Unmark(user, period string) error
+}
+
+// ListRepo is an interface for storing the lists ToDos are grouped in
+type ListRepo interface {
+	Get(id string) (*server.List, error)
+	GetAll() ([]server.List, error)
+	Save(list *server.List) error
 	Delete(id string) error
 }
+
+// LabelRepo is an interface for storing the labels ToDos are tagged with
+type LabelRepo interface {
+	Get(id string) (*server.Label, error)
+	GetAll() ([]server.Label, error)
+	Save(label *server.Label) error
+	Delete(id string) error
+}
+
+// CommentRepo is an interface for storing the comments of ToDos
+type CommentRepo interface {
+	Get(todoID, id string) (*server.Comment, error)
+	// List returns up to limit comments of a ToDo, oldest first, following the cursor, and the
+	// cursor of the next page which is empty after the last page
+	List(todoID, cursor string, limit int) ([]server.Comment, string, error)
+	// Save creates a comment, giving it an ID ordered by creation time, or updates it
+	Save(comment *server.Comment) error
+	// DeleteAll deletes every comment of a ToDo
+	DeleteAll(todoID string) error
+}
+
+// BlobStore is an interface for storing the content of attachments. Clients upload and
+// download blobs directly, using the short-lived URLs issued by the store.
+type BlobStore interface {
+	// UploadURL returns a URL accepting a PUT request with a blob of the given type and size
+	UploadURL(key, contentType string, size int64) (string, error)
+	// DownloadURL returns a URL serving a blob as a file with the given name
+	DownloadURL(key, name string) (string, error)
+	// Put stores a blob received by the server, such as an attachment of an inbound email
+	Put(key, contentType string, content []byte) error
+	Delete(key string) error
+}
+
+// IdempotencyRepo is an interface for storing the responses of idempotent requests
+type IdempotencyRepo interface {
+	Get(key string) (*IdempotencyRecord, error)
+	// Reserve saves a record only if its key is not already stored, otherwise it returns ErrExists
+	Reserve(record *IdempotencyRecord) error
+	Save(record *IdempotencyRecord) error
+	Delete(key string) error
+}
+
+// QuotaRepo is an interface for counting the ToDos each user owns against their quota
+type QuotaRepo interface {
+	// Reserve atomically counts one more ToDo owned by owner, unless max ToDos are already
+	// counted, in which case it returns ErrLimitReached. A max of 0 counts without a limit.
+	Reserve(owner string, max int) error
+	// Release counts one ToDo less owned by owner
+	Release(owner string) error
+}
+
+// RateLimitRepo is an interface for storing the token buckets of rate limited users
+type RateLimitRepo interface {
+	// Take atomically takes a token from the bucket of key, which holds up to capacity tokens
+	// and is refilled with one token every interval, and returns the time the bucket is full
+	// again. It returns ErrLimitReached if the bucket has no token left. The bucket is removed
+	// once it is full.
+	Take(key string, capacity int, interval time.Duration, now time.Time) (time.Time, error)
+}
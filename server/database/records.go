@@ -0,0 +1,60 @@
+package database
+
+import (
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrExists is returned when a conditional write fails because the item already exists
+	ErrExists = errors.New("item already exists")
+	// ErrStale is returned when a conditional write fails because the item was modified concurrently
+	ErrStale = errors.New("item was modified concurrently")
+	// ErrLimitReached is returned when a counter cannot be incremented past its limit or a rate
+	// limit bucket has no token left
+	ErrLimitReached = errors.New("limit reached")
+)
+
+// IdempotencyRecord stores the response sent to a request made with an idempotency key
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	RequestHash string    `json:"requestHash"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	Body        string    `json:"body,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt" dynamodbav:"expiresAt,unixtime"`
+}
+
+// Completed reports whether the response of the request has been stored
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// OutboxRecord stores a domain event in the outbox until the relay publishes it. Published
+// records are kept until they expire, so that the relay can skip events it receives again.
+type OutboxRecord struct {
+	ID string `json:"id"`
+	// Event is the JSON encoding of the event
+	Event     string    `json:"event"`
+	Created   time.Time `json:"created"`
+	Published bool      `json:"published,omitempty"`
+	// ClaimedUntil is the Unix time the claim of the relay publishing the event expires at
+	ClaimedUntil int64 `json:"claimedUntil,omitempty"`
+}
+
+// Connection stores a WebSocket connection of a user, which receives the changes of their ToDos.
+// Connections are removed on disconnect, or when they expire if the disconnect was missed.
+type Connection struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"owner"`
+	Connected time.Time `json:"connected"`
+	ExpiresAt time.Time `json:"expiresAt" dynamodbav:"expiresAt,unixtime"`
+}
+
+// DueReminder is a reminder of a ToDo which is due
+type DueReminder struct {
+	ToDo server.ToDo
+	// At is the time of the reminder
+	At time.Time
+}
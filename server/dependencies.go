@@ -0,0 +1,106 @@
+package server
+
+import "sort"
+
+// DependencyCycle returns the IDs of a dependency cycle going through the ToDo, starting and
+// ending with the ToDo ID, or nil if there is none. Blockers are loaded using get, missing
+// blockers are ignored.
+func DependencyCycle(todo *ToDo, get func(id string) (*ToDo, error)) ([]string, error) {
+
+	visited := map[string]bool{}
+
+	var visit func(id string, path []string) ([]string, error)
+	visit = func(id string, path []string) ([]string, error) {
+
+		path = append(path, id)
+
+		if id == todo.ID {
+			return path, nil
+		}
+
+		if visited[id] {
+			return nil, nil
+		}
+		visited[id] = true
+
+		t, err := get(id)
+		if err != nil || t == nil {
+			return nil, err
+		}
+
+		for _, b := range t.BlockedBy {
+			if cycle, err := visit(b, path); cycle != nil || err != nil {
+				return cycle, err
+			}
+		}
+
+		return nil, nil
+	}
+
+	for _, b := range todo.BlockedBy {
+		if cycle, err := visit(b, []string{todo.ID}); cycle != nil || err != nil {
+			return cycle, err
+		}
+	}
+
+	return nil, nil
+}
+
+// SortByDependencies returns the open ToDos sorted so that every ToDo comes after the open
+// ToDos blocking it. ToDos blocked by nothing but completed or unknown ToDos come first, in
+// order of last modification. ToDos that are part of a dependency cycle are left out.
+func SortByDependencies(todos []ToDo) []ToDo {
+
+	open := map[string]*ToDo{}
+	for i := range todos {
+		if !todos[i].Completed {
+			open[todos[i].ID] = &todos[i]
+		}
+	}
+
+	// pending counts the open blockers of each ToDo, blocks lists the ToDos each one blocks
+	pending := map[string]int{}
+	blocks := map[string][]string{}
+
+	for id, t := range open {
+		for _, b := range t.BlockedBy {
+			if _, ok := open[b]; ok {
+				pending[id]++
+				blocks[b] = append(blocks[b], id)
+			}
+		}
+	}
+
+	var ready []*ToDo
+	for id, t := range open {
+		if pending[id] == 0 {
+			ready = append(ready, t)
+		}
+	}
+
+	sorted := []ToDo{}
+
+	for len(ready) > 0 {
+
+		sort.Slice(ready, func(i, j int) bool {
+			if ready[i].ModTime.Equal(ready[j].ModTime) {
+				return ready[i].ID < ready[j].ID
+			}
+			return ready[i].ModTime.Before(ready[j].ModTime)
+		})
+
+		var next []*ToDo
+		for _, t := range ready {
+			sorted = append(sorted, *t)
+			for _, id := range blocks[t.ID] {
+				if pending[id]--; pending[id] == 0 {
+					next = append(next, open[id])
+				}
+			}
+		}
+
+		ready = next
+	}
+
+	return sorted
+}
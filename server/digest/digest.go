@@ -0,0 +1,129 @@
+// Package digest emails users a summary of their overdue, upcoming and completed ToDos
+package digest
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/pkg/errors"
+)
+
+const (
+	dateLayout = "Monday, January 2"
+	dueLayout  = "Mon Jan 2 15:04"
+)
+
+// Item is a ToDo listed in a digest
+type Item struct {
+	Title string
+	// Due is the due date of the ToDo in the time zone of the user
+	Due string
+}
+
+// Digest sums up the ToDos of a user
+type Digest struct {
+	User string
+	// Date is the day of the digest in the time zone of the user
+	Date      string
+	Overdue   []Item
+	DueToday  []Item
+	Completed []Item
+	// CompletedPeriod names the week the ToDos of Completed were completed in
+	CompletedPeriod string
+	// UnsubscribeURL is the link turning the digest off
+	UnsubscribeURL string
+}
+
+// Empty reports whether the digest has no ToDo to list
+func (d *Digest) Empty() bool {
+	return len(d.Overdue) == 0 && len(d.DueToday) == 0 && len(d.Completed) == 0
+}
+
+// Build returns the digest of the ToDos owned by a user at now, in their time zone. Daily
+// digests list the ToDos completed since Monday, and weekly digests, sent on Mondays, the ToDos
+// completed in the previous ISO week. ToDos completed before their completion time was kept are
+// not listed.
+func Build(user string, frequency server.DigestFrequency, todos []server.ToDo, now time.Time, loc *time.Location) Digest {
+
+	now = now.In(loc)
+	y, m, d := now.Date()
+	tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	monday := time.Date(y, m, d-(int(now.Weekday())+6)%7, 0, 0, 0, 0, loc)
+
+	from, to, period := monday, tomorrow, "this week"
+	if frequency == server.DigestWeekly {
+		from, to, period = monday.AddDate(0, 0, -7), monday, "last week"
+	}
+
+	var overdue, today, completed []server.ToDo
+	for _, t := range todos {
+
+		if t.Owner != user {
+			continue
+		}
+
+		switch {
+		case t.Completed:
+			if t.CompletedAt != nil && !t.CompletedAt.Before(from) && t.CompletedAt.Before(to) {
+				completed = append(completed, t)
+			}
+		case t.Due == nil:
+		case t.Due.Before(now):
+			overdue = append(overdue, t)
+		case t.Due.Before(tomorrow):
+			today = append(today, t)
+		}
+	}
+
+	sort.Slice(completed, func(i, j int) bool { return completed[i].CompletedAt.Before(*completed[j].CompletedAt) })
+
+	return Digest{
+		User:            user,
+		Date:            now.Format(dateLayout),
+		Overdue:         items(overdue, loc),
+		DueToday:        items(today, loc),
+		Completed:       items(completed, loc),
+		CompletedPeriod: period,
+	}
+}
+
+// items lists ToDos, the ones with a due date are sorted by due date
+func items(todos []server.ToDo, loc *time.Location) []Item {
+
+	sort.SliceStable(todos, func(i, j int) bool {
+		return todos[i].Due != nil && (todos[j].Due == nil || todos[i].Due.Before(*todos[j].Due))
+	})
+
+	items := make([]Item, len(todos))
+	for i, t := range todos {
+		items[i].Title = t.Title
+		if t.Due != nil {
+			items[i].Due = t.Due.In(loc).Format(dueLayout)
+		}
+	}
+
+	return items
+}
+
+// Render renders the digest as an email with text and HTML parts
+func Render(d Digest) (notify.Message, error) {
+
+	var text, html bytes.Buffer
+
+	if err := textTemplate.Execute(&text, d); err != nil {
+		return notify.Message{}, errors.Wrapf(err, "Could not render the digest of %s", d.User)
+	}
+
+	if err := htmlTemplate.Execute(&html, d); err != nil {
+		return notify.Message{}, errors.Wrapf(err, "Could not render the digest of %s", d.User)
+	}
+
+	return notify.Message{
+		Subject: "Your ToDos on " + d.Date,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
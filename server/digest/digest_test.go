@@ -0,0 +1,286 @@
+package digest_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/databasetest"
+	"github.com/massimoselvi/serverless-todo-api-go/server/digest"
+)
+
+const (
+	testUser  = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+	otherUser = "9a1d2c7e-0f3b-4e8a-b6d5-7c4e2f1a0b93"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// testNow is Monday, August 5 2019 at 8:00 in Los Angeles
+var testNow = time.Date(2019, 8, 5, 15, 0, 0, 0, time.UTC)
+
+func TestDigest(t *testing.T) {
+	t.Run("Build", testBuild)
+	t.Run("BuildWeekly", testBuildWeekly)
+	t.Run("RenderEscapesHTML", testRenderEscapesHTML)
+	t.Run("Period", testPeriod)
+	t.Run("UnsubscribeToken", testUnsubscribeToken)
+	t.Run("Send", testSend)
+	t.Run("SendOnce", testSendOnce)
+	t.Run("SendFailed", testSendFailed)
+	t.Run("SendEmpty", testSendEmpty)
+	t.Run("SendUnverified", testSendUnverified)
+}
+
+func at(layout string) *time.Time {
+	t, err := time.Parse(time.RFC3339, layout)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func testToDos() []server.ToDo {
+	return []server.ToDo{
+		{Title: "Pay rent", Owner: testUser, Due: at("2019-08-01T17:00:00Z")},
+		{Title: "Call mom", Owner: testUser, Due: at("2019-08-05T20:00:00Z")},
+		// Due on Monday evening in Los Angeles, although it is already Tuesday in UTC
+		{Title: "Water plants", Owner: testUser, Due: at("2019-08-06T05:00:00Z")},
+		{Title: "Book flights", Owner: testUser, Completed: true, CompletedAt: at("2019-08-05T14:00:00Z")},
+		// Completed last week, and edited since
+		{Title: "File taxes", Owner: testUser, Completed: true, CompletedAt: at("2019-08-01T14:00:00Z"), ModTime: *at("2019-08-05T14:00:00Z")},
+		{Title: "Renew passport", Owner: testUser, Completed: true, CompletedAt: at("2019-07-26T14:00:00Z")},
+		{Title: "Someone else's", Owner: otherUser, Due: at("2019-08-01T17:00:00Z")},
+		{Title: "Someday", Owner: testUser},
+	}
+}
+
+func la() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+func titles(items []digest.Item) string {
+	var t []string
+	for _, i := range items {
+		t = append(t, i.Title)
+	}
+	return strings.Join(t, ",")
+}
+
+func testBuild(t *testing.T) {
+
+	d := digest.Build(testUser, server.DigestDaily, testToDos(), testNow, la())
+
+	if d.Date != "Monday, August 5" {
+		t.Fatalf("Expected the date of the user, got %s", d.Date)
+	}
+
+	if titles(d.Overdue) != "Pay rent" || d.Overdue[0].Due != "Thu Aug 1 10:00" {
+		t.Fatalf("Expected the overdue ToDos with their due date in the time zone of the user, got %+v", d.Overdue)
+	}
+
+	if titles(d.DueToday) != "Call mom,Water plants" {
+		t.Fatalf("Expected the ToDos due today in the time zone of the user, got %+v", d.DueToday)
+	}
+
+	if titles(d.Completed) != "Book flights" {
+		t.Fatalf("Expected the ToDos completed since Monday, got %+v", d.Completed)
+	}
+}
+
+func testBuildWeekly(t *testing.T) {
+
+	d := digest.Build(testUser, server.DigestWeekly, testToDos(), testNow, la())
+
+	if titles(d.Completed) != "File taxes" || d.CompletedPeriod != "last week" {
+		t.Fatalf("Expected the ToDos completed in the previous week, got %+v", d.Completed)
+	}
+}
+
+func testRenderEscapesHTML(t *testing.T) {
+
+	todos := []server.ToDo{{Title: "<script>alert(1)</script>", Owner: testUser, Due: at("2019-08-01T17:00:00Z")}}
+
+	msg, err := digest.Render(digest.Build(testUser, server.DigestDaily, todos, testNow, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(msg.HTML, "<script>") {
+		t.Fatalf("Expected the title to be escaped, got %s", msg.HTML)
+	}
+
+	if !strings.Contains(msg.Text, "<script>alert(1)</script>") {
+		t.Fatalf("Expected the title in the text, got %s", msg.Text)
+	}
+}
+
+func testPeriod(t *testing.T) {
+
+	cases := []struct {
+		frequency server.DigestFrequency
+		hour      int
+		now       time.Time
+		period    string
+	}{
+		{server.DigestDaily, 8, testNow, "2019-08-05"},
+		{server.DigestDaily, 9, testNow, ""},
+		{server.DigestWeekly, 7, testNow, "2019-W32"},
+		{server.DigestWeekly, 7, testNow.Add(24 * time.Hour), ""},
+		{"", 0, testNow, ""},
+	}
+
+	for _, c := range cases {
+
+		p := &server.Profile{User: testUser, Digest: c.frequency, DigestHour: c.hour}
+
+		period, ok := digest.Period(p, c.now, la())
+		if period != c.period || ok != (c.period != "") {
+			t.Fatalf("Expected period %q for a %s digest at %d, got %q", c.period, c.frequency, c.hour, period)
+		}
+	}
+}
+
+func testUnsubscribeToken(t *testing.T) {
+
+	token := digest.UnsubscribeToken(testSecret, testUser)
+
+	user, err := digest.VerifyUnsubscribeToken(testSecret, token)
+	if err != nil || user != testUser {
+		t.Fatalf("Expected the token to be valid for the user, got %s, %v", user, err)
+	}
+
+	if _, err := digest.VerifyUnsubscribeToken([]byte("other secret"), token); err != digest.ErrInvalidToken {
+		t.Fatalf("Expected a token signed with another secret to be invalid, got %v", err)
+	}
+
+	forged := digest.UnsubscribeToken([]byte("other secret"), otherUser)
+	tampered := strings.Split(forged, ".")[0] + "." + strings.Split(token, ".")[1]
+	if _, err := digest.VerifyUnsubscribeToken(testSecret, tampered); err != digest.ErrInvalidToken {
+		t.Fatalf("Expected a token for another user to be invalid, got %v", err)
+	}
+}
+
+func setup(n *NotifierMock) (*digest.Sender, *DigestRepoMock, *RepoMock) {
+
+	profiles := databasetest.NewProfileRepoMock(
+		server.Profile{User: testUser, Email: "user@example.com", EmailVerified: true, Digest: server.DigestDaily, DigestHour: 8, TimeZone: "America/Los_Angeles"},
+		// Not due before 18:00 UTC
+		server.Profile{User: otherUser, Email: "other@example.com", EmailVerified: true, Digest: server.DigestDaily, DigestHour: 18},
+	)
+	digests := &DigestRepoMock{Sent: map[string]bool{}}
+	todos := &RepoMock{ToDos: testToDos()}
+
+	return digest.NewSender(profiles, todos, digests, n, testSecret, "https://api.test/v1/digest/unsubscribe"), digests, todos
+}
+
+func testSend(t *testing.T) {
+
+	n := &NotifierMock{}
+	s, _, _ := setup(n)
+
+	sent, err := s.Send(testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != 1 || len(n.Sent) != 1 {
+		t.Fatalf("Expected the digest of the user at their hour to be sent, got %d", len(n.Sent))
+	}
+
+	msg := n.Sent[0]
+	if msg.Subject != "Your ToDos on Monday, August 5" || !strings.Contains(msg.Text, "Pay rent") {
+		t.Fatalf("Expected the digest of the user, got %+v", msg)
+	}
+
+	link := "https://api.test/v1/digest/unsubscribe?token=" + url.QueryEscape(digest.UnsubscribeToken(testSecret, testUser))
+	if !strings.Contains(msg.Text, link) {
+		t.Fatalf("Expected the signed unsubscribe link of the user, got %s", msg.Text)
+	}
+}
+
+func testSendOnce(t *testing.T) {
+
+	n := &NotifierMock{}
+	s, _, _ := setup(n)
+
+	for _, now := range []time.Time{testNow, testNow.Add(time.Hour)} {
+		if _, err := s.Send(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(n.Sent) != 1 {
+		t.Fatalf("Expected the digest to be sent once a day, got %d", len(n.Sent))
+	}
+
+	if _, err := s.Send(testNow.Add(24 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(n.Sent) != 2 {
+		t.Fatalf("Expected the digest of the next day to be sent, got %d", len(n.Sent))
+	}
+}
+
+func testSendFailed(t *testing.T) {
+
+	n := &NotifierMock{Err: errors.New("throttled")}
+	s, digests, _ := setup(n)
+
+	if _, err := s.Send(testNow); err == nil {
+		t.Fatal("Expected an error when the notification fails")
+	}
+
+	if len(digests.Sent) != 0 {
+		t.Fatalf("Expected the digest to be unmarked, got %v", digests.Sent)
+	}
+
+	n.Err = nil
+	if sent, err := s.Send(testNow.Add(time.Hour)); err != nil || sent != 1 {
+		t.Fatalf("Expected the digest to be sent when retried, got %d, %v", sent, err)
+	}
+}
+
+func testSendEmpty(t *testing.T) {
+
+	n := &NotifierMock{}
+	s, digests, todos := setup(n)
+	todos.ToDos = nil
+
+	sent, err := s.Send(testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != 0 || len(n.Sent) != 0 {
+		t.Fatalf("Expected an empty digest not to be sent, got %d", len(n.Sent))
+	}
+
+	if len(digests.Sent) != 1 {
+		t.Fatalf("Expected the empty digest to be marked sent, got %v", digests.Sent)
+	}
+}
+
+func testSendUnverified(t *testing.T) {
+
+	n := &NotifierMock{}
+	profiles := databasetest.NewProfileRepoMock(server.Profile{User: testUser, Email: "user@example.com", Digest: server.DigestDaily, DigestHour: 8, TimeZone: "America/Los_Angeles"})
+	s := digest.NewSender(profiles, &RepoMock{ToDos: testToDos()}, &DigestRepoMock{Sent: map[string]bool{}}, n, testSecret, "https://api.test/v1/digest/unsubscribe")
+
+	sent, err := s.Send(testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != 0 || len(n.Sent) != 0 {
+		t.Fatalf("Expected no digest to be emailed to an unverified address, got %d", len(n.Sent))
+	}
+}
@@ -0,0 +1,63 @@
+package digest_test
+
+import (
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+)
+
+// RepoMock is used to mock a ToDo repository, only GetByOwner is used by the sender
+type RepoMock struct {
+	database.ToDoRepo
+	ToDos []server.ToDo
+}
+
+// GetByOwner returns the ToDos of an owner
+func (m *RepoMock) GetByOwner(owner string) ([]server.ToDo, error) {
+	todos := []server.ToDo{}
+	for _, t := range m.ToDos {
+		if t.Owner == owner {
+			todos = append(todos, t)
+		}
+	}
+	return todos, nil
+}
+
+// DigestRepoMock is used to mock a digest repository
+type DigestRepoMock struct {
+	Sent map[string]bool
+}
+
+// MarkSent marks a digest sent
+func (m *DigestRepoMock) MarkSent(user, period string) error {
+	if m.Sent[user+"#"+period] {
+		return database.ErrExists
+	}
+	m.Sent[user+"#"+period] = true
+	return nil
+}
+
+// Unmark removes the sent marker of a digest
+func (m *DigestRepoMock) Unmark(user, period string) error {
+	delete(m.Sent, user+"#"+period)
+	return nil
+}
+
+// NotifierMock records the notifications sent, or fails with Err. Like the email notifier, it
+// fails with ErrNoContact for users without an email address.
+type NotifierMock struct {
+	Err  error
+	Sent []notify.Message
+}
+
+// Notify records a notification
+func (m *NotifierMock) Notify(to *server.Profile, msg notify.Message) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	if to.Email == "" {
+		return notify.ErrNoContact
+	}
+	m.Sent = append(m.Sent, msg)
+	return nil
+}
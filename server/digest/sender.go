@@ -0,0 +1,154 @@
+package digest
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/pkg/errors"
+)
+
+// Period returns the period whose digest a user should have received at now, daily periods are
+// dates and weekly periods are ISO weeks, both in the time zone of the user. It returns false if
+// no digest is due yet: before the hour of the digest, or before Monday for weekly digests.
+func Period(p *server.Profile, now time.Time, loc *time.Location) (string, bool) {
+
+	now = now.In(loc)
+	if now.Hour() < p.DigestHour {
+		return "", false
+	}
+
+	switch p.Digest {
+	case server.DigestDaily:
+		return now.Format("2006-01-02"), true
+	case server.DigestWeekly:
+		if now.Weekday() != time.Monday {
+			return "", false
+		}
+		y, w := now.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w), true
+	default:
+		return "", false
+	}
+}
+
+// Sender sends the digests of the users who subscribed to them
+type Sender struct {
+	profiles       database.ProfileRepo
+	todos          database.ToDoRepo
+	digests        database.DigestRepo
+	notifier       notify.Notifier
+	secret         []byte
+	unsubscribeURL string
+}
+
+// NewSender returns a sender notifying users with the given notifier. The unsubscribe link of
+// the digests is unsubscribeURL with a token signed with secret.
+func NewSender(profiles database.ProfileRepo, todos database.ToDoRepo, digests database.DigestRepo, notifier notify.Notifier, secret []byte, unsubscribeURL string) *Sender {
+	return &Sender{
+		profiles:       profiles,
+		todos:          todos,
+		digests:        digests,
+		notifier:       notifier,
+		secret:         secret,
+		unsubscribeURL: unsubscribeURL,
+	}
+}
+
+// Send sends the digests due at now and returns how many were sent. A digest is marked sent
+// before it is sent, so it is never sent twice, and unmarked if it could not be sent so that it
+// is retried. Digests without ToDos to list are not sent.
+func (s *Sender) Send(now time.Time) (int, error) {
+
+	profiles, err := s.profiles.GetSubscribed()
+	if err != nil {
+		return 0, err
+	}
+
+	type due struct {
+		profile server.Profile
+		period  string
+		loc     *time.Location
+	}
+
+	var pending []due
+	for _, p := range profiles {
+
+		loc, err := p.Location()
+		if err != nil {
+			loc = time.UTC
+		}
+
+		if period, ok := Period(&p, now, loc); ok {
+			pending = append(pending, due{p, period, loc})
+		}
+	}
+
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	sent := 0
+	var first error
+
+	for _, d := range pending {
+
+		todos, err := s.todos.GetByOwner(d.profile.User)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+
+		ok, err := s.send(&d.profile, d.period, Build(d.profile.User, d.profile.Digest, todos, now, d.loc))
+		if err != nil && first == nil {
+			first = err
+		}
+
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, first
+}
+
+// send sends a digest for a period unless it was already sent, and reports whether it sent it
+func (s *Sender) send(p *server.Profile, period string, d Digest) (bool, error) {
+
+	if err := s.digests.MarkSent(p.User, period); err != nil {
+		if errors.Cause(err) == database.ErrExists {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// The digest stays marked, so that it is not sent later in the period as ToDos become due
+	if d.Empty() {
+		return false, nil
+	}
+
+	d.UnsubscribeURL = s.unsubscribeURL + "?token=" + url.QueryEscape(UnsubscribeToken(s.secret, p.User))
+
+	msg, err := Render(d)
+	if err == nil {
+		err = s.notifier.Notify(p.Contact(), msg)
+	}
+
+	if errors.Cause(err) == notify.ErrNoContact {
+		return false, nil
+	}
+
+	if err != nil {
+		if uerr := s.digests.Unmark(p.User, period); uerr != nil {
+			return false, errors.Wrapf(uerr, "Could not unmark digest after error %v", err)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,43 @@
+package digest
+
+import (
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// textTemplate renders the plain text part of the digest emails
+var textTemplate = texttemplate.Must(texttemplate.New("digest").Parse(`Your ToDos on {{.Date}}
+{{with .Overdue}}
+Overdue
+{{range .}}- {{.Title}}, due {{.Due}}
+{{end}}{{end}}{{with .DueToday}}
+Due today
+{{range .}}- {{.Title}}, due {{.Due}}
+{{end}}{{end}}{{with .Completed}}
+Completed {{$.CompletedPeriod}}
+{{range .}}- {{.Title}}
+{{end}}{{end}}
+Unsubscribe from these emails: {{.UnsubscribeURL}}
+`))
+
+// htmlTemplate renders the HTML part of the digest emails, escaping the titles of the ToDos
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<h1>Your ToDos on {{.Date}}</h1>
+{{with .Overdue}}<h2>Overdue</h2>
+<ul>
+{{range .}}<li>{{.Title}}, due {{.Due}}</li>
+{{end}}</ul>
+{{end}}{{with .DueToday}}<h2>Due today</h2>
+<ul>
+{{range .}}<li>{{.Title}}, due {{.Due}}</li>
+{{end}}</ul>
+{{end}}{{with .Completed}}<h2>Completed {{$.CompletedPeriod}}</h2>
+<ul>
+{{range .}}<li>{{.Title}}</li>
+{{end}}</ul>
+{{end}}<p><a href="{{.UnsubscribeURL}}">Unsubscribe from these emails</a></p>
+</body>
+</html>
+`))
@@ -0,0 +1,51 @@
+package digest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidToken is returned when an unsubscribe token was not signed with the secret
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// tokenPurpose is signed along with the user, so that the secret can sign other tokens which
+// are not accepted as unsubscribe tokens
+const tokenPurpose = "digest.unsubscribe:"
+
+// UnsubscribeToken returns the token of the unsubscribe link of the digests of a user. It does
+// not expire, so that the links of old digests keep working.
+func UnsubscribeToken(secret []byte, user string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(user)) + "." + base64.RawURLEncoding.EncodeToString(sign(secret, user))
+}
+
+// VerifyUnsubscribeToken returns the user of an unsubscribe token, or ErrInvalidToken if it was
+// not signed with the secret
+func VerifyUnsubscribeToken(secret []byte, token string) (string, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", ErrInvalidToken
+	}
+
+	user, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, sign(secret, string(user))) {
+		return "", ErrInvalidToken
+	}
+
+	return string(user), nil
+}
+
+func sign(secret []byte, user string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(tokenPurpose + user))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// EventType identifies a change of a ToDo
+type EventType string
+
+const (
+	// EventToDoCreated is emitted when a ToDo is created
+	EventToDoCreated EventType = "todo.created"
+	// EventToDoUpdated is emitted when a ToDo is updated, other than by completing it
+	EventToDoUpdated EventType = "todo.updated"
+	// EventToDoCompleted is emitted when a ToDo is updated from open to completed
+	EventToDoCompleted EventType = "todo.completed"
+	// EventToDoDeleted is emitted when a ToDo is deleted
+	EventToDoDeleted EventType = "todo.deleted"
+	// EventPing is sent to test a webhook, it is not about any ToDo
+	EventPing EventType = "ping"
+)
+
+// Enum returns the types of the events webhooks can subscribe to
+func (t EventType) Enum() []string {
+	return []string{string(EventToDoCreated), string(EventToDoUpdated), string(EventToDoCompleted), string(EventToDoDeleted)}
+}
+
+// EventVersion is the version of the schema of the events, it is increased whenever a change
+// would break existing consumers
+const EventVersion = 1
+
+// Event is a domain event describing a change of a ToDo, with snapshots of the ToDo before and
+// after the change. Before is nil for created ToDos and After is nil for deleted ToDos.
+type Event struct {
+	ID      string    `json:"id"`
+	Type    EventType `json:"type"`
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	ToDoID  string    `json:"todoId"`
+	Owner   string    `json:"owner"`
+	// Actor is the user whose request changed the ToDo
+	Actor  string `json:"actor,omitempty"`
+	Before *ToDo  `json:"before,omitempty"`
+	After  *ToDo  `json:"after,omitempty"`
+}
+
+// EventPublisher sends domain events to the consumers outside of the API
+type EventPublisher interface {
+	Publish(events ...Event) error
+}
+
+// NewToDoEvent returns the event of a change of a ToDo made by actor
+func NewToDoEvent(actor string, before, after *ToDo) Event {
+
+	e := Event{
+		ID:      uuid.NewV4().String(),
+		Version: EventVersion,
+		Time:    time.Now(),
+		Actor:   actor,
+		Before:  snapshot(before),
+		After:   snapshot(after),
+	}
+
+	switch {
+	case before == nil:
+		e.Type = EventToDoCreated
+	case after == nil:
+		e.Type = EventToDoDeleted
+	case after.Completed && !before.Completed:
+		e.Type = EventToDoCompleted
+	default:
+		e.Type = EventToDoUpdated
+	}
+
+	current := after
+	if current == nil {
+		current = before
+	}
+	e.ToDoID = current.ID
+	e.Owner = current.Owner
+
+	return e
+}
+
+// snapshot copies a ToDo without its computed fields
+func snapshot(t *ToDo) *ToDo {
+
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	c.ItemsProgress = nil
+
+	return &c
+}
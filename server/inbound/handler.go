@@ -0,0 +1,237 @@
+// Package inbound turns the emails forwarded to the team address into ToDos
+package inbound
+
+import (
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/massimoselvi/serverless-todo-api-go/server/publisher"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// maxTitle and maxBody are the maximum lengths of the title of a ToDo and of a comment
+	maxTitle = 500
+	maxBody  = 5000
+	// noSubject is the title of the ToDos of emails without a subject
+	noSubject = "(no subject)"
+	// verdictPass is the status of the SES checks an email passed
+	verdictPass = "PASS"
+	// verdictFail is the status of the SES checks an email failed
+	verdictFail = "FAIL"
+)
+
+// namespace derives the IDs of the ToDos from the IDs of the emails, so that an email retried
+// by SES does not create a second ToDo
+var namespace = uuid.Must(uuid.FromString("6f0c4f4e-5d43-4a0e-9a3b-8f1e0c2d7b61"))
+
+// forwardPrefix matches the prefixes mail clients add to the subject of forwarded emails
+var forwardPrefix = []string{"fwd:", "fw:"}
+
+// Mailbox is where SES stores the raw emails it receives
+type Mailbox interface {
+	// Open returns the raw MIME email with the given SES message ID
+	Open(messageID string) (io.ReadCloser, error)
+}
+
+// Handler creates a ToDo from each email received by SES. The email must come from the email
+// address of a user and pass the DMARC check, which proves the address was not spoofed.
+type Handler struct {
+	mailbox  Mailbox
+	profiles database.ProfileRepo
+	todos    database.OutboxToDoRepo
+	comments database.CommentRepo
+	blobs    database.BlobStore
+	limits   server.AttachmentLimits
+	quotas   database.QuotaRepo
+	maxToDos int
+}
+
+// NewHandler returns a handler reading the emails from mailbox. The body of an email is the first
+// comment of its ToDo and its attachments within limits are attached to the ToDo. The ToDos are
+// counted in quotas like the ToDos created with the API, and the emails of users who already own
+// maxToDos ToDos are dropped, 0 meaning no limit.
+func NewHandler(mailbox Mailbox, profiles database.ProfileRepo, todos database.OutboxToDoRepo, comments database.CommentRepo, blobs database.BlobStore, limits server.AttachmentLimits, quotas database.QuotaRepo, maxToDos int) *Handler {
+	return &Handler{
+		mailbox:  mailbox,
+		profiles: profiles,
+		todos:    todos,
+		comments: comments,
+		blobs:    blobs,
+		limits:   limits,
+		quotas:   quotas,
+		maxToDos: maxToDos,
+	}
+}
+
+// Handle handles an SES event. Emails which cannot become a ToDo are dropped, errors are only
+// returned when SES should retry the event.
+func (h *Handler) Handle(e events.SimpleEmailEvent) error {
+
+	for _, r := range e.Records {
+		if err := h.receive(r.SES); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) receive(ses events.SimpleEmailService) error {
+
+	id := ses.Mail.MessageID
+	receipt := ses.Receipt
+
+	if receipt.SpamVerdict.Status == verdictFail || receipt.VirusVerdict.Status == verdictFail {
+		log.Printf("Dropped email %s: spam or virus", id)
+		return nil
+	}
+
+	if receipt.DMARCVerdict.Status != verdictPass {
+		log.Printf("Dropped email %s: DMARC verdict %s", id, receipt.DMARCVerdict.Status)
+		return nil
+	}
+
+	raw, err := h.mailbox.Open(id)
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+
+	email, err := Parse(raw)
+	if err != nil {
+		log.Printf("Dropped email %s: %v", id, err)
+		return nil
+	}
+
+	profile, err := h.profiles.GetByEmail(email.From)
+	if err != nil {
+		return err
+	}
+
+	if profile == nil {
+		log.Printf("Dropped email %s: %s is not the verified address of a user", id, email.From)
+		return nil
+	}
+
+	// ToDos are created like the API creates them, on behalf of the sender
+	repo := policy.NewToDoRepo(publisher.NewOutboxToDoRepo(h.todos, profile.User), profile.User)
+
+	todo := &server.ToDo{
+		ID:    uuid.NewV5(namespace, id).String(),
+		Title: title(email.Subject),
+	}
+
+	existing, err := repo.Get(todo.ID)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		log.Printf("Dropped email %s: ToDo %s was already created", id, todo.ID)
+		return nil
+	}
+
+	if err := h.quotas.Reserve(profile.User, h.maxToDos); err != nil {
+		if errors.Cause(err) == database.ErrLimitReached {
+			log.Printf("Dropped email %s: %s owns the maximum number of ToDos", id, profile.User)
+			return nil
+		}
+		return err
+	}
+
+	if err := h.attach(todo, email.Attachments); err != nil {
+		h.quotas.Release(profile.User)
+		return err
+	}
+
+	if err := repo.Save(todo); err != nil {
+		h.quotas.Release(profile.User)
+		return err
+	}
+
+	// The comment is saved once the ToDo is, so that a retried email, which is dropped as soon
+	// as its ToDo exists, never saves it twice
+	if email.Body != "" {
+		c := &server.Comment{ToDoID: todo.ID, Author: profile.User, Body: truncate(email.Body, maxBody)}
+		if err := h.comments.Save(c); err != nil {
+			log.Printf("Could not save the body of email %s as a comment of ToDo %s: %v", id, todo.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// attach uploads the attachments of an email within the limits and attaches them to the ToDo.
+// Their IDs are derived from the ToDo, so that a retried email overwrites the same blobs.
+func (h *Handler) attach(todo *server.ToDo, attachments []Attachment) error {
+
+	for i, a := range attachments {
+
+		if len(todo.Attachments) == server.MaxAttachments {
+			log.Printf("Skipped %d attachments of ToDo %s", len(attachments)-i, todo.ID)
+			return nil
+		}
+
+		if h.blobs == nil || !h.limits.Allows(a.ContentType) || int64(len(a.Content)) > h.limits.MaxSize || len(a.Content) == 0 {
+			log.Printf("Skipped attachment %q of type %s and size %d", a.Name, a.ContentType, len(a.Content))
+			continue
+		}
+
+		attachment := server.Attachment{
+			ID:          uuid.NewV5(namespace, todo.ID+"/"+strconv.Itoa(i)).String(),
+			Name:        truncate(a.Name, 255),
+			ContentType: a.ContentType,
+			Size:        int64(len(a.Content)),
+			Created:     time.Now(),
+		}
+
+		if err := h.blobs.Put(todo.BlobKey(attachment.ID), attachment.ContentType, a.Content); err != nil {
+			return err
+		}
+
+		todo.Attachments = append(todo.Attachments, attachment)
+	}
+
+	return nil
+}
+
+// title returns the title of the ToDo of an email with the given subject, without the prefixes
+// of forwarded emails
+func title(subject string) string {
+
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, p := range forwardPrefix {
+			if strings.HasPrefix(strings.ToLower(subject), p) {
+				subject = strings.TrimSpace(subject[len(p):])
+				trimmed = true
+			}
+		}
+	}
+
+	if subject == "" {
+		return noSubject
+	}
+
+	return truncate(subject, maxTitle)
+}
+
+// truncate returns the first n characters of s
+func truncate(s string, n int) string {
+
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
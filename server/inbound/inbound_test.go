@@ -0,0 +1,254 @@
+package inbound_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/databasetest"
+	"github.com/massimoselvi/serverless-todo-api-go/server/inbound"
+)
+
+const testUser = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+
+var testLimits = server.AttachmentLimits{
+	MaxSize:      1 << 20,
+	AllowedTypes: []string{"image/*", "application/pdf"},
+}
+
+func TestInbound(t *testing.T) {
+	t.Run("ParsePlain", testParsePlain)
+	t.Run("ParseMultipart", testParseMultipart)
+	t.Run("ParseHTML", testParseHTML)
+	t.Run("CreateToDo", testCreateToDo)
+	t.Run("CreateToDoOnce", testCreateToDoOnce)
+	t.Run("QuotaReached", testQuotaReached)
+	t.Run("UnknownSender", testUnknownSender)
+	t.Run("UnverifiedSender", testUnverifiedSender)
+	t.Run("FailedDMARC", testFailedDMARC)
+}
+
+func parse(t *testing.T, name string) *inbound.Email {
+
+	f, err := os.Open(filepath.Join("testdata", name+".eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	e, err := inbound.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+func testParsePlain(t *testing.T) {
+
+	e := parse(t, "plain")
+
+	if e.From != "alice@example.com" {
+		t.Fatalf("Expected the lower case address of the sender, got %s", e.From)
+	}
+
+	if e.Subject != "Fwd: Renew passport ✈" {
+		t.Fatalf("Expected the decoded subject, got %q", e.Subject)
+	}
+
+	if !strings.HasPrefix(e.Body, "The passport expires in September, book an appointment at the consulate before the summer ends.") {
+		t.Fatalf("Expected the quoted-printable body to be decoded, got %q", e.Body)
+	}
+
+	if len(e.Attachments) != 0 {
+		t.Fatalf("Expected no attachments, got %d", len(e.Attachments))
+	}
+}
+
+func testParseMultipart(t *testing.T) {
+
+	e := parse(t, "multipart")
+
+	if e.Body != "Invoice attached, due on Friday." {
+		t.Fatalf("Expected the plain text body, got %q", e.Body)
+	}
+
+	if len(e.Attachments) != 3 {
+		t.Fatalf("Expected 3 attachments, got %d", len(e.Attachments))
+	}
+
+	pdf := e.Attachments[0]
+	if pdf.Name != "invoice.pdf" || pdf.ContentType != "application/pdf" || !strings.HasPrefix(string(pdf.Content), "%PDF-1.4") {
+		t.Fatalf("Expected the decoded invoice, got %s %s %q", pdf.Name, pdf.ContentType, pdf.Content)
+	}
+
+	if e.Attachments[1].Name != "reçu.png" {
+		t.Fatalf("Expected the decoded name of the inline image, got %q", e.Attachments[1].Name)
+	}
+}
+
+func testParseHTML(t *testing.T) {
+
+	e := parse(t, "html")
+
+	if e.Subject != "Café order" {
+		t.Fatalf("Expected the decoded subject, got %q", e.Subject)
+	}
+
+	if e.Body != "Order 2 kg of café beans & filters\n\nfrom the usual shop" {
+		t.Fatalf("Expected the text of the HTML body, got %q", e.Body)
+	}
+}
+
+// sesEvent returns the event of the fixture with the given name, with the given DMARC verdict
+func sesEvent(name, dmarc string) events.SimpleEmailEvent {
+
+	var r events.SimpleEmailRecord
+	r.SES.Mail.MessageID = name
+	r.SES.Receipt.SpamVerdict.Status = "PASS"
+	r.SES.Receipt.VirusVerdict.Status = "PASS"
+	r.SES.Receipt.DMARCVerdict.Status = dmarc
+
+	return events.SimpleEmailEvent{Records: []events.SimpleEmailRecord{r}}
+}
+
+func handler() (*inbound.Handler, *OutboxRepoMock, *CommentRepoMock, *BlobStoreMock) {
+
+	profiles := databasetest.NewProfileRepoMock(server.Profile{User: testUser, Email: "alice@example.com", EmailVerified: true})
+	todos := &OutboxRepoMock{ToDos: map[string]*server.ToDo{}}
+	comments := &CommentRepoMock{}
+	blobs := &BlobStoreMock{Blobs: map[string][]byte{}}
+
+	return inbound.NewHandler(&MailboxMock{}, profiles, todos, comments, blobs, testLimits, &QuotaRepoMock{Counts: map[string]int{}}, 0), todos, comments, blobs
+}
+
+func testCreateToDo(t *testing.T) {
+
+	h, todos, comments, blobs := handler()
+
+	if err := h.Handle(sesEvent("multipart", "PASS")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos.ToDos) != 1 {
+		t.Fatalf("Expected 1 ToDo, got %d", len(todos.ToDos))
+	}
+
+	var todo *server.ToDo
+	for _, created := range todos.ToDos {
+		todo = created
+	}
+
+	if todo.Title != "Pay invoice 2019-031" || todo.Owner != testUser {
+		t.Fatalf("Expected a ToDo of the sender titled with the subject, got %+v", todo)
+	}
+
+	if len(todos.Events) != 1 || todos.Events[0].Type != server.EventToDoCreated || todos.Events[0].Actor != testUser {
+		t.Fatalf("Expected the creation of the ToDo by the sender to be recorded, got %+v", todos.Events)
+	}
+
+	if len(comments.Comments) != 1 || comments.Comments[0].Body != "Invoice attached, due on Friday." || comments.Comments[0].ToDoID != todo.ID {
+		t.Fatalf("Expected the body to be commented on the ToDo, got %+v", comments.Comments)
+	}
+
+	// The executable is not an allowed type
+	if len(todo.Attachments) != 2 || todo.Attachments[0].Name != "invoice.pdf" || todo.Attachments[1].ContentType != "image/png" {
+		t.Fatalf("Expected the allowed attachments to be attached, got %+v", todo.Attachments)
+	}
+
+	for _, a := range todo.Attachments {
+		if int64(len(blobs.Blobs[todo.BlobKey(a.ID)])) != a.Size {
+			t.Fatalf("Expected the content of %s to be stored", a.Name)
+		}
+	}
+}
+
+func testCreateToDoOnce(t *testing.T) {
+
+	h, todos, comments, _ := handler()
+
+	for i := 0; i < 2; i++ {
+		if err := h.Handle(sesEvent("plain", "PASS")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(todos.ToDos) != 1 || len(todos.Events) != 1 || len(comments.Comments) != 1 {
+		t.Fatalf("Expected a retried email to create a single ToDo, got %d", len(todos.ToDos))
+	}
+
+	for _, todo := range todos.ToDos {
+		if todo.Title != "Renew passport ✈" {
+			t.Fatalf("Expected the title without the forward prefix, got %q", todo.Title)
+		}
+	}
+}
+
+func testQuotaReached(t *testing.T) {
+
+	todos := &OutboxRepoMock{ToDos: map[string]*server.ToDo{}}
+	comments := &CommentRepoMock{}
+	quotas := &QuotaRepoMock{Counts: map[string]int{testUser: 2}}
+	profiles := databasetest.NewProfileRepoMock(server.Profile{User: testUser, Email: "alice@example.com", EmailVerified: true})
+	h := inbound.NewHandler(&MailboxMock{}, profiles, todos, comments, &BlobStoreMock{}, testLimits, quotas, 2)
+
+	if err := h.Handle(sesEvent("plain", "PASS")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos.ToDos) != 0 || len(comments.Comments) != 0 {
+		t.Fatalf("Expected the email of a user owning the maximum number of ToDos to be dropped, got %d ToDos", len(todos.ToDos))
+	}
+
+	if quotas.Counts[testUser] != 2 {
+		t.Fatalf("Expected 2 ToDos counted, got %d", quotas.Counts[testUser])
+	}
+}
+
+func testUnknownSender(t *testing.T) {
+
+	todos := &OutboxRepoMock{ToDos: map[string]*server.ToDo{}}
+	profiles := databasetest.NewProfileRepoMock(server.Profile{User: testUser, Email: "bob@example.com", EmailVerified: true})
+	h := inbound.NewHandler(&MailboxMock{}, profiles, todos, &CommentRepoMock{}, &BlobStoreMock{}, testLimits, &QuotaRepoMock{Counts: map[string]int{}}, 0)
+
+	if err := h.Handle(sesEvent("html", "PASS")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos.ToDos) != 0 {
+		t.Fatalf("Expected the email of an unknown sender to be dropped, got %d ToDos", len(todos.ToDos))
+	}
+}
+
+func testUnverifiedSender(t *testing.T) {
+
+	todos := &OutboxRepoMock{ToDos: map[string]*server.ToDo{}}
+	profiles := databasetest.NewProfileRepoMock(server.Profile{User: testUser, Email: "alice@example.com"})
+	h := inbound.NewHandler(&MailboxMock{}, profiles, todos, &CommentRepoMock{}, &BlobStoreMock{}, testLimits, &QuotaRepoMock{Counts: map[string]int{}}, 0)
+
+	if err := h.Handle(sesEvent("plain", "PASS")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos.ToDos) != 0 {
+		t.Fatalf("Expected the email of an unverified address to be dropped, got %d ToDos", len(todos.ToDos))
+	}
+}
+
+func testFailedDMARC(t *testing.T) {
+
+	h, todos, _, _ := handler()
+
+	for _, verdict := range []string{"FAIL", "GRAY"} {
+		if err := h.Handle(sesEvent("plain", verdict)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(todos.ToDos) != 0 {
+		t.Fatalf("Expected the emails of unauthenticated senders to be dropped, got %d ToDos", len(todos.ToDos))
+	}
+}
@@ -0,0 +1,86 @@
+package inbound_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// MailboxMock reads the emails from the fixtures, the message ID being the name of the fixture
+type MailboxMock struct{}
+
+// Open opens a fixture
+func (m *MailboxMock) Open(messageID string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join("testdata", messageID+".eml"))
+}
+
+// OutboxRepoMock is used to mock a ToDo repository with an outbox, only the methods used to
+// create ToDos are implemented
+type OutboxRepoMock struct {
+	database.OutboxToDoRepo
+	ToDos  map[string]*server.ToDo
+	Events []server.Event
+}
+
+// Get returns a ToDo, or nil if there is none
+func (m *OutboxRepoMock) Get(id string) (*server.ToDo, error) {
+	return m.ToDos[id], nil
+}
+
+// SaveWithEvent stores a ToDo along with its event
+func (m *OutboxRepoMock) SaveWithEvent(todo *server.ToDo, event func(after *server.ToDo) server.Event) error {
+	c := *todo
+	m.ToDos[todo.ID] = &c
+	m.Events = append(m.Events, event(&c))
+	return nil
+}
+
+// CommentRepoMock is used to mock a comment repository, only Save is used by the handler
+type CommentRepoMock struct {
+	database.CommentRepo
+	Comments []server.Comment
+}
+
+// Save stores a comment
+func (m *CommentRepoMock) Save(comment *server.Comment) error {
+	m.Comments = append(m.Comments, *comment)
+	return nil
+}
+
+// BlobStoreMock is used to mock a blob store, only Put is used by the handler
+type BlobStoreMock struct {
+	database.BlobStore
+	Blobs map[string][]byte
+}
+
+// Put stores a blob
+func (m *BlobStoreMock) Put(key, contentType string, content []byte) error {
+	m.Blobs[key] = content
+	return nil
+}
+
+// QuotaRepoMock is used to mock a quota repository, counting the ToDos of each owner
+type QuotaRepoMock struct {
+	Counts map[string]int
+}
+
+// Reserve counts one more ToDo for an owner, unless they own max ToDos
+func (m *QuotaRepoMock) Reserve(owner string, max int) error {
+	if max > 0 && m.Counts[owner] >= max {
+		return errors.Wrap(database.ErrLimitReached, "quota")
+	}
+	m.Counts[owner]++
+	return nil
+}
+
+// Release counts one less ToDo for an owner
+func (m *QuotaRepoMock) Release(owner string) error {
+	if m.Counts[owner] > 0 {
+		m.Counts[owner]--
+	}
+	return nil
+}
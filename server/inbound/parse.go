@@ -0,0 +1,192 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxDepth is the maximum nesting of multipart bodies
+const maxDepth = 10
+
+// Email is the content of an inbound email
+type Email struct {
+	// From is the address of the sender, in lower case
+	From    string
+	Subject string
+	// Body is the plain text body, or the text of the HTML body if the email has no plain text
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to an inbound email
+type Attachment struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+var (
+	// htmlSkipped matches the elements of an HTML body which have no text
+	htmlSkipped = regexp.MustCompile(`(?is)<(head|style|script)\b.*?</(head|style|script)\s*>`)
+	// htmlBreaks matches the tags separating lines of text
+	htmlBreaks = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6])\b[^>]*>`)
+	htmlTags   = regexp.MustCompile(`<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+)
+
+// Parse parses a raw MIME email
+func Parse(r io.Reader) (*Email, error) {
+
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not read email")
+	}
+
+	from, err := msg.Header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return nil, errors.Errorf("Invalid sender %q", msg.Header.Get("From"))
+	}
+
+	dec := &mime.WordDecoder{}
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	e := &Email{
+		From:    strings.ToLower(from[0].Address),
+		Subject: strings.TrimSpace(subject),
+	}
+
+	p := &parser{email: e}
+	if err := p.part(textproto.MIMEHeader(msg.Header), msg.Body, 0); err != nil {
+		return nil, err
+	}
+
+	e.Body = strings.TrimSpace(p.text)
+	if e.Body == "" {
+		e.Body = htmlText(p.html)
+	}
+
+	return e, nil
+}
+
+// parser collects the bodies and attachments of the parts of an email
+type parser struct {
+	email *Email
+	text  string
+	html  string
+}
+
+// part parses a part of the email, walking the parts of multipart bodies
+func (p *parser) part(header textproto.MIMEHeader, body io.Reader, depth int) error {
+
+	if depth > maxDepth {
+		return errors.New("Email is nested too deeply")
+	}
+
+	contentType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		contentType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(contentType, "multipart/") {
+
+		r := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := r.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return errors.Wrap(err, "Could not read part of email")
+			}
+
+			if err := p.part(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := ioutil.ReadAll(decode(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return errors.Wrapf(err, "Could not decode part of type %s", contentType)
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := dparams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+
+	switch {
+	case disposition != "attachment" && name == "" && contentType == "text/plain" && p.text == "":
+		p.text = toUTF8(content, params["charset"])
+	case disposition != "attachment" && name == "" && contentType == "text/html" && p.html == "":
+		p.html = toUTF8(content, params["charset"])
+	case disposition == "attachment" || name != "":
+		if decoded, err := (&mime.WordDecoder{}).DecodeHeader(name); err == nil {
+			name = decoded
+		}
+		if name == "" {
+			name = "attachment"
+		}
+		p.email.Attachments = append(p.email.Attachments, Attachment{
+			Name:        name,
+			ContentType: contentType,
+			Content:     content,
+		})
+	}
+
+	return nil
+}
+
+// decode returns the content of a part encoded with the given transfer encoding. Quoted-printable
+// parts of multipart bodies are already decoded by the multipart reader.
+func decode(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// toUTF8 converts text in the given charset to UTF-8. Only the charsets whose characters map
+// to the same code points are converted, other text is kept as is.
+func toUTF8(b []byte, charset string) string {
+
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1":
+		var buf bytes.Buffer
+		for _, c := range b {
+			buf.WriteRune(rune(c))
+		}
+		return buf.String()
+	default:
+		return string(b)
+	}
+}
+
+// htmlText returns the text of an HTML body, one line per paragraph
+func htmlText(s string) string {
+	s = htmlSkipped.ReplaceAllString(s, "")
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(htmlTags.ReplaceAllString(s, ""))
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
@@ -0,0 +1,37 @@
+package inbound
+
+import (
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+)
+
+// S3Mailbox reads the emails SES stores in an S3 bucket with its S3 action
+type S3Mailbox struct {
+	s3     s3iface.S3API
+	bucket string
+	prefix string
+}
+
+// NewS3Mailbox returns a mailbox reading the emails stored in bucket under the object key prefix
+// of the S3 action
+func NewS3Mailbox(s3 s3iface.S3API, bucket, prefix string) *S3Mailbox {
+	return &S3Mailbox{s3, bucket, prefix}
+}
+
+// Open returns the raw email stored under the prefix and the SES message ID
+func (m *S3Mailbox) Open(messageID string) (io.ReadCloser, error) {
+
+	out, err := m.s3.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(m.bucket),
+		Key:    aws.String(m.prefix + messageID),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not get email %s", messageID)
+	}
+
+	return out.Body, nil
+}
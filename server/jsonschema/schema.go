@@ -0,0 +1,169 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema, restricted to the keywords supported by OpenAPI 3
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// Enumer is implemented by types restricted to a set of string values
+type Enumer interface {
+	Enum() []string
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	enumerType = reflect.TypeOf((*Enumer)(nil)).Elem()
+)
+
+// Generate returns the schema of the JSON encoding of v. Properties are named after their
+// json tags and constrained by their schema tags, a comma separated list of:
+//
+//	required     the property must be present
+//	readonly     the property is set by the server and rejected in requests
+//	minLength=n  the minimum length of a string
+//	maxLength=n  the maximum length of a string
+//	minimum=n    the minimum value of a number
+//	maximum=n    the maximum value of a number
+//	format=f     the format of a string
+//
+// Objects do not allow additional properties.
+func Generate(v interface{}) *Schema {
+	return generate(reflect.TypeOf(v))
+}
+
+func generate(t reflect.Type) *Schema {
+
+	if t.Kind() == reflect.Ptr {
+		s := generate(t.Elem())
+		s.Nullable = true
+		return s
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	if t.Implements(enumerType) {
+		return &Schema{Type: "string", Enum: reflect.Zero(t).Interface().(Enumer).Enum()}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: generate(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: generate(t.Elem())}
+	case reflect.Struct:
+		return generateStruct(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func generateStruct(t reflect.Type) *Schema {
+
+	s := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		p := generate(f.Type)
+
+		for _, opt := range strings.Split(f.Tag.Get("schema"), ",") {
+
+			kv := strings.SplitN(opt, "=", 2)
+			switch kv[0] {
+			case "required":
+				s.Required = append(s.Required, name)
+			case "readonly":
+				p.ReadOnly = true
+			case "format":
+				p.Format = value(kv)
+			case "minLength":
+				p.MinLength = intValue(kv)
+			case "maxLength":
+				p.MaxLength = intValue(kv)
+			case "minimum":
+				if n, err := strconv.ParseFloat(value(kv), 64); err == nil {
+					p.Minimum = &n
+				}
+			case "maximum":
+				if n, err := strconv.ParseFloat(value(kv), 64); err == nil {
+					p.Maximum = &n
+				}
+			}
+		}
+
+		s.Properties[name] = p
+	}
+
+	return s
+}
+
+func value(kv []string) string {
+	if len(kv) < 2 {
+		return ""
+	}
+	return kv[1]
+}
+
+func intValue(kv []string) *int {
+	n, err := strconv.Atoi(value(kv))
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// Optional returns a copy of the schema where no top level property is required, as used to
+// validate partial updates
+func (s *Schema) Optional() *Schema {
+	c := *s
+	c.Required = nil
+	return &c
+}
@@ -0,0 +1,139 @@
+package jsonschema_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+)
+
+type color string
+
+func (color) Enum() []string {
+	return []string{"red", "green"}
+}
+
+type item struct {
+	Name    string    `json:"name" schema:"required,minLength=1"`
+	Color   color     `json:"color,omitempty"`
+	Count   int       `json:"count" schema:"minimum=0"`
+	Rating  int       `json:"rating,omitempty" schema:"minimum=1,maximum=5"`
+	Tags    []string  `json:"tags,omitempty"`
+	Created time.Time `json:"created" schema:"readonly"`
+	Note    *string   `json:"note,omitempty"`
+}
+
+func TestSchema(t *testing.T) {
+	t.Run("Generate", testGenerate)
+	t.Run("ValidateOK", testValidateOK)
+	t.Run("ValidateViolations", testValidateViolations)
+	t.Run("Optional", testOptional)
+	t.Run("InvalidJSON", testInvalidJSON)
+	t.Run("StripUnchanged", testStripUnchanged)
+}
+
+func testGenerate(t *testing.T) {
+
+	s := jsonschema.Generate(item{})
+
+	if s.Type != "object" || s.AdditionalProperties != false {
+		t.Fatal("Expected a closed object schema")
+	}
+
+	if len(s.Required) != 1 || s.Required[0] != "name" {
+		t.Fatalf("Expected name to be required, got %v", s.Required)
+	}
+
+	if s.Properties["created"].Format != "date-time" || !s.Properties["created"].ReadOnly {
+		t.Fatal("Expected created to be a read-only date-time")
+	}
+
+	if len(s.Properties["color"].Enum) != 2 {
+		t.Fatal("Expected color to be an enum")
+	}
+
+	if s.Properties["tags"].Items.Type != "string" {
+		t.Fatal("Expected tags to be an array of strings")
+	}
+
+	if !s.Properties["note"].Nullable {
+		t.Fatal("Expected note to be nullable")
+	}
+}
+
+func testValidateOK(t *testing.T) {
+
+	v, err := jsonschema.Generate(item{}).Validate([]byte(`{"name":"a","color":"red","count":2,"rating":5,"tags":["x"],"note":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(v) != 0 {
+		t.Fatalf("Expected no violations, got %v", v)
+	}
+}
+
+func testValidateViolations(t *testing.T) {
+
+	v, err := jsonschema.Generate(item{}).Validate([]byte(`{"name":"","color":"blue","count":-1.5,"rating":6,"tags":[1],"created":"2019-01-01T00:00:00Z","other":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]bool{
+		"name":    true,
+		"color":   true,
+		"count":   true,
+		"rating":  true,
+		"tags[0]": true,
+		"created": true,
+		"other":   true,
+	}
+
+	for _, violation := range v {
+		delete(expected, violation.Field)
+	}
+
+	if len(expected) != 0 {
+		t.Fatalf("Expected violations on %v, got %v", expected, v)
+	}
+}
+
+func testOptional(t *testing.T) {
+
+	v, err := jsonschema.Generate(item{}).Optional().Validate([]byte(`{"count":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(v) != 0 {
+		t.Fatalf("Expected no violations, got %v", v)
+	}
+}
+
+func testInvalidJSON(t *testing.T) {
+
+	if _, err := jsonschema.Generate(item{}).Validate([]byte(`{`)); err == nil {
+		t.Fatal("Expected Error")
+	}
+}
+
+type order struct {
+	ID    string `json:"id" schema:"readonly"`
+	Items []item `json:"items"`
+}
+
+func testStripUnchanged(t *testing.T) {
+
+	current := []byte(`{"id":"o1","items":[{"name":"a","count":1,"created":"2019-01-01T00:00:00Z"}]}`)
+
+	b, err := jsonschema.Generate(order{}).StripUnchanged([]byte(`{"id":"o1","items":[{"name":"a","count":12345678901,"created":"2019-02-01T00:00:00Z"}]}`), current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"items":[{"count":12345678901,"created":"2019-02-01T00:00:00Z","name":"a"}]}`
+	if string(b) != expected {
+		t.Fatalf("Expected %s, got %s", expected, b)
+	}
+}
@@ -0,0 +1,249 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageReadOnly is the message of the violations reporting a read-only property
+const MessageReadOnly = "is read-only"
+
+// Violation describes a value of a JSON document not matching its schema
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate checks a JSON document sent by a client against the schema and returns the
+// violations found. Read-only properties are reported as violations. An error is returned
+// if data is not valid JSON.
+func (s *Schema) Validate(data []byte) ([]Violation, error) {
+
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	s.validate("", v, &violations)
+
+	return violations, nil
+}
+
+// StripUnchanged returns the JSON document data without the read-only properties that have
+// the same value in current, the document stored by the server, so that a document read from
+// the server can be sent back as is. The read-only properties that differ are kept, to be
+// reported by Validate. An error is returned if data or current is not valid JSON.
+func (s *Schema) StripUnchanged(data, current []byte) ([]byte, error) {
+
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := decodeJSON(current)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(s.stripUnchanged(v, c))
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v interface{}
+	err := d.Decode(&v)
+	return v, err
+}
+
+func (s *Schema) stripUnchanged(v, current interface{}) interface{} {
+
+	switch v := v.(type) {
+	case []interface{}:
+		c, _ := current.([]interface{})
+		if s.Items != nil {
+			for i, item := range v {
+				var ci interface{}
+				if i < len(c) {
+					ci = c[i]
+				}
+				v[i] = s.Items.stripUnchanged(item, ci)
+			}
+		}
+	case map[string]interface{}:
+		c, _ := current.(map[string]interface{})
+		for k, pv := range v {
+			p, ok := s.Properties[k]
+			if !ok {
+				if additional, ok := s.AdditionalProperties.(*Schema); ok {
+					v[k] = additional.stripUnchanged(pv, c[k])
+				}
+				continue
+			}
+			if p.ReadOnly {
+				if cv, ok := c[k]; ok && reflect.DeepEqual(pv, cv) {
+					delete(v, k)
+				}
+				continue
+			}
+			v[k] = p.stripUnchanged(pv, c[k])
+		}
+	}
+
+	return v
+}
+
+func (s *Schema) validate(field string, v interface{}, violations *[]Violation) {
+
+	report := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if v == nil {
+		if !s.Nullable {
+			report("must not be null")
+		}
+		return
+	}
+
+	switch s.Type {
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			report("must be a string")
+			return
+		}
+		s.validateString(str, report)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			report("must be a boolean")
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			report("must be an integer")
+			return
+		}
+		s.validateNumber(n, report)
+	case "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			report("must be a number")
+			return
+		}
+		s.validateNumber(n, report)
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			report("must be an array")
+			return
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validate(field+"["+strconv.Itoa(i)+"]", item, violations)
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			report("must be an object")
+			return
+		}
+		s.validateObject(field, obj, violations)
+	}
+}
+
+func (s *Schema) validateString(str string, report func(string, ...interface{})) {
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if e == str {
+				found = true
+			}
+		}
+		if !found {
+			report("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+	}
+
+	n := utf8.RuneCountInString(str)
+	if s.MinLength != nil && n < *s.MinLength {
+		report("must be at least %d characters long", *s.MinLength)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		report("must be at most %d characters long", *s.MaxLength)
+	}
+
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			report("must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func (s *Schema) validateNumber(n json.Number, report func(string, ...interface{})) {
+
+	f, err := n.Float64()
+	if err != nil {
+		return
+	}
+
+	if s.Minimum != nil && f < *s.Minimum {
+		report("must be at least %v", *s.Minimum)
+	}
+
+	if s.Maximum != nil && f > *s.Maximum {
+		report("must be at most %v", *s.Maximum)
+	}
+}
+
+func (s *Schema) validateObject(field string, obj map[string]interface{}, violations *[]Violation) {
+
+	prefix := field
+	if prefix != "" {
+		prefix += "."
+	}
+
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			*violations = append(*violations, Violation{Field: prefix + name, Message: "is required"})
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+
+		p, ok := s.Properties[k]
+		if !ok {
+			if additional, ok := s.AdditionalProperties.(*Schema); ok {
+				additional.validate(prefix+k, obj[k], violations)
+			} else {
+				*violations = append(*violations, Violation{Field: prefix + k, Message: "is not a known field"})
+			}
+			continue
+		}
+
+		if p.ReadOnly {
+			*violations = append(*violations, Violation{Field: prefix + k, Message: MessageReadOnly})
+			continue
+		}
+
+		p.validate(prefix+k, obj[k], violations)
+	}
+}
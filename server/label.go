@@ -0,0 +1,76 @@
+package server
+
+import "time"
+
+// Label is a tag managed centrally, ToDos refer to labels by name in their tags
+type Label struct {
+	ID      string    `json:"id" schema:"format=uuid"`
+	Name    string    `json:"name" schema:"required,minLength=1,maxLength=50"`
+	Color   string    `json:"color,omitempty" schema:"maxLength=7"`
+	ModTime time.Time `json:"modTime" schema:"readonly"`
+	Owner   string    `json:"owner,omitempty" schema:"readonly"`
+	// Migration is the rename, merge or deletion of the label still being applied to ToDos
+	Migration *LabelMigration `json:"migration,omitempty" schema:"readonly"`
+
+	// UsageCount is computed from the tags of the ToDos when the label is sent to clients
+	UsageCount int `json:"usageCount" dynamodbav:"-" schema:"readonly"`
+}
+
+// LabelMigration replaces a tag in the ToDos of a user. ToDos are updated in batches and the
+// migration is stored until the last one, so it can be resumed with the ToDos still having
+// the old tag if interrupted.
+type LabelMigration struct {
+	From string `json:"from"`
+	// To is the tag replacing From, empty to remove it
+	To string `json:"to,omitempty"`
+	// Updated counts the ToDos updated so far
+	Updated int `json:"updated"`
+	// DeleteLabel deletes the label once the migration is done
+	DeleteLabel bool `json:"deleteLabel,omitempty"`
+}
+
+// HasTag reports whether the ToDo is tagged with the given tag
+func (t *ToDo) HasTag(tag string) bool {
+	for _, tt := range t.Tags {
+		if tt == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceTag replaces a tag of the ToDo, or removes it if to is empty. It reports whether the
+// tags changed.
+func (t *ToDo) ReplaceTag(from, to string) bool {
+
+	if !t.HasTag(from) {
+		return false
+	}
+
+	tags := []string{}
+	for _, tt := range t.Tags {
+		if tt == from {
+			tt = to
+		}
+		if tt == "" || contains(tags, tt) {
+			continue
+		}
+		tags = append(tags, tt)
+	}
+
+	if len(tags) == 0 {
+		tags = nil
+	}
+
+	t.Tags = tags
+	return true
+}
+
+func contains(values []string, v string) bool {
+	for _, vv := range values {
+		if vv == v {
+			return true
+		}
+	}
+	return false
+}
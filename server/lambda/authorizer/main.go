@@ -0,0 +1,19 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/massimoselvi/serverless-todo-api-go/server/auth"
+)
+
+// main authenticates the connections to the WebSocket API with the Cognito tokens of the users
+func main() {
+
+	issuer := "https://cognito-idp.us-west-2.amazonaws.com/" + os.Getenv("USER_POOL_ID")
+	v := auth.NewVerifier(issuer, os.Getenv("USER_POOL_CLIENT_ID"), &http.Client{Timeout: 5 * time.Second})
+
+	awslambda.Start(v.Authorize)
+}
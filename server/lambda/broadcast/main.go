@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/realtime"
+	"github.com/pkg/errors"
+)
+
+// main sends the events published to the event bus to the WebSocket connections of the owners
+// of the ToDos. WEBSOCKET_ENDPOINT is the https URL of the stage of the WebSocket API.
+func main() {
+
+	s, err := session.NewSession(aws.NewConfig().WithRegion("us-west-2"))
+	if err != nil {
+		panic(err)
+	}
+
+	api := apigatewaymanagementapi.New(s, aws.NewConfig().WithEndpoint(os.Getenv("WEBSOCKET_ENDPOINT")))
+	b := realtime.NewBroadcaster(dynamodb.NewConnectionRepo(awsdynamodb.New(s)), api)
+
+	awslambda.Start(func(ev events.CloudWatchEvent) error {
+
+		var e server.Event
+		if err := json.Unmarshal(ev.Detail, &e); err != nil {
+			return errors.Wrapf(err, "Could not unmarshal event %s", ev.ID)
+		}
+
+		return b.Broadcast(e)
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/digest"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+)
+
+// main emails the digests due when invoked by the schedule, or logs them when NOTIFIER is log
+func main() {
+
+	s, err := session.NewSession(aws.NewConfig().WithRegion("us-west-2"))
+	if err != nil {
+		panic(err)
+	}
+
+	var notifier notify.Notifier = notify.NewSES(ses.New(s), os.Getenv("NOTIFY_FROM"))
+	if os.Getenv("NOTIFIER") == "log" {
+		notifier = notify.NewLog(os.Stderr)
+	}
+
+	db := awsdynamodb.New(s)
+	sender := digest.NewSender(
+		dynamodb.NewProfileRepo(db),
+		dynamodb.NewToDoRepo(db),
+		dynamodb.NewDigestRepo(db),
+		notifier,
+		[]byte(os.Getenv("DIGEST_SECRET")),
+		os.Getenv("DIGEST_UNSUBSCRIBE_URL"),
+	)
+
+	awslambda.Start(func(ev events.CloudWatchEvent) error {
+
+		sent, err := sender.Send(time.Now())
+		log.Printf("Sent %d digests", sent)
+
+		return err
+	})
+}
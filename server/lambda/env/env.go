@@ -0,0 +1,57 @@
+// Package env reads the configuration the Lambda functions receive in environment variables
+package env
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Int returns the integer value of an environment variable, or def if it is not set. It panics
+// if the variable is not an integer, so that a misconfigured function fails when it starts
+// instead of running with the default.
+func Int(key string, def int) int {
+
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		panic(fmt.Sprintf("environment variable %s must be an integer, got %q", key, s))
+	}
+
+	return v
+}
+
+// Float returns the float value of an environment variable, or def if it is not set. It panics
+// if the variable is not a number, like Int.
+func Float(key string, def float64) float64 {
+
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		panic(fmt.Sprintf("environment variable %s must be a number, got %q", key, s))
+	}
+
+	return v
+}
+
+// List returns the comma separated values of an environment variable
+func List(key string) []string {
+
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
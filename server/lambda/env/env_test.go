@@ -0,0 +1,77 @@
+package env_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/env"
+)
+
+func TestEnv(t *testing.T) {
+	t.Run("Int", testInt)
+	t.Run("Float", testFloat)
+	t.Run("Malformed", testMalformed)
+	t.Run("List", testList)
+}
+
+func testInt(t *testing.T) {
+
+	os.Setenv("ENV_TEST_INT", "42")
+	defer os.Unsetenv("ENV_TEST_INT")
+
+	if v := env.Int("ENV_TEST_INT", 1); v != 42 {
+		t.Fatalf("Expected 42, got %d", v)
+	}
+
+	if v := env.Int("ENV_TEST_MISSING", 1); v != 1 {
+		t.Fatalf("Expected the default value, got %d", v)
+	}
+}
+
+func testFloat(t *testing.T) {
+
+	os.Setenv("ENV_TEST_FLOAT", "0.5")
+	defer os.Unsetenv("ENV_TEST_FLOAT")
+
+	if v := env.Float("ENV_TEST_FLOAT", 1); v != 0.5 {
+		t.Fatalf("Expected 0.5, got %v", v)
+	}
+
+	if v := env.Float("ENV_TEST_MISSING", 1); v != 1 {
+		t.Fatalf("Expected the default value, got %v", v)
+	}
+}
+
+func testMalformed(t *testing.T) {
+
+	os.Setenv("ENV_TEST_MALFORMED", "1O")
+	defer os.Unsetenv("ENV_TEST_MALFORMED")
+
+	for name, read := range map[string]func(){
+		"Int":   func() { env.Int("ENV_TEST_MALFORMED", 1) },
+		"Float": func() { env.Float("ENV_TEST_MALFORMED", 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("Expected %s to panic on a malformed value", name)
+				}
+			}()
+			read()
+		}()
+	}
+}
+
+func testList(t *testing.T) {
+
+	os.Setenv("ENV_TEST_LIST", " a, b,,c ")
+	defer os.Unsetenv("ENV_TEST_LIST")
+
+	if v := env.List("ENV_TEST_LIST"); len(v) != 3 || v[0] != "a" || v[1] != "b" || v[2] != "c" {
+		t.Fatalf("Expected [a b c], got %v", v)
+	}
+
+	if v := env.List("ENV_TEST_MISSING"); len(v) != 0 {
+		t.Fatalf("Expected no values, got %v", v)
+	}
+}
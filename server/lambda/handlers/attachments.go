@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"mime"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// attachmentUpload is the response to a new attachment, its content must be uploaded to the URL
+type attachmentUpload struct {
+	Attachment server.Attachment `json:"attachment"`
+	UploadURL  string            `json:"uploadUrl"`
+}
+
+// attachmentDownload is an attachment along with a short-lived URL of its content
+type attachmentDownload struct {
+	Attachment  server.Attachment `json:"attachment"`
+	DownloadURL string            `json:"downloadUrl"`
+}
+
+// attachmentSchema validates the attachments sent to attach a file to a ToDo
+var attachmentSchema = jsonschema.Generate(server.Attachment{})
+
+// WithAttachments lets users attach files to ToDos, storing their content in the given store
+func WithAttachments(store database.BlobStore, limits server.AttachmentLimits) Option {
+	return func(h *ToDoHandler) {
+		h.blobs = store
+		h.attachmentLimits = limits
+	}
+}
+
+// attachedToDo returns the ToDo of an attachments request
+func (h *ToDoHandler) attachedToDo(req events.APIGatewayProxyRequest) (*server.ToDo, error) {
+
+	if h.blobs == nil {
+		return nil, ErrNotFound
+	}
+
+	todo, err := h.repo.Get(req.PathParameters["id"])
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if todo == nil {
+		return nil, ErrNotFound
+	}
+
+	return todo, nil
+}
+
+// checkAttachment normalizes the content type of a new attachment and checks it against the limits
+func (h *ToDoHandler) checkAttachment(todo *server.ToDo, a *server.Attachment) error {
+
+	if len(todo.Attachments) >= server.MaxAttachments {
+		return errors.Wrapf(ErrUnprocessable, "ToDo %s already has %d attachments", todo.ID, server.MaxAttachments)
+	}
+
+	contentType, _, err := mime.ParseMediaType(a.ContentType)
+	if err != nil {
+		return errors.Wrapf(ErrBadRequest, "invalid content type %q", a.ContentType)
+	}
+
+	if !h.attachmentLimits.Allows(contentType) {
+		return errors.Wrapf(ErrUnprocessable, "files of type %s cannot be attached", contentType)
+	}
+
+	if a.Size > h.attachmentLimits.MaxSize {
+		return errors.Wrapf(ErrUnprocessable, "files cannot be larger than %d bytes", h.attachmentLimits.MaxSize)
+	}
+
+	a.ContentType = contentType
+
+	return nil
+}
+
+// postAttachment adds an attachment to a ToDo and returns the URL its content must be uploaded to
+func (h *ToDoHandler) postAttachment(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	todo, err := h.attachedToDo(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	var a server.Attachment
+	if err := decode(attachmentSchema, req.Body, &a); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.checkAttachment(todo, &a); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	a.ID = uuid.NewV4().String()
+	a.Created = time.Now()
+
+	url, err := h.blobs.UploadURL(todo.BlobKey(a.ID), a.ContentType, a.Size)
+	if err != nil {
+		return CreateErrorResponse(ErrInternal)
+	}
+
+	todo.Attachments = append(todo.Attachments, a)
+
+	if err := h.repo.Save(todo); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(attachmentUpload{Attachment: a, UploadURL: url})
+}
+
+func (h *ToDoHandler) getAttachment(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	todo, err := h.attachedToDo(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	a := todo.Attachment(req.PathParameters["attachmentId"])
+	if a == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	url, err := h.blobs.DownloadURL(todo.BlobKey(a.ID), a.Name)
+	if err != nil {
+		return CreateErrorResponse(ErrInternal)
+	}
+
+	return CreateOKResponse(attachmentDownload{Attachment: *a, DownloadURL: url})
+}
+
+func (h *ToDoHandler) deleteAttachment(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	todo, err := h.attachedToDo(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	id := req.PathParameters["attachmentId"]
+	if todo.Attachment(id) == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	kept := todo.Attachments[:0]
+	for _, a := range todo.Attachments {
+		if a.ID != id {
+			kept = append(kept, a)
+		}
+	}
+	todo.Attachments = kept
+
+	if err := h.repo.Save(todo); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if err := h.blobs.Delete(todo.BlobKey(id)); err != nil {
+		return CreateErrorResponse(ErrInternal)
+	}
+
+	withProgress(todo)
+	return CreateOKResponse(todo)
+}
+
+// purge permanently deletes a ToDo along with its comments and the content of its attachments.
+// The ToDo is deleted first, so that the rest is only deleted if the user is allowed to delete it.
+func (h *ToDoHandler) purge(todo *server.ToDo) error {
+
+	if err := h.repo.Delete(todo.ID); err != nil {
+		return repoError(err)
+	}
+
+	if err := h.releaseQuota(todo.Owner); err != nil {
+		return err
+	}
+
+	if h.comments != nil {
+		if err := h.comments.DeleteAll(todo.ID); err != nil {
+			return repoError(err)
+		}
+	}
+
+	if h.blobs == nil {
+		return nil
+	}
+
+	for _, a := range todo.Attachments {
+		if err := h.blobs.Delete(todo.BlobKey(a.ID)); err != nil {
+			return ErrInternal
+		}
+	}
+
+	return nil
+}
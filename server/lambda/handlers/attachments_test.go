@@ -0,0 +1,296 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestAttachments(t *testing.T) {
+	t.Run("PostAttachment", testPostAttachment)
+	t.Run("PostAttachmentTooLarge", testPostAttachmentTooLarge)
+	t.Run("PostAttachmentTypeNotAllowed", testPostAttachmentTypeNotAllowed)
+	t.Run("PostAttachmentReadOnly", testPostAttachmentReadOnly)
+	t.Run("GetAttachment", testGetAttachment)
+	t.Run("DeleteAttachment", testDeleteAttachment)
+	t.Run("DeleteToDoDeletesBlobs", testDeleteToDoDeletesBlobs)
+	t.Run("PutKeepsAttachments", testPutKeepsAttachments)
+}
+
+var testAttachmentLimits = server.AttachmentLimits{
+	MaxSize:      1024,
+	AllowedTypes: []string{"image/*", "application/pdf"},
+}
+
+// attachedRepo stores a ToDo of the user with an attachment and records the saved ToDo
+func attachedRepo(role server.Role) (*RepoMock, *server.ToDo) {
+
+	todo := sharedToDo(role)
+	todo.Attachments = []server.Attachment{{ID: "a1", Name: "scan.pdf", ContentType: "application/pdf", Size: 100}}
+
+	saved := &server.ToDo{}
+
+	m := &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			if id != todo.ID {
+				return nil, nil
+			}
+			c := *todo
+			c.Attachments = append([]server.Attachment{}, todo.Attachments...)
+			return &c, nil
+		},
+		GetChildrenFn: func(parentID string) ([]server.ToDo, error) {
+			return nil, nil
+		},
+		SaveFn: func(t *server.ToDo) error {
+			*saved = *t
+			return nil
+		},
+		DeleteFn: func(id string) error {
+			return nil
+		},
+	}
+
+	return m, saved
+}
+
+func attachmentRequest(method, attachmentID, body string) events.APIGatewayProxyRequest {
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}/attachments",
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     method,
+		Body:           body,
+	}
+
+	if attachmentID != "" {
+		req.Resource = "/todos/{id}/attachments/{attachmentId}"
+		req.PathParameters["attachmentId"] = attachmentID
+	}
+
+	return req
+}
+
+func testPostAttachment(t *testing.T) {
+
+	m, saved := attachedRepo(server.RoleEditor)
+	blobs := &BlobStoreMock{}
+
+	h := handlers.NewToDoHandler(m, handlers.WithAttachments(blobs, testAttachmentLimits))
+	resp, err := h.Handle(attachmentRequest(http.MethodPost, "", `{"name":"photo.png","contentType":"Image/PNG; charset=binary","size":512}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var upload struct {
+		Attachment server.Attachment `json:"attachment"`
+		UploadURL  string            `json:"uploadUrl"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &upload); err != nil {
+		t.Fatal(err)
+	}
+
+	if upload.Attachment.ID == "" || upload.Attachment.ContentType != "image/png" {
+		t.Fatalf("Expected a new attachment of type image/png, got %+v", upload.Attachment)
+	}
+
+	key := testUUID + "/" + upload.Attachment.ID
+	if len(blobs.Uploads) != 1 || blobs.Uploads[0] != key || !strings.Contains(upload.UploadURL, key) {
+		t.Fatalf("Expected an upload URL for blob %s, got %s", key, upload.UploadURL)
+	}
+
+	if len(saved.Attachments) != 2 || saved.Attachments[1].ID != upload.Attachment.ID {
+		t.Fatalf("Expected the attachment to be saved with the ToDo, got %+v", saved.Attachments)
+	}
+}
+
+func testPostAttachmentTooLarge(t *testing.T) {
+
+	m, _ := attachedRepo(server.RoleEditor)
+	blobs := &BlobStoreMock{}
+
+	h := handlers.NewToDoHandler(m, handlers.WithAttachments(blobs, testAttachmentLimits))
+	resp, err := h.Handle(attachmentRequest(http.MethodPost, "", `{"name":"photo.png","contentType":"image/png","size":2048}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+
+	if len(blobs.Uploads) != 0 || m.SaveInvoked {
+		t.Fatal("Expected a too large file not to be attached")
+	}
+}
+
+func testPostAttachmentTypeNotAllowed(t *testing.T) {
+
+	m, _ := attachedRepo(server.RoleEditor)
+	blobs := &BlobStoreMock{}
+
+	h := handlers.NewToDoHandler(m, handlers.WithAttachments(blobs, testAttachmentLimits))
+	resp, err := h.Handle(attachmentRequest(http.MethodPost, "", `{"name":"run.exe","contentType":"application/x-msdownload","size":10}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+
+	if len(blobs.Uploads) != 0 || m.SaveInvoked {
+		t.Fatal("Expected a file of a type not allowed not to be attached")
+	}
+}
+
+func testPostAttachmentReadOnly(t *testing.T) {
+
+	m, _ := attachedRepo(server.RoleViewer)
+	blobs := &BlobStoreMock{}
+
+	h := handlers.NewToDoHandler(m, handlers.WithAttachments(blobs, testAttachmentLimits))
+	resp, err := h.Handle(attachmentRequest(http.MethodPost, "", `{"name":"photo.png","contentType":"image/png","size":512}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func testGetAttachment(t *testing.T) {
+
+	m, _ := attachedRepo(server.RoleViewer)
+	blobs := &BlobStoreMock{}
+
+	h := handlers.NewToDoHandler(m, handlers.WithAttachments(blobs, testAttachmentLimits))
+	resp, err := h.Handle(attachmentRequest(http.MethodGet, "a1", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if !strings.Contains(resp.Body, `"downloadUrl":"https://blobs.test/`+testUUID+`/a1?name=scan.pdf"`) {
+		t.Fatalf("Expected a download URL of the attachment, got %s", resp.Body)
+	}
+
+	resp, err = h.Handle(attachmentRequest(http.MethodGet, "missing", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func testDeleteAttachment(t *testing.T) {
+
+	m, saved := attachedRepo(server.RoleEditor)
+	blobs := &BlobStoreMock{}
+
+	h := handlers.NewToDoHandler(m, handlers.WithAttachments(blobs, testAttachmentLimits))
+	resp, err := h.Handle(attachmentRequest(http.MethodDelete, "a1", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if len(saved.Attachments) != 0 {
+		t.Fatalf("Expected the attachment to be removed from the ToDo, got %+v", saved.Attachments)
+	}
+
+	if len(blobs.Deleted) != 1 || blobs.Deleted[0] != testUUID+"/a1" {
+		t.Fatalf("Expected blob %s/a1 to be deleted, got %v", testUUID, blobs.Deleted)
+	}
+}
+
+func testDeleteToDoDeletesBlobs(t *testing.T) {
+
+	m, _ := attachedRepo(server.RoleEditor)
+	blobs := &BlobStoreMock{}
+
+	h := handlers.NewToDoHandler(m, handlers.WithAttachments(blobs, testAttachmentLimits))
+	resp, err := h.Handle(events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}",
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodDelete,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the owner can delete the ToDo, so its blobs are kept
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if len(blobs.Deleted) != 0 {
+		t.Fatalf("Expected no blob to be deleted, got %v", blobs.Deleted)
+	}
+
+	m, _ = attachedRepo(server.RoleOwner)
+
+	h = handlers.NewToDoHandler(m, handlers.WithAttachments(blobs, testAttachmentLimits))
+	resp, err = h.Handle(events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}",
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodDelete,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if len(blobs.Deleted) != 1 || blobs.Deleted[0] != testUUID+"/a1" {
+		t.Fatalf("Expected blob %s/a1 to be deleted, got %v", testUUID, blobs.Deleted)
+	}
+}
+
+func testPutKeepsAttachments(t *testing.T) {
+
+	m, saved := attachedRepo(server.RoleEditor)
+
+	h := handlers.NewToDoHandler(m, handlers.WithAttachments(&BlobStoreMock{}, testAttachmentLimits))
+	resp, err := h.Handle(events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}",
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodPut,
+		Body:           `{"id":"` + testUUID + `","title":"Renamed"}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if len(saved.Attachments) != 1 {
+		t.Fatalf("Expected the attachments to be kept, got %+v", saved.Attachments)
+	}
+}
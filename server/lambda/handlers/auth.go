@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server/auth"
+)
+
+// principal returns the ID of the user authenticated by the API Gateway authorizer
+func principal(req events.APIGatewayProxyRequest) (string, error) {
+
+	if id := auth.Principal(req.RequestContext.Authorizer); id != "" {
+		return id, nil
+	}
+
+	return "", ErrUnauthorized
+}
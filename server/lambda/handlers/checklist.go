@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// reorderItemsRequest is the body sent to reorder the checklist items of a ToDo
+type reorderItemsRequest struct {
+	ItemIDs []string `json:"itemIds" schema:"required"`
+}
+
+var (
+	// itemSchema validates the checklist items sent to be added to a ToDo
+	itemSchema = jsonschema.Generate(server.ChecklistItem{})
+	// itemPatchSchema validates the fields sent to update a checklist item
+	itemPatchSchema = itemSchema.Optional()
+	// reorderItemsSchema validates the new order of the checklist items
+	reorderItemsSchema = jsonschema.Generate(reorderItemsRequest{})
+)
+
+// withProgress sets the checklist progress of ToDos sent to clients
+func withProgress(todos ...*server.ToDo) {
+	for _, t := range todos {
+		t.ItemsProgress = t.Progress()
+	}
+}
+
+// normalizeItems assigns an ID to new checklist items, renumbers their order and completes the
+// ToDo if all its items are completed
+func normalizeItems(todo *server.ToDo) {
+
+	if len(todo.Items) == 0 {
+		return
+	}
+
+	for i := range todo.Items {
+		if todo.Items[i].ID == "" {
+			todo.Items[i].ID = uuid.NewV4().String()
+		}
+	}
+
+	todo.SortItems()
+	todo.UpdateCompletion()
+}
+
+// updateItems applies fn to the ToDo of the request and saves it
+func (h *ToDoHandler) updateItems(req events.APIGatewayProxyRequest, fn func(todo *server.ToDo) error) (events.APIGatewayProxyResponse, error) {
+
+	id, ok := req.PathParameters["id"]
+	if !ok {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID is required"))
+	}
+
+	todo, err := h.repo.Get(id)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if todo == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	wasCompleted := todo.Completed
+
+	if err := fn(todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	todo.SortItems()
+	todo.UpdateCompletion()
+
+	// A ToDo is not auto-completed while it is blocked by open ToDos
+	if todo.Completed && !wasCompleted {
+		open, err := h.openBlockers(todo)
+		if err != nil {
+			return CreateErrorResponse(repoError(err))
+		}
+		todo.Completed = len(open) == 0
+	}
+
+	if err := h.repo.Save(todo); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	withProgress(todo)
+	return CreateOKResponse(todo)
+}
+
+func (h *ToDoHandler) addItem(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	var item server.ChecklistItem
+	if err := decode(itemSchema, req.Body, &item); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if item.ID != "" {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID must be empty"))
+	}
+
+	return h.updateItems(req, func(todo *server.ToDo) error {
+
+		item.ID = uuid.NewV4().String()
+
+		// Items are appended unless an order is given
+		if _, ok := jsonField(req.Body, "order"); !ok {
+			item.Order = len(todo.Items)
+		}
+
+		// Insert before the item currently at the requested position
+		for i := range todo.Items {
+			if todo.Items[i].Order >= item.Order {
+				todo.Items[i].Order++
+			}
+		}
+
+		todo.Items = append(todo.Items, item)
+		return nil
+	})
+}
+
+func (h *ToDoHandler) updateItem(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	var patch map[string]interface{}
+	if err := decode(itemPatchSchema, req.Body, &patch); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	itemID := req.PathParameters["itemId"]
+
+	if pid, ok := patch["id"]; ok && pid != itemID {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID in body does not match ID in path"))
+	}
+
+	return h.updateItems(req, func(todo *server.ToDo) error {
+
+		item := todo.Item(itemID)
+		if item == nil {
+			return ErrNotFound
+		}
+
+		if title, ok := patch["title"].(string); ok {
+			item.Title = title
+		}
+
+		if completed, ok := patch["completed"].(bool); ok {
+			item.Completed = completed
+		}
+
+		if order, ok := patch["order"].(float64); ok {
+			moveItem(todo, itemID, int(order))
+		}
+
+		return nil
+	})
+}
+
+func (h *ToDoHandler) reorderItems(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	var r reorderItemsRequest
+	if err := decode(reorderItemsSchema, req.Body, &r); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	return h.updateItems(req, func(todo *server.ToDo) error {
+
+		if len(r.ItemIDs) != len(todo.Items) {
+			return errors.Wrap(ErrBadRequest, "itemIds must list every checklist item")
+		}
+
+		seen := map[string]bool{}
+		for order, id := range r.ItemIDs {
+			item := todo.Item(id)
+			if item == nil || seen[id] {
+				return errors.Wrapf(ErrBadRequest, "invalid checklist item %s", id)
+			}
+			seen[id] = true
+			item.Order = order
+		}
+
+		return nil
+	})
+}
+
+func (h *ToDoHandler) removeItem(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	itemID := req.PathParameters["itemId"]
+
+	return h.updateItems(req, func(todo *server.ToDo) error {
+
+		for i := range todo.Items {
+			if todo.Items[i].ID == itemID {
+				todo.Items = append(todo.Items[:i], todo.Items[i+1:]...)
+				return nil
+			}
+		}
+
+		return ErrNotFound
+	})
+}
+
+// moveItem moves a checklist item to the given position, shifting the items in between
+func moveItem(todo *server.ToDo, id string, order int) {
+
+	todo.SortItems()
+
+	from := todo.Item(id).Order
+	if order >= len(todo.Items) {
+		order = len(todo.Items) - 1
+	}
+
+	for i := range todo.Items {
+		o := todo.Items[i].Order
+		switch {
+		case o == from:
+			todo.Items[i].Order = order
+		case from < order && o > from && o <= order:
+			todo.Items[i].Order--
+		case order < from && o >= order && o < from:
+			todo.Items[i].Order++
+		}
+	}
+}
+
+// jsonField returns a top level field of a JSON object
+func jsonField(body, name string) (interface{}, bool) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, false
+	}
+	v, ok := doc[name]
+	return v, ok
+}
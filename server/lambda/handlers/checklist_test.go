@@ -0,0 +1,267 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestChecklist(t *testing.T) {
+	t.Run("AddItem", testAddItem)
+	t.Run("AddItemWithID", testAddItemWithID)
+	t.Run("ToggleLastItemCompletesToDo", testToggleLastItemCompletesToDo)
+	t.Run("CompleteToDoWithOpenItems", testCompleteToDoWithOpenItems)
+	t.Run("ToggleItemNotFound", testToggleItemNotFound)
+	t.Run("ReorderItems", testReorderItems)
+	t.Run("ReorderItemsIncomplete", testReorderItemsIncomplete)
+	t.Run("RemoveItem", testRemoveItem)
+	t.Run("ListProgress", testListProgress)
+}
+
+// checklistToDo returns a ToDo with two checklist items, the first one completed
+func checklistToDo() *server.ToDo {
+	todo := savedToDo
+	todo.Items = []server.ChecklistItem{
+		{ID: "item-1", Title: "Step 1", Completed: true, Order: 0},
+		{ID: "item-2", Title: "Step 2", Order: 1},
+	}
+	return &todo
+}
+
+// checklistRepo returns a repository storing a single checklist ToDo
+func checklistRepo(saved **server.ToDo) *RepoMock {
+	todo := checklistToDo()
+	return &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			return todo, nil
+		},
+		SaveFn: func(t *server.ToDo) error {
+			*saved = t
+			return nil
+		},
+	}
+}
+
+func itemRequest(method, resource, itemID, body string) events.APIGatewayProxyRequest {
+	params := map[string]string{"id": testUUID}
+	if itemID != "" {
+		params["itemId"] = itemID
+	}
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       resource,
+		PathParameters: params,
+		Body:           body,
+		HTTPMethod:     method,
+	}
+}
+
+func testAddItem(t *testing.T) {
+
+	var saved *server.ToDo
+	m := checklistRepo(&saved)
+
+	resp, err := handlers.NewToDoHandler(m).Handle(itemRequest(http.MethodPost, "/todos/{id}/items", "", `{"title":"Step 0","order":0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	if len(saved.Items) != 3 || saved.Items[0].Title != "Step 0" || saved.Items[0].ID == "" {
+		t.Fatalf("Expected new item first with an ID, got %+v", saved.Items)
+	}
+
+	if saved.Items[1].ID != "item-1" || saved.Items[2].Order != 2 {
+		t.Fatalf("Expected existing items to be shifted, got %+v", saved.Items)
+	}
+}
+
+func testAddItemWithID(t *testing.T) {
+
+	var saved *server.ToDo
+	m := checklistRepo(&saved)
+
+	resp, err := handlers.NewToDoHandler(m).Handle(itemRequest(http.MethodPost, "/todos/{id}/items", "", `{"id":"item-3","title":"Step 3"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testToggleLastItemCompletesToDo(t *testing.T) {
+
+	var saved *server.ToDo
+	m := checklistRepo(&saved)
+
+	resp, err := handlers.NewToDoHandler(m).Handle(itemRequest(http.MethodPatch, "/todos/{id}/items/{itemId}", "item-2", `{"completed":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	if !saved.Completed {
+		t.Fatal("Expected ToDo to be completed")
+	}
+
+	var todo server.ToDo
+	if err := json.Unmarshal([]byte(resp.Body), &todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if todo.ItemsProgress == nil || todo.ItemsProgress.Completed != 2 || todo.ItemsProgress.Total != 2 {
+		t.Fatalf("Expected progress 2/2, got %+v", todo.ItemsProgress)
+	}
+}
+
+func testCompleteToDoWithOpenItems(t *testing.T) {
+
+	var saved *server.ToDo
+	m := checklistRepo(&saved)
+
+	todo := checklistToDo()
+	todo.Completed = true
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		Body:           toDoToString(todo),
+		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	if !saved.Completed {
+		t.Fatal("Expected ToDo to stay completed")
+	}
+
+	// Adding an item to a completed ToDo does not reopen it
+	completed := saved
+	m.GetFn = func(string) (*server.ToDo, error) {
+		return completed, nil
+	}
+
+	resp, err = handlers.NewToDoHandler(m).Handle(itemRequest(http.MethodPost, "/todos/{id}/items", "", `{"title":"Step 3"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	if len(saved.Items) != 3 || !saved.Completed {
+		t.Fatal("Expected ToDo to stay completed")
+	}
+}
+
+func testToggleItemNotFound(t *testing.T) {
+
+	var saved *server.ToDo
+	m := checklistRepo(&saved)
+
+	resp, err := handlers.NewToDoHandler(m).Handle(itemRequest(http.MethodPatch, "/todos/{id}/items/{itemId}", "item-9", `{"completed":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func testReorderItems(t *testing.T) {
+
+	var saved *server.ToDo
+	m := checklistRepo(&saved)
+
+	resp, err := handlers.NewToDoHandler(m).Handle(itemRequest(http.MethodPut, "/todos/{id}/items/order", "", `{"itemIds":["item-2","item-1"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	if saved.Items[0].ID != "item-2" || saved.Items[1].ID != "item-1" {
+		t.Fatalf("Expected items to be reordered, got %+v", saved.Items)
+	}
+}
+
+func testReorderItemsIncomplete(t *testing.T) {
+
+	var saved *server.ToDo
+	m := checklistRepo(&saved)
+
+	resp, err := handlers.NewToDoHandler(m).Handle(itemRequest(http.MethodPut, "/todos/{id}/items/order", "", `{"itemIds":["item-2"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func testRemoveItem(t *testing.T) {
+
+	var saved *server.ToDo
+	m := checklistRepo(&saved)
+
+	resp, err := handlers.NewToDoHandler(m).Handle(itemRequest(http.MethodDelete, "/todos/{id}/items/{itemId}", "item-2", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	if len(saved.Items) != 1 || !saved.Completed {
+		t.Fatalf("Expected a single completed item to complete the ToDo, got %+v", saved)
+	}
+}
+
+func testListProgress(t *testing.T) {
+
+	m := &RepoMock{
+		GetAllFn: func() ([]server.ToDo, error) {
+			return []server.ToDo{*checklistToDo()}, nil
+		},
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(getAllRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.Body, `"progress":{"completed":1,"total":2}`) {
+		t.Fatalf("Expected progress in body, got %s", resp.Body)
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultCommentsPage is the number of comments returned when no limit is given
+	defaultCommentsPage = 50
+	// maxCommentsPage is the maximum number of comments returned at once
+	maxCommentsPage = 100
+)
+
+// commentPage is a page of the comments of a ToDo
+type commentPage struct {
+	Comments []server.Comment `json:"comments"`
+	// Next is the cursor of the next page, empty after the last page
+	Next string `json:"next,omitempty"`
+}
+
+// commentSchema validates the comments sent to create or edit a comment
+var commentSchema = jsonschema.Generate(server.Comment{})
+
+// WithComments lets users comment ToDos, storing the comments in the given repository
+func WithComments(repo database.CommentRepo) Option {
+	return func(h *ToDoHandler) {
+		h.comments = repo
+	}
+}
+
+// commentedToDo returns the ToDo of a comments request, which the user must be able to read
+func (h *ToDoHandler) commentedToDo(req events.APIGatewayProxyRequest) (*server.ToDo, error) {
+
+	if h.comments == nil {
+		return nil, ErrNotFound
+	}
+
+	todo, err := h.repo.Get(req.PathParameters["id"])
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if todo == nil {
+		return nil, ErrNotFound
+	}
+
+	return todo, nil
+}
+
+// editableComment returns the comment of a request if the user is its author or the owner of
+// its ToDo
+func (h *ToDoHandler) editableComment(req events.APIGatewayProxyRequest) (*server.Comment, error) {
+
+	todo, err := h.commentedToDo(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := h.comments.Get(todo.ID, req.PathParameters["commentId"])
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if c == nil {
+		return nil, ErrNotFound
+	}
+
+	if c.Author != h.user && todo.RoleOf(h.user) != server.RoleOwner {
+		return nil, errors.Wrapf(ErrForbidden, "only the author or the owner of the ToDo can change comment %s", c.ID)
+	}
+
+	return c, nil
+}
+
+func (h *ToDoHandler) getComments(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	limit := defaultCommentsPage
+	if l, ok := req.QueryStringParameters["limit"]; ok {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxCommentsPage {
+			return CreateErrorResponse(errors.Wrapf(ErrBadRequest, "limit must be between 1 and %d", maxCommentsPage))
+		}
+		limit = n
+	}
+
+	todo, err := h.commentedToDo(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	comments, next, err := h.comments.List(todo.ID, req.QueryStringParameters["cursor"], limit)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(commentPage{Comments: comments, Next: next})
+}
+
+func (h *ToDoHandler) postComment(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	todo, err := h.commentedToDo(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	var c server.Comment
+	if err := decode(commentSchema, req.Body, &c); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	c.ToDoID = todo.ID
+	c.Author = h.user
+
+	if err := h.comments.Save(&c); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(c)
+}
+
+func (h *ToDoHandler) patchComment(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	var body server.Comment
+	if err := decode(commentSchema, req.Body, &body); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	c, err := h.editableComment(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if c.Deleted {
+		return CreateErrorResponse(errors.Wrapf(ErrConflict, "comment %s was deleted", c.ID))
+	}
+
+	now := time.Now()
+	c.Body = body.Body
+	c.Edited = &now
+
+	if err := h.comments.Save(c); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(c)
+}
+
+// deleteComment clears the body of a comment and marks it deleted
+func (h *ToDoHandler) deleteComment(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	c, err := h.editableComment(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if !c.Deleted {
+		now := time.Now()
+		c.Body = ""
+		c.Deleted = true
+		c.Edited = &now
+
+		if err := h.comments.Save(c); err != nil {
+			return CreateErrorResponse(repoError(err))
+		}
+	}
+
+	return CreateOKResponse("")
+}
@@ -0,0 +1,238 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestComments(t *testing.T) {
+	t.Run("PostComment", testPostComment)
+	t.Run("GetCommentsPaginated", testGetCommentsPaginated)
+	t.Run("EditOwnComment", testEditOwnComment)
+	t.Run("EditCommentOfOtherUser", testEditCommentOfOtherUser)
+	t.Run("OwnerDeletesComment", testOwnerDeletesComment)
+	t.Run("CommentUnreadableToDo", testCommentUnreadableToDo)
+	t.Run("DeleteToDoDeletesComments", testDeleteToDoDeletesComments)
+}
+
+// commentRepos stores a ToDo owned by otherUser and shared with testUser as editor, with a
+// comment of each user
+func commentRepos(owner string) (*RepoMock, *CommentRepoMock) {
+
+	todo := sharedToDo(server.RoleEditor)
+	todo.Owner = owner
+
+	m := &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			if id != todo.ID {
+				return nil, nil
+			}
+			c := *todo
+			return &c, nil
+		},
+	}
+
+	comments := &CommentRepoMock{
+		Comments: []*server.Comment{
+			{ToDoID: todo.ID, ID: "a", Author: otherUser, Body: "First"},
+			{ToDoID: todo.ID, ID: "b", Author: testUser, Body: "Second"},
+		},
+	}
+
+	return m, comments
+}
+
+func commentRequest(method, commentID, body string) events.APIGatewayProxyRequest {
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}/comments",
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     method,
+		Body:           body,
+	}
+
+	if commentID != "" {
+		req.Resource = "/todos/{id}/comments/{commentId}"
+		req.PathParameters["commentId"] = commentID
+	}
+
+	return req
+}
+
+func testPostComment(t *testing.T) {
+
+	m, comments := commentRepos(otherUser)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithComments(comments)).Handle(commentRequest(http.MethodPost, "", `{"body":"Looks good"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var c server.Comment
+	if err := json.Unmarshal([]byte(resp.Body), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Author != testUser || c.ToDoID != testUUID || c.Body != "Looks good" {
+		t.Fatalf("Expected comment of the user on the ToDo, got %+v", c)
+	}
+}
+
+func testGetCommentsPaginated(t *testing.T) {
+
+	m, comments := commentRepos(otherUser)
+	h := handlers.NewToDoHandler(m, handlers.WithComments(comments))
+
+	req := commentRequest(http.MethodGet, "", "")
+	req.QueryStringParameters = map[string]string{"limit": "1"}
+
+	var ids []string
+
+	for {
+		resp, err := h.Handle(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var page struct {
+			Comments []server.Comment `json:"comments"`
+			Next     string           `json:"next"`
+		}
+		if err := json.Unmarshal([]byte(resp.Body), &page); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range page.Comments {
+			ids = append(ids, c.ID)
+		}
+
+		if page.Next == "" {
+			break
+		}
+		req.QueryStringParameters["cursor"] = page.Next
+	}
+
+	if fmt.Sprint(ids) != "[a b]" {
+		t.Fatalf("Expected comments a and b, got %v", ids)
+	}
+}
+
+func testEditOwnComment(t *testing.T) {
+
+	m, comments := commentRepos(otherUser)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithComments(comments)).Handle(commentRequest(http.MethodPatch, "b", `{"body":"Edited"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if c := comments.Comments[1]; c.Body != "Edited" || c.Edited == nil {
+		t.Fatalf("Expected comment to be edited, got %+v", c)
+	}
+}
+
+func testEditCommentOfOtherUser(t *testing.T) {
+
+	m, comments := commentRepos(otherUser)
+
+	for _, method := range []string{http.MethodPatch, http.MethodDelete} {
+
+		resp, err := handlers.NewToDoHandler(m, handlers.WithComments(comments)).Handle(commentRequest(method, "a", `{"body":"Edited"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("Expected %d http response code for %s, got %d", http.StatusForbidden, method, resp.StatusCode)
+		}
+	}
+
+	if comments.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testOwnerDeletesComment(t *testing.T) {
+
+	m, comments := commentRepos(testUser)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithComments(comments)).Handle(commentRequest(http.MethodDelete, "a", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if c := comments.Comments[0]; !c.Deleted || c.Body != "" {
+		t.Fatalf("Expected comment to be soft deleted, got %+v", c)
+	}
+
+	if len(comments.Comments) != 2 {
+		t.Fatal("Expected deleted comment to be kept")
+	}
+}
+
+func testCommentUnreadableToDo(t *testing.T) {
+
+	m, comments := commentRepos(otherUser)
+
+	m.GetFn = func(string) (*server.ToDo, error) {
+		return sharedToDo(""), nil
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithComments(comments)).Handle(commentRequest(http.MethodGet, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func testDeleteToDoDeletesComments(t *testing.T) {
+
+	m, comments := commentRepos(testUser)
+	comments.Comments = append(comments.Comments, &server.Comment{ToDoID: "other", ID: "c", Author: testUser, Body: "Kept"})
+
+	m.DeleteFn = func(id string) error {
+		return nil
+	}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}",
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodDelete,
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithComments(comments)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if len(comments.Comments) != 1 || comments.Comments[0].ToDoID != "other" {
+		t.Fatalf("Expected only the comments of the ToDo to be deleted, got %+v", comments.Comments)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+)
+
+// ErrWildcardCredentials is returned by Validate when any origin is allowed to send credentials
+var ErrWildcardCredentials = errors.New("CORS cannot allow credentials from any origin")
+
+// CORS is the cross-origin resource sharing policy applied to the API responses
+type CORS struct {
+	// AllowedOrigins lists the origins allowed to call the API, "*" allows any origin
+	AllowedOrigins []string
+	// AllowedMethods lists the methods allowed in cross-origin requests
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed in cross-origin requests
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers the browser lets scripts read
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and authorization headers to be sent by the browser
+	AllowCredentials bool
+	// MaxAge is how long the browser can cache the result of a preflight request
+	MaxAge time.Duration
+}
+
+// Validate checks that the policy does not let every origin make credentialed requests,
+// which would expose the API to any site the user visits
+func (c *CORS) Validate() error {
+
+	if !c.AllowCredentials {
+		return nil
+	}
+
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			return ErrWildcardCredentials
+		}
+	}
+
+	return nil
+}
+
+// allowedOrigin returns the value of the Access-Control-Allow-Origin header for the given
+// origin, or an empty string if the origin is not allowed
+func (c *CORS) allowedOrigin(origin string) string {
+
+	if origin == "" {
+		return ""
+	}
+
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			// The origin is never reflected for a wildcard, browsers then refuse to send credentials
+			return "*"
+		}
+		if strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+
+	return ""
+}
+
+func (c *CORS) allowedMethod(method string) bool {
+	for _, m := range c.AllowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply adds the CORS headers to a response sent to a cross-origin request
+func (c *CORS) Apply(req events.APIGatewayProxyRequest, resp events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+
+	if resp.Headers == nil {
+		resp.Headers = make(map[string]string)
+	}
+
+	// The response depends on the Origin header, so caches must key on it
+	resp.Headers["Vary"] = "Origin"
+
+	origin := c.allowedOrigin(header(req, "Origin"))
+	if origin == "" {
+		return resp
+	}
+
+	resp.Headers["Access-Control-Allow-Origin"] = origin
+	if c.AllowCredentials && origin != "*" {
+		resp.Headers["Access-Control-Allow-Credentials"] = "true"
+	}
+	if len(c.ExposedHeaders) > 0 {
+		resp.Headers["Access-Control-Expose-Headers"] = strings.Join(c.ExposedHeaders, ", ")
+	}
+
+	return resp
+}
+
+// Preflight answers a CORS preflight (OPTIONS) request
+func (c *CORS) Preflight(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	origin := c.allowedOrigin(header(req, "Origin"))
+	if origin == "" || !c.allowedMethod(header(req, "Access-Control-Request-Method")) {
+		resp, err := CreateErrorResponse(ErrForbidden)
+		return c.Apply(req, resp), err
+	}
+
+	resp := c.Apply(req, events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent})
+	resp.Headers["Access-Control-Allow-Methods"] = strings.Join(c.AllowedMethods, ", ")
+	if len(c.AllowedHeaders) > 0 {
+		resp.Headers["Access-Control-Allow-Headers"] = strings.Join(c.AllowedHeaders, ", ")
+	}
+	if c.MaxAge > 0 {
+		resp.Headers["Access-Control-Max-Age"] = strconv.Itoa(int(c.MaxAge / time.Second))
+	}
+
+	return resp, nil
+}
+
+// header returns the value of a request header, ignoring the case of its name
+func header(req events.APIGatewayProxyRequest, name string) string {
+
+	if v, ok := req.Headers[name]; ok {
+		return v
+	}
+
+	for k, v := range req.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,230 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+const testOrigin = "https://todo.example.com"
+
+var testCORS = &handlers.CORS{
+	AllowedOrigins:   []string{testOrigin},
+	AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
+	AllowedHeaders:   []string{"Content-Type", "Authorization"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+func TestCORS(t *testing.T) {
+	t.Run("PreflightOK", testPreflightOK)
+	t.Run("PreflightOriginNotAllowed", testPreflightOriginNotAllowed)
+	t.Run("PreflightMethodNotAllowed", testPreflightMethodNotAllowed)
+	t.Run("ReflectAllowedOrigin", testReflectAllowedOrigin)
+	t.Run("OmitDisallowedOrigin", testOmitDisallowedOrigin)
+	t.Run("WildcardNotReflected", testWildcardNotReflected)
+	t.Run("ValidateWildcardCredentials", testValidateWildcardCredentials)
+}
+
+func testPreflightOK(t *testing.T) {
+
+	m := &RepoMock{}
+
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"origin":                        testOrigin,
+			"access-control-request-method": "PUT",
+		},
+		HTTPMethod: http.MethodOptions,
+		Resource:   "/todos",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithCORS(testCORS)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusNoContent, resp.StatusCode)
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      testOrigin,
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST, PUT, DELETE",
+		"Access-Control-Allow-Headers":     "Content-Type, Authorization",
+		"Access-Control-Max-Age":           "600",
+		"Vary":                             "Origin",
+	}
+
+	for k, v := range expected {
+		if resp.Headers[k] != v {
+			t.Fatalf("Expected header %s to be '%s', got '%s'", k, v, resp.Headers[k])
+		}
+	}
+}
+
+func testPreflightOriginNotAllowed(t *testing.T) {
+
+	m := &RepoMock{}
+
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Origin":                        "https://evil.example.com",
+			"Access-Control-Request-Method": "PUT",
+		},
+		HTTPMethod: http.MethodOptions,
+		Resource:   "/todos",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithCORS(testCORS)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if _, ok := resp.Headers["Access-Control-Allow-Origin"]; ok {
+		t.Fatal("Expected no Access-Control-Allow-Origin header")
+	}
+}
+
+func testPreflightMethodNotAllowed(t *testing.T) {
+
+	m := &RepoMock{}
+
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Origin":                        testOrigin,
+			"Access-Control-Request-Method": "PATCH",
+		},
+		HTTPMethod: http.MethodOptions,
+		Resource:   "/todos",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithCORS(testCORS)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func testReflectAllowedOrigin(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			return &savedToDo, nil
+		},
+	}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Headers:        map[string]string{"Origin": testOrigin},
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithCORS(testCORS)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Headers["Access-Control-Allow-Origin"] != testOrigin {
+		t.Fatalf("Expected Access-Control-Allow-Origin to be '%s', got '%s'", testOrigin, resp.Headers["Access-Control-Allow-Origin"])
+	}
+
+	if resp.Headers["Vary"] != "Origin" {
+		t.Fatal("Expected Vary: Origin header")
+	}
+}
+
+func testOmitDisallowedOrigin(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			return &savedToDo, nil
+		},
+	}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Headers:        map[string]string{"Origin": "https://evil.example.com"},
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithCORS(testCORS)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := resp.Headers["Access-Control-Allow-Origin"]; ok {
+		t.Fatal("Expected no Access-Control-Allow-Origin header")
+	}
+
+	if _, ok := resp.Headers["Access-Control-Allow-Credentials"]; ok {
+		t.Fatal("Expected no Access-Control-Allow-Credentials header")
+	}
+}
+
+func testWildcardNotReflected(t *testing.T) {
+
+	m := &RepoMock{
+		GetAllFn: func() ([]server.ToDo, error) {
+			return []server.ToDo{}, nil
+		},
+	}
+
+	cors := &handlers.CORS{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET"},
+		AllowCredentials: true,
+	}
+
+	req := events.APIGatewayProxyRequest{
+		Headers:        map[string]string{"Origin": "https://evil.example.com"},
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos",
+		RequestContext: testRequestContext,
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithCORS(cors)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Headers["Access-Control-Allow-Origin"] != "*" {
+		t.Fatalf("Expected header Access-Control-Allow-Origin to be '*', got '%s'", resp.Headers["Access-Control-Allow-Origin"])
+	}
+
+	if _, ok := resp.Headers["Access-Control-Allow-Credentials"]; ok {
+		t.Fatal("Expected no Access-Control-Allow-Credentials header")
+	}
+}
+
+func testValidateWildcardCredentials(t *testing.T) {
+
+	if err := testCORS.Validate(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cors := &handlers.CORS{
+		AllowedOrigins:   []string{testOrigin, "*"},
+		AllowCredentials: true,
+	}
+
+	if err := cors.Validate(); err != handlers.ErrWildcardCredentials {
+		t.Fatalf("Expected %v, got %v", handlers.ErrWildcardCredentials, err)
+	}
+}
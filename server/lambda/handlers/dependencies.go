@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// checkDependencies returns an error if the blockers of the ToDo form a cycle, or if the ToDo
+// is being completed while some of its blockers are still open
+func (h *ToDoHandler) checkDependencies(todo *server.ToDo, wasCompleted bool) error {
+
+	for _, b := range todo.BlockedBy {
+		if b == todo.ID {
+			return errors.Wrapf(ErrConflict, "dependency cycle: %s -> %s", b, b)
+		}
+	}
+
+	cycle, err := server.DependencyCycle(todo, h.repo.Get)
+	if err != nil {
+		return repoError(err)
+	}
+
+	if cycle != nil {
+		return errors.Wrapf(ErrConflict, "dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	if !todo.Completed || wasCompleted {
+		return nil
+	}
+
+	open, err := h.openBlockers(todo)
+	if err != nil {
+		return repoError(err)
+	}
+
+	if len(open) > 0 {
+		ids := make([]string, len(open))
+		for i, b := range open {
+			ids[i] = b.ID
+		}
+		return errors.Wrapf(ErrConflict, "ToDo %s is blocked by open ToDos %s", todo.ID, strings.Join(ids, ", "))
+	}
+
+	return nil
+}
+
+// blockers returns the ToDos blocking the given ToDo, ignoring the ones that no longer exist
+func (h *ToDoHandler) blockers(todo *server.ToDo) ([]server.ToDo, error) {
+
+	blockers := []server.ToDo{}
+
+	for _, id := range todo.BlockedBy {
+
+		b, err := h.repo.Get(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if b != nil {
+			blockers = append(blockers, *b)
+		}
+	}
+
+	return blockers, nil
+}
+
+// openBlockers returns the ToDos blocking the given ToDo that are not completed
+func (h *ToDoHandler) openBlockers(todo *server.ToDo) ([]server.ToDo, error) {
+
+	blockers, err := h.blockers(todo)
+	if err != nil {
+		return nil, err
+	}
+
+	open := []server.ToDo{}
+	for _, b := range blockers {
+		if !b.Completed {
+			open = append(open, b)
+		}
+	}
+
+	return open, nil
+}
+
+func (h *ToDoHandler) getBlockers(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	todo, err := h.repo.Get(req.PathParameters["id"])
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if todo == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	blockers, err := h.blockers(todo)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(blockers)
+}
+
+// getNext returns the open ToDos sorted so that each one comes after its blockers
+func (h *ToDoHandler) getNext(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	todos, err := h.accessible()
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	sorted := server.SortByDependencies(todos)
+	for i := range sorted {
+		withProgress(&sorted[i])
+	}
+
+	return CreateOKResponse(sorted)
+}
@@ -0,0 +1,188 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestDependencies(t *testing.T) {
+	t.Run("CompleteBlockedToDo", testCompleteBlockedToDo)
+	t.Run("CompleteUnblockedToDo", testCompleteUnblockedToDo)
+	t.Run("DependencyCycle", testDependencyCycle)
+	t.Run("GetBlockers", testGetBlockers)
+	t.Run("NextToDos", testNextToDos)
+}
+
+// dependencyRepo stores write <- review <- publish, where each ToDo is blocked by the previous one
+func dependencyRepo() (*RepoMock, map[string]*server.ToDo) {
+
+	now := time.Now()
+
+	todos := map[string]*server.ToDo{
+		"write":   {ID: "write", Title: "Write", ModTime: now, Owner: testUser},
+		"review":  {ID: "review", Title: "Review", BlockedBy: []string{"write"}, ModTime: now.Add(-time.Hour), Owner: testUser},
+		"publish": {ID: "publish", Title: "Publish", BlockedBy: []string{"review"}, ModTime: now.Add(-2 * time.Hour), Owner: testUser},
+		"other":   {ID: "other", Title: "Other", ModTime: now.Add(time.Hour), Owner: testUser},
+	}
+
+	m := &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			if t, ok := todos[id]; ok {
+				c := *t
+				return &c, nil
+			}
+			return nil, nil
+		},
+		GetAllFn: func() ([]server.ToDo, error) {
+			all := []server.ToDo{}
+			for _, t := range todos {
+				all = append(all, *t)
+			}
+			return all, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			c := *todo
+			todos[todo.ID] = &c
+			return nil
+		},
+	}
+
+	return m, todos
+}
+
+func patchRequest(id, body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": id},
+		Body:           body,
+		HTTPMethod:     http.MethodPatch,
+		Resource:       "/todos/{id}",
+	}
+}
+
+func testCompleteBlockedToDo(t *testing.T) {
+
+	m, _ := dependencyRepo()
+
+	resp, err := handlers.NewToDoHandler(m).Handle(patchRequest("review", `{"completed":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	if !strings.Contains(resp.Body, "write") {
+		t.Fatalf("Expected body to name the open blocker, got %s", resp.Body)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testCompleteUnblockedToDo(t *testing.T) {
+
+	m, todos := dependencyRepo()
+	todos["write"].Completed = true
+
+	resp, err := handlers.NewToDoHandler(m).Handle(patchRequest("review", `{"completed":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if !todos["review"].Completed {
+		t.Fatal("Expected review to be completed")
+	}
+}
+
+func testDependencyCycle(t *testing.T) {
+
+	m, _ := dependencyRepo()
+
+	resp, err := handlers.NewToDoHandler(m).Handle(patchRequest("write", `{"blockedBy":["publish"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	var e validationErrorResponse
+	if err := json.Unmarshal([]byte(resp.Body), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(e.Error, "write -> publish -> review -> write") {
+		t.Fatalf("Expected error to contain the cycle path, got %s", e.Error)
+	}
+}
+
+func testGetBlockers(t *testing.T) {
+
+	m, _ := dependencyRepo()
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}/blockers",
+		PathParameters: map[string]string{"id": "publish"},
+		HTTPMethod:     http.MethodGet,
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var blockers []server.ToDo
+	if err := json.Unmarshal([]byte(resp.Body), &blockers); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(blockers) != 1 || blockers[0].ID != "review" {
+		t.Fatalf("Expected review to block publish, got %s", resp.Body)
+	}
+}
+
+func testNextToDos(t *testing.T) {
+
+	m, _ := dependencyRepo()
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/next",
+		HTTPMethod:     http.MethodGet,
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var todos []server.ToDo
+	if err := json.Unmarshal([]byte(resp.Body), &todos); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, t := range todos {
+		ids = append(ids, t.ID)
+	}
+
+	if strings.Join(ids, ",") != "write,other,review,publish" {
+		t.Fatalf("Expected write,other,review,publish, got %v", ids)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+	"github.com/massimoselvi/serverless-todo-api-go/server/publisher"
+)
+
+func TestEvents(t *testing.T) {
+	t.Run("CompleteToDo", testEventCompleteToDo)
+	t.Run("ForbiddenUpdate", testEventForbiddenUpdate)
+}
+
+func completeRequest() events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}",
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodPatch,
+		Body:           `{"completed":true}`,
+	}
+}
+
+func eventRepo(role server.Role) *RepoMock {
+	todo := sharedToDo(role)
+	return &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			c := *todo
+			return &c, nil
+		},
+		SaveFn: func(t *server.ToDo) error {
+			return nil
+		},
+	}
+}
+
+func testEventCompleteToDo(t *testing.T) {
+
+	pub := publisher.NewMemory()
+
+	resp, err := handlers.NewToDoHandler(eventRepo(server.RoleEditor), handlers.WithEvents(pub)).Handle(completeRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	published := pub.Events()
+	if len(published) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(published))
+	}
+
+	e := published[0]
+	if e.Type != server.EventToDoCompleted || e.Actor != testUser || e.Owner != otherUser {
+		t.Fatalf("Expected a todo.completed event by %s on a ToDo of %s, got %+v", testUser, otherUser, e)
+	}
+
+	if e.Before.Completed || !e.After.Completed {
+		t.Fatalf("Expected the ToDo to be open before and completed after, got %+v", e)
+	}
+}
+
+func testEventForbiddenUpdate(t *testing.T) {
+
+	pub := publisher.NewMemory()
+
+	resp, err := handlers.NewToDoHandler(eventRepo(server.RoleViewer), handlers.WithEvents(pub)).Handle(completeRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if len(pub.Events()) != 0 {
+		t.Fatalf("Expected no event for a forbidden update, got %+v", pub.Events())
+	}
+}
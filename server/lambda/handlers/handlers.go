@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
 	"github.com/pkg/errors"
 )
 
@@ -18,7 +20,7 @@ func CreateResponse(data interface{}, code int) (events.APIGatewayProxyResponse,
 	// Try to marashal data, if it fail return errorResponse
 	js, err := json.Marshal(data)
 	if err != nil {
-		r.StatusCode = http.StatusserverServerError
+		r.StatusCode = http.StatusInternalServerError
 		js, err = json.Marshal(errorResponse{Err: err.Error()})
 		if err != nil {
 			return r, err
@@ -26,8 +28,6 @@ func CreateResponse(data interface{}, code int) (events.APIGatewayProxyResponse,
 	}
 
 	r.Headers = make(map[string]string)
-	r.Headers["Access-Control-Allow-Origin"] = "*"
-	r.Headers["Access-Control-Allow-Credentials"] = "true"
 
 	r.Body = string(js)
 
@@ -53,12 +53,21 @@ func CreateErrorResponse(err error) (events.APIGatewayProxyResponse, error) {
 		code = http.StatusMethodNotAllowed
 	case ErrUnauthorized:
 		code = http.StatusUnauthorized
+	case ErrForbidden:
+		code = http.StatusForbidden
+	case ErrConflict:
+		code = http.StatusConflict
+	case ErrUnprocessable:
+		code = http.StatusUnprocessableEntity
+	case ErrTooManyRequests:
+		code = http.StatusTooManyRequests
 	default:
-		code = http.StatusserverServerError
+		code = http.StatusInternalServerError
 	}
 
 	e := &errorResponse{
-		Err: err.Error(),
+		Err:     err.Error(),
+		Details: violations(err),
 	}
 
 	return CreateResponse(e, code)
@@ -68,17 +77,36 @@ func CreateErrorResponse(err error) (events.APIGatewayProxyResponse, error) {
 var (
 	// ErrNotFound is returned when an entity is not found
 	ErrNotFound = errors.New("not found")
-	// Errserver is returned when an server error has occurred
-	Errserver = errors.New("server error")
+	// ErrInternal is returned when an internal error has occurred
+	ErrInternal = errors.New("internal error")
 	// ErrBadRequest is returned when the request is invalid
 	ErrBadRequest = errors.New("bad request")
 	// ErrMethodNotAllowed is returned when the request method (GET, POST, etc.) is not allowed
 	ErrMethodNotAllowed = errors.New("method not allowed")
 	// ErrUnauthorized is returned when the request is not authorized
 	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned when the user is not allowed to perform the request
+	ErrForbidden = errors.New("forbidden")
+	// ErrConflict is returned when the request conflicts with the current state of the resource
+	ErrConflict = errors.New("conflict")
+	// ErrUnprocessable is returned when the request is well formed but cannot be processed
+	ErrUnprocessable = errors.New("unprocessable entity")
+	// ErrTooManyRequests is returned when the user has exceeded the rate limit
+	ErrTooManyRequests = errors.New("too many requests")
 )
 
+// repoError translates an error returned by a repository into the error sent to the client
+func repoError(err error) error {
+	switch errors.Cause(err) {
+	case policy.ErrForbidden:
+		return ErrForbidden
+	default:
+		return ErrInternal
+	}
+}
+
 // errorResponse is the response sent to the client in the event of a error
 type errorResponse struct {
-	Err string `json:"error,omitempty"`
+	Err     string                 `json:"error,omitempty"`
+	Details []jsonschema.Violation `json:"details,omitempty"`
 }
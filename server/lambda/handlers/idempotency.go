@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// idempotencyKeyHeader is the request header carrying the client generated idempotency key
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// defaultIdempotencyTTL is how long the response of an idempotent request is kept
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// idempotencyLease is how long a request holds its idempotency key while it is processed. A
+// key whose request crashed, or could not be released or completed, is freed after the lease.
+const idempotencyLease = time.Minute
+
+// WithIdempotency honors the Idempotency-Key header, storing responses in repo for ttl
+func WithIdempotency(repo database.IdempotencyRepo, ttl time.Duration) Option {
+	return func(h *ToDoHandler) {
+		if ttl <= 0 {
+			ttl = defaultIdempotencyTTL
+		}
+		h.idempotency = repo
+		h.idempotencyTTL = ttl
+	}
+}
+
+// idempotent runs fn once per idempotency key of the user. Retries of a completed request
+// return the original response, retries with a different body are rejected.
+func (h *ToDoHandler) idempotent(req events.APIGatewayProxyRequest, fn func() (events.APIGatewayProxyResponse, error)) (events.APIGatewayProxyResponse, error) {
+
+	key := header(req, idempotencyKeyHeader)
+	if h.idempotency == nil || key == "" {
+		return fn()
+	}
+
+	hash := sha256.Sum256([]byte(req.Body))
+
+	rec := &database.IdempotencyRecord{
+		// Keys are generated by clients, so they are only unique per user and route
+		Key:         strings.Join([]string{h.user, req.HTTPMethod, req.Resource, key}, "#"),
+		RequestHash: hex.EncodeToString(hash[:]),
+		ExpiresAt:   time.Now().Add(idempotencyLease),
+	}
+
+	err := h.idempotency.Reserve(rec)
+	if errors.Cause(err) == database.ErrExists {
+		return h.replay(rec)
+	} else if err != nil {
+		return CreateErrorResponse(ErrInternal)
+	}
+
+	resp, err := fn()
+	if err != nil || resp.StatusCode >= 500 {
+		// Release the key so that the client can retry
+		if derr := h.idempotency.Delete(rec.Key); derr != nil {
+			return CreateErrorResponse(ErrInternal)
+		}
+		return resp, err
+	}
+
+	rec.StatusCode = resp.StatusCode
+	rec.Body = resp.Body
+	rec.ExpiresAt = time.Now().Add(h.idempotencyTTL)
+
+	// A response that cannot be stored would not be replayed to retries, so the failure is
+	// reported to the client
+	if err := h.idempotency.Save(rec); err != nil {
+		return CreateErrorResponse(ErrInternal)
+	}
+
+	return resp, nil
+}
+
+// replay returns the response stored for an idempotency key
+func (h *ToDoHandler) replay(rec *database.IdempotencyRecord) (events.APIGatewayProxyResponse, error) {
+
+	stored, err := h.idempotency.Get(rec.Key)
+	if err != nil {
+		return CreateErrorResponse(ErrInternal)
+	}
+
+	if stored != nil && stored.RequestHash != rec.RequestHash {
+		return CreateErrorResponse(errors.Wrap(ErrUnprocessable, "Idempotency-Key was used with a different request body"))
+	}
+
+	// The key may also have expired since it was reserved, the client can then retry
+	if stored == nil || !stored.Completed() {
+		return CreateErrorResponse(errors.Wrap(ErrConflict, "a request with the same Idempotency-Key is in progress"))
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: stored.StatusCode,
+		Headers:    map[string]string{"Idempotent-Replayed": "true"},
+		Body:       stored.Body,
+	}, nil
+}
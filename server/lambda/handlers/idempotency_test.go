@@ -0,0 +1,348 @@
+package handlers_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+	"github.com/pkg/errors"
+)
+
+const (
+	testIdempotencyKey = "5f6c1d6e-1b7e-4d0a-9a3c-0c2f5a0b9e11"
+	// testStoredKey is the key stored for testIdempotencyKey on POST /todos
+	testStoredKey = testUser + "#POST#/todos#" + testIdempotencyKey
+)
+
+func TestIdempotency(t *testing.T) {
+	t.Run("CreateToDoStoresResponse", testCreateToDoStoresResponse)
+	t.Run("CreateToDoReplay", testCreateToDoReplay)
+	t.Run("CreateToDoKeyReusedWithDifferentBody", testCreateToDoKeyReusedWithDifferentBody)
+	t.Run("CreateToDoKeyInProgress", testCreateToDoKeyInProgress)
+	t.Run("CreateToDoKeyInProgressWithDifferentBody", testCreateToDoKeyInProgressWithDifferentBody)
+	t.Run("CreateToDoKeyExpired", testCreateToDoKeyExpired)
+	t.Run("CreateToDoReleasesKeyOnError", testCreateToDoReleasesKeyOnError)
+	t.Run("CreateToDoReleaseError", testCreateToDoReleaseError)
+	t.Run("CreateToDoStoreResponseError", testCreateToDoStoreResponseError)
+	t.Run("KeyScopedByRoute", testKeyScopedByRoute)
+}
+
+// requestHash returns the hash stored for a request body
+func requestHash(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
+
+func idempotentPost(body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Headers:        map[string]string{"Idempotency-Key": testIdempotencyKey},
+		Body:           body,
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
+	}
+}
+
+func testCreateToDoStoresResponse(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(todo *server.ToDo) error {
+			todo.ID = testUUID
+			return nil
+		},
+	}
+
+	keys := &IdempotencyRepoMock{Records: map[string]*database.IdempotencyRecord{}}
+
+	h := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0))
+
+	resp, err := h.Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	rec := keys.Records[testStoredKey]
+	if rec == nil || !rec.Completed() {
+		t.Fatal("Expected response to be stored")
+	}
+
+	if rec.Body != resp.Body {
+		t.Fatalf("Expected stored body '%s', got '%s'", resp.Body, rec.Body)
+	}
+
+	if rec.ExpiresAt.Before(time.Now().Add(time.Hour)) {
+		t.Fatal("Expected record to be kept for the TTL")
+	}
+}
+
+func testCreateToDoReplay(t *testing.T) {
+
+	saves := 0
+
+	m := &RepoMock{
+		SaveFn: func(todo *server.ToDo) error {
+			saves++
+			todo.ID = testUUID
+			return nil
+		},
+	}
+
+	keys := &IdempotencyRepoMock{Records: map[string]*database.IdempotencyRecord{}}
+
+	h := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0))
+
+	first, err := h.Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := h.Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saves != 1 {
+		t.Fatalf("Expected Save to be invoked once, got %d", saves)
+	}
+
+	if second.StatusCode != first.StatusCode || second.Body != first.Body {
+		t.Fatal("Expected replay to return the original response")
+	}
+
+	if second.Headers["Idempotent-Replayed"] != "true" {
+		t.Fatal("Expected Idempotent-Replayed header")
+	}
+}
+
+func testCreateToDoKeyReusedWithDifferentBody(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(todo *server.ToDo) error {
+			todo.ID = testUUID
+			return nil
+		},
+	}
+
+	keys := &IdempotencyRepoMock{Records: map[string]*database.IdempotencyRecord{}}
+
+	h := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0))
+
+	if _, err := h.Handle(idempotentPost(toDoToString(&newToDo))); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := h.Handle(idempotentPost(toDoToString(&server.ToDo{Title: "Another ToDo"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.Body, handlers.ErrUnprocessable.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrUnprocessable.Error())
+	}
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+}
+
+func testCreateToDoKeyInProgress(t *testing.T) {
+
+	m := &RepoMock{}
+
+	keys := &IdempotencyRepoMock{Records: map[string]*database.IdempotencyRecord{
+		testStoredKey: {
+			Key:         testStoredKey,
+			RequestHash: requestHash(toDoToString(&newToDo)),
+			ExpiresAt:   time.Now().Add(time.Minute),
+		},
+	}}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0)).Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+}
+
+func testCreateToDoReleasesKeyOnError(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(*server.ToDo) error {
+			return errors.New("DB Error")
+		},
+	}
+
+	keys := &IdempotencyRepoMock{Records: map[string]*database.IdempotencyRecord{}}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0)).Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	if !keys.DeleteInvoked || len(keys.Records) != 0 {
+		t.Fatal("Expected idempotency key to be released")
+	}
+}
+
+func testCreateToDoKeyInProgressWithDifferentBody(t *testing.T) {
+
+	m := &RepoMock{}
+
+	keys := &IdempotencyRepoMock{Records: map[string]*database.IdempotencyRecord{
+		testStoredKey: {
+			Key:         testStoredKey,
+			RequestHash: requestHash(toDoToString(&server.ToDo{Title: "Another ToDo"})),
+			ExpiresAt:   time.Now().Add(time.Minute),
+		},
+	}}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0)).Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+}
+
+func testCreateToDoKeyExpired(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(todo *server.ToDo) error {
+			todo.ID = testUUID
+			return nil
+		},
+	}
+
+	keys := &IdempotencyRepoMock{Records: map[string]*database.IdempotencyRecord{
+		testStoredKey: {
+			Key:         testStoredKey,
+			RequestHash: requestHash(toDoToString(&newToDo)),
+			ExpiresAt:   time.Now().Add(-time.Minute),
+		},
+	}}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0)).Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if !m.SaveInvoked {
+		t.Fatal("Expected expired key to be reserved again")
+	}
+}
+
+func testCreateToDoReleaseError(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(*server.ToDo) error {
+			return errors.New("DB Error")
+		},
+	}
+
+	keys := &IdempotencyRepoMock{
+		Records:   map[string]*database.IdempotencyRecord{},
+		DeleteErr: errors.New("DB Error"),
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0)).Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	// The reservation is released by its lease
+	if rec := keys.Records[testStoredKey]; rec == nil || rec.ExpiresAt.After(time.Now().Add(2*time.Minute)) {
+		t.Fatal("Expected reservation to expire after its lease")
+	}
+}
+
+func testCreateToDoStoreResponseError(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(todo *server.ToDo) error {
+			todo.ID = testUUID
+			return nil
+		},
+	}
+
+	keys := &IdempotencyRepoMock{
+		Records: map[string]*database.IdempotencyRecord{},
+		SaveErr: errors.New("DB Error"),
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0)).Handle(idempotentPost(toDoToString(&newToDo)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+}
+
+func testKeyScopedByRoute(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(todo *server.ToDo) error {
+			todo.ID = testUUID
+			return nil
+		},
+	}
+
+	lists := &ListRepoMock{Lists: map[string]*server.List{}}
+
+	keys := &IdempotencyRepoMock{Records: map[string]*database.IdempotencyRecord{}}
+
+	h := handlers.NewToDoHandler(m, handlers.WithIdempotency(keys, 0), handlers.WithLists(lists))
+
+	if _, err := h.Handle(idempotentPost(toDoToString(&newToDo))); err != nil {
+		t.Fatal(err)
+	}
+
+	req := idempotentPost(`{"name":"Work"}`)
+	req.Resource = "/lists"
+
+	resp, err := h.Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	if resp.Headers["Idempotent-Replayed"] == "true" {
+		t.Fatal("Expected the key of another route not to be replayed")
+	}
+}
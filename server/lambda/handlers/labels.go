@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/pkg/errors"
+)
+
+const (
+	// labelBatchSize is the number of ToDos updated by each pass of a label migration
+	labelBatchSize = 100
+	// maxLabelPasses is the number of passes of a label migration run by a single request, the
+	// rest of the migration is run by resuming it
+	maxLabelPasses = 10
+)
+
+// mergeLabelRequest is the body sent to merge a label into another
+type mergeLabelRequest struct {
+	Into string `json:"into" schema:"required,format=uuid"`
+}
+
+var (
+	// labelSchema validates the labels sent to create or replace a label
+	labelSchema = jsonschema.Generate(server.Label{})
+	// mergeLabelSchema validates the label another label is merged into
+	mergeLabelSchema = jsonschema.Generate(mergeLabelRequest{})
+)
+
+// WithLabels lets the tags of ToDos be managed as the labels stored in the given repository
+func WithLabels(repo database.LabelRepo) Option {
+	return func(h *ToDoHandler) {
+		h.labels = repo
+	}
+}
+
+// getLabel returns a label of the user, or ErrNotFound if labels are not enabled or the label
+// does not exist
+func (h *ToDoHandler) getLabel(id string) (*server.Label, error) {
+
+	if h.labels == nil {
+		return nil, ErrNotFound
+	}
+
+	l, err := h.labels.Get(id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if l == nil {
+		return nil, ErrNotFound
+	}
+
+	return l, nil
+}
+
+// checkLabelName returns ErrConflict if another label of the user has the given name
+func (h *ToDoHandler) checkLabelName(label *server.Label) error {
+
+	all, err := h.labels.GetAll()
+	if err != nil {
+		return repoError(err)
+	}
+
+	for _, l := range all {
+		if l.Name == label.Name && l.ID != label.ID {
+			return errors.Wrapf(ErrConflict, "label %s already exists, merge the labels instead", label.Name)
+		}
+	}
+
+	return nil
+}
+
+// withUsage counts the ToDos of the user tagged with each label, which are the ToDos a change of
+// the label applies to
+func (h *ToDoHandler) withUsage(labels ...*server.Label) error {
+
+	todos, err := h.repo.GetByOwner(h.user)
+	if err != nil {
+		return repoError(err)
+	}
+
+	for _, l := range labels {
+		l.UsageCount = 0
+		for _, t := range todos {
+			if t.HasTag(l.Name) {
+				l.UsageCount++
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *ToDoHandler) getLabels(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.labels == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	labels, err := h.labels.GetAll()
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+	ptrs := make([]*server.Label, len(labels))
+	for i := range labels {
+		ptrs[i] = &labels[i]
+	}
+
+	if err := h.withUsage(ptrs...); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	return CreateOKResponse(labels)
+}
+
+func (h *ToDoHandler) getOneLabel(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	l, err := h.getLabel(req.PathParameters["id"])
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.withUsage(l); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	return CreateOKResponse(l)
+}
+
+func (h *ToDoHandler) postLabel(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.labels == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	var label server.Label
+	if err := decode(labelSchema, req.Body, &label); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if label.ID != "" {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID must be empty"))
+	}
+
+	if err := validateColor(label.Color); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.checkLabelName(&label); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.labels.Save(&label); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if err := h.withUsage(&label); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	return CreateOKResponse(label)
+}
+
+// putLabel updates a label, renaming it in the tags of every ToDo if its name changed
+func (h *ToDoHandler) putLabel(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id := req.PathParameters["id"]
+
+	var label server.Label
+	if err := decodeUpdate(labelSchema, req.Body, h.storedLabel(id), &label); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if id != label.ID {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID in body does not match ID in path"))
+	}
+
+	if err := validateColor(label.Color); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	existing, err := h.pendingFree(id)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if label.Name != existing.Name {
+		if err := h.checkLabelName(&label); err != nil {
+			return CreateErrorResponse(err)
+		}
+		label.Migration = &server.LabelMigration{From: existing.Name, To: label.Name}
+	}
+
+	return h.migrateLabel(&label)
+}
+
+// storedLabel returns the label as read from the API, to compare the read-only fields sent back
+func (h *ToDoHandler) storedLabel(id string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+
+		label, err := h.getLabel(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := h.withUsage(label); err != nil {
+			return nil, err
+		}
+
+		return label, nil
+	}
+}
+
+// deleteLabel removes a label from the tags of every ToDo, then deletes it
+func (h *ToDoHandler) deleteLabel(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	label, err := h.pendingFree(req.PathParameters["id"])
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	label.Migration = &server.LabelMigration{From: label.Name, DeleteLabel: true}
+
+	return h.migrateLabel(label)
+}
+
+// mergeLabel replaces a label with another in the tags of every ToDo, then deletes it
+func (h *ToDoHandler) mergeLabel(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	var body mergeLabelRequest
+	if err := decode(mergeLabelSchema, req.Body, &body); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	id := req.PathParameters["id"]
+	if body.Into == id {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "a label cannot be merged into itself"))
+	}
+
+	label, err := h.pendingFree(id)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	into, err := h.pendingFree(body.Into)
+	if errors.Cause(err) == ErrNotFound {
+		return CreateErrorResponse(errors.Wrapf(ErrBadRequest, "label %s not found", body.Into))
+	} else if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	label.Migration = &server.LabelMigration{From: label.Name, To: into.Name, DeleteLabel: true}
+
+	return h.migrateLabel(label)
+}
+
+// resumeLabel continues an interrupted rename, merge or deletion of a label
+func (h *ToDoHandler) resumeLabel(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	label, err := h.getLabel(req.PathParameters["id"])
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if label.Migration == nil {
+		return CreateOKResponse(label)
+	}
+
+	return h.migrateLabel(label)
+}
+
+// pendingFree returns a label, or ErrConflict if the label is being migrated
+func (h *ToDoHandler) pendingFree(id string) (*server.Label, error) {
+
+	label, err := h.getLabel(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if label.Migration != nil {
+		return nil, errors.Wrapf(ErrConflict, "label %s is being migrated, resume the migration first", id)
+	}
+
+	return label, nil
+}
+
+// migrateLabel saves a label and runs its migration for up to maxLabelPasses batches. It
+// responds with 202 if the migration has to be resumed.
+func (h *ToDoHandler) migrateLabel(label *server.Label) (events.APIGatewayProxyResponse, error) {
+
+	if err := h.labels.Save(label); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	owned, err := h.repo.GetByOwner(h.user)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	tagged := []server.ToDo{}
+	for _, t := range owned {
+		if t.HasTag(label.Migration.From) {
+			tagged = append(tagged, t)
+		}
+	}
+
+	for pass := 0; label.Migration != nil; pass++ {
+
+		if pass == maxLabelPasses {
+			return CreateResponse(label, http.StatusAccepted)
+		}
+
+		batch := tagged
+		if len(batch) > labelBatchSize {
+			batch = batch[:labelBatchSize]
+		}
+		tagged = tagged[len(batch):]
+
+		if err := h.migrateBatch(label, batch, len(tagged) == 0); err != nil {
+			return CreateErrorResponse(err)
+		}
+	}
+
+	if err := h.withUsage(label); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	return CreateOKResponse(label)
+}
+
+// migrateBatch applies the migration of a label to a batch of ToDos owned by the user, then
+// saves its progress, or ends the migration after the last batch. Migrated ToDos no longer
+// have the old tag, so an interrupted migration resumes with the ToDos left.
+func (h *ToDoHandler) migrateBatch(label *server.Label, todos []server.ToDo, last bool) error {
+
+	m := label.Migration
+
+	for _, t := range todos {
+		if !t.ReplaceTag(m.From, m.To) {
+			continue
+		}
+		if err := h.repo.Save(&t); err != nil {
+			return repoError(err)
+		}
+		m.Updated++
+	}
+
+	if !last {
+		if err := h.labels.Save(label); err != nil {
+			return repoError(err)
+		}
+		return nil
+	}
+
+	label.Migration = nil
+
+	var err error
+	if m.DeleteLabel {
+		err = h.labels.Delete(label.ID)
+	} else {
+		err = h.labels.Save(label)
+	}
+
+	if err != nil {
+		return repoError(err)
+	}
+
+	return nil
+}
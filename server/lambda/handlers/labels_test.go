@@ -0,0 +1,269 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestLabels(t *testing.T) {
+	t.Run("CreateLabelDuplicate", testCreateLabelDuplicate)
+	t.Run("GetLabelsUsage", testGetLabelsUsage)
+	t.Run("RenameLabel", testRenameLabel)
+	t.Run("RenameLabelResume", testRenameLabelResume)
+	t.Run("MergeLabel", testMergeLabel)
+	t.Run("DeleteLabel", testDeleteLabel)
+	t.Run("UpdateLabelBeingMigrated", testUpdateLabelBeingMigrated)
+}
+
+// labelRepos stores the urgent and later labels of the test user, and n ToDos tagged urgent,
+// every other one also tagged later. The last ToDo is owned by another user.
+func labelRepos(n int) (*RepoMock, *LabelRepoMock, map[string]*server.ToDo) {
+
+	labels := &LabelRepoMock{
+		Labels: map[string]*server.Label{
+			"urgent": {ID: "urgent", Name: "urgent", Owner: testUser},
+			"later":  {ID: "later", Name: "later", Owner: testUser},
+		},
+	}
+
+	todos := map[string]*server.ToDo{}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("todo-%04d", i)
+		t := &server.ToDo{ID: id, Title: id, Owner: testUser, Tags: []string{"urgent"}}
+		if i%2 == 1 {
+			t.Tags = append(t.Tags, "later")
+		}
+		if i == n-1 {
+			t.Owner = otherUser
+			t.Members = map[string]server.Role{testUser: server.RoleEditor}
+		}
+		todos[id] = t
+	}
+
+	m := &RepoMock{
+		GetByOwnerFn: func(owner string) ([]server.ToDo, error) {
+			var ids []string
+			for id, t := range todos {
+				if t.Owner == owner {
+					ids = append(ids, id)
+				}
+			}
+			sort.Strings(ids)
+
+			owned := []server.ToDo{}
+			for _, id := range ids {
+				owned = append(owned, *todos[id])
+			}
+			return owned, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			c := *todo
+			todos[todo.ID] = &c
+			return nil
+		},
+	}
+
+	m.GetFn = func(id string) (*server.ToDo, error) {
+		if t, ok := todos[id]; ok {
+			c := *t
+			return &c, nil
+		}
+		return nil, nil
+	}
+
+	return m, labels, todos
+}
+
+func decodeLabel(t *testing.T, body string) server.Label {
+	var l server.Label
+	if err := json.Unmarshal([]byte(body), &l); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func testCreateLabelDuplicate(t *testing.T) {
+
+	m, labels, _ := labelRepos(1)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLabels(labels)).Handle(listRequest(http.MethodPost, "/labels", "", `{"name":"urgent"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	if labels.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testGetLabelsUsage(t *testing.T) {
+
+	m, labels, _ := labelRepos(4)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLabels(labels)).Handle(listRequest(http.MethodGet, "/labels", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []server.Label
+	if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || got[0].Name != "later" || got[1].Name != "urgent" {
+		t.Fatalf("Expected later and urgent labels, got %+v", got)
+	}
+
+	// the last ToDo is shared with the user, who cannot relabel it
+	if got[0].UsageCount != 1 || got[1].UsageCount != 3 {
+		t.Fatalf("Expected usage counts 1 and 3, got %d and %d", got[0].UsageCount, got[1].UsageCount)
+	}
+}
+
+func testRenameLabel(t *testing.T) {
+
+	m, labels, todos := labelRepos(250)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLabels(labels)).Handle(listRequest(http.MethodPut, "/labels/{id}", "urgent", `{"id":"urgent","name":"asap"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	label := decodeLabel(t, resp.Body)
+	if label.Name != "asap" || label.Migration != nil || label.UsageCount != 249 {
+		t.Fatalf("Expected renamed label used by 249 ToDos, got %+v", label)
+	}
+
+	for id, todo := range todos {
+		owned := todo.Owner == testUser
+		if todo.HasTag("asap") != owned || todo.HasTag("urgent") == owned {
+			t.Fatalf("Unexpected tags %v on ToDo %s", todo.Tags, id)
+		}
+	}
+}
+
+func testRenameLabelResume(t *testing.T) {
+
+	n := 100*10 + 50
+	m, labels, todos := labelRepos(n)
+
+	h := handlers.NewToDoHandler(m, handlers.WithLabels(labels))
+
+	resp, err := h.Handle(listRequest(http.MethodPut, "/labels/{id}", "urgent", `{"id":"urgent","name":"asap"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusAccepted, resp.StatusCode)
+	}
+
+	pending := labels.Labels["urgent"].Migration
+	if pending == nil || pending.Updated != 1000 {
+		t.Fatalf("Expected a saved migration after 1000 ToDos, got %+v", pending)
+	}
+
+	resp, err = h.Handle(listRequest(http.MethodPut, "/labels/{id}", "urgent", `{"id":"urgent","name":"other"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	resp, err = h.Handle(listRequest(http.MethodPost, "/labels/{id}/resume", "urgent", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if labels.Labels["urgent"].Migration != nil {
+		t.Fatal("Expected migration to be done")
+	}
+
+	if last := todos["todo-"+strconv.Itoa(n-2)]; !last.HasTag("asap") {
+		t.Fatalf("Expected the last page to be renamed, got %v", last.Tags)
+	}
+}
+
+func testMergeLabel(t *testing.T) {
+
+	m, labels, todos := labelRepos(4)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLabels(labels)).Handle(listRequest(http.MethodPost, "/labels/{id}/merge", "later", `{"into":"urgent"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if _, ok := labels.Labels["later"]; ok {
+		t.Fatal("Expected merged label to be deleted")
+	}
+
+	if tags := todos["todo-0001"].Tags; len(tags) != 1 || tags[0] != "urgent" {
+		t.Fatalf("Expected tags to be merged, got %v", tags)
+	}
+}
+
+func testDeleteLabel(t *testing.T) {
+
+	m, labels, todos := labelRepos(4)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLabels(labels)).Handle(listRequest(http.MethodDelete, "/labels/{id}", "urgent", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if _, ok := labels.Labels["urgent"]; ok {
+		t.Fatal("Expected label to be deleted")
+	}
+
+	if tags := todos["todo-0000"].Tags; tags != nil {
+		t.Fatalf("Expected label to be detached, got %v", tags)
+	}
+
+	if tags := todos["todo-0001"].Tags; len(tags) != 1 || tags[0] != "later" {
+		t.Fatalf("Expected other labels to be kept, got %v", tags)
+	}
+}
+
+func testUpdateLabelBeingMigrated(t *testing.T) {
+
+	m, labels, _ := labelRepos(1)
+	labels.Labels["urgent"].Migration = &server.LabelMigration{From: "urgent", DeleteLabel: true}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLabels(labels)).Handle(listRequest(http.MethodPost, "/labels/{id}/merge", "later", `{"into":"urgent"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+}
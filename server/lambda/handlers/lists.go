@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/pkg/errors"
+)
+
+const (
+	// listTodosCascade deletes the ToDos of a list along with the list
+	listTodosCascade = "cascade"
+	// listTodosMove moves the ToDos of a list to the list given by the to parameter
+	listTodosMove = "move"
+)
+
+// moveToDoRequest is the body sent to move a ToDo to another list
+type moveToDoRequest struct {
+	// ListID is the list to move the ToDo to, empty to remove it from its list
+	ListID string `json:"listId" schema:"format=uuid"`
+}
+
+var (
+	// listSchema validates the lists sent to create or replace a list
+	listSchema = jsonschema.Generate(server.List{})
+	// moveToDoSchema validates the list a ToDo is moved to
+	moveToDoSchema = jsonschema.Generate(moveToDoRequest{})
+
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// WithLists lets ToDos be grouped in the lists stored in the given repository
+func WithLists(repo database.ListRepo) Option {
+	return func(h *ToDoHandler) {
+		h.lists = repo
+	}
+}
+
+// getList returns a list of the user, or ErrNotFound if lists are not enabled or the list
+// does not exist
+func (h *ToDoHandler) getList(id string) (*server.List, error) {
+
+	if h.lists == nil {
+		return nil, ErrNotFound
+	}
+
+	l, err := h.lists.Get(id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if l == nil {
+		return nil, ErrNotFound
+	}
+
+	return l, nil
+}
+
+// checkList returns an error if a ToDo is put in a list the user does not own or that is
+// archived. current is the list the ToDo is already in.
+func (h *ToDoHandler) checkList(id, current string) error {
+
+	if id == "" || id == current {
+		return nil
+	}
+
+	l, err := h.getList(id)
+	if errors.Cause(err) == ErrNotFound || errors.Cause(err) == ErrForbidden {
+		return errors.Wrapf(ErrBadRequest, "list %s not found", id)
+	} else if err != nil {
+		return err
+	}
+
+	if l.Archived {
+		return errors.Wrapf(ErrConflict, "list %s is archived", id)
+	}
+
+	return nil
+}
+
+// validateColor returns an error if a color is not formatted as #rrggbb
+func validateColor(color string) error {
+	if color != "" && !colorPattern.MatchString(color) {
+		return errors.Wrapf(ErrBadRequest, "color %q must be formatted as #rrggbb", color)
+	}
+	return nil
+}
+
+func (h *ToDoHandler) getLists(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.lists == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	all, err := h.lists.GetAll()
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	archived := req.QueryStringParameters["archived"] == "true"
+
+	lists := []server.List{}
+	for _, l := range all {
+		if archived || !l.Archived {
+			lists = append(lists, l)
+		}
+	}
+
+	server.SortLists(lists)
+	return CreateOKResponse(lists)
+}
+
+func (h *ToDoHandler) getOneList(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	l, err := h.getList(req.PathParameters["id"])
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	return CreateOKResponse(l)
+}
+
+func (h *ToDoHandler) postList(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.lists == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	var list server.List
+	if err := decode(listSchema, req.Body, &list); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if list.ID != "" {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID must be empty"))
+	}
+
+	if err := validateColor(list.Color); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.lists.Save(&list); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(list)
+}
+
+func (h *ToDoHandler) putList(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id := req.PathParameters["id"]
+
+	var list server.List
+	stored := func() (interface{}, error) { return h.getList(id) }
+	if err := decodeUpdate(listSchema, req.Body, stored, &list); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if id != list.ID {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID in body does not match ID in path"))
+	}
+
+	if err := validateColor(list.Color); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if _, err := h.getList(id); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.lists.Save(&list); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(list)
+}
+
+// deleteList deletes a list. A list with ToDos is only deleted if todos is cascade, to delete
+// them too, or move, to move them to the list given by the to parameter.
+func (h *ToDoHandler) deleteList(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id := req.PathParameters["id"]
+
+	if _, err := h.getList(id); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	// The ToDos the user cannot read are also in the list, the list is only deleted if the user
+	// is allowed to change all of them, which is checked before any change is made
+	todos, err := h.unfiltered.GetByList(id)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if len(todos) > 0 {
+
+		switch mode := req.QueryStringParameters["todos"]; mode {
+		case listTodosCascade:
+			for _, t := range todos {
+				if _, err := h.checkCascade(&t); err != nil {
+					return CreateErrorResponse(err)
+				}
+			}
+			for _, t := range todos {
+				if err := h.detachChildren(&t, childrenCascade); err != nil {
+					return CreateErrorResponse(err)
+				}
+				if err := h.purge(&t); err != nil {
+					return CreateErrorResponse(err)
+				}
+			}
+		case listTodosMove:
+			to := req.QueryStringParameters["to"]
+			if to == id {
+				return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ToDos cannot be moved to the deleted list"))
+			}
+			if err := h.checkList(to, ""); err != nil {
+				return CreateErrorResponse(err)
+			}
+			for _, t := range todos {
+				if !policy.Allowed(t.RoleOf(h.user), policy.ActionWrite) {
+					return CreateErrorResponse(errors.Wrapf(ErrForbidden, "ToDo %s cannot be moved", t.ID))
+				}
+			}
+			for _, t := range todos {
+				if err := h.move(t.ID, id, to); err != nil {
+					return CreateErrorResponse(err)
+				}
+			}
+		default:
+			return CreateErrorResponse(errors.Wrapf(ErrConflict, "list %s has %d ToDos, set todos to %s or %s", id, len(todos), listTodosCascade, listTodosMove))
+		}
+	}
+
+	if err := h.lists.Delete(id); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse("")
+}
+
+func (h *ToDoHandler) getListToDos(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id := req.PathParameters["id"]
+
+	if _, err := h.getList(id); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	todos, err := h.repo.GetByList(id)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	server.SortByRank(todos)
+	for i := range todos {
+		withProgress(&todos[i])
+	}
+
+	return CreateOKResponse(todos)
+}
+
+// moveToDo moves a ToDo to another list in a single conditional write
+func (h *ToDoHandler) moveToDo(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id := req.PathParameters["id"]
+
+	var body moveToDoRequest
+	if err := decode(moveToDoSchema, req.Body, &body); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	todo, err := h.repo.Get(id)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if todo == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	if err := h.checkList(body.ListID, todo.ListID); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if body.ListID != todo.ListID {
+		if err := h.move(id, todo.ListID, body.ListID); err != nil {
+			return CreateErrorResponse(err)
+		}
+	}
+
+	todo, err = h.repo.Get(id)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	withProgress(todo)
+	return CreateOKResponse(todo)
+}
+
+// move moves a ToDo between lists, it returns ErrConflict if the ToDo was moved or the list
+// was deleted concurrently
+func (h *ToDoHandler) move(id, from, to string) error {
+
+	err := h.repo.Move(id, from, to)
+
+	switch errors.Cause(err) {
+	case nil:
+		return nil
+	case database.ErrStale:
+		return errors.Wrapf(ErrConflict, "ToDo %s or list %s was modified concurrently", id, to)
+	case policy.ErrForbidden:
+		return ErrForbidden
+	default:
+		return ErrInternal
+	}
+}
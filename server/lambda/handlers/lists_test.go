@@ -0,0 +1,367 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+	"github.com/pkg/errors"
+)
+
+func TestLists(t *testing.T) {
+	t.Run("CreateList", testCreateList)
+	t.Run("CreateListInvalidColor", testCreateListInvalidColor)
+	t.Run("GetLists", testGetLists)
+	t.Run("GetListToDos", testGetListToDos)
+	t.Run("CreateToDoInUnknownList", testCreateToDoInUnknownList)
+	t.Run("MoveToDo", testMoveToDo)
+	t.Run("MoveToDoToArchivedList", testMoveToDoToArchivedList)
+	t.Run("MoveToDoConcurrently", testMoveToDoConcurrently)
+	t.Run("DeleteListWithToDos", testDeleteListWithToDos)
+	t.Run("DeleteListCascade", testDeleteListCascade)
+	t.Run("DeleteListMoveToDos", testDeleteListMoveToDos)
+	t.Run("DeleteListWithUnreadableToDos", testDeleteListWithUnreadableToDos)
+}
+
+// listRepos stores the work, home and archived lists of the test user, a list of another user,
+// and a ToDo in the work list
+func listRepos() (*RepoMock, *ListRepoMock, map[string]*server.ToDo) {
+
+	lists := &ListRepoMock{
+		Lists: map[string]*server.List{
+			"work":     {ID: "work", Name: "Work", Order: 1, Owner: testUser},
+			"home":     {ID: "home", Name: "Home", Order: 0, Owner: testUser},
+			"archived": {ID: "archived", Name: "Old", Archived: true, Owner: testUser},
+			"other":    {ID: "other", Name: "Other", Owner: otherUser},
+		},
+	}
+
+	todos := map[string]*server.ToDo{
+		"report": {ID: "report", Title: "Report", ListID: "work", Owner: testUser},
+	}
+
+	m := &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			if t, ok := todos[id]; ok {
+				c := *t
+				return &c, nil
+			}
+			return nil, nil
+		},
+		GetByListFn: func(listID string) ([]server.ToDo, error) {
+			res := []server.ToDo{}
+			for _, t := range todos {
+				if t.ListID == listID {
+					res = append(res, *t)
+				}
+			}
+			return res, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			c := *todo
+			todos[todo.ID] = &c
+			return nil
+		},
+		MoveFn: func(id, from, to string) error {
+			todos[id].ListID = to
+			return nil
+		},
+		DeleteFn: func(id string) error {
+			delete(todos, id)
+			return nil
+		},
+	}
+
+	return m, lists, todos
+}
+
+// listRequest returns a request on a resource of the lists or labels APIs
+func listRequest(method, resource, id, body string) events.APIGatewayProxyRequest {
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       resource,
+		HTTPMethod:     method,
+		Body:           body,
+	}
+	if id != "" {
+		req.PathParameters = map[string]string{"id": id}
+	}
+	return req
+}
+
+func testCreateList(t *testing.T) {
+
+	m, lists, _ := listRepos()
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodPost, "/lists", "", `{"name":"Errands","color":"#ff8800"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var list server.List
+	if err := json.Unmarshal([]byte(resp.Body), &list); err != nil {
+		t.Fatal(err)
+	}
+
+	if list.Owner != testUser || lists.Lists[list.ID] == nil {
+		t.Fatalf("Expected list to be saved for the user, got %+v", list)
+	}
+}
+
+func testCreateListInvalidColor(t *testing.T) {
+
+	m, lists, _ := listRepos()
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodPost, "/lists", "", `{"name":"Errands","color":"orange"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	if lists.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testGetLists(t *testing.T) {
+
+	m, lists, _ := listRepos()
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodGet, "/lists", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []server.List
+	if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// Archived lists and lists of other users are left out, lists are sorted by order
+	if len(got) != 2 || got[0].ID != "home" || got[1].ID != "work" {
+		t.Fatalf("Expected home and work lists, got %+v", got)
+	}
+}
+
+func testGetListToDos(t *testing.T) {
+
+	m, lists, _ := listRepos()
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodGet, "/lists/{id}/todos", "work", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []server.ToDo
+	if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].ID != "report" {
+		t.Fatalf("Expected the report ToDo, got %+v", got)
+	}
+
+	resp, err = handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodGet, "/lists/{id}/todos", "other", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func testCreateToDoInUnknownList(t *testing.T) {
+
+	for _, list := range []string{"missing", "other"} {
+
+		m, lists, _ := listRepos()
+
+		resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(postBody(`{"title":"Groceries","listId":"` + list + `"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected %d http response code for list %s, got %d", http.StatusBadRequest, list, resp.StatusCode)
+		}
+
+		if m.SaveInvoked {
+			t.Fatal("Save invoked")
+		}
+	}
+}
+
+func testMoveToDo(t *testing.T) {
+
+	m, lists, todos := listRepos()
+
+	var from, to string
+	m.MoveFn = func(id, f, tt string) error {
+		from, to = f, tt
+		todos[id].ListID = tt
+		return nil
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodPut, "/todos/{id}/list", "report", `{"listId":"home"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if from != "work" || to != "home" {
+		t.Fatalf("Expected move from work to home, got %s to %s", from, to)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Expected the move to be a single conditional write, Save invoked")
+	}
+}
+
+func testMoveToDoToArchivedList(t *testing.T) {
+
+	m, lists, _ := listRepos()
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodPut, "/todos/{id}/list", "report", `{"listId":"archived"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	if m.MoveInvoked {
+		t.Fatal("Move invoked")
+	}
+}
+
+func testMoveToDoConcurrently(t *testing.T) {
+
+	m, lists, _ := listRepos()
+
+	m.MoveFn = func(id, from, to string) error {
+		return errors.Wrap(database.ErrStale, "ToDo report")
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodPut, "/todos/{id}/list", "report", `{"listId":"home"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+}
+
+func testDeleteListWithToDos(t *testing.T) {
+
+	m, lists, _ := listRepos()
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(listRequest(http.MethodDelete, "/lists/{id}", "work", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	if lists.DeleteInvoked {
+		t.Fatal("Delete invoked")
+	}
+}
+
+func testDeleteListCascade(t *testing.T) {
+
+	m, lists, todos := listRepos()
+
+	req := listRequest(http.MethodDelete, "/lists/{id}", "work", "")
+	req.QueryStringParameters = map[string]string{"todos": "cascade"}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if _, ok := todos["report"]; ok {
+		t.Fatal("Expected the ToDos of the list to be deleted")
+	}
+
+	if _, ok := lists.Lists["work"]; ok {
+		t.Fatal("Expected the list to be deleted")
+	}
+}
+
+func testDeleteListMoveToDos(t *testing.T) {
+
+	m, lists, todos := listRepos()
+
+	req := listRequest(http.MethodDelete, "/lists/{id}", "work", "")
+	req.QueryStringParameters = map[string]string{"todos": "move", "to": "home"}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if todos["report"].ListID != "home" {
+		t.Fatalf("Expected the ToDos to be moved to the home list, got %s", todos["report"].ListID)
+	}
+
+	if _, ok := lists.Lists["work"]; ok {
+		t.Fatal("Expected the list to be deleted")
+	}
+}
+
+func testDeleteListWithUnreadableToDos(t *testing.T) {
+
+	for _, mode := range []map[string]string{{"todos": "cascade"}, {"todos": "move", "to": "home"}} {
+
+		m, lists, todos := listRepos()
+		// The report can be changed but the notes belong to another user
+		todos["notes"] = &server.ToDo{ID: "notes", Title: "Notes", ListID: "work", Owner: otherUser}
+
+		req := listRequest(http.MethodDelete, "/lists/{id}", "work", "")
+		req.QueryStringParameters = mode
+
+		resp, err := handlers.NewToDoHandler(m, handlers.WithLists(lists)).Handle(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+		}
+
+		if m.DeleteInvoked || m.MoveInvoked || todos["report"].ListID != "work" {
+			t.Fatalf("Expected no ToDo to be changed with %v", mode)
+		}
+
+		if _, ok := lists.Lists["work"]; !ok {
+			t.Fatal("Expected the list to be kept")
+		}
+	}
+}
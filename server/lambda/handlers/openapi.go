@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+)
+
+// openAPIDocument is an OpenAPI 3 document describing the API
+type openAPIDocument struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       openAPIInfo                     `json:"info"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components openAPIComponents               `json:"components"`
+	Security   []map[string][]string           `json:"security"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*jsonschema.Schema `json:"schemas"`
+	SecuritySchemes map[string]securityScheme     `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// operation documents a route of the API
+type operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Parameters  []parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]response `json:"responses"`
+	// Security is only set on public operations, to override the document security
+	Security interface{} `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                  `json:"description"`
+	Headers     map[string]headerObject `json:"headers,omitempty"`
+	Content     map[string]mediaType    `json:"content,omitempty"`
+}
+
+type headerObject struct {
+	Description string             `json:"description"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *jsonschema.Schema `json:"schema"`
+}
+
+// op starts the documentation of an operation requiring authentication
+func op(id, summary string) *operation {
+	return &operation{
+		OperationID: id,
+		Summary:     summary,
+		Responses:   map[string]response{},
+	}
+}
+
+// public documents an operation that can be called without being authenticated
+func (o *operation) public() *operation {
+	o.Security = []map[string][]string{}
+	return o
+}
+
+// path documents a required path parameter
+func (o *operation) path(name string) *operation {
+	o.Parameters = append(o.Parameters, parameter{Name: name, In: "path", Required: true, Schema: &jsonschema.Schema{Type: "string"}})
+	return o.errors(http.StatusBadRequest)
+}
+
+// query documents an optional query parameter
+func (o *operation) query(name, description string) *operation {
+	o.Parameters = append(o.Parameters, parameter{Name: name, In: "query", Description: description, Schema: &jsonschema.Schema{Type: "string"}})
+	return o
+}
+
+// header documents an optional request header
+func (o *operation) header(name, description string) *operation {
+	o.Parameters = append(o.Parameters, parameter{Name: name, In: "header", Description: description, Schema: &jsonschema.Schema{Type: "string"}})
+	return o
+}
+
+// body documents the JSON request body
+func (o *operation) body(s *jsonschema.Schema) *operation {
+	o.RequestBody = &requestBody{Required: true, Content: map[string]mediaType{"application/json": {Schema: s}}}
+	return o.errors(http.StatusBadRequest)
+}
+
+// returns documents the successful response, a nil schema documents an empty body
+func (o *operation) returns(code int, s *jsonschema.Schema) *operation {
+	r := response{Description: http.StatusText(code)}
+	if s != nil {
+		r.Content = map[string]mediaType{"application/json": {Schema: s}}
+	}
+	o.Responses[strconv.Itoa(code)] = r
+	return o
+}
+
+// errors documents error responses, keeping the ones already documented by fails
+func (o *operation) errors(codes ...int) *operation {
+	for _, code := range codes {
+		if _, ok := o.Responses[strconv.Itoa(code)]; !ok {
+			o.fails(code, http.StatusText(code))
+		}
+	}
+	return o
+}
+
+// fails documents an error response along with when it is sent
+func (o *operation) fails(code int, description string) *operation {
+	o.Responses[strconv.Itoa(code)] = response{
+		Description: description,
+		Content:     map[string]mediaType{"application/json": {Schema: ref("Error")}},
+	}
+	return o
+}
+
+// rateLimited documents the headers the rate limiter adds to every response and the response
+// to the requests it rejects
+func (o *operation) rateLimited() *operation {
+
+	integer := &jsonschema.Schema{Type: "integer"}
+	headers := map[string]headerObject{
+		"RateLimit-Limit":     {Description: "Number of requests that can be made in a burst", Schema: integer},
+		"RateLimit-Remaining": {Description: "Number of requests that can still be made in a burst", Schema: integer},
+		"RateLimit-Reset":     {Description: "Seconds until a full burst of requests can be made again", Schema: integer},
+	}
+
+	o.fails(http.StatusTooManyRequests, "Too many requests were made, the request can be retried after the Retry-After header")
+
+	for code, r := range o.Responses {
+		r.Headers = headers
+		if code == strconv.Itoa(http.StatusTooManyRequests) {
+			r.Headers = map[string]headerObject{"Retry-After": {Description: "Seconds until the request can be retried", Schema: integer}}
+			for name, h := range headers {
+				r.Headers[name] = h
+			}
+		}
+		o.Responses[code] = r
+	}
+
+	return o
+}
+
+func ref(name string) *jsonschema.Schema {
+	return &jsonschema.Schema{Ref: "#/components/schemas/" + name}
+}
+
+func arrayOf(s *jsonschema.Schema) *jsonschema.Schema {
+	return &jsonschema.Schema{Type: "array", Items: s}
+}
+
+// schemas returns the schemas shared by the operations
+func schemas() map[string]*jsonschema.Schema {
+	return map[string]*jsonschema.Schema{
+		"ToDo":            toDoSchema,
+		"ToDoPatch":       toDoPatchSchema,
+		"ToDoNode":        toDoNodeSchema(),
+		"MoveToDoBetween": moveSchema,
+		"ToDoChanges":     jsonschema.Generate(toDoChanges{}),
+		"SyncPush":        syncPushSchema,
+		"SyncPushResult":  jsonschema.Generate(syncPushResponse{}),
+
+		"ChecklistItem":         itemSchema,
+		"ChecklistItemPatch":    itemPatchSchema,
+		"ReorderChecklistItems": reorderItemsSchema,
+
+		"List":     listSchema,
+		"MoveToDo": moveToDoSchema,
+
+		"Label":      labelSchema,
+		"MergeLabel": mergeLabelSchema,
+
+		"Comment":     commentSchema,
+		"CommentPage": jsonschema.Generate(commentPage{}),
+
+		"Attachment":         attachmentSchema,
+		"AttachmentUpload":   jsonschema.Generate(attachmentUpload{}),
+		"AttachmentDownload": jsonschema.Generate(attachmentDownload{}),
+
+		"Webhook":      webhookSchema,
+		"Delivery":     jsonschema.Generate(server.Delivery{}),
+		"DeliveryPage": jsonschema.Generate(deliveryPage{}),
+
+		"Profile": profileSchema,
+
+		"Error": jsonschema.Generate(errorResponse{}),
+	}
+}
+
+// toDoNodeSchema returns the schema of a ToDo along with its nested sub-tasks
+func toDoNodeSchema() *jsonschema.Schema {
+
+	node := *toDoSchema
+	node.Properties = map[string]*jsonschema.Schema{}
+	for k, v := range toDoSchema.Properties {
+		node.Properties[k] = v
+	}
+	node.Properties["children"] = arrayOf(ref("ToDoNode"))
+
+	return &node
+}
+
+// openAPISpec builds the OpenAPI document from the routes served by the handler
+func openAPISpec() *openAPIDocument {
+
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.2",
+		Info: openAPIInfo{
+			Title:   "ToDo API",
+			Version: "1.0.0",
+		},
+		Paths: map[string]map[string]operation{},
+		Components: openAPIComponents{
+			Schemas: schemas(),
+			SecuritySchemes: map[string]securityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}},
+	}
+
+	for _, r := range routes() {
+
+		if r.doc == nil {
+			continue
+		}
+
+		o := *r.doc
+		o.errors(http.StatusInternalServerError)
+		// Authenticated requests are rate limited
+		if o.Security == nil {
+			o.errors(http.StatusUnauthorized, http.StatusForbidden)
+			o.rateLimited()
+		}
+
+		if doc.Paths[r.Resource] == nil {
+			doc.Paths[r.Resource] = map[string]operation{}
+		}
+		doc.Paths[r.Resource][strings.ToLower(r.Method)] = o
+	}
+
+	return doc
+}
+
+func (h *ToDoHandler) openAPI(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return CreateOKResponse(openAPISpec())
+}
@@ -0,0 +1,124 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Responses   map[string]json.RawMessage `json:"responses"`
+	Security    *[]interface{}             `json:"security"`
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                       `json:"openapi"`
+	Paths      map[string]map[string]openAPIOperation       `json:"paths"`
+	Components struct{ Schemas map[string]json.RawMessage } `json:"components"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	t.Run("ServeSpecWithoutAuth", testServeSpecWithoutAuth)
+	t.Run("SpecCoversRoutes", testSpecCoversRoutes)
+	t.Run("SpecDocumentsLimits", testSpecDocumentsLimits)
+}
+
+func getOpenAPI(t *testing.T) openAPIDocument {
+
+	req := events.APIGatewayProxyRequest{
+		Resource:   "/openapi.json",
+		HTTPMethod: http.MethodGet,
+	}
+
+	resp, err := handlers.NewToDoHandler(&RepoMock{}).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var doc openAPIDocument
+	if err := json.Unmarshal([]byte(resp.Body), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func testServeSpecWithoutAuth(t *testing.T) {
+
+	doc := getOpenAPI(t)
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("Expected an OpenAPI 3 document, got version '%s'", doc.OpenAPI)
+	}
+
+	for _, name := range []string{"ToDo", "Error"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Fatalf("Expected schema %s", name)
+		}
+	}
+
+	o := doc.Paths["/openapi.json"]["get"]
+	if o.Security == nil || len(*o.Security) != 0 {
+		t.Fatal("Expected GET /openapi.json to override the document security")
+	}
+}
+
+func testSpecCoversRoutes(t *testing.T) {
+
+	doc := getOpenAPI(t)
+
+	for _, r := range handlers.Routes() {
+
+		o, ok := doc.Paths[r.Resource][strings.ToLower(r.Method)]
+		if !ok {
+			t.Errorf("Route %s %s is missing from the OpenAPI spec", r.Method, r.Resource)
+			continue
+		}
+
+		if o.OperationID == "" {
+			t.Errorf("Route %s %s has no operationId", r.Method, r.Resource)
+		}
+
+		if len(o.Responses) == 0 {
+			t.Errorf("Route %s %s has no documented responses", r.Method, r.Resource)
+		}
+	}
+}
+
+func testSpecDocumentsLimits(t *testing.T) {
+
+	doc := getOpenAPI(t)
+
+	var forbidden struct{ Description string }
+	if err := json.Unmarshal(doc.Paths["/todos"]["post"].Responses["403"], &forbidden); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(forbidden.Description, "maximum number of ToDos") {
+		t.Fatalf("Expected the quota to be documented, got '%s'", forbidden.Description)
+	}
+
+	var tooMany struct{ Headers map[string]json.RawMessage }
+	if err := json.Unmarshal(doc.Paths["/todos"]["get"].Responses["429"], &tooMany); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"} {
+		if _, ok := tooMany.Headers[name]; !ok {
+			t.Fatalf("Expected header %s to be documented on 429 responses", name)
+		}
+	}
+
+	if _, ok := doc.Paths["/openapi.json"]["get"].Responses["429"]; ok {
+		t.Fatal("Expected public routes not to be rate limited")
+	}
+}
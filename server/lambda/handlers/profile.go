@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/digest"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/pkg/errors"
+)
+
+// phonePattern matches phone numbers in E.164 format
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// emailTokenTTL is how long the links verifying email addresses are valid
+const emailTokenTTL = 24 * time.Hour
+
+// emailTokenPurpose is signed along with the verified address, so that the tokens of other
+// purposes signed with the same secret are not accepted
+const emailTokenPurpose = "profile.email:"
+
+// profileSchema validates the profiles sent to replace the profile of the user
+var profileSchema = jsonschema.Generate(server.Profile{})
+
+// WithProfiles lets users set the contact details notifications are sent to, storing them in
+// the given repository
+func WithProfiles(repo database.ProfileRepo) Option {
+	return func(h *ToDoHandler) {
+		h.profiles = repo
+	}
+}
+
+// WithDigests lets users unsubscribe from their digests with the links of the digests, whose
+// tokens are signed with secret. It requires WithProfiles.
+func WithDigests(secret []byte) Option {
+	return func(h *ToDoHandler) {
+		h.digestSecret = secret
+	}
+}
+
+// WithEmailVerification lets users verify the email address of their profile by following a
+// link to verifyURL, emailed with the given notifier. The tokens of the links are signed with
+// secret. It requires WithProfiles.
+func WithEmailVerification(notifier notify.Notifier, secret []byte, verifyURL string) Option {
+	return func(h *ToDoHandler) {
+		h.verifier = notifier
+		h.verifySecret = secret
+		h.verifyURL = verifyURL
+	}
+}
+
+// validateReminders returns an error if the ToDo has too many reminders
+func validateReminders(todo server.ToDo) error {
+
+	if len(todo.Reminders) > server.MaxReminders {
+		return errors.Wrapf(ErrBadRequest, "ToDos cannot have more than %d reminders", server.MaxReminders)
+	}
+
+	return nil
+}
+
+// getProfile returns the profile of the user, which is empty until they set it
+func (h *ToDoHandler) getProfile(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.profiles == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	p, err := h.profiles.Get(h.user)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if p == nil {
+		p = &server.Profile{User: h.user}
+	}
+
+	return CreateOKResponse(p)
+}
+
+// putProfile replaces the profile of the user
+func (h *ToDoHandler) putProfile(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.profiles == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	var p server.Profile
+	if err := decodeUpdate(profileSchema, req.Body, h.storedProfile, &p); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if p.Email != "" {
+		if a, err := mail.ParseAddress(p.Email); err != nil || a.Address != p.Email {
+			return CreateErrorResponse(errors.Wrapf(ErrBadRequest, "invalid email address %q", p.Email))
+		}
+		// Inbound emails are matched to the profile by their lower case sender address
+		p.Email = strings.ToLower(p.Email)
+	}
+
+	if p.Phone != "" && !phonePattern.MatchString(p.Phone) {
+		return CreateErrorResponse(errors.Wrapf(ErrBadRequest, "phone number %q must be in E.164 format", p.Phone))
+	}
+
+	if _, err := time.LoadLocation(p.TimeZone); err != nil {
+		return CreateErrorResponse(errors.Wrapf(ErrBadRequest, "unknown time zone %q", p.TimeZone))
+	}
+
+	existing, err := h.profiles.Get(h.user)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	changed := existing == nil || existing.Email != p.Email
+	if !changed {
+		p.EmailVerified = existing.EmailVerified
+	}
+
+	if changed && p.Email != "" {
+		owner, err := h.profiles.GetByEmail(p.Email)
+		if err != nil {
+			return CreateErrorResponse(repoError(err))
+		}
+		if owner != nil && owner.User != h.user {
+			return CreateErrorResponse(errors.Wrapf(ErrConflict, "email address %q is used by another user", p.Email))
+		}
+	}
+
+	p.User = h.user
+	if err := h.profiles.Save(&p); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if changed && existing != nil && existing.Email != "" {
+		if err := h.profiles.ReleaseEmail(existing.Email, h.user); err != nil {
+			return CreateErrorResponse(repoError(err))
+		}
+	}
+
+	if changed && p.Email != "" {
+		if err := h.sendVerification(&p); err != nil {
+			return CreateErrorResponse(err)
+		}
+	}
+
+	return CreateOKResponse(p)
+}
+
+// storedProfile returns the profile of the user as read from the API, to compare the read-only
+// fields sent back
+func (h *ToDoHandler) storedProfile() (interface{}, error) {
+
+	p, err := h.profiles.Get(h.user)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if p == nil {
+		p = &server.Profile{User: h.user}
+	}
+
+	return p, nil
+}
+
+// resendVerification emails the link verifying the email address of the user again
+func (h *ToDoHandler) resendVerification(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.profiles == nil || h.verifier == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	p, err := h.profiles.Get(h.user)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if p == nil || p.Email == "" {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "the profile has no email address"))
+	}
+
+	if p.EmailVerified {
+		return CreateErrorResponse(errors.Wrapf(ErrConflict, "email address %q is already verified", p.Email))
+	}
+
+	if err := h.sendVerification(p); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	return CreateOKResponse("")
+}
+
+// sendVerification emails the link verifying the address of a profile to the address, which is
+// not verified yet. Nothing is sent when email verification is not configured.
+func (h *ToDoHandler) sendVerification(p *server.Profile) error {
+
+	if h.verifier == nil {
+		return nil
+	}
+
+	link := h.verifyURL + "?token=" + url.QueryEscape(emailToken(h.verifySecret, p.User, p.Email, time.Now().Add(emailTokenTTL)))
+
+	msg := notify.Message{
+		Subject: "Verify your email address",
+		Text:    "Follow this link within a day to verify " + p.Email + ", reminders and digests are only emailed to verified addresses and ToDos only created from their emails:\n\n" + link + "\n",
+	}
+
+	if err := h.verifier.Notify(p, msg); err != nil {
+		return errors.Wrapf(ErrInternal, "could not email the verification link: %v", err)
+	}
+
+	return nil
+}
+
+// verifyEmail marks the email address of the token of a verification link verified, claiming it
+// for the user of the token. It is called without being authenticated.
+func (h *ToDoHandler) verifyEmail(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.profiles == nil || h.verifySecret == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	user, email, err := verifyEmailToken(h.verifySecret, req.QueryStringParameters["token"], time.Now())
+	if err != nil {
+		return CreateErrorResponse(errors.Wrap(ErrForbidden, err.Error()))
+	}
+
+	p, err := h.profiles.Get(user)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	// The user changed their address since the link was sent
+	if p == nil || p.Email != email {
+		return CreateErrorResponse(errors.Wrap(ErrForbidden, "the token is not for the email address of the profile"))
+	}
+
+	if p.EmailVerified {
+		return CreateOKResponse("")
+	}
+
+	if err := h.profiles.ClaimEmail(email, user); err != nil {
+		if errors.Cause(err) == database.ErrExists {
+			return CreateErrorResponse(errors.Wrapf(ErrConflict, "email address %q is used by another user", email))
+		}
+		return CreateErrorResponse(repoError(err))
+	}
+
+	p.EmailVerified = true
+	if err := h.profiles.Save(p); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse("")
+}
+
+// emailToken returns the token of the link verifying the email address of a user, valid until
+// expires
+func emailToken(secret []byte, user, email string, expires time.Time) string {
+	payload := user + "\n" + email + "\n" + strconv.FormatInt(expires.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(signEmail(secret, payload))
+}
+
+// verifyEmailToken returns the user and the email address of a verification token, or an error
+// if it was not signed with the secret or expired at now
+func verifyEmailToken(secret []byte, token string, now time.Time) (string, string, error) {
+
+	invalid := errors.New("invalid verification token")
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", "", invalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", "", invalid
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, signEmail(secret, string(payload))) {
+		return "", "", invalid
+	}
+
+	fields := strings.Split(string(payload), "\n")
+	if len(fields) != 3 {
+		return "", "", invalid
+	}
+
+	expires, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || now.Unix() > expires {
+		return "", "", errors.New("expired verification token")
+	}
+
+	return fields[0], fields[1], nil
+}
+
+func signEmail(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(emailTokenPurpose + payload))
+	return mac.Sum(nil)
+}
+
+// unsubscribeDigest turns off the digest of the user of the token of an unsubscribe link, it is
+// called without being authenticated
+func (h *ToDoHandler) unsubscribeDigest(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.profiles == nil || h.digestSecret == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	user, err := digest.VerifyUnsubscribeToken(h.digestSecret, req.QueryStringParameters["token"])
+	if err != nil {
+		return CreateErrorResponse(errors.Wrap(ErrForbidden, err.Error()))
+	}
+
+	p, err := h.profiles.Get(user)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if p != nil && p.Digest != "" {
+		p.Digest = ""
+		if err := h.profiles.Save(p); err != nil {
+			return CreateErrorResponse(repoError(err))
+		}
+	}
+
+	return CreateOKResponse("")
+}
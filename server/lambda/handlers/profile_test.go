@@ -0,0 +1,317 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/databasetest"
+	"github.com/massimoselvi/serverless-todo-api-go/server/digest"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestProfile(t *testing.T) {
+	t.Run("GetEmptyProfile", testGetEmptyProfile)
+	t.Run("PutProfile", testPutProfile)
+	t.Run("PutInvalidProfile", testPutInvalidProfile)
+	t.Run("TooManyReminders", testTooManyReminders)
+	t.Run("VerifyEmail", testVerifyEmail)
+	t.Run("VerifyEmailClaimed", testVerifyEmailClaimed)
+	t.Run("VerifyEmailChanged", testVerifyEmailChanged)
+	t.Run("PutProfileEmailTaken", testPutProfileEmailTaken)
+	t.Run("UnsubscribeDigest", testUnsubscribeDigest)
+	t.Run("UnsubscribeDigestForged", testUnsubscribeDigestForged)
+}
+
+var testDigestSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func profileRequest(method, body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/profile",
+		HTTPMethod:     method,
+		Body:           body,
+	}
+}
+
+func testGetEmptyProfile(t *testing.T) {
+
+	m := &databasetest.ProfileRepoMock{Profiles: map[string]*server.Profile{}}
+
+	resp, err := handlers.NewToDoHandler(&RepoMock{}, handlers.WithProfiles(m)).Handle(profileRequest(http.MethodGet, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var p server.Profile
+	if err := json.Unmarshal([]byte(resp.Body), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.User != testUser || p.Email != "" {
+		t.Fatalf("Expected the empty profile of the user, got %+v", p)
+	}
+}
+
+func testPutProfile(t *testing.T) {
+
+	m := &databasetest.ProfileRepoMock{Profiles: map[string]*server.Profile{}}
+
+	resp, err := handlers.NewToDoHandler(&RepoMock{}, handlers.WithProfiles(m)).Handle(profileRequest(http.MethodPut, `{"email":"user@example.com","phone":"+14155550100","digest":"weekly","digestHour":7,"timeZone":"Europe/Rome"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	p := m.Profiles[testUser]
+	if p == nil || p.Email != "user@example.com" || p.Phone != "+14155550100" || p.Digest != server.DigestWeekly || p.DigestHour != 7 {
+		t.Fatalf("Expected the profile of the user to be saved, got %+v", p)
+	}
+}
+
+func testPutInvalidProfile(t *testing.T) {
+
+	for _, body := range []string{
+		`{"email":"not an email"}`,
+		`{"email":"User <user@example.com>"}`,
+		`{"phone":"415-555-0100"}`,
+		`{"digest":"monthly"}`,
+		`{"digest":"daily","digestHour":24}`,
+		`{"timeZone":"Mars/Olympus_Mons"}`,
+		`{"user":"` + otherUser + `"}`,
+	} {
+		m := &databasetest.ProfileRepoMock{Profiles: map[string]*server.Profile{}}
+
+		resp, err := handlers.NewToDoHandler(&RepoMock{}, handlers.WithProfiles(m)).Handle(profileRequest(http.MethodPut, body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected %d http response code for %s, got %d", http.StatusBadRequest, body, resp.StatusCode)
+		}
+
+		if len(m.Profiles) != 0 {
+			t.Fatal("Expected the profile not to be saved")
+		}
+	}
+}
+
+func testTooManyReminders(t *testing.T) {
+
+	reminders := make([]string, server.MaxReminders+1)
+	for i := range reminders {
+		reminders[i] = fmt.Sprintf("%q", time.Now().Add(time.Duration(i+1)*time.Hour).Format(time.RFC3339))
+	}
+
+	m := &RepoMock{}
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos",
+		HTTPMethod:     http.MethodPost,
+		Body:           `{"title":"Pay rent","reminders":[` + strings.Join(reminders, ",") + `]}`,
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+// putEmail sets the email address of a user and returns the verification link emailed to it
+func putEmail(t *testing.T, m *databasetest.ProfileRepoMock, user, email string) string {
+
+	n := &NotifierMock{}
+	h := handlers.NewToDoHandler(&RepoMock{}, handlers.WithProfiles(m), handlers.WithEmailVerification(n, testDigestSecret, "https://api.test/v1/profile/email/verify"))
+
+	req := profileRequest(http.MethodPut, fmt.Sprintf(`{"email":%q}`, email))
+	req.RequestContext.Authorizer = map[string]interface{}{"principalId": user}
+
+	resp, err := h.Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	if p := m.Profiles[user]; p.EmailVerified {
+		t.Fatalf("Expected the new address to be unverified, got %+v", p)
+	}
+
+	if len(n.Sent) != 1 {
+		t.Fatalf("Expected the verification link to be emailed, got %d emails", len(n.Sent))
+	}
+
+	i := strings.Index(n.Sent[0].Text, "token=")
+	if i < 0 {
+		t.Fatalf("Expected a verification link, got %s", n.Sent[0].Text)
+	}
+
+	return strings.Fields(n.Sent[0].Text[i+len("token="):])[0]
+}
+
+// verify follows a verification link without being authenticated
+func verify(t *testing.T, m *databasetest.ProfileRepoMock, token string) int {
+
+	token, err := url.QueryUnescape(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlers.NewToDoHandler(&RepoMock{}, handlers.WithProfiles(m), handlers.WithEmailVerification(&NotifierMock{}, testDigestSecret, "https://api.test/v1/profile/email/verify"))
+
+	resp, err := h.Handle(events.APIGatewayProxyRequest{
+		Resource:              "/profile/email/verify",
+		HTTPMethod:            http.MethodGet,
+		QueryStringParameters: map[string]string{"token": token},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode
+}
+
+func testVerifyEmail(t *testing.T) {
+
+	m := databasetest.NewProfileRepoMock()
+
+	token := putEmail(t, m, testUser, "user@example.com")
+
+	if code := verify(t, m, token); code != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, code)
+	}
+
+	if p, _ := m.GetByEmail("user@example.com"); p == nil || p.User != testUser {
+		t.Fatalf("Expected the address to be verified for the user, got %+v", p)
+	}
+}
+
+func testVerifyEmailClaimed(t *testing.T) {
+
+	m := databasetest.NewProfileRepoMock()
+
+	// Both users set the address before either verified it
+	first := putEmail(t, m, testUser, "user@example.com")
+	second := putEmail(t, m, otherUser, "user@example.com")
+
+	if code := verify(t, m, first); code != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, code)
+	}
+
+	if code := verify(t, m, second); code != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, code)
+	}
+
+	if m.Profiles[otherUser].EmailVerified {
+		t.Fatal("Expected a single profile to verify the address")
+	}
+}
+
+func testVerifyEmailChanged(t *testing.T) {
+
+	m := databasetest.NewProfileRepoMock()
+
+	old := putEmail(t, m, testUser, "old@example.com")
+	putEmail(t, m, testUser, "new@example.com")
+
+	if code := verify(t, m, old); code != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, code)
+	}
+
+	if m.Profiles[testUser].EmailVerified {
+		t.Fatal("Expected the new address to stay unverified")
+	}
+}
+
+func testPutProfileEmailTaken(t *testing.T) {
+
+	m := databasetest.NewProfileRepoMock(server.Profile{User: otherUser, Email: "user@example.com", EmailVerified: true})
+
+	resp, err := handlers.NewToDoHandler(&RepoMock{}, handlers.WithProfiles(m)).Handle(profileRequest(http.MethodPut, `{"email":"user@example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	if m.Profiles[testUser] != nil {
+		t.Fatal("Expected the profile not to be saved")
+	}
+}
+
+func unsubscribeRequest(token string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		Resource:              "/digest/unsubscribe",
+		HTTPMethod:            http.MethodGet,
+		QueryStringParameters: map[string]string{"token": token},
+	}
+}
+
+func testUnsubscribeDigest(t *testing.T) {
+
+	m := &databasetest.ProfileRepoMock{Profiles: map[string]*server.Profile{
+		testUser: {User: testUser, Email: "user@example.com", Digest: server.DigestDaily},
+	}}
+	h := handlers.NewToDoHandler(&RepoMock{}, handlers.WithProfiles(m), handlers.WithDigests(testDigestSecret))
+
+	// The link is followed from the email, without being authenticated
+	resp, err := h.Handle(unsubscribeRequest(digest.UnsubscribeToken(testDigestSecret, testUser)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if p := m.Profiles[testUser]; p.Digest != "" || p.Email != "user@example.com" {
+		t.Fatalf("Expected only the digest of the user to be turned off, got %+v", p)
+	}
+}
+
+func testUnsubscribeDigestForged(t *testing.T) {
+
+	m := &databasetest.ProfileRepoMock{Profiles: map[string]*server.Profile{
+		testUser: {User: testUser, Digest: server.DigestDaily},
+	}}
+	h := handlers.NewToDoHandler(&RepoMock{}, handlers.WithProfiles(m), handlers.WithDigests(testDigestSecret))
+
+	resp, err := h.Handle(unsubscribeRequest(digest.UnsubscribeToken([]byte("guessed"), testUser)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if m.Profiles[testUser].Digest != server.DigestDaily {
+		t.Fatal("Expected the digest to stay on")
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/massimoselvi/serverless-todo-api-go/server/rank"
+	"github.com/pkg/errors"
+)
+
+// moveRequest is the body sent to move a ToDo between two others of its list
+type moveRequest struct {
+	// Before is the ToDo to move the ToDo before
+	Before string `json:"before,omitempty" schema:"format=uuid"`
+	// After is the ToDo to move the ToDo after
+	After string `json:"after,omitempty" schema:"format=uuid"`
+}
+
+// moveSchema validates the anchors a ToDo is moved between
+var moveSchema = jsonschema.Generate(moveRequest{})
+
+// siblings returns the other ToDos of the list of a ToDo, ordered by rank. ToDos without a list
+// are ordered among the other ToDos of their owner without a list.
+func (h *ToDoHandler) siblings(todo *server.ToDo) ([]server.ToDo, error) {
+
+	var all []server.ToDo
+	var err error
+
+	if todo.ListID != "" {
+		all, err = h.repo.GetByList(todo.ListID)
+	} else {
+		all, err = h.repo.GetByOwner(todo.Owner)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	siblings := []server.ToDo{}
+	for _, t := range all {
+		if t.ID != todo.ID && t.ListID == todo.ListID {
+			siblings = append(siblings, t)
+		}
+	}
+
+	server.SortByRank(siblings)
+	return siblings, nil
+}
+
+// moveBetween moves a ToDo right after the ToDo given by after, or right before the ToDo given by
+// before. Only the moved ToDo is written, unless its neighbours have no rank yet or the new
+// rank would be too long, in which case the whole list is rebalanced.
+func (h *ToDoHandler) moveBetween(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	var body moveRequest
+	if err := decode(moveSchema, req.Body, &body); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if body.Before == "" && body.After == "" {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "before or after is required"))
+	}
+
+	todo, err := h.repo.Get(req.PathParameters["id"])
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if todo == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	siblings, err := h.siblings(todo)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	pos, err := position(siblings, body)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	var lower, upper string
+	if pos > 0 {
+		lower = siblings[pos-1].Rank
+	}
+	if pos < len(siblings) {
+		upper = siblings[pos].Rank
+	}
+
+	key, err := rank.Between(lower, upper)
+
+	switch {
+	case (pos > 0 && lower == "") || (pos < len(siblings) && upper == ""), err != nil, len(key) > rank.MaxLength:
+		if err := h.rebalance(todo, siblings, pos); err != nil {
+			return CreateErrorResponse(err)
+		}
+	default:
+		todo.Rank = key
+		if err := h.repo.Save(todo); err != nil {
+			return CreateErrorResponse(repoError(err))
+		}
+	}
+
+	withProgress(todo)
+	return CreateOKResponse(todo)
+}
+
+// position returns the index the moved ToDo takes among its siblings
+func position(siblings []server.ToDo, body moveRequest) (int, error) {
+
+	index := func(id string) (int, error) {
+		for i, t := range siblings {
+			if t.ID == id {
+				return i, nil
+			}
+		}
+		return 0, errors.Wrapf(ErrBadRequest, "ToDo %s is not in the same list", id)
+	}
+
+	var before, after int
+	var err error
+
+	if body.Before != "" {
+		if before, err = index(body.Before); err != nil {
+			return 0, err
+		}
+	}
+
+	if body.After != "" {
+		if after, err = index(body.After); err != nil {
+			return 0, err
+		}
+		after++
+	}
+
+	switch {
+	case body.Before == "":
+		return after, nil
+	case body.After == "":
+		return before, nil
+	case after != before:
+		return 0, errors.Wrapf(ErrBadRequest, "ToDos %s and %s are not next to each other", body.After, body.Before)
+	default:
+		return before, nil
+	}
+}
+
+// rebalance inserts a ToDo among its siblings at the given position and gives all of them
+// evenly spaced ranks. Nothing is written unless the user may write every ToDo whose rank
+// changes.
+func (h *ToDoHandler) rebalance(todo *server.ToDo, siblings []server.ToDo, pos int) error {
+
+	ordered := make([]*server.ToDo, 0, len(siblings)+1)
+	for i := range siblings[:pos] {
+		ordered = append(ordered, &siblings[i])
+	}
+	ordered = append(ordered, todo)
+	for i := range siblings[pos:] {
+		ordered = append(ordered, &siblings[pos+i])
+	}
+
+	keys := rank.Spread(len(ordered))
+
+	for i, t := range ordered {
+		if t.Rank != keys[i] && !policy.Allowed(t.RoleOf(h.user), policy.ActionWrite) {
+			return errors.Wrapf(ErrForbidden, "ToDo %s cannot be ranked", t.ID)
+		}
+	}
+
+	for i, t := range ordered {
+		if t.Rank == keys[i] {
+			continue
+		}
+		t.Rank = keys[i]
+		if err := h.repo.Save(t); err != nil {
+			return repoError(err)
+		}
+	}
+
+	return nil
+}
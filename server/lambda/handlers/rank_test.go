@@ -0,0 +1,250 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestRank(t *testing.T) {
+	t.Run("MoveBetween", testMoveBetween)
+	t.Run("MoveToEnd", testMoveToEnd)
+	t.Run("MoveUnrankedRebalances", testMoveUnrankedRebalances)
+	t.Run("MoveRebalanceForbidden", testMoveRebalanceForbidden)
+	t.Run("MoveInvalidAnchors", testMoveInvalidAnchors)
+	t.Run("PutKeepsRank", testPutKeepsRank)
+}
+
+// rankRepo stores the ToDos a, b and c in this order, and d in another list
+func rankRepo(ranks ...string) (*RepoMock, map[string]*server.ToDo) {
+
+	todos := map[string]*server.ToDo{
+		"a": {ID: "a", Title: "A", Rank: ranks[0], Owner: testUser},
+		"b": {ID: "b", Title: "B", Rank: ranks[1], Owner: testUser},
+		"c": {ID: "c", Title: "C", Rank: ranks[2], Owner: testUser},
+		"d": {ID: "d", Title: "D", ListID: "work", Owner: testUser},
+	}
+
+	m := &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			if t, ok := todos[id]; ok {
+				c := *t
+				return &c, nil
+			}
+			return nil, nil
+		},
+		GetByOwnerFn: func(owner string) ([]server.ToDo, error) {
+			all := []server.ToDo{}
+			for _, t := range todos {
+				if t.Owner == owner {
+					all = append(all, *t)
+				}
+			}
+			return all, nil
+		},
+		GetByListFn: func(listID string) ([]server.ToDo, error) {
+			all := []server.ToDo{}
+			for _, t := range todos {
+				if t.ListID == listID {
+					all = append(all, *t)
+				}
+			}
+			return all, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			c := *todo
+			todos[todo.ID] = &c
+			return nil
+		},
+	}
+
+	return m, todos
+}
+
+func moveRequest(id, body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}/move",
+		PathParameters: map[string]string{"id": id},
+		Body:           body,
+		HTTPMethod:     http.MethodPost,
+	}
+}
+
+// order returns the IDs of the ToDos without a list, ordered by rank
+func order(todos map[string]*server.ToDo) []string {
+
+	var ids []string
+	for id, t := range todos {
+		if t.ListID == "" {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return todos[ids[i]].Rank < todos[ids[j]].Rank })
+	return ids
+}
+
+func assertOrder(t *testing.T, todos map[string]*server.ToDo, want ...string) {
+
+	got := order(todos)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected order %v, got %v", want, got)
+		}
+	}
+}
+
+func testMoveBetween(t *testing.T) {
+
+	m, todos := rankRepo("1", "2", "3")
+
+	saves := 0
+	save := m.SaveFn
+	m.SaveFn = func(todo *server.ToDo) error {
+		saves++
+		return save(todo)
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(moveRequest("c", `{"after":"a","before":"b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if saves != 1 {
+		t.Fatalf("Expected a single write, got %d", saves)
+	}
+
+	assertOrder(t, todos, "a", "c", "b")
+}
+
+func testMoveToEnd(t *testing.T) {
+
+	m, todos := rankRepo("1", "2", "3")
+
+	resp, err := handlers.NewToDoHandler(m).Handle(moveRequest("a", `{"after":"c"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var todo server.ToDo
+	if err := json.Unmarshal([]byte(resp.Body), &todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if todo.Rank <= "3" {
+		t.Fatalf("Expected rank after 3, got %q", todo.Rank)
+	}
+
+	assertOrder(t, todos, "b", "c", "a")
+}
+
+func testMoveUnrankedRebalances(t *testing.T) {
+
+	m, todos := rankRepo("", "", "")
+
+	resp, err := handlers.NewToDoHandler(m).Handle(moveRequest("c", `{"before":"a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		if todos[id].Rank == "" {
+			t.Fatalf("Expected ToDo %s to be ranked", id)
+		}
+	}
+
+	if todos["c"].Rank >= todos["a"].Rank {
+		t.Fatalf("Expected c before a, got %q and %q", todos["c"].Rank, todos["a"].Rank)
+	}
+}
+
+// testMoveRebalanceForbidden moves a ToDo in a shared list whose ToDos are not ranked yet, one of
+// them being only readable by the user
+func testMoveRebalanceForbidden(t *testing.T) {
+
+	m, todos := rankRepo("", "", "")
+	for _, id := range []string{"a", "b", "c"} {
+		todos[id].ListID = "shared"
+	}
+	todos["b"].Owner = otherUser
+	todos["b"].Members = map[string]server.Role{testUser: server.RoleViewer}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(moveRequest("c", `{"before":"a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testMoveInvalidAnchors(t *testing.T) {
+
+	for _, body := range []string{
+		`{}`,
+		`{"after":"d"}`,
+		`{"after":"missing"}`,
+		`{"after":"b","before":"a"}`,
+		`{"rank":"0"}`,
+	} {
+		m, _ := rankRepo("1", "2", "3")
+
+		resp, err := handlers.NewToDoHandler(m).Handle(moveRequest("c", body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected %d http response code for %s, got %d", http.StatusBadRequest, body, resp.StatusCode)
+		}
+
+		if m.SaveInvoked {
+			t.Fatal("Save invoked")
+		}
+	}
+}
+
+func testPutKeepsRank(t *testing.T) {
+
+	m, todos := rankRepo("1", "2", "3")
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": "b"},
+		Body:           `{"id":"b","title":"Renamed"}`,
+		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if todos["b"].Rank != "2" {
+		t.Fatalf("Expected rank to be kept, got %q", todos["b"].Rank)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/ratelimit"
+	"github.com/pkg/errors"
+)
+
+// WithRateLimit limits the number of requests each user or API key can make
+func WithRateLimit(limiter *ratelimit.Limiter) Option {
+	return func(h *ToDoHandler) {
+		h.limiter = limiter
+	}
+}
+
+// WithQuota limits the number of ToDos each user can own, as counted in quotas
+func WithQuota(maxToDos int, quotas database.QuotaRepo) Option {
+	return func(h *ToDoHandler) {
+		h.maxToDos = maxToDos
+		h.quotas = quotas
+	}
+}
+
+// rateLimited handles the request if the user has not exceeded the rate limit
+func (h *ToDoHandler) rateLimited(req events.APIGatewayProxyRequest, fn func() (events.APIGatewayProxyResponse, error)) (events.APIGatewayProxyResponse, error) {
+
+	if h.limiter == nil {
+		return fn()
+	}
+
+	key := "user#" + h.user
+	if apiKey := req.RequestContext.Identity.APIKey; apiKey != "" {
+		key = "apikey#" + apiKey
+	}
+
+	res, err := h.limiter.Allow(key)
+	if err != nil {
+		return CreateErrorResponse(ErrInternal)
+	}
+
+	var resp events.APIGatewayProxyResponse
+	if res.Allowed {
+		resp, err = fn()
+	} else {
+		resp, err = CreateErrorResponse(ErrTooManyRequests)
+	}
+
+	if resp.Headers == nil {
+		resp.Headers = make(map[string]string)
+	}
+
+	reset := strconv.Itoa(int(math.Ceil(res.Reset.Seconds())))
+
+	resp.Headers["RateLimit-Limit"] = strconv.Itoa(res.Limit)
+	resp.Headers["RateLimit-Remaining"] = strconv.Itoa(res.Remaining)
+	resp.Headers["RateLimit-Reset"] = reset
+	if !res.Allowed {
+		resp.Headers["Retry-After"] = reset
+	}
+
+	return resp, err
+}
+
+// reserveQuota counts a ToDo created for owner against their quota, in a single conditional
+// update so that concurrent requests cannot exceed it. The ToDo is counted without checking the
+// quota unless enforce is set. The slot must be released if the ToDo is not saved.
+func (h *ToDoHandler) reserveQuota(owner string, enforce bool) error {
+
+	if h.maxToDos <= 0 || h.quotas == nil {
+		return nil
+	}
+
+	max := h.maxToDos
+	if !enforce {
+		max = 0
+	}
+
+	err := h.quotas.Reserve(owner, max)
+	if errors.Cause(err) == database.ErrLimitReached {
+		return errors.Wrapf(ErrForbidden, "quota of %d todos reached", h.maxToDos)
+	} else if err != nil {
+		return repoError(err)
+	}
+
+	return nil
+}
+
+// releaseQuota stops counting a ToDo of owner against their quota, once it is deleted or if it
+// could not be saved. The error of the save is returned rather than the one of releasing its
+// slot, which can only leave the quota of the user lower than it should be.
+func (h *ToDoHandler) releaseQuota(owner string) error {
+
+	if h.maxToDos <= 0 || h.quotas == nil {
+		return nil
+	}
+
+	if err := h.quotas.Release(owner); err != nil {
+		return repoError(err)
+	}
+
+	return nil
+}
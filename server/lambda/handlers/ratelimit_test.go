@@ -0,0 +1,238 @@
+package handlers_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+	"github.com/massimoselvi/serverless-todo-api-go/server/ratelimit"
+	"github.com/pkg/errors"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Run("RateLimitHeaders", testRateLimitHeaders)
+	t.Run("RateLimitExceeded", testRateLimitExceeded)
+	t.Run("QuotaExceeded", testQuotaExceeded)
+	t.Run("QuotaNotExceeded", testQuotaNotExceeded)
+	t.Run("QuotaReleasedOnSaveError", testQuotaReleasedOnSaveError)
+	t.Run("QuotaReleasedOnDelete", testQuotaReleasedOnDelete)
+}
+
+// newTestLimiter returns a limiter keeping its buckets in memory
+func newTestLimiter(capacity int, rate float64) *ratelimit.Limiter {
+	limiter, err := ratelimit.NewLimiter(&RateLimitRepoMock{Buckets: map[string]time.Time{}}, capacity, rate)
+	if err != nil {
+		panic(err)
+	}
+	return limiter
+}
+
+func getAllRequest() events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos",
+	}
+}
+
+func testRateLimitHeaders(t *testing.T) {
+
+	m := &RepoMock{
+		GetAllFn: func() ([]server.ToDo, error) {
+			return []server.ToDo{savedToDo}, nil
+		},
+	}
+
+	limiter := newTestLimiter(2, 1)
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithRateLimit(limiter)).Handle(getAllRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if resp.Headers["RateLimit-Limit"] != "2" {
+		t.Fatalf("Expected RateLimit-Limit to be 2, got '%s'", resp.Headers["RateLimit-Limit"])
+	}
+
+	if resp.Headers["RateLimit-Remaining"] != "1" {
+		t.Fatalf("Expected RateLimit-Remaining to be 1, got '%s'", resp.Headers["RateLimit-Remaining"])
+	}
+}
+
+func testRateLimitExceeded(t *testing.T) {
+
+	m := &RepoMock{
+		GetAllFn: func() ([]server.ToDo, error) {
+			return []server.ToDo{savedToDo}, nil
+		},
+	}
+
+	limiter := newTestLimiter(1, 0.001)
+	h := handlers.NewToDoHandler(m, handlers.WithRateLimit(limiter))
+
+	if _, err := h.Handle(getAllRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := h.Handle(getAllRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.Body, handlers.ErrTooManyRequests.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrTooManyRequests.Error())
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+
+	if resp.Headers["RateLimit-Remaining"] != "0" || resp.Headers["Retry-After"] == "" {
+		t.Fatal("Expected RateLimit-Remaining and Retry-After headers")
+	}
+}
+
+func testQuotaExceeded(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(*server.ToDo) error {
+			return nil
+		},
+	}
+
+	quotas := &QuotaRepoMock{Counts: map[string]int{testUser: 1}}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Body:           toDoToString(&newToDo),
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithQuota(1, quotas)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if quotas.Counts[testUser] != 1 {
+		t.Fatalf("Expected 1 ToDo counted, got %d", quotas.Counts[testUser])
+	}
+}
+
+func testQuotaNotExceeded(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(todo *server.ToDo) error {
+			todo.ID = testUUID
+			return nil
+		},
+	}
+
+	// ToDos shared with the user are counted in the quota of their owner
+	quotas := &QuotaRepoMock{Counts: map[string]int{otherUser: 5}}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Body:           toDoToString(&newToDo),
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithQuota(1, quotas)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.SaveInvoked {
+		t.Fatal("Save not invoked")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if quotas.Counts[testUser] != 1 {
+		t.Fatalf("Expected 1 ToDo counted, got %d", quotas.Counts[testUser])
+	}
+}
+
+func testQuotaReleasedOnSaveError(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(*server.ToDo) error {
+			return errors.New("DB Error")
+		},
+	}
+
+	quotas := &QuotaRepoMock{Counts: map[string]int{}}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Body:           toDoToString(&newToDo),
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithQuota(1, quotas)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	if quotas.Counts[testUser] != 0 {
+		t.Fatalf("Expected the slot to be released, got %d ToDos counted", quotas.Counts[testUser])
+	}
+}
+
+func testQuotaReleasedOnDelete(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			return &savedToDo, nil
+		},
+		DeleteFn: func(string) error {
+			return nil
+		},
+	}
+
+	quotas := &QuotaRepoMock{Counts: map[string]int{testUser: 1}}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodDelete,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithQuota(1, quotas)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if quotas.Counts[testUser] != 0 {
+		t.Fatalf("Expected the ToDo not to be counted any more, got %d", quotas.Counts[testUser])
+	}
+}
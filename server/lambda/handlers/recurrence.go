@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultOccurrences is the number of occurrences previewed when no count is given
+	defaultOccurrences = 5
+	// maxOccurrences is the maximum number of occurrences that can be previewed
+	maxOccurrences = 100
+)
+
+// validateRecurrence returns an error if the recurrence rule or the time zone of the ToDo are
+// invalid
+func validateRecurrence(todo server.ToDo) error {
+
+	if _, err := todo.Location(); err != nil {
+		return errors.Wrapf(ErrBadRequest, "unknown time zone %q", todo.TimeZone)
+	}
+
+	if _, err := todo.Rule(); err != nil {
+		return errors.Wrap(ErrBadRequest, err.Error())
+	}
+
+	return nil
+}
+
+// scheduleNext creates the next occurrence of a recurring ToDo that has just been completed
+func (h *ToDoHandler) scheduleNext(todo *server.ToDo, wasCompleted bool) error {
+
+	if !todo.Completed || wasCompleted {
+		return nil
+	}
+
+	next, err := todo.NextOccurrence()
+	if err != nil {
+		return errors.Wrap(ErrBadRequest, err.Error())
+	}
+
+	if next == nil {
+		return nil
+	}
+
+	normalizeItems(next)
+
+	// The user was allowed to complete the ToDo, the next occurrence still belongs to the
+	// owner of the series and is created even if their quota is reached
+	if err := h.reserveQuota(next.Owner, false); err != nil {
+		return err
+	}
+
+	if err := h.unfiltered.Save(next); err != nil {
+		h.releaseQuota(next.Owner)
+		return repoError(err)
+	}
+
+	return nil
+}
+
+func (h *ToDoHandler) getOccurrences(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	count := defaultOccurrences
+	if c, ok := req.QueryStringParameters["count"]; ok {
+		n, err := strconv.Atoi(c)
+		if err != nil || n < 1 || n > maxOccurrences {
+			return CreateErrorResponse(errors.Wrapf(ErrBadRequest, "count must be between 1 and %d", maxOccurrences))
+		}
+		count = n
+	}
+
+	todo, err := h.repo.Get(req.PathParameters["id"])
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if todo == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	occurrences, err := todo.Occurrences(count)
+	if err != nil {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, err.Error()))
+	}
+
+	if occurrences == nil {
+		occurrences = []time.Time{}
+	}
+
+	return CreateOKResponse(occurrences)
+}
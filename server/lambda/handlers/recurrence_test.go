@@ -0,0 +1,213 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestRecurrence(t *testing.T) {
+	t.Run("CompleteRecurringToDo", testCompleteRecurringToDo)
+	t.Run("CompleteLastOccurrence", testCompleteLastOccurrence)
+	t.Run("EditorCompletesRecurringToDo", testEditorCompletesRecurringToDo)
+	t.Run("InvalidRecurrence", testInvalidRecurrence)
+	t.Run("GetOccurrences", testGetOccurrences)
+}
+
+// recurringRepo stores a ToDo due every weekday at 9:00 in New York, next due on Friday 2019-07-12
+func recurringRepo(recurrence string) (*RepoMock, map[string]*server.ToDo) {
+
+	ny, _ := time.LoadLocation("America/New_York")
+	due := time.Date(2019, time.July, 12, 9, 0, 0, 0, ny).UTC()
+
+	todos := map[string]*server.ToDo{
+		"standup": {
+			ID:         "standup",
+			Title:      "Standup",
+			Due:        &due,
+			Recurrence: recurrence,
+			TimeZone:   "America/New_York",
+			Items:      []server.ChecklistItem{{ID: "notes", Title: "Notes", Completed: true}},
+			Owner:      testUser,
+		},
+	}
+
+	m := &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			if t, ok := todos[id]; ok {
+				c := *t
+				return &c, nil
+			}
+			return nil, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			c := *todo
+			todos[todo.ID] = &c
+			return nil
+		},
+	}
+
+	return m, todos
+}
+
+func testCompleteRecurringToDo(t *testing.T) {
+
+	m, todos := recurringRepo("FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;COUNT=5")
+
+	resp, err := handlers.NewToDoHandler(m).Handle(patchRequest("standup", `{"completed":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if !todos["standup"].Completed {
+		t.Fatal("Expected ToDo to be completed")
+	}
+
+	next, ok := todos[""]
+	if !ok {
+		t.Fatal("Expected next occurrence to be saved")
+	}
+
+	if next.Completed || next.Items[0].Completed || next.Items[0].ID == "notes" {
+		t.Fatalf("Expected next occurrence to be open with fresh items, got %+v", next)
+	}
+
+	// Monday 9:00 in New York
+	if d := next.Due.Format(time.RFC3339); d != "2019-07-15T13:00:00Z" {
+		t.Fatalf("Expected next occurrence due on 2019-07-15T13:00:00Z, got %s", d)
+	}
+
+	if next.Recurrence != "FREQ=WEEKLY;COUNT=4;BYDAY=MO,TU,WE,TH,FR" {
+		t.Fatalf("Expected the rest of the series, got %s", next.Recurrence)
+	}
+}
+
+func testEditorCompletesRecurringToDo(t *testing.T) {
+
+	m, todos := recurringRepo("FREQ=DAILY")
+	todos["standup"].Owner = otherUser
+	todos["standup"].Members = map[string]server.Role{testUser: server.RoleEditor}
+	todos["standup"].Rank = "m"
+
+	resp, err := handlers.NewToDoHandler(m).Handle(patchRequest("standup", `{"completed":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	next, ok := todos[""]
+	if !ok {
+		t.Fatal("Expected next occurrence to be saved")
+	}
+
+	if next.Owner != otherUser {
+		t.Fatalf("Expected next occurrence to belong to %s, got %s", otherUser, next.Owner)
+	}
+
+	if next.Rank != "" {
+		t.Fatalf("Expected next occurrence to be unranked, got %s", next.Rank)
+	}
+}
+
+func testCompleteLastOccurrence(t *testing.T) {
+
+	m, todos := recurringRepo("FREQ=DAILY;COUNT=1")
+
+	resp, err := handlers.NewToDoHandler(m).Handle(patchRequest("standup", `{"completed":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if len(todos) != 1 {
+		t.Fatalf("Expected no next occurrence, got %d ToDos", len(todos))
+	}
+}
+
+func testInvalidRecurrence(t *testing.T) {
+
+	for _, body := range []string{
+		`{"recurrence":"FREQ=SOMETIMES"}`,
+		`{"timeZone":"Mars/Olympus_Mons"}`,
+		`{"due":null}`,
+	} {
+		m, _ := recurringRepo("FREQ=DAILY")
+
+		resp, err := handlers.NewToDoHandler(m).Handle(patchRequest("standup", body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected %d http response code for %s, got %d", http.StatusBadRequest, body, resp.StatusCode)
+		}
+
+		if m.SaveInvoked {
+			t.Fatal("Save invoked")
+		}
+	}
+}
+
+func testGetOccurrences(t *testing.T) {
+
+	m, _ := recurringRepo("FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR")
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext:        testRequestContext,
+		Resource:              "/todos/{id}/occurrences",
+		PathParameters:        map[string]string{"id": "standup"},
+		QueryStringParameters: map[string]string{"count": "3"},
+		HTTPMethod:            http.MethodGet,
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var occurrences []time.Time
+	if err := json.Unmarshal([]byte(resp.Body), &occurrences); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"2019-07-15T13:00:00Z", "2019-07-16T13:00:00Z", "2019-07-17T13:00:00Z"}
+	if len(occurrences) != len(want) {
+		t.Fatalf("Expected %d occurrences, got %d", len(want), len(occurrences))
+	}
+
+	for i, w := range want {
+		if o := occurrences[i].UTC().Format(time.RFC3339); o != w {
+			t.Fatalf("Expected occurrence %d at %s, got %s", i, w, o)
+		}
+	}
+
+	req.QueryStringParameters["count"] = "1000"
+
+	resp, err = handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
@@ -1,19 +1,34 @@
 package handlers_test
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
 )
 
 // ClientMock is used to mock a client that uses makes call to DynamoDBAPI
 type RepoMock struct {
-	GetFn         func(string) (*server.ToDo, error)
-	GetAllFn      func() ([]server.ToDo, error)
-	SaveFn        func(todo *server.ToDo) error
-	DeleteFn      func(string) error
-	GetInvoked    bool
-	GetAllInvoked bool
-	SaveInvoked   bool
-	DeleteInvoked bool
+	GetFn                func(string) (*server.ToDo, error)
+	GetAllFn             func() ([]server.ToDo, error)
+	GetChildrenFn        func(string) ([]server.ToDo, error)
+	GetByListFn          func(string) ([]server.ToDo, error)
+	GetByOwnerFn         func(string) ([]server.ToDo, error)
+	ModifiedSinceFn      func(string, time.Time) ([]server.ToDo, error)
+	MoveFn               func(id, from, to string) error
+	SaveFn               func(todo *server.ToDo) error
+	DeleteFn             func(string) error
+	GetInvoked           bool
+	GetAllInvoked        bool
+	GetChildrenInvoked   bool
+	GetByListInvoked     bool
+	GetByOwnerInvoked    bool
+	ModifiedSinceInvoked bool
+	MoveInvoked          bool
+	SaveInvoked          bool
+	DeleteInvoked        bool
 }
 
 // Get returns a ToDo by its ID
@@ -28,6 +43,39 @@ func (m *RepoMock) GetAll() ([]server.ToDo, error) {
 	return m.GetAllFn()
 }
 
+// GetChildren returns the ToDos whose parent is the given ToDo, or none if GetChildrenFn is not set
+func (m *RepoMock) GetChildren(parentID string) ([]server.ToDo, error) {
+	m.GetChildrenInvoked = true
+	if m.GetChildrenFn == nil {
+		return nil, nil
+	}
+	return m.GetChildrenFn(parentID)
+}
+
+// GetByList returns the ToDos in the given list
+func (m *RepoMock) GetByList(listID string) ([]server.ToDo, error) {
+	m.GetByListInvoked = true
+	return m.GetByListFn(listID)
+}
+
+// GetByOwner returns the ToDos of the given owner
+func (m *RepoMock) GetByOwner(owner string) ([]server.ToDo, error) {
+	m.GetByOwnerInvoked = true
+	return m.GetByOwnerFn(owner)
+}
+
+// ModifiedSince returns the ToDos of the given owner modified after since
+func (m *RepoMock) ModifiedSince(owner string, since time.Time) ([]server.ToDo, error) {
+	m.ModifiedSinceInvoked = true
+	return m.ModifiedSinceFn(owner, since)
+}
+
+// Move sets the list of a ToDo
+func (m *RepoMock) Move(id, from, to string) error {
+	m.MoveInvoked = true
+	return m.MoveFn(id, from, to)
+}
+
 // Save creates or updates a ToDo
 func (m *RepoMock) Save(todo *server.ToDo) error {
 	m.SaveInvoked = true
@@ -39,3 +87,417 @@ func (m *RepoMock) Delete(id string) error {
 	m.DeleteInvoked = true
 	return m.DeleteFn(id)
 }
+
+// ListRepoMock is used to mock a list repository
+type ListRepoMock struct {
+	Lists         map[string]*server.List
+	SaveInvoked   bool
+	DeleteInvoked bool
+}
+
+// Get returns a list by its ID
+func (m *ListRepoMock) Get(id string) (*server.List, error) {
+	l, ok := m.Lists[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *l
+	return &c, nil
+}
+
+// GetAll returns all lists
+func (m *ListRepoMock) GetAll() ([]server.List, error) {
+	lists := []server.List{}
+	for _, l := range m.Lists {
+		lists = append(lists, *l)
+	}
+	return lists, nil
+}
+
+// Save creates or updates a list
+func (m *ListRepoMock) Save(list *server.List) error {
+	m.SaveInvoked = true
+	if list.ID == "" {
+		list.ID = "new-list"
+	}
+	c := *list
+	m.Lists[list.ID] = &c
+	return nil
+}
+
+// Delete permanently removes a list
+func (m *ListRepoMock) Delete(id string) error {
+	m.DeleteInvoked = true
+	delete(m.Lists, id)
+	return nil
+}
+
+// LabelRepoMock is used to mock a label repository
+type LabelRepoMock struct {
+	Labels        map[string]*server.Label
+	SaveInvoked   bool
+	DeleteInvoked bool
+}
+
+// Get returns a label by its ID
+func (m *LabelRepoMock) Get(id string) (*server.Label, error) {
+	l, ok := m.Labels[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *l
+	return &c, nil
+}
+
+// GetAll returns all labels
+func (m *LabelRepoMock) GetAll() ([]server.Label, error) {
+	labels := []server.Label{}
+	for _, l := range m.Labels {
+		labels = append(labels, *l)
+	}
+	return labels, nil
+}
+
+// Save creates or updates a label
+func (m *LabelRepoMock) Save(label *server.Label) error {
+	m.SaveInvoked = true
+	if label.ID == "" {
+		label.ID = "new-label"
+	}
+	c := *label
+	m.Labels[label.ID] = &c
+	return nil
+}
+
+// Delete permanently removes a label
+func (m *LabelRepoMock) Delete(id string) error {
+	m.DeleteInvoked = true
+	delete(m.Labels, id)
+	return nil
+}
+
+// IdempotencyRepoMock is used to mock an idempotency key repository
+type IdempotencyRepoMock struct {
+	Records        map[string]*database.IdempotencyRecord
+	SaveErr        error
+	DeleteErr      error
+	SaveInvoked    bool
+	DeleteInvoked  bool
+	ReserveInvoked bool
+}
+
+// Get returns the record stored for an idempotency key
+func (m *IdempotencyRepoMock) Get(key string) (*database.IdempotencyRecord, error) {
+	if r, ok := m.Records[key]; ok && r.ExpiresAt.After(time.Now()) {
+		return r, nil
+	}
+	return nil, nil
+}
+
+// Reserve saves a record only if its key is not already stored
+func (m *IdempotencyRepoMock) Reserve(record *database.IdempotencyRecord) error {
+	m.ReserveInvoked = true
+	if r, ok := m.Records[record.Key]; ok && r.ExpiresAt.After(time.Now()) {
+		return database.ErrExists
+	}
+	r := *record
+	m.Records[record.Key] = &r
+	return nil
+}
+
+// Save creates or replaces the record of an idempotency key
+func (m *IdempotencyRepoMock) Save(record *database.IdempotencyRecord) error {
+	m.SaveInvoked = true
+	if m.SaveErr != nil {
+		return m.SaveErr
+	}
+	r := *record
+	m.Records[record.Key] = &r
+	return nil
+}
+
+// Delete removes an idempotency key
+func (m *IdempotencyRepoMock) Delete(key string) error {
+	m.DeleteInvoked = true
+	if m.DeleteErr != nil {
+		return m.DeleteErr
+	}
+	delete(m.Records, key)
+	return nil
+}
+
+// RateLimitRepoMock is used to mock a rate limit repository
+type RateLimitRepoMock struct {
+	Buckets map[string]time.Time
+}
+
+// Take takes a token from the bucket of a key, which is stored as the time it is full again
+func (m *RateLimitRepoMock) Take(key string, capacity int, interval time.Duration, now time.Time) (time.Time, error) {
+	fullAt := m.Buckets[key]
+	if fullAt.Before(now) {
+		fullAt = now
+	}
+	if fullAt.After(now.Add(time.Duration(capacity-1) * interval)) {
+		return time.Time{}, database.ErrLimitReached
+	}
+	m.Buckets[key] = fullAt.Add(interval)
+	return m.Buckets[key], nil
+}
+
+// QuotaRepoMock is used to mock a quota repository
+type QuotaRepoMock struct {
+	Counts map[string]int
+}
+
+// Reserve adds one to the count of a user below max
+func (m *QuotaRepoMock) Reserve(owner string, max int) error {
+	if max > 0 && m.Counts[owner] >= max {
+		return database.ErrLimitReached
+	}
+	m.Counts[owner]++
+	return nil
+}
+
+// Release subtracts one from the count of a user
+func (m *QuotaRepoMock) Release(owner string) error {
+	m.Counts[owner]--
+	return nil
+}
+
+// CommentRepoMock is used to mock a comment repository
+type CommentRepoMock struct {
+	Comments    []*server.Comment
+	SaveInvoked bool
+}
+
+// Get returns a comment of a ToDo by its ID
+func (m *CommentRepoMock) Get(todoID, id string) (*server.Comment, error) {
+	for _, c := range m.Comments {
+		if c.ToDoID == todoID && c.ID == id {
+			cc := *c
+			return &cc, nil
+		}
+	}
+	return nil, nil
+}
+
+// List returns a page of the comments of a ToDo
+func (m *CommentRepoMock) List(todoID, cursor string, limit int) ([]server.Comment, string, error) {
+	page := []server.Comment{}
+	for _, c := range m.Comments {
+		if c.ToDoID == todoID && c.ID > cursor {
+			page = append(page, *c)
+		}
+	}
+	if len(page) > limit {
+		page = page[:limit]
+		return page, page[limit-1].ID, nil
+	}
+	return page, "", nil
+}
+
+// Save creates or updates a comment
+func (m *CommentRepoMock) Save(comment *server.Comment) error {
+	m.SaveInvoked = true
+	if comment.ID == "" {
+		comment.ID = fmt.Sprintf("c%03d", len(m.Comments))
+		c := *comment
+		m.Comments = append(m.Comments, &c)
+		return nil
+	}
+	for i, c := range m.Comments {
+		if c.ID == comment.ID {
+			cc := *comment
+			m.Comments[i] = &cc
+		}
+	}
+	return nil
+}
+
+// DeleteAll deletes every comment of a ToDo
+func (m *CommentRepoMock) DeleteAll(todoID string) error {
+	kept := []*server.Comment{}
+	for _, c := range m.Comments {
+		if c.ToDoID != todoID {
+			kept = append(kept, c)
+		}
+	}
+	m.Comments = kept
+	return nil
+}
+
+// BlobStoreMock is used to mock a blob store
+type BlobStoreMock struct {
+	Uploads []string
+	Deleted []string
+}
+
+// UploadURL returns a fake URL recording the key, type and size of the upload
+func (m *BlobStoreMock) UploadURL(key, contentType string, size int64) (string, error) {
+	m.Uploads = append(m.Uploads, key)
+	return fmt.Sprintf("https://blobs.test/%s?type=%s&size=%d", key, contentType, size), nil
+}
+
+// DownloadURL returns a fake URL of the blob
+func (m *BlobStoreMock) DownloadURL(key, name string) (string, error) {
+	return "https://blobs.test/" + key + "?name=" + name, nil
+}
+
+// Put is not used by the handler
+func (m *BlobStoreMock) Put(key, contentType string, content []byte) error {
+	return nil
+}
+
+// Delete records the deleted key
+func (m *BlobStoreMock) Delete(key string) error {
+	m.Deleted = append(m.Deleted, key)
+	return nil
+}
+
+// WebhookRepoMock is used to mock a webhook repository
+type WebhookRepoMock struct {
+	Hooks         map[string]*server.Webhook
+	SaveInvoked   bool
+	DeleteInvoked bool
+}
+
+// Get returns a webhook by its ID
+func (m *WebhookRepoMock) Get(id string) (*server.Webhook, error) {
+	w, ok := m.Hooks[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *w
+	return &c, nil
+}
+
+// GetByOwner returns the webhooks of a user
+func (m *WebhookRepoMock) GetByOwner(owner string) ([]server.Webhook, error) {
+	hooks := []server.Webhook{}
+	for _, w := range m.Hooks {
+		if w.Owner == owner {
+			hooks = append(hooks, *w)
+		}
+	}
+	return hooks, nil
+}
+
+// Save creates or updates a webhook
+func (m *WebhookRepoMock) Save(webhook *server.Webhook) error {
+	m.SaveInvoked = true
+	if webhook.ID == "" {
+		webhook.ID = "new-webhook"
+	}
+	c := *webhook
+	m.Hooks[webhook.ID] = &c
+	return nil
+}
+
+// Delete permanently removes a webhook
+func (m *WebhookRepoMock) Delete(id string) error {
+	m.DeleteInvoked = true
+	delete(m.Hooks, id)
+	return nil
+}
+
+// DeliveryRepoMock is used to mock a delivery and a dead-letter repository
+type DeliveryRepoMock struct {
+	Deliveries []*server.Delivery
+}
+
+// List returns the deliveries of a webhook
+func (m *DeliveryRepoMock) List(webhookID, cursor string, limit int) ([]server.Delivery, string, error) {
+	page := []server.Delivery{}
+	for _, d := range m.Deliveries {
+		if d.WebhookID == webhookID {
+			page = append(page, *d)
+		}
+	}
+	return page, "", nil
+}
+
+// Due returns no deliveries
+func (m *DeliveryRepoMock) Due(now time.Time, limit int) ([]server.Delivery, error) {
+	return nil, nil
+}
+
+// Save creates or updates a delivery
+func (m *DeliveryRepoMock) Save(delivery *server.Delivery) error {
+	if delivery.ID == "" {
+		delivery.ID = fmt.Sprintf("d%03d", len(m.Deliveries))
+		c := *delivery
+		m.Deliveries = append(m.Deliveries, &c)
+		return nil
+	}
+	for i, d := range m.Deliveries {
+		if d.ID == delivery.ID {
+			c := *delivery
+			m.Deliveries[i] = &c
+		}
+	}
+	return nil
+}
+
+// TombstoneRepoMock is used to mock a tombstone repository
+type TombstoneRepoMock struct {
+	Tombstones []server.Tombstone
+	Invoked    bool
+}
+
+// Since returns the tombstones deleted after since
+func (m *TombstoneRepoMock) Since(user string, since time.Time) ([]server.Tombstone, error) {
+	m.Invoked = true
+	tombstones := []server.Tombstone{}
+	for _, t := range m.Tombstones {
+		if t.Deleted.After(since) {
+			tombstones = append(tombstones, t)
+		}
+	}
+	return tombstones, nil
+}
+
+// Add records a tombstone
+func (m *TombstoneRepoMock) Add(readers []string, tombstone server.Tombstone) error {
+	m.Tombstones = append(m.Tombstones, tombstone)
+	return nil
+}
+
+// ShareRepoMock is used to mock a share repository
+type ShareRepoMock struct {
+	// Changed are the times the ToDos shared with testUser changed at, by ID
+	Changed map[string]time.Time
+}
+
+// Share records that a ToDo shared with a member changed
+func (m *ShareRepoMock) Share(member, todoID string, changed time.Time) error {
+	m.Changed[todoID] = changed
+	return nil
+}
+
+// Unshare removes a shared ToDo
+func (m *ShareRepoMock) Unshare(member, todoID string) error {
+	delete(m.Changed, todoID)
+	return nil
+}
+
+// ChangedSince returns the IDs of the shared ToDos which changed after since
+func (m *ShareRepoMock) ChangedSince(member string, since time.Time) ([]string, error) {
+	ids := []string{}
+	for id, changed := range m.Changed {
+		if changed.After(since) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// NotifierMock records the notifications sent
+type NotifierMock struct {
+	Sent []notify.Message
+}
+
+// Notify records a notification
+func (m *NotifierMock) Notify(to *server.Profile, msg notify.Message) error {
+	m.Sent = append(m.Sent, msg)
+	return nil
+}
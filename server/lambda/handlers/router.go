@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Route is an API Gateway resource and HTTP method served by the ToDoHandler
+type Route struct {
+	Method   string
+	Resource string
+}
+
+// route binds a Route to the method handling it
+type route struct {
+	Route
+	// public routes can be called without being authenticated
+	public bool
+	doc    *operation
+	handle func(h *ToDoHandler, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+}
+
+// Routes returns the routes served by the ToDoHandler
+func Routes() []Route {
+
+	var rs []Route
+	for _, r := range routes() {
+		rs = append(rs, r.Route)
+	}
+
+	return rs
+}
+
+// match returns the route serving the request
+func match(req events.APIGatewayProxyRequest) (*route, error) {
+
+	found := false
+
+	for _, r := range routes() {
+		if r.Resource != req.Resource {
+			continue
+		}
+		found = true
+		if r.Method == req.HTTPMethod {
+			r := r
+			return &r, nil
+		}
+	}
+
+	if found {
+		return nil, ErrMethodNotAllowed
+	}
+
+	return nil, ErrNotFound
+}
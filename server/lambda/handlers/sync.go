@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/pkg/errors"
+)
+
+const (
+	// syncOverlap is subtracted from the time of sync tokens, so that changes saved while the
+	// changes are read are sent again rather than missed
+	syncOverlap = 10 * time.Second
+	// maxSyncMutations is the maximum number of mutations pushed at once
+	maxSyncMutations = 100
+)
+
+// The outcomes of the mutations pushed by clients
+const (
+	// syncApplied is the status of mutations made to the current version of their ToDo
+	syncApplied = "applied"
+	// syncMerged is the status of mutations merged with the changes made on the server since
+	// their base version, the fields which could not be merged are returned as conflicts
+	syncMerged = "merged"
+	// syncCreated is the status of mutations of ToDos created offline, which the server did
+	// not have
+	syncCreated = "created"
+	// syncGone is the status of mutations of ToDos deleted on the server, which are dropped
+	syncGone = "gone"
+	// syncRejected is the status of invalid mutations, which are dropped
+	syncRejected = "rejected"
+)
+
+// toDoChanges are the changes of the ToDos of a user since a sync token
+type toDoChanges struct {
+	// ToDos were created or updated since the token
+	ToDos []server.ToDo `json:"todos"`
+	// Deleted are the tombstones of the ToDos deleted since the token
+	Deleted []server.Tombstone `json:"deleted"`
+	// Full is set when ToDos are all the ToDos of the user, because no token was given or it
+	// expired. Clients must then drop the ToDos they have which are not in ToDos.
+	Full bool `json:"full,omitempty"`
+	// Token is the sync token to get the next changes with
+	Token string `json:"token"`
+}
+
+// WithSync lets clients sync the changes of ToDos since their last sync, reading the tombstones
+// of the ToDos they can no longer read and the changes of the ToDos shared with them from the
+// given repositories. Tokens older than the retention of the tombstones get a full resync.
+func WithSync(tombstones database.TombstoneRepo, shares database.ShareRepo, retention time.Duration) Option {
+	return func(h *ToDoHandler) {
+		h.tombstones = tombstones
+		h.shares = shares
+		h.syncRetention = retention
+	}
+}
+
+// syncToken returns the opaque sync token of a time
+func syncToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixNano(), 10)))
+}
+
+// parseSyncToken returns the time of a sync token
+func parseSyncToken(token string) (time.Time, error) {
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, errors.Wrap(ErrBadRequest, "invalid sync token")
+	}
+
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrap(ErrBadRequest, "invalid sync token")
+	}
+
+	return time.Unix(0, n), nil
+}
+
+// getChanges returns the ToDos created, updated and deleted since the sync token, or all the
+// ToDos of the user when the token is missing or older than the retention of the tombstones.
+// The ToDos owned by the user are queried by modTime, and the ToDos shared with the user are
+// read from the shares which changed since the token.
+func (h *ToDoHandler) getChanges(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.tombstones == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	now := time.Now()
+	changes := toDoChanges{
+		Deleted: []server.Tombstone{},
+		Full:    true,
+		Token:   syncToken(now.Add(-syncOverlap)),
+	}
+
+	var since time.Time
+	if token, ok := req.QueryStringParameters["since"]; ok && token != "" {
+		t, err := parseSyncToken(token)
+		if err != nil {
+			return CreateErrorResponse(err)
+		}
+		since = t
+		changes.Full = since.Before(now.Add(-h.syncRetention))
+	}
+
+	if changes.Full {
+		since = time.Time{}
+	}
+
+	todos, err := h.repo.ModifiedSince(h.user, since)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	shared, err := h.sharedSince(since)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	changes.ToDos = append(todos, shared...)
+	for i := range changes.ToDos {
+		withProgress(&changes.ToDos[i])
+	}
+
+	if !changes.Full {
+		if changes.Deleted, err = h.tombstones.Since(h.user, since); err != nil {
+			return CreateErrorResponse(repoError(err))
+		}
+	}
+
+	return CreateOKResponse(changes)
+}
+
+// sharedSince returns the ToDos shared with the user which changed after since, skipping the ToDos
+// deleted or unshared since their share was read, whose tombstones are then sent
+func (h *ToDoHandler) sharedSince(since time.Time) ([]server.ToDo, error) {
+
+	ids, err := h.shares.ChangedSince(h.user, since)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	todos := []server.ToDo{}
+	for _, id := range ids {
+
+		todo, err := h.repo.Get(id)
+		if errors.Cause(err) == policy.ErrForbidden {
+			continue
+		}
+		if err != nil {
+			return nil, repoError(err)
+		}
+
+		if todo != nil {
+			todos = append(todos, *todo)
+		}
+	}
+
+	return todos, nil
+}
+
+// accessible returns the ToDos the user owns and the ToDos shared with them, read through the
+// owner index and the shares rather than by scanning every ToDo. Without sync, the ToDos are
+// scanned and filtered by the policy.
+func (h *ToDoHandler) accessible() ([]server.ToDo, error) {
+
+	if h.shares == nil {
+		todos, err := h.repo.GetAll()
+		if err != nil {
+			return nil, repoError(err)
+		}
+		return todos, nil
+	}
+
+	owned, err := h.repo.GetByOwner(h.user)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	shared, err := h.sharedSince(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(owned, shared...), nil
+}
+
+// syncPush are the changes clients made offline, applied in order
+type syncPush struct {
+	Mutations []syncMutation `json:"mutations" schema:"required"`
+}
+
+// syncMutation is a change a client made to its copy of a ToDo
+type syncMutation struct {
+	ID string `json:"id" schema:"required,format=uuid"`
+	// BaseVersion is the modTime of the ToDo as last synced by the client
+	BaseVersion time.Time `json:"baseVersion" schema:"required"`
+	// Base is the ToDo as last synced by the client, without the fields set by the server
+	Base *server.ToDo `json:"base" schema:"required"`
+	// ToDo is the ToDo as changed by the client, it is ignored when Deleted is set
+	ToDo *server.ToDo `json:"todo,omitempty"`
+	// Deleted is set when the client deleted the ToDo
+	Deleted bool `json:"deleted,omitempty"`
+	// Changed is when the client made the change, the last writer wins the fields changed on
+	// both sides
+	Changed time.Time `json:"changed" schema:"required"`
+}
+
+// syncResult is the outcome of a mutation
+type syncResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// ToDo is the ToDo after the mutation, absent if it was deleted
+	ToDo      *server.ToDo      `json:"todo,omitempty"`
+	Conflicts []server.Conflict `json:"conflicts,omitempty"`
+	// Error is why the mutation was rejected
+	Error string `json:"error,omitempty"`
+}
+
+// syncPushResponse has the result of each mutation, in order
+type syncPushResponse struct {
+	Results []syncResult `json:"results"`
+}
+
+// syncPushSchema validates the mutations pushed by clients
+var syncPushSchema = jsonschema.Generate(syncPush{})
+
+// postSync applies the changes clients made offline. Each mutation is merged with the changes
+// made on the server since its base version and has its own result, so that an invalid mutation
+// does not reject the others.
+func (h *ToDoHandler) postSync(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	if h.tombstones == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	received := time.Now()
+
+	var push syncPush
+	if err := decode(syncPushSchema, req.Body, &push); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if len(push.Mutations) > maxSyncMutations {
+		return CreateErrorResponse(errors.Wrapf(ErrBadRequest, "at most %d mutations can be pushed at once", maxSyncMutations))
+	}
+
+	resp := syncPushResponse{Results: []syncResult{}}
+
+	for _, m := range push.Mutations {
+
+		result, err := h.applyMutation(m, received)
+
+		switch errors.Cause(err) {
+		case nil:
+		case ErrBadRequest, ErrForbidden, ErrNotFound, ErrConflict, ErrUnprocessable:
+			result = syncResult{ID: m.ID, Status: syncRejected, Error: err.Error()}
+		default:
+			return CreateErrorResponse(err)
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	return CreateOKResponse(resp)
+}
+
+// applyMutation merges a mutation with the current version of its ToDo and saves the result.
+// The mutations of ToDos the server does not have create them, unless they were deleted.
+func (h *ToDoHandler) applyMutation(m syncMutation, received time.Time) (syncResult, error) {
+
+	current, err := h.repo.Get(m.ID)
+	if err != nil {
+		return syncResult{}, repoError(err)
+	}
+
+	if current == nil {
+		return h.applyCreation(m)
+	}
+
+	result := syncResult{ID: m.ID, Status: syncApplied}
+	if !current.ModTime.Equal(m.BaseVersion) {
+		result.Status = syncMerged
+	}
+
+	if m.Deleted {
+		return h.applyDeletion(current, result)
+	}
+
+	if m.ToDo == nil {
+		return syncResult{}, errors.Wrap(ErrBadRequest, "todo is required unless deleted is set")
+	}
+
+	todo, conflicts, err := server.Merge(m.Base, m.ToDo, current, m.Changed, received)
+	if err != nil {
+		return syncResult{}, errors.Wrapf(ErrInternal, "could not merge ToDo %s: %v", m.ID, err)
+	}
+
+	if err := validateMembers(todo); err != nil {
+		return syncResult{}, err
+	}
+
+	if err := validateRecurrence(todo); err != nil {
+		return syncResult{}, err
+	}
+
+	if err := validateReminders(todo); err != nil {
+		return syncResult{}, err
+	}
+
+	if err := h.checkParent(&todo); err != nil {
+		return syncResult{}, err
+	}
+
+	if err := h.checkList(todo.ListID, current.ListID); err != nil {
+		return syncResult{}, err
+	}
+
+	normalizeItems(&todo)
+
+	if err := h.checkDependencies(&todo, current.Completed); err != nil {
+		return syncResult{}, err
+	}
+
+	if err := h.repo.Save(&todo); err != nil {
+		return syncResult{}, repoError(err)
+	}
+
+	if err := h.scheduleNext(&todo, current.Completed); err != nil {
+		return syncResult{}, err
+	}
+
+	withProgress(&todo)
+	result.ToDo = &todo
+	if len(conflicts) > 0 {
+		result.Conflicts = conflicts
+	}
+
+	return result, nil
+}
+
+// applyCreation creates the ToDo of a mutation made offline, unless it has a tombstone since the
+// base version of the mutation, the server then deleted it and the mutation is dropped
+func (h *ToDoHandler) applyCreation(m syncMutation) (syncResult, error) {
+
+	tombstones, err := h.tombstones.Since(h.user, m.BaseVersion)
+	if err != nil {
+		return syncResult{}, repoError(err)
+	}
+
+	for _, t := range tombstones {
+		if t.ID == m.ID {
+			return syncResult{ID: m.ID, Status: syncGone}, nil
+		}
+	}
+
+	if m.Deleted {
+		return syncResult{ID: m.ID, Status: syncGone}, nil
+	}
+
+	if m.ToDo == nil {
+		return syncResult{}, errors.Wrap(ErrBadRequest, "todo is required unless deleted is set")
+	}
+
+	todo := *m.ToDo
+	todo.ID = m.ID
+
+	if err := h.checkNew(&todo); err != nil {
+		return syncResult{}, err
+	}
+
+	if err := h.reserveQuota(h.user, true); err != nil {
+		return syncResult{}, err
+	}
+
+	if err := h.repo.Save(&todo); err != nil {
+		h.releaseQuota(h.user)
+		return syncResult{}, repoError(err)
+	}
+
+	withProgress(&todo)
+	return syncResult{ID: m.ID, Status: syncCreated, ToDo: &todo}, nil
+}
+
+// applyDeletion deletes the ToDo of a mutation, moving its sub-tasks to its parent, unless it
+// changed on the server since the base version of the mutation, which is then a conflict
+func (h *ToDoHandler) applyDeletion(current *server.ToDo, result syncResult) (syncResult, error) {
+
+	if result.Status == syncMerged {
+		withProgress(current)
+		result.ToDo = current
+		result.Conflicts = []server.Conflict{{Field: "deleted", Client: true, Server: false}}
+		return result, nil
+	}
+
+	if err := h.detachChildren(current, childrenReparent); err != nil {
+		return syncResult{}, err
+	}
+
+	if err := h.purge(current); err != nil {
+		return syncResult{}, err
+	}
+
+	return result, nil
+}
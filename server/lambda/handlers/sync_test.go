@@ -0,0 +1,463 @@
+package handlers_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestSync(t *testing.T) {
+	t.Run("ChangesWithoutToken", testChangesWithoutToken)
+	t.Run("ChangesSinceToken", testChangesSinceToken)
+	t.Run("ChangesSharedToDos", testChangesSharedToDos)
+	t.Run("ChangesExpiredToken", testChangesExpiredToken)
+	t.Run("ChangesInvalidToken", testChangesInvalidToken)
+	t.Run("ListOwnedAndShared", testListOwnedAndShared)
+}
+
+type changesResponse struct {
+	ToDos   []server.ToDo      `json:"todos"`
+	Deleted []server.Tombstone `json:"deleted"`
+	Full    bool               `json:"full"`
+	Token   string             `json:"token"`
+}
+
+// syncRepos stores a ToDo of testUser modified an hour ago and one modified now, and a
+// tombstone of a ToDo deleted a minute ago
+func syncRepos() (*RepoMock, *TombstoneRepoMock) {
+
+	now := time.Now()
+	todos := []server.ToDo{
+		{ID: "old", Title: "Old", Owner: testUser, ModTime: now.Add(-time.Hour)},
+		{ID: "new", Title: "New", Owner: testUser, ModTime: now},
+	}
+
+	m := &RepoMock{
+		ModifiedSinceFn: func(owner string, since time.Time) ([]server.ToDo, error) {
+			modified := []server.ToDo{}
+			for _, todo := range todos {
+				if todo.Owner == owner && todo.ModTime.After(since) {
+					modified = append(modified, todo)
+				}
+			}
+			return modified, nil
+		},
+	}
+
+	tombstones := &TombstoneRepoMock{Tombstones: []server.Tombstone{{ID: "gone", Deleted: now.Add(-time.Minute)}}}
+
+	return m, tombstones
+}
+
+func getChanges(t *testing.T, m *RepoMock, tombstones *TombstoneRepoMock, since string) (events.APIGatewayProxyResponse, changesResponse) {
+	return getSharedChanges(t, m, tombstones, &ShareRepoMock{Changed: map[string]time.Time{}}, since)
+}
+
+func getSharedChanges(t *testing.T, m *RepoMock, tombstones *TombstoneRepoMock, shares *ShareRepoMock, since string) (events.APIGatewayProxyResponse, changesResponse) {
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/changes",
+		HTTPMethod:     http.MethodGet,
+	}
+
+	if since != "" {
+		req.QueryStringParameters = map[string]string{"since": since}
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithSync(tombstones, shares, 24*time.Hour)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes changesResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal([]byte(resp.Body), &changes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return resp, changes
+}
+
+func testChangesWithoutToken(t *testing.T) {
+
+	m, tombstones := syncRepos()
+
+	_, changes := getChanges(t, m, tombstones, "")
+
+	if !changes.Full || len(changes.ToDos) != 2 || changes.Token == "" {
+		t.Fatalf("Expected all the ToDos and a token, got %+v", changes)
+	}
+
+	if tombstones.Invoked {
+		t.Fatal("Expected no tombstones in a full resync")
+	}
+}
+
+func testChangesSinceToken(t *testing.T) {
+
+	m, tombstones := syncRepos()
+
+	_, first := getChanges(t, m, tombstones, "")
+
+	tombstones.Tombstones = append(tombstones.Tombstones, server.Tombstone{ID: "just-gone", Deleted: time.Now()})
+
+	_, changes := getChanges(t, m, tombstones, first.Token)
+
+	if changes.Full || len(changes.ToDos) != 1 || changes.ToDos[0].ID != "new" {
+		t.Fatalf("Expected only the ToDo modified since the token, got %+v", changes.ToDos)
+	}
+
+	if len(changes.Deleted) != 1 || changes.Deleted[0].ID != "just-gone" {
+		t.Fatalf("Expected only the tombstone of the ToDo deleted since the token, got %+v", changes.Deleted)
+	}
+}
+
+func testChangesSharedToDos(t *testing.T) {
+
+	m, tombstones := syncRepos()
+	m.GetFn = func(id string) (*server.ToDo, error) {
+		switch id {
+		case "shared":
+			return &server.ToDo{ID: id, Title: "Shared", Owner: otherUser, Members: map[string]server.Role{testUser: server.RoleViewer}}, nil
+		case "unshared":
+			return &server.ToDo{ID: id, Title: "Unshared", Owner: otherUser}, nil
+		default:
+			return nil, nil
+		}
+	}
+
+	since := time.Now().Add(-time.Minute)
+	shares := &ShareRepoMock{Changed: map[string]time.Time{
+		"shared":   time.Now(),
+		"unshared": time.Now(),
+		"deleted":  time.Now(),
+		"stale":    since.Add(-time.Hour),
+	}}
+
+	_, changes := getSharedChanges(t, m, tombstones, shares, base64Token(since))
+
+	ids := map[string]bool{}
+	for _, todo := range changes.ToDos {
+		ids[todo.ID] = true
+	}
+
+	if len(changes.ToDos) != 2 || !ids["new"] || !ids["shared"] {
+		t.Fatalf("Expected the owned and the shared ToDos changed since the token, got %+v", changes.ToDos)
+	}
+}
+
+func testListOwnedAndShared(t *testing.T) {
+
+	m := &RepoMock{
+		GetByOwnerFn: func(owner string) ([]server.ToDo, error) {
+			return []server.ToDo{{ID: "owned", Title: "Owned", Owner: owner}}, nil
+		},
+		GetFn: func(id string) (*server.ToDo, error) {
+			return &server.ToDo{ID: id, Title: "Shared", Owner: otherUser, Members: map[string]server.Role{testUser: server.RoleViewer}}, nil
+		},
+	}
+	shares := &ShareRepoMock{Changed: map[string]time.Time{"shared": time.Now()}}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos",
+		HTTPMethod:     http.MethodGet,
+	}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithSync(&TombstoneRepoMock{}, shares, 24*time.Hour)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var todos []server.ToDo
+	if err := json.Unmarshal([]byte(resp.Body), &todos); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos) != 2 {
+		t.Fatalf("Expected the owned and the shared ToDos, got %s", resp.Body)
+	}
+
+	if m.GetAllInvoked {
+		t.Fatal("Expected the ToDos not to be scanned")
+	}
+}
+
+func testChangesExpiredToken(t *testing.T) {
+
+	m, tombstones := syncRepos()
+
+	// A token of two days ago, older than the one day retention of tombstones
+	token := base64Token(time.Now().Add(-48 * time.Hour))
+
+	_, changes := getChanges(t, m, tombstones, token)
+
+	if !changes.Full || len(changes.ToDos) != 2 {
+		t.Fatalf("Expected a full resync, got %+v", changes)
+	}
+}
+
+func testChangesInvalidToken(t *testing.T) {
+
+	m, tombstones := syncRepos()
+
+	resp, _ := getChanges(t, m, tombstones, "not a token")
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+// base64Token returns the sync token of a time
+func base64Token(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixNano(), 10)))
+}
+
+func TestSyncPush(t *testing.T) {
+	t.Run("PushUnchangedOnServer", testPushUnchangedOnServer)
+	t.Run("PushMergesFields", testPushMergesFields)
+	t.Run("PushLastWriterWins", testPushLastWriterWins)
+	t.Run("PushItemsConflict", testPushItemsConflict)
+	t.Run("PushFutureChangeClamped", testPushFutureChangeClamped)
+	t.Run("PushDeletedOnServer", testPushDeletedOnServer)
+	t.Run("PushCreatesNewToDo", testPushCreatesNewToDo)
+	t.Run("PushDeleteChangedOnServer", testPushDeleteChangedOnServer)
+	t.Run("PushRejectsInvalidMutation", testPushRejectsInvalidMutation)
+}
+
+type pushResponse struct {
+	Results []struct {
+		ID        string            `json:"id"`
+		Status    string            `json:"status"`
+		ToDo      *server.ToDo      `json:"todo"`
+		Conflicts []server.Conflict `json:"conflicts"`
+		Error     string            `json:"error"`
+	} `json:"results"`
+}
+
+// pushRepo stores the ToDos of testUser, saving them with a new modTime
+func pushRepo(todos ...server.ToDo) (*RepoMock, map[string]server.ToDo) {
+
+	stored := map[string]server.ToDo{}
+	for _, todo := range todos {
+		stored[todo.ID] = todo
+	}
+
+	m := &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			todo, ok := stored[id]
+			if !ok {
+				return nil, nil
+			}
+			return &todo, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			todo.ModTime = time.Now()
+			stored[todo.ID] = *todo
+			return nil
+		},
+		DeleteFn: func(id string) error {
+			delete(stored, id)
+			return nil
+		},
+	}
+
+	return m, stored
+}
+
+func push(t *testing.T, m *RepoMock, body string) pushResponse {
+	return pushTombstoned(t, m, &TombstoneRepoMock{}, body)
+}
+
+func pushTombstoned(t *testing.T, m *RepoMock, tombstones *TombstoneRepoMock, body string) pushResponse {
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/sync",
+		HTTPMethod:     http.MethodPost,
+		Body:           body,
+	}
+
+	shares := &ShareRepoMock{Changed: map[string]time.Time{}}
+
+	resp, err := handlers.NewToDoHandler(m, handlers.WithSync(tombstones, shares, 24*time.Hour)).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+
+	var r pushResponse
+	if err := json.Unmarshal([]byte(resp.Body), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+// mutation returns the body of a mutation of the ToDo testUUID, made at changed
+func mutation(base time.Time, baseToDo, todo string, changed time.Time) string {
+	return fmt.Sprintf(`{"id":%q,"baseVersion":%q,"base":%s,"todo":%s,"changed":%q}`,
+		testUUID, base.Format(time.RFC3339Nano), baseToDo, todo, changed.Format(time.RFC3339Nano))
+}
+
+func testPushUnchangedOnServer(t *testing.T) {
+
+	synced := time.Now().Add(-time.Hour)
+	m, stored := pushRepo(server.ToDo{ID: testUUID, Title: "Buy milk", Owner: testUser, ModTime: synced})
+
+	r := push(t, m, `{"mutations":[`+mutation(synced, `{"title":"Buy milk"}`, `{"title":"Buy oat milk","completed":true}`, time.Now())+`]}`)
+
+	if r.Results[0].Status != "applied" {
+		t.Fatalf("Expected the mutation to be applied, got %s", r.Results[0].Status)
+	}
+
+	if todo := stored[testUUID]; todo.Title != "Buy oat milk" || !todo.Completed {
+		t.Fatalf("Expected the changes of the client to be saved, got %+v", todo)
+	}
+}
+
+func testPushMergesFields(t *testing.T) {
+
+	synced := time.Now().Add(-time.Hour)
+	m, stored := pushRepo(server.ToDo{ID: testUUID, Title: "Buy milk", Owner: testUser, Completed: true, Tags: []string{"home", "urgent"}, ModTime: time.Now()})
+
+	r := push(t, m, `{"mutations":[`+mutation(synced,
+		`{"title":"Buy milk","tags":["home"]}`,
+		`{"title":"Buy oat milk","tags":["shopping"]}`,
+		synced.Add(time.Minute))+`]}`)
+
+	if r.Results[0].Status != "merged" || len(r.Results[0].Conflicts) != 0 {
+		t.Fatalf("Expected the mutation to be merged without conflicts, got %+v", r.Results[0])
+	}
+
+	todo := stored[testUUID]
+	if todo.Title != "Buy oat milk" || !todo.Completed {
+		t.Fatalf("Expected the title of the client and the completion of the server, got %+v", todo)
+	}
+
+	if len(todo.Tags) != 2 || todo.Tags[0] != "shopping" || todo.Tags[1] != "urgent" {
+		t.Fatalf("Expected the tag added on each side without the one removed by the client, got %v", todo.Tags)
+	}
+}
+
+func testPushLastWriterWins(t *testing.T) {
+
+	synced := time.Now().Add(-time.Hour)
+	m, stored := pushRepo(server.ToDo{ID: testUUID, Title: "Server title", Owner: testUser, ModTime: time.Now()})
+
+	// The client changed the title before the server did
+	push(t, m, `{"mutations":[`+mutation(synced, `{"title":"Title"}`, `{"title":"Client title"}`, synced.Add(time.Minute))+`]}`)
+
+	if title := stored[testUUID].Title; title != "Server title" {
+		t.Fatalf("Expected the title of the last writer, got %s", title)
+	}
+}
+
+func testPushItemsConflict(t *testing.T) {
+
+	synced := time.Now().Add(-time.Hour)
+	m, stored := pushRepo(server.ToDo{ID: testUUID, Title: "Trip", Owner: testUser, ModTime: time.Now(), Items: []server.ChecklistItem{{ID: "a", Title: "Tickets"}}})
+
+	r := push(t, m, `{"mutations":[`+mutation(synced, `{"title":"Trip"}`, `{"title":"Trip","items":[{"id":"b","title":"Passport"}]}`, time.Now())+`]}`)
+
+	conflicts := r.Results[0].Conflicts
+	if len(conflicts) != 1 || conflicts[0].Field != "items" {
+		t.Fatalf("Expected a conflict on the items, got %+v", conflicts)
+	}
+
+	if items := stored[testUUID].Items; len(items) != 1 || items[0].ID != "a" {
+		t.Fatalf("Expected the items of the server to be kept, got %+v", items)
+	}
+}
+
+func testPushFutureChangeClamped(t *testing.T) {
+
+	synced := time.Now().Add(-time.Hour)
+	m, stored := pushRepo(server.ToDo{ID: testUUID, Title: "Server title", Owner: testUser, ModTime: time.Now().Add(time.Minute)})
+
+	// The clock of the client is a day ahead, its change is still older than the server one
+	// once clamped to the time the server receives it
+	push(t, m, `{"mutations":[`+mutation(synced, `{"title":"Title"}`, `{"title":"Client title"}`, time.Now().Add(24*time.Hour))+`]}`)
+
+	if title := stored[testUUID].Title; title != "Server title" {
+		t.Fatalf("Expected the change from the future not to win, got %s", title)
+	}
+}
+
+func testPushDeletedOnServer(t *testing.T) {
+
+	synced := time.Now().Add(-time.Hour)
+	m, _ := pushRepo()
+	tombstones := &TombstoneRepoMock{Tombstones: []server.Tombstone{{ID: testUUID, Deleted: time.Now()}}}
+
+	r := pushTombstoned(t, m, tombstones, `{"mutations":[`+mutation(synced, `{"title":"Gone"}`, `{"title":"Changed"}`, time.Now())+`]}`)
+
+	if r.Results[0].Status != "gone" || m.SaveInvoked {
+		t.Fatalf("Expected the mutation of a deleted ToDo to be dropped, got %s", r.Results[0].Status)
+	}
+}
+
+func testPushCreatesNewToDo(t *testing.T) {
+
+	m, stored := pushRepo()
+
+	r := push(t, m, `{"mutations":[`+mutation(time.Time{}, `{"title":"New"}`, `{"title":"New"}`, time.Now())+`]}`)
+
+	if r.Results[0].Status != "created" || r.Results[0].ToDo == nil {
+		t.Fatalf("Expected the ToDo created offline to be created, got %+v", r.Results[0])
+	}
+
+	if todo, ok := stored[testUUID]; !ok || todo.Title != "New" || todo.Owner != testUser {
+		t.Fatalf("Expected the ToDo to be saved with its ID for the user, got %+v", todo)
+	}
+}
+
+func testPushDeleteChangedOnServer(t *testing.T) {
+
+	synced := time.Now().Add(-time.Hour)
+	m, stored := pushRepo(server.ToDo{ID: testUUID, Title: "Changed", Owner: testUser, ModTime: time.Now()})
+
+	body := fmt.Sprintf(`{"mutations":[{"id":%q,"baseVersion":%q,"base":{"title":"Title"},"deleted":true,"changed":%q}]}`,
+		testUUID, synced.Format(time.RFC3339Nano), time.Now().Format(time.RFC3339Nano))
+
+	r := push(t, m, body)
+
+	if len(r.Results[0].Conflicts) != 1 || r.Results[0].Conflicts[0].Field != "deleted" {
+		t.Fatalf("Expected a deletion conflict, got %+v", r.Results[0])
+	}
+
+	if _, ok := stored[testUUID]; !ok {
+		t.Fatal("Expected the ToDo changed on the server to be kept")
+	}
+}
+
+func testPushRejectsInvalidMutation(t *testing.T) {
+
+	synced := time.Now().Add(-time.Hour)
+	m, stored := pushRepo(server.ToDo{ID: testUUID, Title: "Title", Owner: testUser, ModTime: synced})
+
+	r := push(t, m, `{"mutations":[`+
+		mutation(synced, `{"title":"Title"}`, `{"title":"Title","recurrence":"FREQ=SOMETIMES"}`, time.Now())+`,`+
+		mutation(synced, `{"title":"Title"}`, `{"title":"Renamed"}`, time.Now())+`]}`)
+
+	if r.Results[0].Status != "rejected" || r.Results[0].Error == "" {
+		t.Fatalf("Expected the invalid mutation to be rejected, got %+v", r.Results[0])
+	}
+
+	if r.Results[1].Status != "applied" || stored[testUUID].Title != "Renamed" {
+		t.Fatalf("Expected the valid mutation to be applied, got %+v", r.Results[1])
+	}
+}
@@ -2,71 +2,507 @@ package handlers
 
 import (
 	"encoding/json"
+	"net/http"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/massimoselvi/serverless-todo-api-go/server"
 	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/massimoselvi/serverless-todo-api-go/server/publisher"
+	"github.com/massimoselvi/serverless-todo-api-go/server/ratelimit"
+	"github.com/massimoselvi/serverless-todo-api-go/server/webhook"
 	"github.com/pkg/errors"
 )
 
 // ToDoHandler provides a handle method to handle incoming AWS API Gateway request
 type ToDoHandler struct {
-	repo database.ToDoRepo
+	repo             database.ToDoRepo
+	lists            database.ListRepo
+	labels           database.LabelRepo
+	comments         database.CommentRepo
+	blobs            database.BlobStore
+	attachmentLimits server.AttachmentLimits
+	pub              server.EventPublisher
+	outbox           database.OutboxToDoRepo
+	webhooks         database.WebhookRepo
+	deliveries       database.DeliveryRepo
+	dispatcher       *webhook.Dispatcher
+	tombstones       database.TombstoneRepo
+	shares           database.ShareRepo
+	syncRetention    time.Duration
+	profiles         database.ProfileRepo
+	digestSecret     []byte
+	verifier         notify.Notifier
+	verifySecret     []byte
+	verifyURL        string
+	cors             *CORS
+	idempotency      database.IdempotencyRepo
+	idempotencyTTL   time.Duration
+	limiter          *ratelimit.Limiter
+	maxToDos         int
+	quotas           database.QuotaRepo
+
+	// unfiltered reads and saves the ToDos whatever the role of the user, so that the
+	// permissions on every ToDo affected by a change can be checked before making it, and the
+	// changes made on behalf of their owner are not attributed to the user
+	unfiltered database.ToDoRepo
+	// user is the authenticated user of the request being handled
+	user string
+}
+
+// Option configures an optional feature of a ToDoHandler
+type Option func(*ToDoHandler)
+
+// WithCORS applies the given CORS policy to every response and answers preflight requests
+func WithCORS(cors *CORS) Option {
+	return func(h *ToDoHandler) {
+		h.cors = cors
+	}
+}
+
+// WithEvents publishes a domain event for every change made to a ToDo
+func WithEvents(pub server.EventPublisher) Option {
+	return func(h *ToDoHandler) {
+		h.pub = pub
+	}
+}
+
+// WithOutbox records a domain event for every change made to a ToDo in the outbox of the given
+// repository, in the same transaction as the change. It replaces the repository of the handler.
+func WithOutbox(repo database.OutboxToDoRepo) Option {
+	return func(h *ToDoHandler) {
+		h.repo = repo
+		h.outbox = repo
+	}
 }
 
 // NewToDoHandler creates a new ToDo handler
-func NewToDoHandler(repo database.ToDoRepo) *ToDoHandler {
-	return &ToDoHandler{
+func NewToDoHandler(repo database.ToDoRepo, opts ...Option) *ToDoHandler {
+
+	h := &ToDoHandler{
 		repo: repo,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // Handle handles a request from AWS API Gateway and returns a response
 func (h *ToDoHandler) Handle(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 
-	switch req.HTTPMethod {
-	case "GET":
-		return h.get(req)
-	case "POST":
-		return h.post(req)
-	case "PUT":
-		return h.put(req)
-	case "DELETE":
-		return h.delete(req)
-	default:
-		return CreateErrorResponse(ErrMethodNotAllowed)
+	if h.cors == nil {
+		return h.handle(req)
+	}
+
+	// Preflight requests are sent by the browser without credentials
+	if req.HTTPMethod == http.MethodOptions {
+		return h.cors.Preflight(req)
 	}
+
+	resp, err := h.handle(req)
+	return h.cors.Apply(req, resp), err
 }
 
-func (h *ToDoHandler) get(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+func (h *ToDoHandler) handle(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	rt, err := match(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
 
-	if id, ok := req.PathParameters["id"]; ok {
-		return h.getOne(id)
+	if rt.public {
+		return rt.handle(h, req)
 	}
 
-	return h.getAll()
+	user, err := principal(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	// Every repository call made while handling the request is checked against the user's role
+	scoped := *h
+	// Events are only emitted for the changes the policy allows
+	repo := h.repo
+	switch {
+	case h.outbox != nil:
+		repo = publisher.NewOutboxToDoRepo(h.outbox, user)
+	case h.pub != nil:
+		repo = publisher.NewToDoRepo(repo, h.pub, user)
+	}
+	scoped.repo = policy.NewToDoRepo(repo, user)
+	scoped.unfiltered = repo
+	if h.lists != nil {
+		scoped.lists = policy.NewListRepo(h.lists, user)
+	}
+	if h.labels != nil {
+		scoped.labels = policy.NewLabelRepo(h.labels, user)
+	}
+	scoped.user = user
+
+	return scoped.rateLimited(req, func() (events.APIGatewayProxyResponse, error) {
+		return rt.handle(&scoped, req)
+	})
+}
+
+// routes returns the routes served by the ToDoHandler along with their documentation
+func routes() []route {
+	return []route{
+		{
+			Route:  Route{http.MethodGet, "/todos"},
+			doc:    op("listToDos", "List the ToDos the user owns or which are shared with them").query("parent", "Only list the sub-tasks of this ToDo").returns(http.StatusOK, arrayOf(ref("ToDo"))),
+			handle: (*ToDoHandler).getAll,
+		},
+		{
+			Route: Route{http.MethodPost, "/todos"},
+			doc: op("createToDo", "Create a ToDo owned by the user").
+				header(idempotencyKeyHeader, "Key making retries of the request return the original response").
+				body(ref("ToDo")).
+				returns(http.StatusOK, ref("ToDo")).
+				errors(http.StatusConflict, http.StatusUnprocessableEntity).
+				fails(http.StatusForbidden, "The user owns the maximum number of ToDos, or is not allowed to add the ToDo to its parent or list"),
+			handle: func(h *ToDoHandler, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				return h.idempotent(req, func() (events.APIGatewayProxyResponse, error) {
+					return h.post(req)
+				})
+			},
+		},
+		{
+			Route:  Route{http.MethodGet, "/todos/{id}"},
+			doc:    op("getToDo", "Get a ToDo").path("id").returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getOne,
+		},
+		{
+			Route:  Route{http.MethodPut, "/todos/{id}"},
+			doc:    op("updateToDo", "Replace a ToDo").path("id").body(ref("ToDo")).returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).put,
+		},
+		{
+			Route:  Route{http.MethodPatch, "/todos/{id}"},
+			doc:    op("patchToDo", "Update some fields of a ToDo").path("id").body(ref("ToDoPatch")).returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).patch,
+		},
+		{
+			Route:  Route{http.MethodDelete, "/todos/{id}"},
+			doc:    op("deleteToDo", "Delete a ToDo").path("id").query("children", "cascade to delete the sub-tasks, reparent to move them to the parent of the ToDo").returns(http.StatusOK, nil).errors(http.StatusNotFound, http.StatusConflict),
+			handle: (*ToDoHandler).delete,
+		},
+		{
+			Route:  Route{http.MethodGet, "/todos/{id}/tree"},
+			doc:    op("getToDoTree", "Get a ToDo along with its nested sub-tasks").path("id").returns(http.StatusOK, ref("ToDoNode")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getTree,
+		},
+		{
+			Route:  Route{http.MethodGet, "/todos/{id}/blockers"},
+			doc:    op("getToDoBlockers", "List the ToDos blocking a ToDo").path("id").returns(http.StatusOK, arrayOf(ref("ToDo"))).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getBlockers,
+		},
+		{
+			Route:  Route{http.MethodGet, "/todos/{id}/occurrences"},
+			doc:    op("listToDoOccurrences", "Preview the next due dates of a recurring ToDo").path("id").query("count", "Number of occurrences, 5 by default and at most 100").returns(http.StatusOK, arrayOf(&jsonschema.Schema{Type: "string", Format: "date-time"})).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getOccurrences,
+		},
+		{
+			Route: Route{http.MethodGet, "/todos/changes"},
+			doc: op("getToDoChanges", "List the ToDos created, updated and deleted since a sync token, or all the ToDos when the token expired").
+				query("since", "Sync token returned by the previous sync, all the ToDos are returned if empty").
+				returns(http.StatusOK, ref("ToDoChanges")).
+				errors(http.StatusBadRequest),
+			handle: (*ToDoHandler).getChanges,
+		},
+		{
+			Route: Route{http.MethodPost, "/todos/sync"},
+			doc: op("pushToDoChanges", "Apply the changes made offline to ToDos, merging them with the changes made since their base version").
+				body(ref("SyncPush")).
+				returns(http.StatusOK, ref("SyncPushResult")).
+				errors(http.StatusBadRequest),
+			handle: (*ToDoHandler).postSync,
+		},
+		{
+			Route:  Route{http.MethodGet, "/todos/next"},
+			doc:    op("listNextToDos", "List the open ToDos, each one after the ToDos blocking it").returns(http.StatusOK, arrayOf(ref("ToDo"))),
+			handle: (*ToDoHandler).getNext,
+		},
+		{
+			Route:  Route{http.MethodPost, "/todos/{id}/move"},
+			doc:    op("moveToDoBetween", "Move a ToDo before or after another ToDo of its list").path("id").body(ref("MoveToDoBetween")).returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).moveBetween,
+		},
+		{
+			Route:  Route{http.MethodPut, "/todos/{id}/list"},
+			doc:    op("moveToDo", "Move a ToDo to another list").path("id").body(ref("MoveToDo")).returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound, http.StatusConflict),
+			handle: (*ToDoHandler).moveToDo,
+		},
+		{
+			Route:  Route{http.MethodPost, "/todos/{id}/items"},
+			doc:    op("addChecklistItem", "Add a checklist item to a ToDo").path("id").body(ref("ChecklistItem")).returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).addItem,
+		},
+		{
+			Route:  Route{http.MethodPut, "/todos/{id}/items/order"},
+			doc:    op("reorderChecklistItems", "Reorder the checklist items of a ToDo").path("id").body(ref("ReorderChecklistItems")).returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).reorderItems,
+		},
+		{
+			Route:  Route{http.MethodPatch, "/todos/{id}/items/{itemId}"},
+			doc:    op("updateChecklistItem", "Rename, toggle or move a checklist item").path("id").path("itemId").body(ref("ChecklistItemPatch")).returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).updateItem,
+		},
+		{
+			Route:  Route{http.MethodDelete, "/todos/{id}/items/{itemId}"},
+			doc:    op("removeChecklistItem", "Remove a checklist item from a ToDo").path("id").path("itemId").returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).removeItem,
+		},
+		{
+			Route: Route{http.MethodGet, "/todos/{id}/comments"},
+			doc: op("listComments", "List the comments of a ToDo, oldest first").path("id").
+				query("limit", "Number of comments, 50 by default and at most 100").
+				query("cursor", "Cursor of the page, returned as next by the previous page").
+				returns(http.StatusOK, ref("CommentPage")).
+				errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getComments,
+		},
+		{
+			Route:  Route{http.MethodPost, "/todos/{id}/comments"},
+			doc:    op("createComment", "Comment a ToDo").path("id").body(ref("Comment")).returns(http.StatusOK, ref("Comment")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).postComment,
+		},
+		{
+			Route:  Route{http.MethodPatch, "/todos/{id}/comments/{commentId}"},
+			doc:    op("editComment", "Edit a comment, only allowed to its author and the owner of the ToDo").path("id").path("commentId").body(ref("Comment")).returns(http.StatusOK, ref("Comment")).errors(http.StatusNotFound, http.StatusConflict),
+			handle: (*ToDoHandler).patchComment,
+		},
+		{
+			Route:  Route{http.MethodDelete, "/todos/{id}/comments/{commentId}"},
+			doc:    op("deleteComment", "Delete a comment, only allowed to its author and the owner of the ToDo").path("id").path("commentId").returns(http.StatusOK, nil).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).deleteComment,
+		},
+		{
+			Route: Route{http.MethodPost, "/todos/{id}/attachments"},
+			doc: op("createAttachment", "Attach a file to a ToDo, returning the URL to upload its content to").path("id").
+				body(ref("Attachment")).
+				returns(http.StatusOK, ref("AttachmentUpload")).
+				errors(http.StatusNotFound, http.StatusUnprocessableEntity),
+			handle: (*ToDoHandler).postAttachment,
+		},
+		{
+			Route:  Route{http.MethodGet, "/todos/{id}/attachments/{attachmentId}"},
+			doc:    op("getAttachment", "Get an attachment of a ToDo along with the URL to download its content from").path("id").path("attachmentId").returns(http.StatusOK, ref("AttachmentDownload")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getAttachment,
+		},
+		{
+			Route:  Route{http.MethodDelete, "/todos/{id}/attachments/{attachmentId}"},
+			doc:    op("deleteAttachment", "Remove an attachment from a ToDo and delete its content").path("id").path("attachmentId").returns(http.StatusOK, ref("ToDo")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).deleteAttachment,
+		},
+		{
+			Route:  Route{http.MethodGet, "/lists"},
+			doc:    op("listLists", "List the lists of the user").query("archived", "true to include archived lists").returns(http.StatusOK, arrayOf(ref("List"))),
+			handle: (*ToDoHandler).getLists,
+		},
+		{
+			Route: Route{http.MethodPost, "/lists"},
+			doc: op("createList", "Create a list").
+				header(idempotencyKeyHeader, "Key making retries of the request return the original response").
+				body(ref("List")).
+				returns(http.StatusOK, ref("List")).
+				errors(http.StatusConflict, http.StatusUnprocessableEntity),
+			handle: func(h *ToDoHandler, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				return h.idempotent(req, func() (events.APIGatewayProxyResponse, error) {
+					return h.postList(req)
+				})
+			},
+		},
+		{
+			Route:  Route{http.MethodGet, "/lists/{id}"},
+			doc:    op("getList", "Get a list").path("id").returns(http.StatusOK, ref("List")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getOneList,
+		},
+		{
+			Route:  Route{http.MethodPut, "/lists/{id}"},
+			doc:    op("updateList", "Replace a list").path("id").body(ref("List")).returns(http.StatusOK, ref("List")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).putList,
+		},
+		{
+			Route: Route{http.MethodDelete, "/lists/{id}"},
+			doc: op("deleteList", "Delete a list").path("id").
+				query("todos", "cascade to delete the ToDos of the list, move to move them to the list given by to").
+				query("to", "List to move the ToDos to, none if empty").
+				returns(http.StatusOK, nil).
+				errors(http.StatusNotFound, http.StatusConflict),
+			handle: (*ToDoHandler).deleteList,
+		},
+		{
+			Route:  Route{http.MethodGet, "/lists/{id}/todos"},
+			doc:    op("listListToDos", "List the ToDos of a list").path("id").returns(http.StatusOK, arrayOf(ref("ToDo"))).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getListToDos,
+		},
+		{
+			Route:  Route{http.MethodGet, "/labels"},
+			doc:    op("listLabels", "List the labels of the user with the number of ToDos tagged with each").returns(http.StatusOK, arrayOf(ref("Label"))),
+			handle: (*ToDoHandler).getLabels,
+		},
+		{
+			Route:  Route{http.MethodPost, "/labels"},
+			doc:    op("createLabel", "Create a label").body(ref("Label")).returns(http.StatusOK, ref("Label")).errors(http.StatusConflict),
+			handle: (*ToDoHandler).postLabel,
+		},
+		{
+			Route:  Route{http.MethodGet, "/labels/{id}"},
+			doc:    op("getLabel", "Get a label").path("id").returns(http.StatusOK, ref("Label")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getOneLabel,
+		},
+		{
+			Route: Route{http.MethodPut, "/labels/{id}"},
+			doc: op("updateLabel", "Replace a label, renaming it in the tags of the ToDos").path("id").body(ref("Label")).
+				returns(http.StatusOK, ref("Label")).
+				returns(http.StatusAccepted, ref("Label")).
+				errors(http.StatusNotFound, http.StatusConflict),
+			handle: (*ToDoHandler).putLabel,
+		},
+		{
+			Route: Route{http.MethodDelete, "/labels/{id}"},
+			doc: op("deleteLabel", "Delete a label, removing it from the tags of the ToDos").path("id").
+				returns(http.StatusOK, ref("Label")).
+				returns(http.StatusAccepted, ref("Label")).
+				errors(http.StatusNotFound, http.StatusConflict),
+			handle: (*ToDoHandler).deleteLabel,
+		},
+		{
+			Route: Route{http.MethodPost, "/labels/{id}/merge"},
+			doc: op("mergeLabel", "Merge a label into another, replacing it in the tags of the ToDos").path("id").body(ref("MergeLabel")).
+				returns(http.StatusOK, ref("Label")).
+				returns(http.StatusAccepted, ref("Label")).
+				errors(http.StatusNotFound, http.StatusConflict),
+			handle: (*ToDoHandler).mergeLabel,
+		},
+		{
+			Route: Route{http.MethodPost, "/labels/{id}/resume"},
+			doc: op("resumeLabelMigration", "Resume an interrupted rename, merge or deletion of a label").path("id").
+				returns(http.StatusOK, ref("Label")).
+				returns(http.StatusAccepted, ref("Label")).
+				errors(http.StatusNotFound),
+			handle: (*ToDoHandler).resumeLabel,
+		},
+		{
+			Route:  Route{http.MethodGet, "/webhooks"},
+			doc:    op("listWebhooks", "List the webhooks of the user, without their secrets").returns(http.StatusOK, arrayOf(ref("Webhook"))),
+			handle: (*ToDoHandler).getWebhooks,
+		},
+		{
+			Route:  Route{http.MethodPost, "/webhooks"},
+			doc:    op("createWebhook", "Subscribe a webhook to the events of the ToDos of the user, returning its secret").body(ref("Webhook")).returns(http.StatusOK, ref("Webhook")).errors(http.StatusUnprocessableEntity),
+			handle: (*ToDoHandler).postWebhook,
+		},
+		{
+			Route:  Route{http.MethodGet, "/webhooks/{id}"},
+			doc:    op("getWebhook", "Get a webhook, without its secret").path("id").returns(http.StatusOK, ref("Webhook")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getWebhook,
+		},
+		{
+			Route:  Route{http.MethodPut, "/webhooks/{id}"},
+			doc:    op("updateWebhook", "Replace a webhook, keeping its secret unless a new one is given").path("id").body(ref("Webhook")).returns(http.StatusOK, ref("Webhook")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).putWebhook,
+		},
+		{
+			Route:  Route{http.MethodDelete, "/webhooks/{id}"},
+			doc:    op("deleteWebhook", "Delete a webhook").path("id").returns(http.StatusOK, nil).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).deleteWebhook,
+		},
+		{
+			Route: Route{http.MethodGet, "/webhooks/{id}/deliveries"},
+			doc: op("listWebhookDeliveries", "List the deliveries of a webhook, newest first").path("id").
+				query("limit", "Number of deliveries, 50 by default and at most 100").
+				query("cursor", "Cursor of the page, returned as next by the previous page").
+				returns(http.StatusOK, ref("DeliveryPage")).
+				errors(http.StatusNotFound),
+			handle: (*ToDoHandler).getDeliveries,
+		},
+		{
+			Route:  Route{http.MethodPost, "/webhooks/{id}/ping"},
+			doc:    op("pingWebhook", "Deliver a ping event to a webhook once, returning the delivery").path("id").returns(http.StatusOK, ref("Delivery")).errors(http.StatusNotFound),
+			handle: (*ToDoHandler).pingWebhook,
+		},
+		{
+			Route:  Route{http.MethodGet, "/profile"},
+			doc:    op("getProfile", "Get the contact details notifications are sent to").returns(http.StatusOK, ref("Profile")),
+			handle: (*ToDoHandler).getProfile,
+		},
+		{
+			Route:  Route{http.MethodPut, "/profile"},
+			doc:    op("updateProfile", "Replace the contact details notifications are sent to").body(ref("Profile")).returns(http.StatusOK, ref("Profile")),
+			handle: (*ToDoHandler).putProfile,
+		},
+		{
+			Route:  Route{http.MethodPost, "/profile/email/verification"},
+			doc:    op("resendEmailVerification", "Email the link verifying the email address of the profile again").returns(http.StatusOK, nil).errors(http.StatusBadRequest, http.StatusConflict),
+			handle: (*ToDoHandler).resendVerification,
+		},
+		{
+			Route:  Route{http.MethodGet, "/profile/email/verify"},
+			public: true,
+			doc:    op("verifyEmail", "Verify the email address of the user of the token of a verification link").public().query("token", "Token of the verification link").returns(http.StatusOK, nil).errors(http.StatusForbidden, http.StatusConflict),
+			handle: (*ToDoHandler).verifyEmail,
+		},
+		{
+			Route:  Route{http.MethodGet, "/digest/unsubscribe"},
+			public: true,
+			doc:    op("unsubscribeDigest", "Stop the digest emails of the user of the token of an unsubscribe link").public().query("token", "Token of the unsubscribe link").returns(http.StatusOK, nil).errors(http.StatusForbidden),
+			handle: (*ToDoHandler).unsubscribeDigest,
+		},
+		{
+			Route:  Route{http.MethodGet, "/openapi.json"},
+			public: true,
+			doc:    op("getOpenAPI", "Get the OpenAPI specification of the API").public().returns(http.StatusOK, nil),
+			handle: (*ToDoHandler).openAPI,
+		},
+	}
 }
 
-func (h *ToDoHandler) getOne(id string) (events.APIGatewayProxyResponse, error) {
+func (h *ToDoHandler) getOne(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id := req.PathParameters["id"]
 
 	todo, err := h.repo.Get(id)
 	if err != nil {
-		return CreateErrorResponse(ErrInternal)
+		return CreateErrorResponse(repoError(err))
 	}
 
 	if todo == nil {
 		return CreateErrorResponse(ErrNotFound)
 	}
 
+	withProgress(todo)
 	return CreateOKResponse(todo)
 
 }
 
-func (h *ToDoHandler) getAll() (events.APIGatewayProxyResponse, error) {
+func (h *ToDoHandler) getAll(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	var todos []server.ToDo
+
+	if parent, ok := req.QueryStringParameters["parent"]; ok {
+		children, err := h.repo.GetChildren(parent)
+		if err != nil {
+			return CreateErrorResponse(repoError(err))
+		}
+		todos = children
+	} else {
+		all, err := h.accessible()
+		if err != nil {
+			return CreateErrorResponse(err)
+		}
+		todos = all
+	}
 
-	todos, err := h.repo.GetAll()
-	if err != nil {
-		return CreateErrorResponse(ErrInternal)
+	server.SortByRank(todos)
+	for i := range todos {
+		withProgress(&todos[i])
 	}
 
 	return CreateOKResponse(todos)
@@ -75,22 +511,60 @@ func (h *ToDoHandler) getAll() (events.APIGatewayProxyResponse, error) {
 
 func (h *ToDoHandler) post(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 
-	todo, err := parseToDo(req.Body)
-	if err != nil {
-		return CreateErrorResponse(ErrInternal)
+	var todo server.ToDo
+	if err := decode(toDoSchema, req.Body, &todo); err != nil {
+		return CreateErrorResponse(err)
 	}
 
 	if todo.ID != "" {
 		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID must be empty"))
 	}
 
-	err = h.repo.Save(&todo)
-	if err != nil {
-		return CreateErrorResponse(ErrInternal)
+	if err := h.checkNew(&todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.reserveQuota(h.user, true); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.repo.Save(&todo); err != nil {
+		h.releaseQuota(h.user)
+		return CreateErrorResponse(repoError(err))
 	}
+
+	withProgress(&todo)
 	return CreateOKResponse(todo)
 }
 
+// checkNew validates a ToDo being created and normalizes its checklist
+func (h *ToDoHandler) checkNew(todo *server.ToDo) error {
+
+	if err := validateMembers(*todo); err != nil {
+		return err
+	}
+
+	if err := validateRecurrence(*todo); err != nil {
+		return err
+	}
+
+	if err := validateReminders(*todo); err != nil {
+		return err
+	}
+
+	if err := h.checkParent(todo); err != nil {
+		return err
+	}
+
+	if err := h.checkList(todo.ListID, ""); err != nil {
+		return err
+	}
+
+	normalizeItems(todo)
+
+	return h.checkDependencies(todo, false)
+}
+
 func (h *ToDoHandler) put(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 
 	id, ok := req.PathParameters["id"]
@@ -98,25 +572,132 @@ func (h *ToDoHandler) put(req events.APIGatewayProxyRequest) (events.APIGatewayP
 		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID is required"))
 	}
 
-	todo, err := parseToDo(req.Body)
-	if err != nil {
-		return CreateErrorResponse(ErrInternal)
+	var todo server.ToDo
+	if err := decodeUpdate(toDoSchema, req.Body, h.storedToDo(id), &todo); err != nil {
+		return CreateErrorResponse(err)
 	}
 
 	if id != todo.ID {
 		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID in body does not match ID in path"))
 	}
 
-	if t, err := h.repo.Get(id); err != nil {
-		return CreateErrorResponse(ErrInternal)
-	} else if t == nil {
+	if err := validateMembers(todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := validateRecurrence(todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := validateReminders(todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.checkParent(&todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	existing, err := h.repo.Get(id)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	} else if existing == nil {
 		return CreateErrorResponse(ErrNotFound)
 	}
 
+	// The rank is read-only and only changed by moving the ToDo
+	todo.Rank = existing.Rank
+	todo.Attachments = existing.Attachments
+
+	if err := h.checkList(todo.ListID, existing.ListID); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	normalizeItems(&todo)
+
+	if err := h.checkDependencies(&todo, existing.Completed); err != nil {
+		return CreateErrorResponse(err)
+	}
+
 	err = h.repo.Save(&todo)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if err := h.scheduleNext(&todo, existing.Completed); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	withProgress(&todo)
+	return CreateOKResponse(todo)
+}
+
+// patch updates the fields of a ToDo present in the body, following JSON merge patch (RFC 7396)
+func (h *ToDoHandler) patch(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id, ok := req.PathParameters["id"]
+	if !ok {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID is required"))
+	}
+
+	var patch map[string]interface{}
+	if err := decodeUpdate(toDoPatchSchema, req.Body, h.storedToDo(id), &patch); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if pid, ok := patch["id"]; ok && pid != id {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID in body does not match ID in path"))
+	}
+
+	existing, err := h.repo.Get(id)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if existing == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	todo, err := mergeToDo(existing, patch)
 	if err != nil {
 		return CreateErrorResponse(ErrInternal)
 	}
+
+	if err := validateMembers(todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := validateRecurrence(todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := validateReminders(todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.checkParent(&todo); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.checkList(todo.ListID, existing.ListID); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	normalizeItems(&todo)
+
+	if err := h.checkDependencies(&todo, existing.Completed); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	err = h.repo.Save(&todo)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if err := h.scheduleNext(&todo, existing.Completed); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	withProgress(&todo)
 	return CreateOKResponse(todo)
 }
 
@@ -130,23 +711,96 @@ func (h *ToDoHandler) delete(req events.APIGatewayProxyRequest) (events.APIGatew
 
 	t, err := h.repo.Get(id)
 	if err != nil {
-		return CreateErrorResponse(ErrInternal)
+		return CreateErrorResponse(repoError(err))
 	}
 
 	if t == nil {
 		return CreateErrorResponse(ErrNotFound)
 	}
 
-	if err := h.repo.Delete(id); err != nil {
-		return CreateErrorResponse(ErrInternal)
+	if err := h.detachChildren(t, req.QueryStringParameters["children"]); err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if err := h.purge(t); err != nil {
+		return CreateErrorResponse(err)
 	}
 
 	return CreateOKResponse("")
 
 }
 
-func parseToDo(body string) (server.ToDo, error) {
-	var t server.ToDo
-	err := json.Unmarshal([]byte(body), &t)
-	return t, err
+// storedToDo returns the ToDo as read from the API, to compare the read-only fields sent back
+func (h *ToDoHandler) storedToDo(id string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+
+		todo, err := h.repo.Get(id)
+		if err != nil {
+			return nil, repoError(err)
+		}
+
+		if todo == nil {
+			return nil, ErrNotFound
+		}
+
+		withProgress(todo)
+		return todo, nil
+	}
+}
+
+func validateMembers(todo server.ToDo) error {
+	for user, role := range todo.Members {
+		if !role.Valid() {
+			return errors.Wrapf(ErrBadRequest, "invalid role %q for member %s", role, user)
+		}
+	}
+	return nil
+}
+
+// mergeToDo applies a JSON merge patch to a ToDo
+func mergeToDo(todo *server.ToDo, patch map[string]interface{}) (server.ToDo, error) {
+
+	var doc map[string]interface{}
+
+	b, err := json.Marshal(todo)
+	if err != nil {
+		return server.ToDo{}, err
+	}
+
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return server.ToDo{}, err
+	}
+
+	b, err = json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return server.ToDo{}, err
+	}
+
+	var merged server.ToDo
+	err = json.Unmarshal(b, &merged)
+	return merged, err
+}
+
+// mergePatch returns the target document with the patch applied, as defined by RFC 7396
+func mergePatch(target interface{}, patch interface{}) interface{} {
+
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+
+	return t
 }
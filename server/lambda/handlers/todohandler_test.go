@@ -13,7 +13,15 @@ import (
 	"github.com/pkg/errors"
 )
 
-const testUUID = "a8a43435-20d8-4af2-8f94-f504aff2c6f3"
+const (
+	testUUID  = "a8a43435-20d8-4af2-8f94-f504aff2c6f3"
+	testUser  = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+	otherUser = "c5d1f7a2-9e41-4b8e-a3b6-6f2d0e8c4a91"
+)
+
+var testRequestContext = events.APIGatewayProxyRequestContext{
+	Authorizer: map[string]interface{}{"principalId": testUser},
+}
 
 var newToDo = server.ToDo{
 	Title: "Some ToDo",
@@ -22,31 +30,37 @@ var newToDo = server.ToDo{
 var savedToDo = server.ToDo{
 	ID:    testUUID,
 	Title: "Some ToDo",
+	Owner: testUser,
 }
 
 func TestToDoRepo(t *testing.T) {
 	t.Run("GetToDoOK", testGetToDoOK)
 	t.Run("GetToDoNotFound", testGetToDoNotFound)
-	t.Run("GetToDoserverError", testGetToDoserverError)
+	t.Run("GetToDoInternalError", testGetToDoInternalError)
 	t.Run("GetAllToDoOK", testGetAllToDoOK)
-	t.Run("GetAllToDoserverError", testGetAllToDoserverError)
+	t.Run("GetAllToDoInternalError", testGetAllToDoInternalError)
 	t.Run("CreateToDoOK", testCreateToDoOK)
 	t.Run("CreateToDoBadRequest", testCreateToDoBadRequest)
-	t.Run("CreateToDoserverErrorOnParse", testCreateToDoserverErrorOnParse)
-	t.Run("CreateToDoserverErrorOnSave", testCreateToDoserverErrorOnSave)
+	t.Run("CreateToDoBadRequestOnParse", testCreateToDoBadRequestOnParse)
+	t.Run("CreateToDoInternalErrorOnSave", testCreateToDoInternalErrorOnSave)
 	t.Run("UpdateToDoOK", testUpdateToDoOK)
 	t.Run("UpdateToDoBadRequestMissingID", testUpdateToDoBadRequestMissingID)
 	t.Run("UpdateToDoBadRequestNoMatch", testUpdateToDoBadRequestNoMatch)
 	t.Run("UpdateToDoNotFound", testUpdateToDoNotFound)
-	t.Run("UpdateToDoserverErrorOnParse", testUpdateToDoserverErrorOnParse)
-	t.Run("UpdateToDoserverErrorOnGet", testUpdateToDoserverErrorOnGet)
-	t.Run("UpdateToDoserverErrorOnSave", testUpdateToDoserverErrorOnSave)
+	t.Run("UpdateToDoBadRequestOnParse", testUpdateToDoBadRequestOnParse)
+	t.Run("UpdateToDoInternalErrorOnGet", testUpdateToDoInternalErrorOnGet)
+	t.Run("UpdateToDoInternalErrorOnSave", testUpdateToDoInternalErrorOnSave)
 	t.Run("DeleteToDoOK", testDeleteToDoOK)
 	t.Run("DeleteToDoBadRequestMissingID", testDeleteToDoBadRequestMissingID)
 	t.Run("DeleteToDoNotFound", testDeleteToDoNotFound)
-	t.Run("DeleteToDoserverErrorOnGet", testDeleteToDoserverErrorOnGet)
-	t.Run("DeleteToDoserverErrorOnDelete", testDeleteToDoserverErrorOnDelete)
+	t.Run("DeleteToDoInternalErrorOnGet", testDeleteToDoInternalErrorOnGet)
+	t.Run("DeleteToDoInternalErrorOnDelete", testDeleteToDoInternalErrorOnDelete)
 	t.Run("MethodNotAllowed", testMethodNotAllowed)
+	t.Run("Unauthorized", testUnauthorized)
+	t.Run("GetToDoForbidden", testGetToDoForbidden)
+	t.Run("UpdateToDoForbiddenForViewer", testUpdateToDoForbiddenForViewer)
+	t.Run("DeleteToDoForbiddenForViewer", testDeleteToDoForbiddenForViewer)
+	t.Run("DeleteToDoForbiddenForEditor", testDeleteToDoForbiddenForEditor)
 }
 
 func testGetToDoOK(t *testing.T) {
@@ -58,8 +72,10 @@ func testGetToDoOK(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -90,8 +106,10 @@ func testGetToDoNotFound(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -113,7 +131,7 @@ func testGetToDoNotFound(t *testing.T) {
 
 }
 
-func testGetToDoserverError(t *testing.T) {
+func testGetToDoInternalError(t *testing.T) {
 
 	m := &RepoMock{
 		GetFn: func(string) (*server.ToDo, error) {
@@ -122,8 +140,10 @@ func testGetToDoserverError(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -131,16 +151,16 @@ func testGetToDoserverError(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+	if !strings.Contains(resp.Body, handlers.ErrInternal.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrInternal.Error())
 	}
 
 	if !m.GetInvoked {
 		t.Fatal("Get not invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
 	}
 
 }
@@ -154,7 +174,9 @@ func testGetAllToDoOK(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
-		HTTPMethod: http.MethodGet,
+		RequestContext: testRequestContext,
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -176,7 +198,7 @@ func testGetAllToDoOK(t *testing.T) {
 
 }
 
-func testGetAllToDoserverError(t *testing.T) {
+func testGetAllToDoInternalError(t *testing.T) {
 
 	m := &RepoMock{
 		GetAllFn: func() ([]server.ToDo, error) {
@@ -185,7 +207,9 @@ func testGetAllToDoserverError(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
-		HTTPMethod: http.MethodGet,
+		RequestContext: testRequestContext,
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -193,16 +217,16 @@ func testGetAllToDoserverError(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+	if !strings.Contains(resp.Body, handlers.ErrInternal.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrInternal.Error())
 	}
 
 	if !m.GetAllInvoked {
 		t.Fatal("GetAll not invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
 	}
 
 }
@@ -217,8 +241,10 @@ func testCreateToDoOK(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
-		Body:       toDoToString(&newToDo),
-		HTTPMethod: http.MethodPost,
+		RequestContext: testRequestContext,
+		Body:           toDoToString(&newToDo),
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -249,8 +275,10 @@ func testCreateToDoBadRequest(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
-		Body:       toDoToString(&savedToDo),
-		HTTPMethod: http.MethodPost,
+		RequestContext: testRequestContext,
+		Body:           toDoToString(&savedToDo),
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -272,7 +300,7 @@ func testCreateToDoBadRequest(t *testing.T) {
 
 }
 
-func testCreateToDoserverErrorOnParse(t *testing.T) {
+func testCreateToDoBadRequestOnParse(t *testing.T) {
 
 	m := &RepoMock{
 		SaveFn: func(*server.ToDo) error {
@@ -281,8 +309,10 @@ func testCreateToDoserverErrorOnParse(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
-		Body:       "garbage",
-		HTTPMethod: http.MethodPost,
+		RequestContext: testRequestContext,
+		Body:           "garbage",
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -290,21 +320,21 @@ func testCreateToDoserverErrorOnParse(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+	if !strings.Contains(resp.Body, handlers.ErrBadRequest.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrBadRequest.Error())
 	}
 
 	if m.SaveInvoked {
 		t.Fatal("Save invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
 	}
 
 }
 
-func testCreateToDoserverErrorOnSave(t *testing.T) {
+func testCreateToDoInternalErrorOnSave(t *testing.T) {
 
 	m := &RepoMock{
 		SaveFn: func(*server.ToDo) error {
@@ -313,8 +343,10 @@ func testCreateToDoserverErrorOnSave(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
-		Body:       toDoToString(&newToDo),
-		HTTPMethod: http.MethodPost,
+		RequestContext: testRequestContext,
+		Body:           toDoToString(&newToDo),
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -322,16 +354,16 @@ func testCreateToDoserverErrorOnSave(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+	if !strings.Contains(resp.Body, handlers.ErrInternal.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrInternal.Error())
 	}
 
 	if !m.SaveInvoked {
 		t.Fatal("Save not invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
 	}
 
 }
@@ -348,9 +380,11 @@ func testUpdateToDoOK(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		Body:           toDoToString(&savedToDo),
 		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -388,8 +422,10 @@ func testUpdateToDoBadRequestMissingID(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
-		Body:       toDoToString(&savedToDo),
-		HTTPMethod: http.MethodPut,
+		RequestContext: testRequestContext,
+		Body:           toDoToString(&savedToDo),
+		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -427,9 +463,11 @@ func testUpdateToDoBadRequestNoMatch(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": "garbage"},
 		Body:           toDoToString(&savedToDo),
 		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -467,9 +505,11 @@ func testUpdateToDoNotFound(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		Body:           toDoToString(&savedToDo),
 		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -495,7 +535,7 @@ func testUpdateToDoNotFound(t *testing.T) {
 
 }
 
-func testUpdateToDoserverErrorOnParse(t *testing.T) {
+func testUpdateToDoBadRequestOnParse(t *testing.T) {
 
 	m := &RepoMock{
 		GetFn: func(string) (*server.ToDo, error) {
@@ -507,9 +547,11 @@ func testUpdateToDoserverErrorOnParse(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		Body:           "garbage",
 		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -517,8 +559,8 @@ func testUpdateToDoserverErrorOnParse(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+	if !strings.Contains(resp.Body, handlers.ErrBadRequest.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrBadRequest.Error())
 	}
 
 	if m.GetInvoked {
@@ -529,13 +571,13 @@ func testUpdateToDoserverErrorOnParse(t *testing.T) {
 		t.Fatal("Save invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
 	}
 
 }
 
-func testUpdateToDoserverErrorOnGet(t *testing.T) {
+func testUpdateToDoInternalErrorOnGet(t *testing.T) {
 
 	m := &RepoMock{
 		GetFn: func(string) (*server.ToDo, error) {
@@ -547,9 +589,11 @@ func testUpdateToDoserverErrorOnGet(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		Body:           toDoToString(&savedToDo),
 		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -557,8 +601,8 @@ func testUpdateToDoserverErrorOnGet(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+	if !strings.Contains(resp.Body, handlers.ErrInternal.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrInternal.Error())
 	}
 
 	if !m.GetInvoked {
@@ -569,13 +613,13 @@ func testUpdateToDoserverErrorOnGet(t *testing.T) {
 		t.Fatal("Save invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
 	}
 
 }
 
-func testUpdateToDoserverErrorOnSave(t *testing.T) {
+func testUpdateToDoInternalErrorOnSave(t *testing.T) {
 
 	m := &RepoMock{
 		GetFn: func(string) (*server.ToDo, error) {
@@ -587,9 +631,11 @@ func testUpdateToDoserverErrorOnSave(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		Body:           toDoToString(&savedToDo),
 		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -597,9 +643,9 @@ func testUpdateToDoserverErrorOnSave(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
+	if !strings.Contains(resp.Body, handlers.ErrInternal.Error()) {
 		fmt.Println(resp.Body)
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrInternal.Error())
 	}
 
 	if !m.GetInvoked {
@@ -610,8 +656,8 @@ func testUpdateToDoserverErrorOnSave(t *testing.T) {
 		t.Fatal("Save not invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
 	}
 
 }
@@ -628,8 +674,10 @@ func testDeleteToDoOK(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		HTTPMethod:     http.MethodDelete,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -668,7 +716,9 @@ func testDeleteToDoBadRequestMissingID(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
-		HTTPMethod: http.MethodDelete,
+		RequestContext: testRequestContext,
+		HTTPMethod:     http.MethodDelete,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -707,8 +757,10 @@ func testDeleteToDoNotFound(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		HTTPMethod:     http.MethodDelete,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -735,7 +787,7 @@ func testDeleteToDoNotFound(t *testing.T) {
 
 }
 
-func testDeleteToDoserverErrorOnGet(t *testing.T) {
+func testDeleteToDoInternalErrorOnGet(t *testing.T) {
 
 	m := &RepoMock{
 		GetFn: func(string) (*server.ToDo, error) {
@@ -747,8 +799,10 @@ func testDeleteToDoserverErrorOnGet(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		HTTPMethod:     http.MethodDelete,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -756,9 +810,9 @@ func testDeleteToDoserverErrorOnGet(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
+	if !strings.Contains(resp.Body, handlers.ErrInternal.Error()) {
 		fmt.Println(resp.Body)
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrInternal.Error())
 	}
 
 	if !m.GetInvoked {
@@ -769,13 +823,13 @@ func testDeleteToDoserverErrorOnGet(t *testing.T) {
 		t.Fatal("Delete invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
 	}
 
 }
 
-func testDeleteToDoserverErrorOnDelete(t *testing.T) {
+func testDeleteToDoInternalErrorOnDelete(t *testing.T) {
 
 	m := &RepoMock{
 		GetFn: func(string) (*server.ToDo, error) {
@@ -787,8 +841,10 @@ func testDeleteToDoserverErrorOnDelete(t *testing.T) {
 	}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
 		HTTPMethod:     http.MethodDelete,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -796,9 +852,9 @@ func testDeleteToDoserverErrorOnDelete(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !strings.Contains(resp.Body, handlers.Errserver.Error()) {
+	if !strings.Contains(resp.Body, handlers.ErrInternal.Error()) {
 		fmt.Println(resp.Body)
-		t.Fatalf("Expected body to contain '%s'", handlers.Errserver.Error())
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrInternal.Error())
 	}
 
 	if !m.GetInvoked {
@@ -809,8 +865,8 @@ func testDeleteToDoserverErrorOnDelete(t *testing.T) {
 		t.Fatal("Delete not invoked")
 	}
 
-	if resp.StatusCode != http.StatusserverServerError {
-		t.Fatalf("Expected %d http response code, got %d", http.StatusserverServerError, resp.StatusCode)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusInternalServerError, resp.StatusCode)
 	}
 
 }
@@ -820,8 +876,10 @@ func testMethodNotAllowed(t *testing.T) {
 	m := &RepoMock{}
 
 	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
 		PathParameters: map[string]string{"id": testUUID},
-		HTTPMethod:     http.MethodPatch,
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos/{id}",
 	}
 
 	resp, err := handlers.NewToDoHandler(m).Handle(req)
@@ -840,7 +898,165 @@ func testMethodNotAllowed(t *testing.T) {
 
 }
 
+func testUnauthorized(t *testing.T) {
+
+	m := &RepoMock{}
+
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.Body, handlers.ErrUnauthorized.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrUnauthorized.Error())
+	}
+
+	if m.GetInvoked {
+		t.Fatal("Get invoked")
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+}
+
+func testGetToDoForbidden(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			return sharedToDo(""), nil
+		},
+	}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.Body, handlers.ErrForbidden.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrForbidden.Error())
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+}
+
+func testUpdateToDoForbiddenForViewer(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			return sharedToDo(server.RoleViewer), nil
+		},
+		SaveFn: func(*server.ToDo) error {
+			return nil
+		},
+	}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		Body:           toDoToString(&savedToDo),
+		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.Body, handlers.ErrForbidden.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrForbidden.Error())
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+}
+
+func testDeleteToDoForbiddenForViewer(t *testing.T) {
+	testDeleteToDoForbidden(t, server.RoleViewer)
+}
+
+func testDeleteToDoForbiddenForEditor(t *testing.T) {
+	testDeleteToDoForbidden(t, server.RoleEditor)
+}
+
+func testDeleteToDoForbidden(t *testing.T, role server.Role) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			return sharedToDo(role), nil
+		},
+		DeleteFn: func(string) error {
+			return nil
+		},
+	}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodDelete,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.Body, handlers.ErrForbidden.Error()) {
+		t.Fatalf("Expected body to contain '%s'", handlers.ErrForbidden.Error())
+	}
+
+	if m.DeleteInvoked {
+		t.Fatal("Delete invoked")
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+}
+
+// sharedToDo returns a ToDo owned by another user where the test user has the given role
+func sharedToDo(role server.Role) *server.ToDo {
+	t := savedToDo
+	t.Owner = otherUser
+	if role != "" {
+		t.Members = map[string]server.Role{testUser: role}
+	}
+	return &t
+}
+
+// toDoToString returns the JSON sent by a client for the ToDo, without the read-only fields
 func toDoToString(todo *server.ToDo) string {
+	var doc map[string]interface{}
 	b, _ := json.Marshal(todo)
+	json.Unmarshal(b, &doc)
+	delete(doc, "modTime")
+	delete(doc, "owner")
+	b, _ = json.Marshal(doc)
 	return string(b)
 }
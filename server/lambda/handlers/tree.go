@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/pkg/errors"
+)
+
+// maxTreeDepth is the maximum number of levels of sub-tasks
+const maxTreeDepth = 16
+
+const (
+	// childrenCascade deletes the sub-tasks along with their parent
+	childrenCascade = "cascade"
+	// childrenReparent moves the sub-tasks to the parent of the deleted ToDo
+	childrenReparent = "reparent"
+)
+
+// checkParent returns an error if the parent of the ToDo does not exist or if it would make
+// the ToDo one of its own ancestors
+func (h *ToDoHandler) checkParent(todo *server.ToDo) error {
+
+	id := todo.ParentID
+
+	for depth := 0; id != ""; depth++ {
+
+		if id == todo.ID {
+			return errors.Wrapf(ErrConflict, "ToDo %s cannot be a sub-task of itself", todo.ID)
+		}
+
+		if depth == maxTreeDepth {
+			return errors.Wrapf(ErrBadRequest, "sub-tasks cannot be nested more than %d levels deep", maxTreeDepth)
+		}
+
+		parent, err := h.repo.Get(id)
+		if err != nil {
+			return repoError(err)
+		}
+
+		if parent == nil {
+			return errors.Wrapf(ErrBadRequest, "parent ToDo %s not found", id)
+		}
+
+		id = parent.ParentID
+	}
+
+	return nil
+}
+
+func (h *ToDoHandler) getTree(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id := req.PathParameters["id"]
+
+	todo, err := h.repo.Get(id)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if todo == nil {
+		return CreateErrorResponse(ErrNotFound)
+	}
+
+	root := &server.ToDoNode{ToDo: *todo}
+	if err := h.addChildren(root, 0); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(root)
+}
+
+// addChildren loads the sub-tasks of a node recursively
+func (h *ToDoHandler) addChildren(node *server.ToDoNode, depth int) error {
+
+	withProgress(&node.ToDo)
+	node.Children = []*server.ToDoNode{}
+
+	if depth == maxTreeDepth {
+		return nil
+	}
+
+	children, err := h.repo.GetChildren(node.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range children {
+		child := &server.ToDoNode{ToDo: c}
+		if err := h.addChildren(child, depth+1); err != nil {
+			return err
+		}
+		node.Children = append(node.Children, child)
+	}
+
+	return nil
+}
+
+// detachChildren deletes or re-parents the sub-tasks of a ToDo about to be deleted. The
+// permissions are checked on every affected ToDo, including the ones the user cannot read,
+// before any of them is changed.
+func (h *ToDoHandler) detachChildren(todo *server.ToDo, mode string) error {
+
+	if mode != childrenCascade && !policy.Allowed(todo.RoleOf(h.user), policy.ActionDelete) {
+		return errors.Wrapf(ErrForbidden, "ToDo %s cannot be deleted", todo.ID)
+	}
+
+	switch mode {
+	case childrenCascade:
+		descendants, err := h.checkCascade(todo)
+		if err != nil {
+			return err
+		}
+
+		// Descendants follow their ancestors, so the leaves are deleted first
+		for i := len(descendants) - 1; i >= 0; i-- {
+			if err := h.purge(&descendants[i]); err != nil {
+				return err
+			}
+		}
+	case childrenReparent:
+		children, err := h.unfiltered.GetChildren(todo.ID)
+		if err != nil {
+			return repoError(err)
+		}
+
+		for _, c := range children {
+			if !policy.Allowed(c.RoleOf(h.user), policy.ActionWrite) {
+				return errors.Wrapf(ErrForbidden, "sub-task %s cannot be moved", c.ID)
+			}
+		}
+
+		for _, c := range children {
+			c.ParentID = todo.ParentID
+			if err := h.repo.Save(&c); err != nil {
+				return repoError(err)
+			}
+		}
+	default:
+		children, err := h.unfiltered.GetChildren(todo.ID)
+		if err != nil {
+			return repoError(err)
+		}
+
+		if len(children) > 0 {
+			return errors.Wrapf(ErrConflict, "ToDo %s has sub-tasks, set children to %s or %s", todo.ID, childrenCascade, childrenReparent)
+		}
+	}
+
+	return nil
+}
+
+// checkCascade returns the descendants of a ToDo deleted along with its sub-tasks, or an error
+// if the user is not allowed to delete the ToDo or one of them
+func (h *ToDoHandler) checkCascade(todo *server.ToDo) ([]server.ToDo, error) {
+
+	if !policy.Allowed(todo.RoleOf(h.user), policy.ActionDelete) {
+		return nil, errors.Wrapf(ErrForbidden, "ToDo %s cannot be deleted", todo.ID)
+	}
+
+	descendants, err := h.descendants(todo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range descendants {
+		if !policy.Allowed(d.RoleOf(h.user), policy.ActionDelete) {
+			return nil, errors.Wrapf(ErrForbidden, "sub-task %s cannot be deleted", d.ID)
+		}
+	}
+
+	return descendants, nil
+}
+
+// descendants returns all the sub-tasks below a ToDo, whether the user can read them or not,
+// each one after its parent. Like checkParent, it visits each ToDo once and stops below
+// maxTreeDepth levels, so that a cycle of parents cannot be walked forever.
+func (h *ToDoHandler) descendants(id string) ([]server.ToDo, error) {
+
+	visited := map[string]bool{id: true}
+	level := []string{id}
+
+	var all []server.ToDo
+
+	for depth := 0; len(level) > 0; depth++ {
+
+		if depth > maxTreeDepth {
+			return nil, errors.Wrapf(ErrConflict, "ToDo %s has sub-tasks nested more than %d levels deep", id, maxTreeDepth)
+		}
+
+		var next []string
+		for _, parent := range level {
+
+			children, err := h.unfiltered.GetChildren(parent)
+			if err != nil {
+				return nil, repoError(err)
+			}
+
+			for _, c := range children {
+				if visited[c.ID] {
+					continue
+				}
+				visited[c.ID] = true
+				all = append(all, c)
+				next = append(next, c.ID)
+			}
+		}
+
+		level = next
+	}
+
+	return all, nil
+}
@@ -0,0 +1,328 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+func TestTree(t *testing.T) {
+	t.Run("UpdateToDoParentCycle", testUpdateToDoParentCycle)
+	t.Run("CreateToDoParentNotFound", testCreateToDoParentNotFound)
+	t.Run("GetTree", testGetTree)
+	t.Run("ListChildren", testListChildren)
+	t.Run("DeleteParentWithoutFlag", testDeleteParentWithoutFlag)
+	t.Run("DeleteParentCascade", testDeleteParentCascade)
+	t.Run("DeleteParentCascadeCycle", testDeleteParentCascadeCycle)
+	t.Run("DeleteParentCascadeTooDeep", testDeleteParentCascadeTooDeep)
+	t.Run("DeleteParentReparent", testDeleteParentReparent)
+	t.Run("DeleteParentCascadeForbidden", testDeleteParentCascadeForbidden)
+	t.Run("DeleteParentReparentForbidden", testDeleteParentReparentForbidden)
+}
+
+// treeRepo stores the tree root -> child -> grandchild in memory
+func treeRepo() (*RepoMock, map[string]*server.ToDo) {
+
+	todos := map[string]*server.ToDo{
+		"root":       {ID: "root", Title: "Root", Owner: testUser},
+		"child":      {ID: "child", Title: "Child", ParentID: "root", Owner: testUser},
+		"grandchild": {ID: "grandchild", Title: "Grandchild", ParentID: "child", Owner: testUser},
+	}
+
+	m := &RepoMock{
+		GetFn: func(id string) (*server.ToDo, error) {
+			if t, ok := todos[id]; ok {
+				c := *t
+				return &c, nil
+			}
+			return nil, nil
+		},
+		GetChildrenFn: func(parentID string) ([]server.ToDo, error) {
+			children := []server.ToDo{}
+			for _, t := range todos {
+				if t.ParentID == parentID {
+					children = append(children, *t)
+				}
+			}
+			return children, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			c := *todo
+			todos[todo.ID] = &c
+			return nil
+		},
+		DeleteFn: func(id string) error {
+			delete(todos, id)
+			return nil
+		},
+	}
+
+	return m, todos
+}
+
+func testUpdateToDoParentCycle(t *testing.T) {
+
+	m, todos := treeRepo()
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": "root"},
+		Body:           `{"id":"root","title":"Root","parentId":"grandchild"}`,
+		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	if todos["root"].ParentID != "" {
+		t.Fatal("Expected root to be left unchanged")
+	}
+}
+
+func testCreateToDoParentNotFound(t *testing.T) {
+
+	m, _ := treeRepo()
+
+	resp, err := handlers.NewToDoHandler(m).Handle(postBody(`{"title":"Orphan","parentId":"missing"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testGetTree(t *testing.T) {
+
+	m, _ := treeRepo()
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       "/todos/{id}/tree",
+		PathParameters: map[string]string{"id": "root"},
+		HTTPMethod:     http.MethodGet,
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var root server.ToDoNode
+	if err := json.Unmarshal([]byte(resp.Body), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	if root.ID != "root" || len(root.Children) != 1 || root.Children[0].ID != "child" {
+		t.Fatalf("Expected root with a single child, got %s", resp.Body)
+	}
+
+	if len(root.Children[0].Children) != 1 || root.Children[0].Children[0].ID != "grandchild" {
+		t.Fatalf("Expected child with a single grandchild, got %s", resp.Body)
+	}
+}
+
+func testListChildren(t *testing.T) {
+
+	m, _ := treeRepo()
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext:        testRequestContext,
+		QueryStringParameters: map[string]string{"parent": "child"},
+		HTTPMethod:            http.MethodGet,
+		Resource:              "/todos",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var todos []server.ToDo
+	if err := json.Unmarshal([]byte(resp.Body), &todos); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos) != 1 || todos[0].ID != "grandchild" {
+		t.Fatalf("Expected only the grandchild, got %s", resp.Body)
+	}
+
+	if m.GetAllInvoked {
+		t.Fatal("GetAll invoked")
+	}
+}
+
+func deleteRequest(id, children string) events.APIGatewayProxyRequest {
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": id},
+		HTTPMethod:     http.MethodDelete,
+		Resource:       "/todos/{id}",
+	}
+	if children != "" {
+		req.QueryStringParameters = map[string]string{"children": children}
+	}
+	return req
+}
+
+func testDeleteParentWithoutFlag(t *testing.T) {
+
+	m, _ := treeRepo()
+
+	resp, err := handlers.NewToDoHandler(m).Handle(deleteRequest("root", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	if m.DeleteInvoked {
+		t.Fatal("Delete invoked")
+	}
+}
+
+func testDeleteParentCascade(t *testing.T) {
+
+	m, todos := treeRepo()
+
+	resp, err := handlers.NewToDoHandler(m).Handle(deleteRequest("root", "cascade"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if len(todos) != 0 {
+		t.Fatalf("Expected every ToDo to be deleted, got %v", todos)
+	}
+}
+
+func testDeleteParentCascadeCycle(t *testing.T) {
+
+	m, todos := treeRepo()
+	// A cycle of parents saved before they were checked
+	todos["root"].ParentID = "grandchild"
+
+	resp, err := handlers.NewToDoHandler(m).Handle(deleteRequest("root", "cascade"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if len(todos) != 0 {
+		t.Fatalf("Expected every ToDo to be deleted, got %v", todos)
+	}
+}
+
+func testDeleteParentCascadeTooDeep(t *testing.T) {
+
+	m, todos := treeRepo()
+	parent := "grandchild"
+	for i := 0; i < 16; i++ {
+		id := fmt.Sprintf("descendant-%d", i)
+		todos[id] = &server.ToDo{ID: id, Title: "Descendant", ParentID: parent, Owner: testUser}
+		parent = id
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(deleteRequest("root", "cascade"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	if m.DeleteInvoked {
+		t.Fatal("Expected no ToDo to be deleted")
+	}
+}
+
+func testDeleteParentReparent(t *testing.T) {
+
+	m, todos := treeRepo()
+
+	resp, err := handlers.NewToDoHandler(m).Handle(deleteRequest("child", "reparent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if _, ok := todos["child"]; ok {
+		t.Fatal("Expected child to be deleted")
+	}
+
+	if todos["grandchild"].ParentID != "root" {
+		t.Fatalf("Expected grandchild to be moved to root, got parent '%s'", todos["grandchild"].ParentID)
+	}
+}
+
+func testDeleteParentCascadeForbidden(t *testing.T) {
+
+	m, todos := treeRepo()
+	// The grandchild belongs to another user and cannot even be read
+	todos["grandchild"].Owner = otherUser
+
+	resp, err := handlers.NewToDoHandler(m).Handle(deleteRequest("root", "cascade"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if m.DeleteInvoked || len(todos) != 3 {
+		t.Fatal("Expected no ToDo to be deleted")
+	}
+}
+
+func testDeleteParentReparentForbidden(t *testing.T) {
+
+	m, todos := treeRepo()
+	todos["grandchild"].Owner = otherUser
+
+	resp, err := handlers.NewToDoHandler(m).Handle(deleteRequest("child", "reparent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if m.DeleteInvoked || m.SaveInvoked {
+		t.Fatal("Expected no ToDo to be changed")
+	}
+}
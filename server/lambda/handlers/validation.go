@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/pkg/errors"
+)
+
+var (
+	// toDoSchema validates the ToDos sent to create or replace a ToDo
+	toDoSchema = jsonschema.Generate(server.ToDo{})
+	// toDoPatchSchema validates the fields sent to update part of a ToDo
+	toDoPatchSchema = toDoSchema.Optional()
+)
+
+// ValidationError is returned when a request body does not match its schema
+type ValidationError struct {
+	Violations []jsonschema.Violation
+}
+
+// Error returns the violations as a single message
+func (e *ValidationError) Error() string {
+
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Field + " " + v.Message
+	}
+
+	return ErrBadRequest.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Cause returns ErrBadRequest, so that validation errors are sent with a 400 http status code
+func (e *ValidationError) Cause() error {
+	return ErrBadRequest
+}
+
+// validate checks a request body against a schema
+func validate(s *jsonschema.Schema, body string) error {
+
+	violations, err := s.Validate([]byte(body))
+	if err != nil {
+		return errors.Wrap(ErrBadRequest, "body is not valid JSON")
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// decode checks a request body against a schema and unmarshals it into v
+func decode(s *jsonschema.Schema, body string, v interface{}) error {
+
+	if err := validate(s, body); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		return errors.Wrap(ErrBadRequest, "body does not match its schema")
+	}
+
+	return nil
+}
+
+// decodeUpdate checks a request body updating a resource against a schema and unmarshals it
+// into v. The read-only fields equal to the ones of the stored resource, returned by current,
+// are left out, so that a resource read from the API can be sent back as is. current is only
+// called if the body has read-only fields.
+func decodeUpdate(s *jsonschema.Schema, body string, current func() (interface{}, error), v interface{}) error {
+
+	err := decode(s, body, v)
+	if !readOnly(err) {
+		return err
+	}
+
+	c, err := current()
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrapf(ErrInternal, "could not encode the stored resource: %v", err)
+	}
+
+	stripped, err := s.StripUnchanged([]byte(body), b)
+	if err != nil {
+		return errors.Wrap(ErrBadRequest, "body is not valid JSON")
+	}
+
+	return decode(s, string(stripped), v)
+}
+
+// readOnly returns whether err only reports read-only fields
+func readOnly(err error) bool {
+
+	vs := violations(err)
+	for _, v := range vs {
+		if v.Message != jsonschema.MessageReadOnly {
+			return false
+		}
+	}
+
+	return len(vs) > 0
+}
+
+// violations returns the schema violations carried by err, if any
+func violations(err error) []jsonschema.Violation {
+
+	for err != nil {
+
+		if v, ok := err.(*ValidationError); ok {
+			return v.Violations
+		}
+
+		c, ok := err.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+
+	return nil
+}
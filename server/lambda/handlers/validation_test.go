@@ -0,0 +1,276 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+)
+
+type validationErrorResponse struct {
+	Error   string `json:"error"`
+	Details []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"details"`
+}
+
+func TestValidation(t *testing.T) {
+	t.Run("CreateToDoUnknownField", testCreateToDoUnknownField)
+	t.Run("CreateToDoModTime", testCreateToDoModTime)
+	t.Run("CreateToDoWrongType", testCreateToDoWrongType)
+	t.Run("UpdateToDoRoundTrip", testUpdateToDoRoundTrip)
+	t.Run("UpdateToDoChangedModTime", testUpdateToDoChangedModTime)
+	t.Run("CreateToDoMissingTitle", testCreateToDoMissingTitle)
+	t.Run("UpdateToDoInvalidRole", testUpdateToDoInvalidRole)
+	t.Run("PatchToDoOK", testPatchToDoOK)
+	t.Run("PatchToDoWrongType", testPatchToDoWrongType)
+}
+
+// assertViolation checks that the response is a 400 reporting a violation on field
+func assertViolation(t *testing.T, resp events.APIGatewayProxyResponse, field string) {
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	var e validationErrorResponse
+	if err := json.Unmarshal([]byte(resp.Body), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range e.Details {
+		if d.Field == field {
+			return
+		}
+	}
+
+	t.Fatalf("Expected a violation on field '%s', got %s", field, resp.Body)
+}
+
+func postBody(body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Body:           body,
+		HTTPMethod:     http.MethodPost,
+		Resource:       "/todos",
+	}
+}
+
+func testCreateToDoUnknownField(t *testing.T) {
+
+	m := &RepoMock{}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(postBody(`{"title":"Some ToDo","priority":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertViolation(t, resp, "priority")
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testCreateToDoModTime(t *testing.T) {
+
+	m := &RepoMock{}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(postBody(`{"title":"Some ToDo","modTime":"2019-07-01T10:00:00Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertViolation(t, resp, "modTime")
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testCreateToDoWrongType(t *testing.T) {
+
+	m := &RepoMock{}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(postBody(`{"title":"Some ToDo","completed":"yes"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertViolation(t, resp, "completed")
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testUpdateToDoRoundTrip(t *testing.T) {
+
+	existing := savedToDo
+	existing.ModTime = time.Date(2019, time.July, 1, 10, 0, 0, 0, time.UTC)
+	existing.Rank = "m"
+	existing.Items = []server.ChecklistItem{{ID: "item-1", Title: "Step 1", Completed: true}}
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := existing
+			return &todo, nil
+		},
+		SaveFn: func(*server.ToDo) error {
+			return nil
+		},
+	}
+
+	h := handlers.NewToDoHandler(m)
+
+	get, err := h.Handle(events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/todos/{id}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := h.Handle(events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		Body:           get.Body,
+		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d: %s", http.StatusOK, resp.StatusCode, resp.Body)
+	}
+}
+
+func testUpdateToDoChangedModTime(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := savedToDo
+			todo.ModTime = time.Date(2019, time.July, 1, 10, 0, 0, 0, time.UTC)
+			return &todo, nil
+		},
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		Body:           `{"id":"` + testUUID + `","title":"Some ToDo","modTime":"2019-07-02T10:00:00Z"}`,
+		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertViolation(t, resp, "modTime")
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testCreateToDoMissingTitle(t *testing.T) {
+
+	m := &RepoMock{}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(postBody(`{"completed":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertViolation(t, resp, "title")
+}
+
+func testUpdateToDoInvalidRole(t *testing.T) {
+
+	m := &RepoMock{}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		Body:           `{"id":"` + testUUID + `","title":"Some ToDo","members":{"bob":"admin"}}`,
+		HTTPMethod:     http.MethodPut,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertViolation(t, resp, "members.bob")
+}
+
+func testPatchToDoOK(t *testing.T) {
+
+	var saved *server.ToDo
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := savedToDo
+			return &todo, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			saved = todo
+			return nil
+		},
+	}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		Body:           `{"completed":true}`,
+		HTTPMethod:     http.MethodPatch,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if saved == nil || !saved.Completed || saved.Title != savedToDo.Title {
+		t.Fatalf("Expected ToDo to be completed with its title unchanged, got %+v", saved)
+	}
+}
+
+func testPatchToDoWrongType(t *testing.T) {
+
+	m := &RepoMock{}
+
+	req := events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		PathParameters: map[string]string{"id": testUUID},
+		Body:           `{"completed":"yes"}`,
+		HTTPMethod:     http.MethodPatch,
+		Resource:       "/todos/{id}",
+	}
+
+	resp, err := handlers.NewToDoHandler(m).Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertViolation(t, resp, "completed")
+
+	if m.GetInvoked {
+		t.Fatal("Get invoked")
+	}
+}
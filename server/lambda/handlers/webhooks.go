@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/jsonschema"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/massimoselvi/serverless-todo-api-go/server/webhook"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultDeliveriesPage is the number of deliveries returned when no limit is given
+	defaultDeliveriesPage = 50
+	// maxDeliveriesPage is the maximum number of deliveries returned at once
+	maxDeliveriesPage = 100
+	// maxWebhooks is the maximum number of webhooks of a user
+	maxWebhooks = 10
+	// secretBytes is the number of random bytes of a generated webhook secret
+	secretBytes = 32
+)
+
+// deliveryPage is a page of the deliveries of a webhook
+type deliveryPage struct {
+	Deliveries []server.Delivery `json:"deliveries"`
+	// Next is the cursor of the next page, empty after the last page
+	Next string `json:"next,omitempty"`
+}
+
+// webhookSchema validates the webhooks sent to create or replace a webhook
+var webhookSchema = jsonschema.Generate(server.Webhook{})
+
+// WithWebhooks lets users subscribe webhooks to the events of their ToDos, storing them in the
+// given repositories. Pings are sent with the dispatcher.
+func WithWebhooks(hooks database.WebhookRepo, deliveries database.DeliveryRepo, dispatcher *webhook.Dispatcher) Option {
+	return func(h *ToDoHandler) {
+		h.webhooks = hooks
+		h.deliveries = deliveries
+		h.dispatcher = dispatcher
+	}
+}
+
+// scopedWebhooks returns the webhooks of the user, or ErrNotFound if webhooks are not enabled
+func (h *ToDoHandler) scopedWebhooks() (*policy.WebhookRepo, error) {
+
+	if h.webhooks == nil {
+		return nil, ErrNotFound
+	}
+
+	return policy.NewWebhookRepo(h.webhooks, h.user), nil
+}
+
+// ownWebhook returns the webhook of a request, which the user must own
+func (h *ToDoHandler) ownWebhook(req events.APIGatewayProxyRequest) (*server.Webhook, error) {
+
+	hooks, err := h.scopedWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := hooks.Get(req.PathParameters["id"])
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if w == nil {
+		return nil, ErrNotFound
+	}
+
+	return w, nil
+}
+
+// parseWebhook parses and validates the webhook of a request body
+func parseWebhook(body string) (*server.Webhook, error) {
+
+	var w server.Webhook
+	if err := decode(webhookSchema, body, &w); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, errors.Wrapf(ErrBadRequest, "URL %s must be an absolute https URL", w.URL)
+	}
+
+	return &w, nil
+}
+
+// generateSecret returns a random secret to sign the deliveries of a webhook with
+func generateSecret() (string, error) {
+
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "Could not generate webhook secret")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func (h *ToDoHandler) getWebhooks(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	hooks, err := h.scopedWebhooks()
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	all, err := hooks.GetByOwner(h.user)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	for i := range all {
+		all[i].Secret = ""
+	}
+
+	return CreateOKResponse(all)
+}
+
+func (h *ToDoHandler) getWebhook(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	w, err := h.ownWebhook(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	w.Secret = ""
+	return CreateOKResponse(w)
+}
+
+// postWebhook creates a webhook, its secret is only returned in the response
+func (h *ToDoHandler) postWebhook(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	hooks, err := h.scopedWebhooks()
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	w, err := parseWebhook(req.Body)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	if w.ID != "" {
+		return CreateErrorResponse(errors.Wrap(ErrBadRequest, "ID must be empty"))
+	}
+
+	existing, err := hooks.GetByOwner(h.user)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	if len(existing) >= maxWebhooks {
+		return CreateErrorResponse(errors.Wrapf(ErrUnprocessable, "users cannot have more than %d webhooks", maxWebhooks))
+	}
+
+	if w.Secret == "" {
+		if w.Secret, err = generateSecret(); err != nil {
+			return CreateErrorResponse(err)
+		}
+	}
+
+	if err := hooks.Save(w); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(w)
+}
+
+// putWebhook replaces a webhook, keeping its secret unless a new one is given
+func (h *ToDoHandler) putWebhook(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	w, err := parseWebhook(req.Body)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	existing, err := h.ownWebhook(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	w.ID = existing.ID
+	w.Created = existing.Created
+	if w.Secret == "" {
+		w.Secret = existing.Secret
+	}
+
+	hooks, _ := h.scopedWebhooks()
+	if err := hooks.Save(w); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	w.Secret = ""
+	return CreateOKResponse(w)
+}
+
+func (h *ToDoHandler) deleteWebhook(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	w, err := h.ownWebhook(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	hooks, _ := h.scopedWebhooks()
+	if err := hooks.Delete(w.ID); err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse("")
+}
+
+func (h *ToDoHandler) getDeliveries(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	limit := defaultDeliveriesPage
+	if l, ok := req.QueryStringParameters["limit"]; ok {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxDeliveriesPage {
+			return CreateErrorResponse(errors.Wrapf(ErrBadRequest, "limit must be between 1 and %d", maxDeliveriesPage))
+		}
+		limit = n
+	}
+
+	w, err := h.ownWebhook(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	deliveries, next, err := h.deliveries.List(w.ID, req.QueryStringParameters["cursor"], limit)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(deliveryPage{Deliveries: deliveries, Next: next})
+}
+
+// pingWebhook sends a ping event to a webhook and returns the outcome of the delivery
+func (h *ToDoHandler) pingWebhook(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	w, err := h.ownWebhook(req)
+	if err != nil {
+		return CreateErrorResponse(err)
+	}
+
+	d, err := h.dispatcher.Ping(w)
+	if err != nil {
+		return CreateErrorResponse(repoError(err))
+	}
+
+	return CreateOKResponse(d)
+}
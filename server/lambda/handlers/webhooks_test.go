@@ -0,0 +1,206 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+	"github.com/massimoselvi/serverless-todo-api-go/server/webhook"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestWebhooks(t *testing.T) {
+	t.Run("PostWebhookGeneratesSecret", testPostWebhookGeneratesSecret)
+	t.Run("PostWebhookRequiresHTTPS", testPostWebhookRequiresHTTPS)
+	t.Run("GetWebhooksHidesSecrets", testGetWebhooksHidesSecrets)
+	t.Run("PutWebhookKeepsSecret", testPutWebhookKeepsSecret)
+	t.Run("WebhookOfOtherUser", testWebhookOfOtherUser)
+	t.Run("GetDeliveries", testGetDeliveries)
+	t.Run("PingWebhook", testPingWebhook)
+}
+
+// webhookHandler returns a handler with a webhook of testUser and one of otherUser, sending
+// deliveries with client
+func webhookHandler(url string, client *http.Client) (*handlers.ToDoHandler, *WebhookRepoMock, *DeliveryRepoMock) {
+
+	hooks := &WebhookRepoMock{Hooks: map[string]*server.Webhook{
+		"mine":   {ID: "mine", URL: url, Secret: testSecret, Owner: testUser},
+		"theirs": {ID: "theirs", URL: url, Secret: testSecret, Owner: otherUser},
+	}}
+	deliveries := &DeliveryRepoMock{}
+
+	dispatcher := webhook.NewDispatcher(hooks, deliveries, &DeliveryRepoMock{}, client)
+
+	return handlers.NewToDoHandler(&RepoMock{}, handlers.WithWebhooks(hooks, deliveries, dispatcher)), hooks, deliveries
+}
+
+func webhookRequest(method, resource, id, body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: testRequestContext,
+		Resource:       resource,
+		PathParameters: map[string]string{"id": id},
+		HTTPMethod:     method,
+		Body:           body,
+	}
+}
+
+func testPostWebhookGeneratesSecret(t *testing.T) {
+
+	h, hooks, _ := webhookHandler("https://hooks.test", http.DefaultClient)
+
+	resp, err := h.Handle(webhookRequest(http.MethodPost, "/webhooks", "", `{"url":"https://example.com/hook","events":["todo.completed"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var w server.Webhook
+	if err := json.Unmarshal([]byte(resp.Body), &w); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(w.Secret) != 64 || w.Owner != testUser {
+		t.Fatalf("Expected a generated secret and the user as owner, got %+v", w)
+	}
+
+	if hooks.Hooks[w.ID].Secret != w.Secret {
+		t.Fatal("Expected the returned secret to be saved")
+	}
+}
+
+func testPostWebhookRequiresHTTPS(t *testing.T) {
+
+	h, hooks, _ := webhookHandler("https://hooks.test", http.DefaultClient)
+
+	resp, err := h.Handle(webhookRequest(http.MethodPost, "/webhooks", "", `{"url":"http://example.com/hook"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	if hooks.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testGetWebhooksHidesSecrets(t *testing.T) {
+
+	h, _, _ := webhookHandler("https://hooks.test", http.DefaultClient)
+
+	resp, err := h.Handle(webhookRequest(http.MethodGet, "/webhooks", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hooks []server.Webhook
+	if err := json.Unmarshal([]byte(resp.Body), &hooks); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hooks) != 1 || hooks[0].ID != "mine" || hooks[0].Secret != "" {
+		t.Fatalf("Expected only the webhook of the user without its secret, got %+v", hooks)
+	}
+}
+
+func testPutWebhookKeepsSecret(t *testing.T) {
+
+	h, hooks, _ := webhookHandler("https://hooks.test", http.DefaultClient)
+
+	resp, err := h.Handle(webhookRequest(http.MethodPut, "/webhooks/{id}", "mine", `{"url":"https://example.com/other"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if w := hooks.Hooks["mine"]; w.URL != "https://example.com/other" || w.Secret != testSecret {
+		t.Fatalf("Expected the URL replaced and the secret kept, got %+v", w)
+	}
+}
+
+func testWebhookOfOtherUser(t *testing.T) {
+
+	h, hooks, _ := webhookHandler("https://hooks.test", http.DefaultClient)
+
+	resp, err := h.Handle(webhookRequest(http.MethodDelete, "/webhooks/{id}", "theirs", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	if hooks.DeleteInvoked {
+		t.Fatal("Delete invoked")
+	}
+}
+
+func testGetDeliveries(t *testing.T) {
+
+	h, _, deliveries := webhookHandler("https://hooks.test", http.DefaultClient)
+	deliveries.Deliveries = []*server.Delivery{
+		{WebhookID: "mine", ID: "b", Status: server.DeliverySucceeded},
+		{WebhookID: "theirs", ID: "a", Status: server.DeliveryDead},
+	}
+
+	req := webhookRequest(http.MethodGet, "/webhooks/{id}/deliveries", "mine", "")
+	req.QueryStringParameters = map[string]string{"limit": "10"}
+
+	resp, err := h.Handle(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var page struct {
+		Deliveries []server.Delivery `json:"deliveries"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &page); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Deliveries) != 1 || page.Deliveries[0].ID != "b" {
+		t.Fatalf("Expected the delivery of the webhook, got %+v", page.Deliveries)
+	}
+}
+
+func testPingWebhook(t *testing.T) {
+
+	var event string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		event = req.Header.Get(webhook.EventHeader)
+	}))
+	defer srv.Close()
+
+	h, _, deliveries := webhookHandler(srv.URL, srv.Client())
+
+	resp, err := h.Handle(webhookRequest(http.MethodPost, "/webhooks/{id}/ping", "mine", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	if event != string(server.EventPing) {
+		t.Fatalf("Expected a ping event, got %q", event)
+	}
+
+	if len(deliveries.Deliveries) != 1 || deliveries.Deliveries[0].Status != server.DeliverySucceeded {
+		t.Fatal("Expected the ping delivery to be logged")
+	}
+}
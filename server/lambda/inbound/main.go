@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/blob"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/inbound"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/env"
+)
+
+// main creates ToDos from the emails received by the SES receipt rule, which stores them in
+// INBOUND_BUCKET before invoking the function
+func main() {
+
+	s, err := session.NewSession(aws.NewConfig().WithRegion("us-west-2"))
+	if err != nil {
+		panic(err)
+	}
+
+	db := awsdynamodb.New(s)
+	client := s3.New(s)
+
+	h := inbound.NewHandler(
+		inbound.NewS3Mailbox(client, os.Getenv("INBOUND_BUCKET"), os.Getenv("INBOUND_PREFIX")),
+		dynamodb.NewProfileRepo(db),
+		dynamodb.NewToDoRepo(db),
+		dynamodb.NewCommentRepo(db),
+		blob.NewS3Store(client, os.Getenv("ATTACHMENTS_BUCKET"), 15*time.Minute),
+		server.AttachmentLimits{
+			MaxSize:      int64(env.Int("MAX_ATTACHMENT_SIZE", 10<<20)),
+			AllowedTypes: env.List("ATTACHMENT_TYPES"),
+		},
+		dynamodb.NewQuotaRepo(db),
+		env.Int("MAX_TODOS_PER_USER", 0),
+	)
+
+	awslambda.Start(h.Handle)
+}
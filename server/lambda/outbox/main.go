@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/env"
+	"github.com/massimoselvi/serverless-todo-api-go/server/publisher"
+)
+
+// main relays the events of the outbox, from the stream of the outbox table by default, or by
+// polling it on a schedule when RELAY_MODE is poll
+func main() {
+
+	s, err := session.NewSession(aws.NewConfig().WithRegion("us-west-2"))
+	if err != nil {
+		panic(err)
+	}
+
+	var pub server.EventPublisher
+	if topic := os.Getenv("EVENTS_TOPIC_ARN"); topic != "" {
+		pub = publisher.NewSNS(sns.New(s), topic)
+	} else {
+		pub = publisher.NewEventBridge(eventbridge.New(s), os.Getenv("EVENT_BUS_NAME"))
+	}
+
+	relay := publisher.NewRelay(dynamodb.NewOutboxRepo(awsdynamodb.New(s)), pub)
+
+	if os.Getenv("RELAY_MODE") == "poll" {
+		limit := env.Int("RELAY_BATCH_SIZE", 100)
+		awslambda.Start(func(events.CloudWatchEvent) error {
+			_, err := relay.Drain(limit)
+			return err
+		})
+		return
+	}
+
+	awslambda.Start(relay.HandleStream)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/env"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/massimoselvi/serverless-todo-api-go/server/reminder"
+)
+
+// main sends the due reminders of ToDos when invoked by the schedule
+func main() {
+
+	s, err := session.NewSession(aws.NewConfig().WithRegion("us-west-2"))
+	if err != nil {
+		panic(err)
+	}
+
+	db := awsdynamodb.New(s)
+	sender := reminder.NewSender(
+		dynamodb.NewReminderRepo(db),
+		dynamodb.NewProfileRepo(db),
+		notifier(s),
+	)
+
+	limit := env.Int("REMINDER_BATCH_SIZE", 100)
+
+	awslambda.Start(func(ev events.CloudWatchEvent) error {
+
+		sent, err := sender.Send(time.Now(), limit)
+		log.Printf("Sent %d reminders", sent)
+
+		return err
+	})
+}
+
+// notifier returns the notifier of the channel set by the NOTIFIER environment variable, which
+// is ses, sns or log
+func notifier(s *session.Session) notify.Notifier {
+
+	switch os.Getenv("NOTIFIER") {
+	case "ses":
+		return notify.NewSES(ses.New(s), os.Getenv("NOTIFY_FROM"))
+	case "sns":
+		return notify.NewSNS(sns.New(s))
+	default:
+		return notify.NewLog(os.Stderr)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/projection"
+)
+
+// main applies the changes of the todos table, read from its stream, to the projections
+func main() {
+
+	s, err := session.NewSession(aws.NewConfig().WithRegion("us-west-2"))
+	if err != nil {
+		panic(err)
+	}
+
+	db := awsdynamodb.New(s)
+
+	c := projection.NewConsumer(
+		projection.NewStats(dynamodb.NewStatsRepo(db)),
+		projection.NewSearchIndex(dynamodb.NewSearchIndexRepo(db)),
+		projection.NewSharing(dynamodb.NewShareRepo(db), dynamodb.NewTombstoneRepo(db)),
+	)
+
+	awslambda.Start(c.Handle)
+}
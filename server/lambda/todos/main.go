@@ -1,12 +1,23 @@
 package main
 
 import (
+	"os"
+	"time"
+
 	awslambda "github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/blob"
 	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/env"
 	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/handlers"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/massimoselvi/serverless-todo-api-go/server/ratelimit"
+	"github.com/massimoselvi/serverless-todo-api-go/server/webhook"
 )
 
 func main() {
@@ -16,10 +27,69 @@ func main() {
 		panic(err)
 	}
 
+	cors := corsFromEnv()
+	if err := cors.Validate(); err != nil {
+		panic(err)
+	}
+
 	db := awsdynamodb.New(s)
 	repo := dynamodb.NewToDoRepo(db)
 
-	h := handlers.NewToDoHandler(repo)
+	limiter, err := ratelimit.NewLimiter(dynamodb.NewRateLimitRepo(db), env.Int("RATE_LIMIT_BURST", 60), env.Float("RATE_LIMIT_RATE", 1))
+	if err != nil {
+		panic(err)
+	}
+
+	deliveries := dynamodb.NewDeliveryRepo(db)
+	dispatcher := webhook.NewDispatcher(dynamodb.NewWebhookRepo(db), deliveries, dynamodb.NewDeadLetterRepo(db), webhook.NewClient(10*time.Second))
+
+	opts := []handlers.Option{
+		handlers.WithCORS(cors),
+		handlers.WithIdempotency(dynamodb.NewIdempotencyRepo(db), 24*time.Hour),
+		handlers.WithRateLimit(limiter),
+		handlers.WithQuota(env.Int("MAX_TODOS_PER_USER", 0), dynamodb.NewQuotaRepo(db)),
+		handlers.WithLists(dynamodb.NewListRepo(db)),
+		handlers.WithLabels(dynamodb.NewLabelRepo(db)),
+		handlers.WithComments(dynamodb.NewCommentRepo(db)),
+		handlers.WithWebhooks(dynamodb.NewWebhookRepo(db), deliveries, dispatcher),
+		handlers.WithSync(dynamodb.NewTombstoneRepo(db), dynamodb.NewShareRepo(db), dynamodb.TombstoneRetention),
+		handlers.WithProfiles(dynamodb.NewProfileRepo(db)),
+		// Events are published from the outbox by the outbox function
+		handlers.WithOutbox(repo),
+	}
+
+	if bucket := os.Getenv("ATTACHMENTS_BUCKET"); bucket != "" {
+		opts = append(opts, handlers.WithAttachments(blob.NewS3Store(s3.New(s), bucket, 15*time.Minute), server.AttachmentLimits{
+			MaxSize:      int64(env.Int("MAX_ATTACHMENT_SIZE", 10<<20)),
+			AllowedTypes: env.List("ATTACHMENT_TYPES"),
+		}))
+	}
+
+	if secret := os.Getenv("DIGEST_SECRET"); secret != "" {
+		opts = append(opts, handlers.WithDigests([]byte(secret)))
+	}
+
+	if secret := os.Getenv("EMAIL_VERIFICATION_SECRET"); secret != "" {
+		notifier := notify.NewSES(ses.New(s), os.Getenv("NOTIFY_FROM"))
+		opts = append(opts, handlers.WithEmailVerification(notifier, []byte(secret), os.Getenv("EMAIL_VERIFICATION_URL")))
+	}
+
+	h := handlers.NewToDoHandler(repo, opts...)
 
 	awslambda.Start(h.Handle)
 }
+
+// corsFromEnv reads the CORS policy of the current stage from the environment
+func corsFromEnv() *handlers.CORS {
+
+	maxAge := env.Int("CORS_MAX_AGE", 0)
+
+	return &handlers.CORS{
+		AllowedOrigins:   env.List("CORS_ALLOWED_ORIGINS"),
+		AllowedMethods:   env.List("CORS_ALLOWED_METHODS"),
+		AllowedHeaders:   env.List("CORS_ALLOWED_HEADERS"),
+		ExposedHeaders:   env.List("CORS_EXPOSED_HEADERS"),
+		AllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+		MaxAge:           time.Duration(maxAge) * time.Second,
+	}
+}
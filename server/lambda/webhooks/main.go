@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/massimoselvi/serverless-todo-api-go/server/lambda/env"
+	"github.com/massimoselvi/serverless-todo-api-go/server/publisher"
+	"github.com/massimoselvi/serverless-todo-api-go/server/webhook"
+	"github.com/pkg/errors"
+)
+
+// main delivers the events published to the event bus to the webhooks subscribed to them, and
+// retries the failed deliveries when invoked by the schedule
+func main() {
+
+	s, err := session.NewSession(aws.NewConfig().WithRegion("us-west-2"))
+	if err != nil {
+		panic(err)
+	}
+
+	db := awsdynamodb.New(s)
+	dispatcher := webhook.NewDispatcher(
+		dynamodb.NewWebhookRepo(db),
+		dynamodb.NewDeliveryRepo(db),
+		dynamodb.NewDeadLetterRepo(db),
+		webhook.NewClient(10*time.Second),
+	)
+
+	limit := env.Int("WEBHOOK_RETRY_BATCH_SIZE", 100)
+
+	awslambda.Start(func(ev events.CloudWatchEvent) error {
+
+		if ev.Source != publisher.Source {
+			_, err := dispatcher.Retry(limit)
+			return err
+		}
+
+		var e server.Event
+		if err := json.Unmarshal(ev.Detail, &e); err != nil {
+			return errors.Wrapf(err, "Could not unmarshal event %s", ev.ID)
+		}
+
+		return dispatcher.Dispatch(e)
+	})
+}
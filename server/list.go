@@ -0,0 +1,27 @@
+package server
+
+import (
+	"sort"
+	"time"
+)
+
+// List groups ToDos into a named project
+type List struct {
+	ID       string    `json:"id" schema:"format=uuid"`
+	Name     string    `json:"name" schema:"required,minLength=1,maxLength=100"`
+	Color    string    `json:"color,omitempty" schema:"maxLength=7"`
+	Archived bool      `json:"archived"`
+	Order    int       `json:"order" schema:"minimum=0"`
+	ModTime  time.Time `json:"modTime" schema:"readonly"`
+	Owner    string    `json:"owner,omitempty" schema:"readonly"`
+}
+
+// SortLists orders lists by their order, then by name
+func SortLists(lists []List) {
+	sort.SliceStable(lists, func(i, j int) bool {
+		if lists[i].Order != lists[j].Order {
+			return lists[i].Order < lists[j].Order
+		}
+		return lists[i].Name < lists[j].Name
+	})
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// serverFields are the fields of a ToDo set by the server, which clients cannot change
+var serverFields = map[string]bool{
+	"id":          true,
+	"modTime":     true,
+	"owner":       true,
+	"rank":        true,
+	"attachments": true,
+	"progress":    true,
+	"completedAt": true,
+}
+
+// setFields are the fields of a ToDo holding sets of strings, the additions and removals made
+// on both sides are merged
+var setFields = map[string]bool{
+	"tags": true,
+}
+
+// structuredFields are the fields of a ToDo which cannot be merged when changed on both sides
+var structuredFields = map[string]bool{
+	"members":   true,
+	"items":     true,
+	"blockedBy": true,
+}
+
+// Conflict is a field of a ToDo changed by a client and on the server which could not be merged.
+// The server value is kept.
+type Conflict struct {
+	Field  string      `json:"field"`
+	Client interface{} `json:"client"`
+	Server interface{} `json:"server"`
+}
+
+// Merge merges the changes a client made at changed to base, its copy of a ToDo, with the
+// changes made on the server since, which led to current. Fields changed on a single side take
+// the changed value. Fields changed on both sides are merged field by field: sets get the
+// additions and removals of both sides, other scalars take the value of the last writer, and
+// structured fields keep the server value and are returned as conflicts. The time of the client
+// change is clamped to received, the time the server received it, so that a client with a clock
+// ahead does not win every field.
+func Merge(base, client, current *ToDo, changed, received time.Time) (ToDo, []Conflict, error) {
+
+	if changed.After(received) {
+		changed = received
+	}
+
+	b, err := fields(base)
+	if err != nil {
+		return ToDo{}, nil, err
+	}
+
+	c, err := fields(client)
+	if err != nil {
+		return ToDo{}, nil, err
+	}
+
+	merged, err := fields(current)
+	if err != nil {
+		return ToDo{}, nil, err
+	}
+
+	names := map[string]bool{}
+	for _, m := range []map[string]interface{}{b, c, merged} {
+		for name := range m {
+			names[name] = true
+		}
+	}
+
+	conflicts := []Conflict{}
+	clientWins := changed.After(current.ModTime)
+
+	for _, name := range sortedKeys(names) {
+
+		if serverFields[name] {
+			continue
+		}
+
+		bv, cv, sv := b[name], c[name], merged[name]
+
+		switch {
+		case reflect.DeepEqual(bv, cv) || reflect.DeepEqual(cv, sv):
+			// Only the server changed the field, if at all
+		case reflect.DeepEqual(bv, sv):
+			set(merged, name, cv)
+		case setFields[name]:
+			set(merged, name, mergeSets(bv, cv, sv))
+		case structuredFields[name]:
+			conflicts = append(conflicts, Conflict{Field: name, Client: cv, Server: sv})
+		case clientWins:
+			set(merged, name, cv)
+		}
+	}
+
+	var t ToDo
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return ToDo{}, nil, err
+	}
+
+	if err := json.Unmarshal(data, &t); err != nil {
+		return ToDo{}, nil, err
+	}
+
+	return t, conflicts, nil
+}
+
+// fields returns the JSON fields of a ToDo
+func fields(t *ToDo) (map[string]interface{}, error) {
+
+	m := map[string]interface{}{}
+	if t == nil {
+		return m, nil
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, json.Unmarshal(data, &m)
+}
+
+// set sets a field, removing it when the value is absent
+func set(m map[string]interface{}, name string, v interface{}) {
+	if v == nil {
+		delete(m, name)
+		return
+	}
+	m[name] = v
+}
+
+// mergeSets returns the server set with the additions and removals the client made to base
+func mergeSets(base, client, server interface{}) interface{} {
+
+	b, c := stringSet(base), stringSet(client)
+
+	merged := stringSet(server)
+	for s := range c {
+		if !b[s] {
+			merged[s] = true
+		}
+	}
+	for s := range b {
+		if !c[s] {
+			delete(merged, s)
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil
+	}
+
+	values := []interface{}{}
+	for _, s := range sortedKeys(merged) {
+		values = append(values, s)
+	}
+
+	return values
+}
+
+// stringSet returns the strings of a JSON array
+func stringSet(v interface{}) map[string]bool {
+
+	set := map[string]bool{}
+
+	values, _ := v.([]interface{})
+	for _, s := range values {
+		if s, ok := s.(string); ok {
+			set[s] = true
+		}
+	}
+
+	return set
+}
+
+func sortedKeys(m map[string]bool) []string {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"io"
+	"log"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+)
+
+// Log writes notifications to a log instead of sending them, for local development
+type Log struct {
+	logger *log.Logger
+}
+
+// NewLog returns a notifier writing notifications to w
+func NewLog(w io.Writer) *Log {
+	return &Log{log.New(w, "notify: ", log.LstdFlags)}
+}
+
+// Notify logs a notification
+func (n *Log) Notify(to *server.Profile, msg Message) error {
+	n.logger.Printf("to %s: %s\n%s", to.User, msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,22 @@
+// Package notify sends notifications to users through the channel of the deployment
+package notify
+
+import (
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// ErrNoContact is returned when a user has no contact details for the channel of a notifier
+var ErrNoContact = errors.New("user has no contact details for the channel")
+
+// Message is a notification, channels which do not support HTML send the text
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Notifier sends notifications to users
+type Notifier interface {
+	Notify(to *server.Profile, msg Message) error
+}
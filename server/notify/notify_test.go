@@ -0,0 +1,121 @@
+package notify_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/aws/aws-sdk-go/service/ses/sesiface"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/pkg/errors"
+)
+
+const testUser = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+
+// SESMock records the emails sent
+type SESMock struct {
+	sesiface.SESAPI
+	Sent []*ses.SendEmailInput
+}
+
+// SendEmail records an email
+func (m *SESMock) SendEmail(input *ses.SendEmailInput) (*ses.SendEmailOutput, error) {
+	m.Sent = append(m.Sent, input)
+	return &ses.SendEmailOutput{}, nil
+}
+
+// SNSMock records the text messages sent
+type SNSMock struct {
+	snsiface.SNSAPI
+	Sent []*sns.PublishInput
+}
+
+// Publish records a text message
+func (m *SNSMock) Publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	m.Sent = append(m.Sent, input)
+	return &sns.PublishOutput{}, nil
+}
+
+var testMessage = notify.Message{Subject: "Reminder: Pay rent", Text: "Pay rent is due", HTML: "<p>Pay rent is due</p>"}
+
+func TestNotify(t *testing.T) {
+	t.Run("SES", testSES)
+	t.Run("SESNoEmail", testSESNoEmail)
+	t.Run("SNS", testSNS)
+	t.Run("SNSNoPhone", testSNSNoPhone)
+	t.Run("Log", testLog)
+}
+
+func testSES(t *testing.T) {
+
+	m := &SESMock{}
+	if err := notify.NewSES(m, "todo@example.com").Notify(&server.Profile{User: testUser, Email: "user@example.com"}, testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Sent) != 1 {
+		t.Fatalf("Expected 1 email, got %d", len(m.Sent))
+	}
+
+	e := m.Sent[0]
+	if *e.Source != "todo@example.com" || *e.Destination.ToAddresses[0] != "user@example.com" {
+		t.Fatalf("Expected an email from the sender to the user, got %v", e)
+	}
+
+	if *e.Message.Subject.Data != testMessage.Subject || *e.Message.Body.Text.Data != testMessage.Text || *e.Message.Body.Html.Data != testMessage.HTML {
+		t.Fatalf("Expected the email to hold the message, got %v", e.Message)
+	}
+}
+
+func testSESNoEmail(t *testing.T) {
+
+	m := &SESMock{}
+	err := notify.NewSES(m, "todo@example.com").Notify(&server.Profile{User: testUser, Phone: "+14155550100"}, testMessage)
+	if errors.Cause(err) != notify.ErrNoContact {
+		t.Fatalf("Expected ErrNoContact, got %v", err)
+	}
+
+	if len(m.Sent) != 0 {
+		t.Fatalf("Expected no email, got %d", len(m.Sent))
+	}
+}
+
+func testSNS(t *testing.T) {
+
+	m := &SNSMock{}
+	if err := notify.NewSNS(m).Notify(&server.Profile{User: testUser, Phone: "+14155550100"}, testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Sent) != 1 {
+		t.Fatalf("Expected 1 text message, got %d", len(m.Sent))
+	}
+
+	if *m.Sent[0].PhoneNumber != "+14155550100" || *m.Sent[0].Message != testMessage.Subject+"\n"+testMessage.Text {
+		t.Fatalf("Expected the subject and text to be texted to the user, got %v", m.Sent[0])
+	}
+}
+
+func testSNSNoPhone(t *testing.T) {
+
+	err := notify.NewSNS(&SNSMock{}).Notify(&server.Profile{User: testUser, Email: "user@example.com"}, testMessage)
+	if errors.Cause(err) != notify.ErrNoContact {
+		t.Fatalf("Expected ErrNoContact, got %v", err)
+	}
+}
+
+func testLog(t *testing.T) {
+
+	var b bytes.Buffer
+	if err := notify.NewLog(&b).Notify(&server.Profile{User: testUser}, testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(b.String(), testMessage.Subject) {
+		t.Fatalf("Expected the message to be logged, got %q", b.String())
+	}
+}
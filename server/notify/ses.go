@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/aws/aws-sdk-go/service/ses/sesiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// charset is the character set of the emails
+const charset = "UTF-8"
+
+// SES sends notifications by email with Amazon SES
+type SES struct {
+	api  sesiface.SESAPI
+	from string
+}
+
+// NewSES returns a notifier sending emails from the given verified address
+func NewSES(api sesiface.SESAPI, from string) *SES {
+	return &SES{
+		api:  api,
+		from: from,
+	}
+}
+
+// Notify emails a notification to the email address of a user
+func (n *SES) Notify(to *server.Profile, msg Message) error {
+
+	if to.Email == "" {
+		return errors.Wrapf(ErrNoContact, "user %s has no email address", to.User)
+	}
+
+	body := &ses.Body{
+		Text: &ses.Content{Charset: aws.String(charset), Data: aws.String(msg.Text)},
+	}
+	if msg.HTML != "" {
+		body.Html = &ses.Content{Charset: aws.String(charset), Data: aws.String(msg.HTML)}
+	}
+
+	input := &ses.SendEmailInput{
+		Source:      aws.String(n.from),
+		Destination: &ses.Destination{ToAddresses: []*string{aws.String(to.Email)}},
+		Message: &ses.Message{
+			Subject: &ses.Content{Charset: aws.String(charset), Data: aws.String(msg.Subject)},
+			Body:    body,
+		},
+	}
+
+	if _, err := n.api.SendEmail(input); err != nil {
+		return errors.Wrapf(err, "Could not email user %s", to.User)
+	}
+
+	return nil
+}
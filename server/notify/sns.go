@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// SNS sends notifications by text message with Amazon SNS
+type SNS struct {
+	api snsiface.SNSAPI
+}
+
+// NewSNS returns a notifier sending text messages
+func NewSNS(api snsiface.SNSAPI) *SNS {
+	return &SNS{api}
+}
+
+// Notify texts the subject and the text of a notification to the phone number of a user
+func (n *SNS) Notify(to *server.Profile, msg Message) error {
+
+	if to.Phone == "" {
+		return errors.Wrapf(ErrNoContact, "user %s has no phone number", to.User)
+	}
+
+	input := &sns.PublishInput{
+		PhoneNumber: aws.String(to.Phone),
+		Message:     aws.String(msg.Subject + "\n" + msg.Text),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{
+			// Notifications are time sensitive
+			"AWS.SNS.SMS.SMSType": {DataType: aws.String("String"), StringValue: aws.String("Transactional")},
+		},
+	}
+
+	if _, err := n.api.Publish(input); err != nil {
+		return errors.Wrapf(err, "Could not text user %s", to.User)
+	}
+
+	return nil
+}
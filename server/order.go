@@ -0,0 +1,19 @@
+package server
+
+import "sort"
+
+// SortByRank orders ToDos by rank. ToDos that were never moved have no rank and come last,
+// oldest first.
+func SortByRank(todos []ToDo) {
+	sort.SliceStable(todos, func(i, j int) bool {
+		a, b := todos[i], todos[j]
+		switch {
+		case a.Rank != "" && b.Rank != "":
+			return a.Rank < b.Rank
+		case a.Rank != "" || b.Rank != "":
+			return a.Rank != ""
+		default:
+			return a.ModTime.Before(b.ModTime)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// LabelRepo restricts a label repository to the labels owned by a user. Labels are not shared.
+type LabelRepo struct {
+	repo database.LabelRepo
+	user string
+}
+
+// NewLabelRepo returns a label repository that only lets user access the labels they own
+func NewLabelRepo(repo database.LabelRepo, user string) *LabelRepo {
+	return &LabelRepo{
+		repo: repo,
+		user: user,
+	}
+}
+
+// Get returns a label by its ID if the user owns it
+func (r *LabelRepo) Get(id string) (*server.Label, error) {
+
+	l, err := r.repo.Get(id)
+	if err != nil || l == nil {
+		return l, err
+	}
+
+	if l.Owner != r.user {
+		return nil, errors.Wrapf(ErrForbidden, "user %s cannot read label %s", r.user, id)
+	}
+
+	return l, nil
+}
+
+// GetAll returns the labels owned by the user
+func (r *LabelRepo) GetAll() ([]server.Label, error) {
+
+	all, err := r.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := []server.Label{}
+	for _, l := range all {
+		if l.Owner == r.user {
+			labels = append(labels, l)
+		}
+	}
+
+	return labels, nil
+}
+
+// Save creates a label owned by the user, or updates a label the user owns
+func (r *LabelRepo) Save(label *server.Label) error {
+
+	if label.ID != "" {
+		existing, err := r.Get(label.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.Owner != r.user {
+			return errors.Wrapf(ErrForbidden, "user %s cannot update label %s", r.user, label.ID)
+		}
+	}
+
+	label.Owner = r.user
+	return r.repo.Save(label)
+}
+
+// Delete permanently removes a label if the user owns it
+func (r *LabelRepo) Delete(id string) error {
+
+	if _, err := r.Get(id); err != nil {
+		return err
+	}
+
+	return r.repo.Delete(id)
+}
@@ -0,0 +1,44 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/pkg/errors"
+)
+
+func TestLabelPolicy(t *testing.T) {
+	t.Run("GetLabelOfOtherUser", testGetLabelOfOtherUser)
+	t.Run("DeleteLabelOfOtherUser", testDeleteLabelOfOtherUser)
+}
+
+func ownedLabels() *LabelRepoMock {
+	return &LabelRepoMock{
+		Labels: map[string]*server.Label{
+			"urgent": {ID: "urgent", Name: "urgent", Owner: owner},
+		},
+	}
+}
+
+func testGetLabelOfOtherUser(t *testing.T) {
+
+	_, err := policy.NewLabelRepo(ownedLabels(), stranger).Get("urgent")
+	if errors.Cause(err) != policy.ErrForbidden {
+		t.Fatalf("Expected %v, got %v", policy.ErrForbidden, err)
+	}
+}
+
+func testDeleteLabelOfOtherUser(t *testing.T) {
+
+	m := ownedLabels()
+
+	err := policy.NewLabelRepo(m, stranger).Delete("urgent")
+	if errors.Cause(err) != policy.ErrForbidden {
+		t.Fatalf("Expected %v, got %v", policy.ErrForbidden, err)
+	}
+
+	if m.DeleteInvoked {
+		t.Fatal("Delete invoked")
+	}
+}
@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// ListRepo restricts a list repository to the lists owned by a user. Lists are not shared.
+type ListRepo struct {
+	repo database.ListRepo
+	user string
+}
+
+// NewListRepo returns a list repository that only lets user access the lists they own
+func NewListRepo(repo database.ListRepo, user string) *ListRepo {
+	return &ListRepo{
+		repo: repo,
+		user: user,
+	}
+}
+
+// Get returns a list by its ID if the user owns it
+func (r *ListRepo) Get(id string) (*server.List, error) {
+
+	l, err := r.repo.Get(id)
+	if err != nil || l == nil {
+		return l, err
+	}
+
+	if l.Owner != r.user {
+		return nil, errors.Wrapf(ErrForbidden, "user %s cannot read list %s", r.user, id)
+	}
+
+	return l, nil
+}
+
+// GetAll returns the lists owned by the user
+func (r *ListRepo) GetAll() ([]server.List, error) {
+
+	all, err := r.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	lists := []server.List{}
+	for _, l := range all {
+		if l.Owner == r.user {
+			lists = append(lists, l)
+		}
+	}
+
+	return lists, nil
+}
+
+// Save creates a list owned by the user, or updates a list the user owns
+func (r *ListRepo) Save(list *server.List) error {
+
+	if list.ID != "" {
+		existing, err := r.Get(list.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.Owner != r.user {
+			return errors.Wrapf(ErrForbidden, "user %s cannot update list %s", r.user, list.ID)
+		}
+	}
+
+	list.Owner = r.user
+	return r.repo.Save(list)
+}
+
+// Delete permanently removes a list if the user owns it
+func (r *ListRepo) Delete(id string) error {
+
+	if _, err := r.Get(id); err != nil {
+		return err
+	}
+
+	return r.repo.Delete(id)
+}
@@ -0,0 +1,69 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/pkg/errors"
+)
+
+func TestListPolicy(t *testing.T) {
+	t.Run("GetAllListsFiltersOthers", testGetAllListsFiltersOthers)
+	t.Run("SaveListOfOtherUser", testSaveListOfOtherUser)
+	t.Run("ViewerCannotMove", testViewerCannotMove)
+}
+
+func ownedLists() *ListRepoMock {
+	return &ListRepoMock{
+		Lists: map[string]*server.List{
+			"work": {ID: "work", Name: "Work", Owner: owner},
+			"home": {ID: "home", Name: "Home", Owner: stranger},
+		},
+	}
+}
+
+func testGetAllListsFiltersOthers(t *testing.T) {
+
+	lists, err := policy.NewListRepo(ownedLists(), owner).GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lists) != 1 || lists[0].ID != "work" {
+		t.Fatalf("Expected only the work list, got %+v", lists)
+	}
+}
+
+func testSaveListOfOtherUser(t *testing.T) {
+
+	m := ownedLists()
+
+	err := policy.NewListRepo(m, owner).Save(&server.List{ID: "home", Name: "Mine now"})
+	if errors.Cause(err) != policy.ErrForbidden {
+		t.Fatalf("Expected %v, got %v", policy.ErrForbidden, err)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testViewerCannotMove(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := sharedToDo()
+			return &todo, nil
+		},
+	}
+
+	err := policy.NewToDoRepo(m, viewer).Move(testUUID, "", "work")
+	if errors.Cause(err) != policy.ErrForbidden {
+		t.Fatalf("Expected %v, got %v", policy.ErrForbidden, err)
+	}
+
+	if m.MoveInvoked {
+		t.Fatal("Move invoked")
+	}
+}
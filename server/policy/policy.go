@@ -0,0 +1,207 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// ErrForbidden is returned when the user does not have a role allowing the requested action
+var ErrForbidden = errors.New("forbidden")
+
+// Action is an operation a user can attempt on a resource
+type Action string
+
+const (
+	// ActionRead allows a resource to be read
+	ActionRead Action = "read"
+	// ActionWrite allows a resource to be updated
+	ActionWrite Action = "write"
+	// ActionDelete allows a resource to be deleted
+	ActionDelete Action = "delete"
+	// ActionShare allows the members of a resource to be changed
+	ActionShare Action = "share"
+)
+
+// permissions lists the actions granted to each role
+var permissions = map[server.Role][]Action{
+	server.RoleOwner:  {ActionRead, ActionWrite, ActionDelete, ActionShare},
+	server.RoleEditor: {ActionRead, ActionWrite},
+	server.RoleViewer: {ActionRead},
+}
+
+// Allowed reports whether the given role is allowed to perform the action
+func Allowed(role server.Role, action Action) bool {
+	for _, a := range permissions[role] {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ToDoRepo enforces role based permissions on a ToDo repository on behalf of a user
+type ToDoRepo struct {
+	repo database.ToDoRepo
+	user string
+}
+
+// NewToDoRepo returns a ToDo repository that only lets user perform the actions allowed by
+// the role they have on each ToDo
+func NewToDoRepo(repo database.ToDoRepo, user string) *ToDoRepo {
+	return &ToDoRepo{
+		repo: repo,
+		user: user,
+	}
+}
+
+// Get returns a ToDo by its ID if the user is allowed to read it
+func (r *ToDoRepo) Get(id string) (*server.ToDo, error) {
+
+	t, err := r.repo.Get(id)
+	if err != nil || t == nil {
+		return t, err
+	}
+
+	if !Allowed(t.RoleOf(r.user), ActionRead) {
+		return nil, errors.Wrapf(ErrForbidden, "user %s cannot read ToDo %s", r.user, id)
+	}
+
+	return t, nil
+}
+
+// GetAll returns all ToDos the user is allowed to read
+func (r *ToDoRepo) GetAll() ([]server.ToDo, error) {
+
+	all, err := r.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.readable(all), nil
+}
+
+// GetChildren returns the children of a ToDo the user is allowed to read
+func (r *ToDoRepo) GetChildren(parentID string) ([]server.ToDo, error) {
+
+	children, err := r.repo.GetChildren(parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.readable(children), nil
+}
+
+// GetByList returns the ToDos of a list the user is allowed to read
+func (r *ToDoRepo) GetByList(listID string) ([]server.ToDo, error) {
+
+	todos, err := r.repo.GetByList(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.readable(todos), nil
+}
+
+// GetByOwner returns the ToDos of an owner the user is allowed to read
+func (r *ToDoRepo) GetByOwner(owner string) ([]server.ToDo, error) {
+
+	todos, err := r.repo.GetByOwner(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.readable(todos), nil
+}
+
+// ModifiedSince returns the ToDos of an owner modified after since the user is allowed to read
+func (r *ToDoRepo) ModifiedSince(owner string, since time.Time) ([]server.ToDo, error) {
+
+	todos, err := r.repo.ModifiedSince(owner, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.readable(todos), nil
+}
+
+// readable returns the ToDos the user is allowed to read
+func (r *ToDoRepo) readable(all []server.ToDo) []server.ToDo {
+
+	todos := []server.ToDo{}
+	for _, t := range all {
+		if Allowed(t.RoleOf(r.user), ActionRead) {
+			todos = append(todos, t)
+		}
+	}
+
+	return todos
+}
+
+// Save creates a ToDo owned by the user, or updates a ToDo the user is allowed to write. Only
+// owners can change the members of a ToDo. The time the ToDo was completed at is stamped here,
+// as clients cannot set it.
+func (r *ToDoRepo) Save(todo *server.ToDo) error {
+
+	if todo.ID == "" {
+		todo.Owner = r.user
+		todo.StampCompletion(nil, time.Now())
+		return r.repo.Save(todo)
+	}
+
+	existing, err := r.repo.Get(todo.ID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		todo.Owner = r.user
+		todo.StampCompletion(nil, time.Now())
+		return r.repo.Save(todo)
+	}
+
+	role := existing.RoleOf(r.user)
+	if !Allowed(role, ActionWrite) {
+		return errors.Wrapf(ErrForbidden, "user %s cannot update ToDo %s", r.user, todo.ID)
+	}
+
+	todo.Owner = existing.Owner
+	todo.StampCompletion(existing, time.Now())
+	if !Allowed(role, ActionShare) {
+		todo.Members = existing.Members
+	}
+
+	return r.repo.Save(todo)
+}
+
+// Move sets the list of a ToDo if the user is allowed to update it
+func (r *ToDoRepo) Move(id, from, to string) error {
+
+	t, err := r.repo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if t != nil && !Allowed(t.RoleOf(r.user), ActionWrite) {
+		return errors.Wrapf(ErrForbidden, "user %s cannot update ToDo %s", r.user, id)
+	}
+
+	return r.repo.Move(id, from, to)
+}
+
+// Delete permanently removes a ToDo if the user is allowed to delete it
+func (r *ToDoRepo) Delete(id string) error {
+
+	t, err := r.repo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if t != nil && !Allowed(t.RoleOf(r.user), ActionDelete) {
+		return errors.Wrapf(ErrForbidden, "user %s cannot delete ToDo %s", r.user, id)
+	}
+
+	return r.repo.Delete(id)
+}
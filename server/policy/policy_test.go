@@ -0,0 +1,248 @@
+package policy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/policy"
+	"github.com/pkg/errors"
+)
+
+const (
+	testUUID = "a8a43435-20d8-4af2-8f94-f504aff2c6f3"
+	owner    = "owner-user"
+	editor   = "editor-user"
+	viewer   = "viewer-user"
+	stranger = "stranger-user"
+)
+
+func sharedToDo() server.ToDo {
+	return server.ToDo{
+		ID:    testUUID,
+		Title: "Shared ToDo",
+		Owner: owner,
+		Members: map[string]server.Role{
+			editor: server.RoleEditor,
+			viewer: server.RoleViewer,
+		},
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	t.Run("GetAllFiltersUnreadable", testGetAllFiltersUnreadable)
+	t.Run("GetForbiddenForStranger", testGetForbiddenForStranger)
+	t.Run("CreateSetsOwner", testCreateSetsOwner)
+	t.Run("EditorCannotShare", testEditorCannotShare)
+	t.Run("SaveStampsCompletion", testSaveStampsCompletion)
+	t.Run("OwnerCanDelete", testOwnerCanDelete)
+	t.Run("ViewerCannotSave", testViewerCannotSave)
+	t.Run("LegacyToDoReadOnly", testLegacyToDoReadOnly)
+}
+
+func testGetAllFiltersUnreadable(t *testing.T) {
+
+	m := &RepoMock{
+		GetAllFn: func() ([]server.ToDo, error) {
+			return []server.ToDo{sharedToDo(), {ID: "other", Owner: stranger}}, nil
+		},
+	}
+
+	todos, err := policy.NewToDoRepo(m, viewer).GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(todos) != 1 || todos[0].ID != testUUID {
+		t.Fatalf("Expected only ToDo %s, got %v", testUUID, todos)
+	}
+}
+
+func testGetForbiddenForStranger(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := sharedToDo()
+			return &todo, nil
+		},
+	}
+
+	_, err := policy.NewToDoRepo(m, stranger).Get(testUUID)
+	if errors.Cause(err) != policy.ErrForbidden {
+		t.Fatalf("Expected %v, got %v", policy.ErrForbidden, err)
+	}
+}
+
+func testCreateSetsOwner(t *testing.T) {
+
+	m := &RepoMock{
+		SaveFn: func(*server.ToDo) error {
+			return nil
+		},
+	}
+
+	todo := &server.ToDo{Title: "New ToDo", Owner: stranger}
+
+	if err := policy.NewToDoRepo(m, editor).Save(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if todo.Owner != editor {
+		t.Fatalf("Expected owner %s, got %s", editor, todo.Owner)
+	}
+}
+
+func testEditorCannotShare(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := sharedToDo()
+			return &todo, nil
+		},
+		SaveFn: func(*server.ToDo) error {
+			return nil
+		},
+	}
+
+	todo := sharedToDo()
+	todo.Title = "Updated"
+	todo.Owner = editor
+	todo.Members = map[string]server.Role{stranger: server.RoleEditor}
+
+	if err := policy.NewToDoRepo(m, editor).Save(&todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if todo.Owner != owner {
+		t.Fatalf("Expected owner %s, got %s", owner, todo.Owner)
+	}
+
+	if _, ok := todo.Members[stranger]; ok {
+		t.Fatal("Expected members to be left unchanged")
+	}
+}
+
+func testSaveStampsCompletion(t *testing.T) {
+
+	completed := time.Date(2019, 7, 1, 9, 0, 0, 0, time.UTC)
+	stored := sharedToDo()
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := stored
+			return &todo, nil
+		},
+		SaveFn: func(todo *server.ToDo) error {
+			stored = *todo
+			return nil
+		},
+	}
+
+	repo := policy.NewToDoRepo(m, owner)
+
+	todo := sharedToDo()
+	todo.Completed = true
+	todo.CompletedAt = &completed
+
+	if err := repo.Save(&todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if todo.CompletedAt == nil || todo.CompletedAt.Equal(completed) {
+		t.Fatalf("Expected the completion time to be set by the server, got %v", todo.CompletedAt)
+	}
+
+	first := *todo.CompletedAt
+	todo.Title = "Edited"
+
+	if err := repo.Save(&todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if !todo.CompletedAt.Equal(first) {
+		t.Fatalf("Expected later edits to keep the completion time %v, got %v", first, todo.CompletedAt)
+	}
+
+	todo.Completed = false
+
+	if err := repo.Save(&todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if todo.CompletedAt != nil {
+		t.Fatalf("Expected a reopened ToDo to have no completion time, got %v", todo.CompletedAt)
+	}
+}
+
+func testOwnerCanDelete(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := sharedToDo()
+			return &todo, nil
+		},
+		DeleteFn: func(string) error {
+			return nil
+		},
+	}
+
+	if err := policy.NewToDoRepo(m, owner).Delete(testUUID); err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.DeleteInvoked {
+		t.Fatal("Delete not invoked")
+	}
+}
+
+func testViewerCannotSave(t *testing.T) {
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := sharedToDo()
+			return &todo, nil
+		},
+	}
+
+	todo := sharedToDo()
+
+	err := policy.NewToDoRepo(m, viewer).Save(&todo)
+	if errors.Cause(err) != policy.ErrForbidden {
+		t.Fatalf("Expected %v, got %v", policy.ErrForbidden, err)
+	}
+
+	if m.SaveInvoked {
+		t.Fatal("Save invoked")
+	}
+}
+
+func testLegacyToDoReadOnly(t *testing.T) {
+
+	legacy := server.ToDo{ID: testUUID, Title: "Legacy ToDo"}
+
+	m := &RepoMock{
+		GetFn: func(string) (*server.ToDo, error) {
+			todo := legacy
+			return &todo, nil
+		},
+	}
+
+	repo := policy.NewToDoRepo(m, stranger)
+
+	if _, err := repo.Get(testUUID); err != nil {
+		t.Fatalf("Expected legacy ToDo to be readable, got %v", err)
+	}
+
+	todo := legacy
+	if err := repo.Save(&todo); errors.Cause(err) != policy.ErrForbidden {
+		t.Fatalf("Expected %v, got %v", policy.ErrForbidden, err)
+	}
+
+	if err := repo.Delete(testUUID); errors.Cause(err) != policy.ErrForbidden {
+		t.Fatalf("Expected %v, got %v", policy.ErrForbidden, err)
+	}
+
+	if m.SaveInvoked || m.DeleteInvoked {
+		t.Fatal("Legacy ToDo changed")
+	}
+}
@@ -0,0 +1,171 @@
+package policy_test
+
+import (
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+)
+
+// RepoMock is used to mock a ToDo repository
+type RepoMock struct {
+	GetFn                func(string) (*server.ToDo, error)
+	GetAllFn             func() ([]server.ToDo, error)
+	GetChildrenFn        func(string) ([]server.ToDo, error)
+	GetByListFn          func(string) ([]server.ToDo, error)
+	GetByOwnerFn         func(string) ([]server.ToDo, error)
+	ModifiedSinceFn      func(string, time.Time) ([]server.ToDo, error)
+	MoveFn               func(id, from, to string) error
+	SaveFn               func(todo *server.ToDo) error
+	DeleteFn             func(string) error
+	GetInvoked           bool
+	GetAllInvoked        bool
+	GetChildrenInvoked   bool
+	GetByListInvoked     bool
+	GetByOwnerInvoked    bool
+	ModifiedSinceInvoked bool
+	MoveInvoked          bool
+	SaveInvoked          bool
+	DeleteInvoked        bool
+}
+
+// Get returns a ToDo by its ID
+func (m *RepoMock) Get(id string) (*server.ToDo, error) {
+	m.GetInvoked = true
+	return m.GetFn(id)
+}
+
+// GetAll returns all ToDos
+func (m *RepoMock) GetAll() ([]server.ToDo, error) {
+	m.GetAllInvoked = true
+	return m.GetAllFn()
+}
+
+// GetChildren returns the ToDos whose parent is the given ToDo
+func (m *RepoMock) GetChildren(parentID string) ([]server.ToDo, error) {
+	m.GetChildrenInvoked = true
+	return m.GetChildrenFn(parentID)
+}
+
+// GetByList returns the ToDos in the given list
+func (m *RepoMock) GetByList(listID string) ([]server.ToDo, error) {
+	m.GetByListInvoked = true
+	return m.GetByListFn(listID)
+}
+
+// GetByOwner returns the ToDos of the given owner
+func (m *RepoMock) GetByOwner(owner string) ([]server.ToDo, error) {
+	m.GetByOwnerInvoked = true
+	return m.GetByOwnerFn(owner)
+}
+
+// ModifiedSince returns the ToDos of the given owner modified after since
+func (m *RepoMock) ModifiedSince(owner string, since time.Time) ([]server.ToDo, error) {
+	m.ModifiedSinceInvoked = true
+	return m.ModifiedSinceFn(owner, since)
+}
+
+// Move sets the list of a ToDo
+func (m *RepoMock) Move(id, from, to string) error {
+	m.MoveInvoked = true
+	return m.MoveFn(id, from, to)
+}
+
+// Save creates or updates a ToDo
+func (m *RepoMock) Save(todo *server.ToDo) error {
+	m.SaveInvoked = true
+	return m.SaveFn(todo)
+}
+
+// Delete permanently removes a ToDo
+func (m *RepoMock) Delete(id string) error {
+	m.DeleteInvoked = true
+	return m.DeleteFn(id)
+}
+
+// ListRepoMock is used to mock a list repository
+type ListRepoMock struct {
+	Lists         map[string]*server.List
+	SaveInvoked   bool
+	DeleteInvoked bool
+}
+
+// Get returns a list by its ID
+func (m *ListRepoMock) Get(id string) (*server.List, error) {
+	l, ok := m.Lists[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *l
+	return &c, nil
+}
+
+// GetAll returns all lists
+func (m *ListRepoMock) GetAll() ([]server.List, error) {
+	lists := []server.List{}
+	for _, l := range m.Lists {
+		lists = append(lists, *l)
+	}
+	return lists, nil
+}
+
+// Save creates or updates a list
+func (m *ListRepoMock) Save(list *server.List) error {
+	m.SaveInvoked = true
+	if list.ID == "" {
+		list.ID = "new-list"
+	}
+	c := *list
+	m.Lists[list.ID] = &c
+	return nil
+}
+
+// Delete permanently removes a list
+func (m *ListRepoMock) Delete(id string) error {
+	m.DeleteInvoked = true
+	delete(m.Lists, id)
+	return nil
+}
+
+// LabelRepoMock is used to mock a label repository
+type LabelRepoMock struct {
+	Labels        map[string]*server.Label
+	SaveInvoked   bool
+	DeleteInvoked bool
+}
+
+// Get returns a label by its ID
+func (m *LabelRepoMock) Get(id string) (*server.Label, error) {
+	l, ok := m.Labels[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *l
+	return &c, nil
+}
+
+// GetAll returns all labels
+func (m *LabelRepoMock) GetAll() ([]server.Label, error) {
+	labels := []server.Label{}
+	for _, l := range m.Labels {
+		labels = append(labels, *l)
+	}
+	return labels, nil
+}
+
+// Save creates or updates a label
+func (m *LabelRepoMock) Save(label *server.Label) error {
+	m.SaveInvoked = true
+	if label.ID == "" {
+		label.ID = "new-label"
+	}
+	c := *label
+	m.Labels[label.ID] = &c
+	return nil
+}
+
+// Delete permanently removes a label
+func (m *LabelRepoMock) Delete(id string) error {
+	m.DeleteInvoked = true
+	delete(m.Labels, id)
+	return nil
+}
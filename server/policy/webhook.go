@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// WebhookRepo restricts a webhook repository to the webhooks owned by a user
+type WebhookRepo struct {
+	repo database.WebhookRepo
+	user string
+}
+
+// NewWebhookRepo returns a webhook repository that only lets user access the webhooks they own
+func NewWebhookRepo(repo database.WebhookRepo, user string) *WebhookRepo {
+	return &WebhookRepo{
+		repo: repo,
+		user: user,
+	}
+}
+
+// Get returns a webhook by its ID if the user owns it
+func (r *WebhookRepo) Get(id string) (*server.Webhook, error) {
+
+	w, err := r.repo.Get(id)
+	if err != nil || w == nil {
+		return w, err
+	}
+
+	if w.Owner != r.user {
+		return nil, errors.Wrapf(ErrForbidden, "user %s cannot read webhook %s", r.user, id)
+	}
+
+	return w, nil
+}
+
+// GetByOwner returns the webhooks of an owner, who must be the user
+func (r *WebhookRepo) GetByOwner(owner string) ([]server.Webhook, error) {
+
+	if owner != r.user {
+		return nil, errors.Wrapf(ErrForbidden, "user %s cannot read the webhooks of %s", r.user, owner)
+	}
+
+	return r.repo.GetByOwner(owner)
+}
+
+// Save creates a webhook owned by the user, or updates a webhook the user owns
+func (r *WebhookRepo) Save(webhook *server.Webhook) error {
+
+	if webhook.ID != "" {
+		existing, err := r.Get(webhook.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.Owner != r.user {
+			return errors.Wrapf(ErrForbidden, "user %s cannot update webhook %s", r.user, webhook.ID)
+		}
+	}
+
+	webhook.Owner = r.user
+	return r.repo.Save(webhook)
+}
+
+// Delete permanently removes a webhook if the user owns it
+func (r *WebhookRepo) Delete(id string) error {
+
+	if _, err := r.Get(id); err != nil {
+		return err
+	}
+
+	return r.repo.Delete(id)
+}
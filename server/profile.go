@@ -0,0 +1,51 @@
+package server
+
+import "time"
+
+// DigestFrequency is how often the digest of the ToDos of a user is sent
+type DigestFrequency string
+
+const (
+	// DigestDaily sends the digest every day
+	DigestDaily DigestFrequency = "daily"
+	// DigestWeekly sends the digest every Monday
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// Enum returns the frequencies of the digest, users without a frequency get no digest
+func (f DigestFrequency) Enum() []string {
+	return []string{string(DigestDaily), string(DigestWeekly)}
+}
+
+// Profile holds the contact details notifications are sent to
+type Profile struct {
+	User string `json:"user" schema:"readonly"`
+	// Email is the address emails are sent to and received from, it is stored in lower case
+	Email string `json:"email,omitempty" schema:"format=email,maxLength=254"`
+	// EmailVerified is set once the user followed the verification link emailed to Email, emails
+	// are only sent to and received from verified addresses
+	EmailVerified bool `json:"emailVerified,omitempty" schema:"readonly"`
+	// Phone is the number text messages are sent to, in E.164 format such as +14155550100
+	Phone string `json:"phone,omitempty" schema:"maxLength=16"`
+	// Digest is how often the overdue and upcoming ToDos of the user are emailed, never if empty
+	Digest DigestFrequency `json:"digest,omitempty"`
+	// DigestHour is the hour of the day the digest is sent at, in TimeZone
+	DigestHour int `json:"digestHour" schema:"minimum=0,maximum=23"`
+	// TimeZone is the IANA time zone of the user, UTC if empty
+	TimeZone string `json:"timeZone,omitempty" schema:"maxLength=64"`
+}
+
+// Contact returns the profile notifications are sent to, without the email address until it is
+// verified
+func (p *Profile) Contact() *Profile {
+	c := *p
+	if !c.EmailVerified {
+		c.Email = ""
+	}
+	return &c
+}
+
+// Location returns the time zone of the user
+func (p *Profile) Location() (*time.Location, error) {
+	return time.LoadLocation(p.TimeZone)
+}
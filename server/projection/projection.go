@@ -0,0 +1,87 @@
+package projection
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/dynamodb"
+	"github.com/pkg/errors"
+)
+
+// Change is a change of a ToDo read from the stream of the todos table. Before is nil for created
+// ToDos and After is nil for deleted ToDos.
+type Change struct {
+	// ID identifies the stream record, it is the same when the record is retried
+	ID     string
+	Before *server.ToDo
+	After  *server.ToDo
+}
+
+// Projection maintains a view of the ToDos from their changes. Changes may be applied more than
+// once when a batch is retried.
+type Projection interface {
+	Apply(c Change) error
+}
+
+// BatchItemFailure identifies a record of a batch to retry
+type BatchItemFailure struct {
+	ItemIdentifier string `json:"itemIdentifier"`
+}
+
+// BatchResponse reports the records of a stream batch which failed, so that only they are
+// retried. aws-lambda-go does not define this response yet.
+type BatchResponse struct {
+	BatchItemFailures []BatchItemFailure `json:"batchItemFailures"`
+}
+
+// Consumer applies the changes of the todos table to the projections
+type Consumer struct {
+	projections []Projection
+}
+
+// NewConsumer returns a consumer applying changes to the given projections
+func NewConsumer(projections ...Projection) *Consumer {
+	return &Consumer{projections}
+}
+
+// Handle applies the records of a stream batch in order. Processing stops at the first failed
+// record, which is reported along with the records after it, as they must be applied after it.
+func (c *Consumer) Handle(ev events.DynamoDBEvent) (BatchResponse, error) {
+
+	resp := BatchResponse{BatchItemFailures: []BatchItemFailure{}}
+
+	for i, rec := range ev.Records {
+
+		if err := c.apply(rec); err != nil {
+			for _, r := range ev.Records[i:] {
+				resp.BatchItemFailures = append(resp.BatchItemFailures, BatchItemFailure{ItemIdentifier: r.Change.SequenceNumber})
+			}
+			return resp, nil
+		}
+	}
+
+	return resp, nil
+}
+
+// apply applies a stream record to every projection
+func (c *Consumer) apply(rec events.DynamoDBEventRecord) error {
+
+	before, err := dynamodb.UnmarshalImage(rec.Change.OldImage)
+	if err != nil {
+		return errors.Wrapf(err, "Could not read old image of record %s", rec.EventID)
+	}
+
+	after, err := dynamodb.UnmarshalImage(rec.Change.NewImage)
+	if err != nil {
+		return errors.Wrapf(err, "Could not read new image of record %s", rec.EventID)
+	}
+
+	change := Change{ID: rec.EventID, Before: before, After: after}
+
+	for _, p := range c.projections {
+		if err := p.Apply(change); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package projection_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/projection"
+)
+
+const testUser = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+
+func TestProjections(t *testing.T) {
+	t.Run("PartialBatchFailure", testPartialBatchFailure)
+	t.Run("StatsDelta", testStatsDelta)
+	t.Run("SearchIndexWords", testSearchIndexWords)
+	t.Run("SharingRemovedMembers", testSharingRemovedMembers)
+	t.Run("SharingDeletedToDo", testSharingDeletedToDo)
+}
+
+// ProjectionMock records the applied changes and fails the change of the given ToDo
+type ProjectionMock struct {
+	Changes []projection.Change
+	FailOn  string
+}
+
+func (m *ProjectionMock) Apply(c projection.Change) error {
+	if c.After != nil && c.After.ID == m.FailOn {
+		return errors.New("unavailable")
+	}
+	m.Changes = append(m.Changes, c)
+	return nil
+}
+
+// StatsRepoMock records the deltas added to the stats
+type StatsRepoMock struct {
+	Deltas []server.Stats
+}
+
+func (m *StatsRepoMock) Get(owner string) (*server.Stats, error) {
+	return nil, nil
+}
+
+func (m *StatsRepoMock) Add(changeID string, delta server.Stats) error {
+	m.Deltas = append(m.Deltas, delta)
+	return nil
+}
+
+// SearchIndexRepoMock records the last indexed words
+type SearchIndexRepoMock struct {
+	Add, Remove []string
+	Invoked     bool
+}
+
+func (m *SearchIndexRepoMock) Index(todoID, owner string, add, remove []string) error {
+	m.Invoked = true
+	m.Add, m.Remove = add, remove
+	return nil
+}
+
+// ShareRepoMock records the ToDos shared with each member
+type ShareRepoMock struct {
+	Shared map[string]bool
+}
+
+func (m *ShareRepoMock) Share(member, todoID string, changed time.Time) error {
+	m.Shared[member+"/"+todoID] = true
+	return nil
+}
+
+func (m *ShareRepoMock) Unshare(member, todoID string) error {
+	delete(m.Shared, member+"/"+todoID)
+	return nil
+}
+
+func (m *ShareRepoMock) ChangedSince(member string, since time.Time) ([]string, error) {
+	return nil, nil
+}
+
+// TombstoneRepoMock records the readers of the added tombstones
+type TombstoneRepoMock struct {
+	Readers []string
+}
+
+func (m *TombstoneRepoMock) Since(user string, since time.Time) ([]server.Tombstone, error) {
+	return nil, nil
+}
+
+func (m *TombstoneRepoMock) Add(readers []string, tombstone server.Tombstone) error {
+	m.Readers = append(m.Readers, readers...)
+	return nil
+}
+
+func record(seq, name string, old, new map[string]events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventID:   "e" + seq,
+		EventName: name,
+		Change: events.DynamoDBStreamRecord{
+			SequenceNumber: seq,
+			OldImage:       old,
+			NewImage:       new,
+		},
+	}
+}
+
+func image(id string, completed bool) map[string]events.DynamoDBAttributeValue {
+	return map[string]events.DynamoDBAttributeValue{
+		"id":        events.NewStringAttribute(id),
+		"title":     events.NewStringAttribute("ToDo " + id),
+		"owner":     events.NewStringAttribute(testUser),
+		"completed": events.NewBooleanAttribute(completed),
+	}
+}
+
+func testPartialBatchFailure(t *testing.T) {
+
+	m := &ProjectionMock{FailOn: "t2"}
+
+	resp, err := projection.NewConsumer(m).Handle(events.DynamoDBEvent{
+		Records: []events.DynamoDBEventRecord{
+			record("100", "INSERT", nil, image("t1", false)),
+			record("200", "MODIFY", image("t2", false), image("t2", true)),
+			record("300", "REMOVE", image("t3", false), nil),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Changes) != 1 || m.Changes[0].ID != "e100" || m.Changes[0].Before != nil || m.Changes[0].After.ID != "t1" {
+		t.Fatalf("Expected only the change before the failure to be applied, got %+v", m.Changes)
+	}
+
+	want := []projection.BatchItemFailure{{ItemIdentifier: "200"}, {ItemIdentifier: "300"}}
+	if !reflect.DeepEqual(resp.BatchItemFailures, want) {
+		t.Fatalf("Expected the failed record and the records after it to be retried, got %+v", resp.BatchItemFailures)
+	}
+}
+
+func testStatsDelta(t *testing.T) {
+
+	repo := &StatsRepoMock{}
+	p := projection.NewStats(repo)
+
+	open := &server.ToDo{ID: "t1", Owner: testUser}
+	done := &server.ToDo{ID: "t1", Owner: testUser, Completed: true}
+	renamed := &server.ToDo{ID: "t1", Owner: testUser, Title: "Renamed"}
+
+	changes := []projection.Change{
+		{ID: "1", After: open},
+		{ID: "2", Before: open, After: renamed},
+		{ID: "3", Before: open, After: done},
+		{ID: "4", Before: done},
+	}
+
+	for _, c := range changes {
+		if err := p.Apply(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []server.Stats{
+		{Owner: testUser, Total: 1},
+		{Owner: testUser, Completed: 1},
+		{Owner: testUser, Total: -1, Completed: -1},
+	}
+
+	if !reflect.DeepEqual(repo.Deltas, want) {
+		t.Fatalf("Expected deltas %+v, got %+v", want, repo.Deltas)
+	}
+}
+
+func testSearchIndexWords(t *testing.T) {
+
+	repo := &SearchIndexRepoMock{}
+	p := projection.NewSearchIndex(repo)
+
+	before := &server.ToDo{ID: "t1", Owner: testUser, Title: "Buy milk, eggs & a loaf", Tags: []string{"Errands"}}
+	after := &server.ToDo{ID: "t1", Owner: testUser, Title: "Buy oat milk", Tags: []string{"errands"}}
+
+	if err := p.Apply(projection.Change{ID: "1", Before: before, After: after}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(repo.Add, []string{"oat"}) || !reflect.DeepEqual(repo.Remove, []string{"eggs", "loaf"}) {
+		t.Fatalf("Expected oat to be added and eggs and loaf removed, got %v and %v", repo.Add, repo.Remove)
+	}
+
+	repo.Invoked = false
+	if err := p.Apply(projection.Change{ID: "2", Before: after, After: after}); err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.Invoked {
+		t.Fatal("Expected the index not to be written when the words are unchanged")
+	}
+}
+
+func testSharingRemovedMembers(t *testing.T) {
+
+	shares := &ShareRepoMock{Shared: map[string]bool{}}
+	tombstones := &TombstoneRepoMock{}
+	p := projection.NewSharing(shares, tombstones)
+
+	before := &server.ToDo{ID: "t1", Owner: testUser, Members: map[string]server.Role{"a": server.RoleViewer, "b": server.RoleEditor}}
+	after := &server.ToDo{ID: "t1", Owner: testUser, Members: map[string]server.Role{"a": server.RoleViewer}}
+
+	for _, c := range []projection.Change{{ID: "1", After: before}, {ID: "2", Before: before, After: after}} {
+		if err := p.Apply(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !reflect.DeepEqual(shares.Shared, map[string]bool{"a/t1": true}) {
+		t.Fatalf("Expected the ToDo to be shared only with the remaining member, got %v", shares.Shared)
+	}
+
+	if !reflect.DeepEqual(tombstones.Readers, []string{"b"}) {
+		t.Fatalf("Expected a tombstone for the removed member, got %v", tombstones.Readers)
+	}
+}
+
+func testSharingDeletedToDo(t *testing.T) {
+
+	shares := &ShareRepoMock{Shared: map[string]bool{"a/t1": true}}
+	tombstones := &TombstoneRepoMock{}
+	p := projection.NewSharing(shares, tombstones)
+
+	before := &server.ToDo{ID: "t1", Owner: testUser, Members: map[string]server.Role{"a": server.RoleViewer}}
+
+	if err := p.Apply(projection.Change{ID: "1", Before: before}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shares.Shared) != 0 {
+		t.Fatalf("Expected the ToDo to be unshared, got %v", shares.Shared)
+	}
+
+	sort.Strings(tombstones.Readers)
+	if !reflect.DeepEqual(tombstones.Readers, []string{testUser, "a"}) {
+		t.Fatalf("Expected a tombstone for the owner and the member, got %v", tombstones.Readers)
+	}
+}
@@ -0,0 +1,89 @@
+package projection
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+)
+
+// minWordLength is the length of the shortest indexed word
+const minWordLength = 2
+
+// SearchIndex indexes the ToDos by the words of their title and their tags
+type SearchIndex struct {
+	repo database.SearchIndexRepo
+}
+
+// NewSearchIndex returns a projection keeping the index in the given repository
+func NewSearchIndex(repo database.SearchIndexRepo) *SearchIndex {
+	return &SearchIndex{repo}
+}
+
+// Apply indexes the words added by a change and removes the words it removed
+func (p *SearchIndex) Apply(c Change) error {
+
+	before, after := Words(c.Before), Words(c.After)
+
+	add := difference(after, before)
+	remove := difference(before, after)
+
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	t := c.After
+	if t == nil {
+		t = c.Before
+	}
+
+	return p.repo.Index(t.ID, t.Owner, add, remove)
+}
+
+// Words returns the sorted lowercase words of the title and the tags of a ToDo
+func Words(t *server.ToDo) []string {
+
+	if t == nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+
+	fields := strings.FieldsFunc(t.Title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, w := range append(fields, t.Tags...) {
+		if w = strings.ToLower(w); len([]rune(w)) >= minWordLength {
+			seen[w] = true
+		}
+	}
+
+	words := make([]string, 0, len(seen))
+	for w := range seen {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+
+	return words
+}
+
+// difference returns the words of a which are not in b
+func difference(a, b []string) []string {
+
+	in := map[string]bool{}
+	for _, w := range b {
+		in[w] = true
+	}
+
+	var d []string
+	for _, w := range a {
+		if !in[w] {
+			d = append(d, w)
+		}
+	}
+
+	return d
+}
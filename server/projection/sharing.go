@@ -0,0 +1,87 @@
+package projection
+
+import (
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+)
+
+// Sharing keeps track of the ToDos shared with each user, and records a tombstone for the users
+// who can no longer read a ToDo, because it was deleted or they were removed from its members.
+// Changes are recorded at the time they are applied rather than at the time of the ToDo, so that
+// clients syncing since a time do not miss the changes applied late.
+type Sharing struct {
+	shares     database.ShareRepo
+	tombstones database.TombstoneRepo
+}
+
+// NewSharing returns a projection keeping the shared ToDos and the tombstones in the given
+// repositories
+func NewSharing(shares database.ShareRepo, tombstones database.TombstoneRepo) *Sharing {
+	return &Sharing{
+		shares:     shares,
+		tombstones: tombstones,
+	}
+}
+
+// Apply records the change of a ToDo for each of its members, and the tombstones of its former
+// readers
+func (p *Sharing) Apply(c Change) error {
+
+	now := time.Now()
+
+	if c.After == nil {
+		if c.Before == nil {
+			return nil
+		}
+		for member := range c.Before.Members {
+			if err := p.shares.Unshare(member, c.Before.ID); err != nil {
+				return err
+			}
+		}
+		return p.tombstones.Add(readers(c.Before), server.Tombstone{ID: c.Before.ID, Deleted: now})
+	}
+
+	for member := range c.After.Members {
+		if err := p.shares.Share(member, c.After.ID, now); err != nil {
+			return err
+		}
+	}
+
+	if c.Before == nil {
+		return nil
+	}
+
+	removed := []string{}
+	for member := range c.Before.Members {
+		if _, ok := c.After.Members[member]; ok {
+			continue
+		}
+		if err := p.shares.Unshare(member, c.Before.ID); err != nil {
+			return err
+		}
+		removed = append(removed, member)
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	return p.tombstones.Add(removed, server.Tombstone{ID: c.Before.ID, Deleted: now})
+}
+
+// readers returns the users who could read a ToDo
+func readers(todo *server.ToDo) []string {
+
+	if todo.Owner == "" {
+		return []string{database.AnyReader}
+	}
+
+	users := []string{todo.Owner}
+	for member := range todo.Members {
+		users = append(users, member)
+	}
+
+	return users
+}
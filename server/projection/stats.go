@@ -0,0 +1,37 @@
+package projection
+
+import (
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+)
+
+// Stats counts the ToDos of each user
+type Stats struct {
+	repo database.StatsRepo
+}
+
+// NewStats returns a projection keeping the counters in the given repository
+func NewStats(repo database.StatsRepo) *Stats {
+	return &Stats{repo}
+}
+
+// Apply adds the difference made by a change to the counters of the owner of the ToDo
+func (p *Stats) Apply(c Change) error {
+
+	before, after := server.StatsOf(c.Before), server.StatsOf(c.After)
+
+	delta := server.Stats{
+		Owner:     after.Owner,
+		Total:     after.Total - before.Total,
+		Completed: after.Completed - before.Completed,
+	}
+	if delta.Owner == "" {
+		delta.Owner = before.Owner
+	}
+
+	if delta.Total == 0 && delta.Completed == 0 {
+		return nil
+	}
+
+	return p.repo.Add(c.ID, delta)
+}
@@ -0,0 +1,119 @@
+package publisher_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/publisher"
+)
+
+func TestAWSPublishers(t *testing.T) {
+	t.Run("EventBridgeBatches", testEventBridgeBatches)
+	t.Run("EventBridgeFailedEntry", testEventBridgeFailedEntry)
+	t.Run("SNSAttributes", testSNSAttributes)
+}
+
+// EventBridgeMock is used to mock EventBridge
+type EventBridgeMock struct {
+	eventbridgeiface.EventBridgeAPI
+	PutEventsFn func(*eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error)
+}
+
+func (m *EventBridgeMock) PutEvents(input *eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error) {
+	return m.PutEventsFn(input)
+}
+
+// SNSMock is used to mock SNS
+type SNSMock struct {
+	snsiface.SNSAPI
+	PublishFn func(*sns.PublishInput) (*sns.PublishOutput, error)
+}
+
+func (m *SNSMock) Publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	return m.PublishFn(input)
+}
+
+func testEvents(n int) []server.Event {
+	events := make([]server.Event, n)
+	for i := range events {
+		events[i] = server.NewToDoEvent(testUser, nil, &server.ToDo{ID: fmt.Sprintf("t%d", i), Owner: testUser})
+	}
+	return events
+}
+
+func testEventBridgeBatches(t *testing.T) {
+
+	var batches []int
+	m := &EventBridgeMock{
+		PutEventsFn: func(input *eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error) {
+			batches = append(batches, len(input.Entries))
+			e := input.Entries[0]
+			if *e.EventBusName != "todo-events" || *e.Source != publisher.Source || *e.DetailType != "todo.created" {
+				t.Fatalf("Expected a todo.created entry on bus todo-events, got %s", e)
+			}
+			var detail server.Event
+			if err := json.Unmarshal([]byte(*e.Detail), &detail); err != nil || detail.After == nil {
+				t.Fatalf("Expected the event as detail, got %s", *e.Detail)
+			}
+			return &eventbridge.PutEventsOutput{FailedEntryCount: aws.Int64(0)}, nil
+		},
+	}
+
+	if err := publisher.NewEventBridge(m, "todo-events").Publish(testEvents(23)...); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(batches) != "[10 10 3]" {
+		t.Fatalf("Expected batches of up to 10 events, got %v", batches)
+	}
+}
+
+func testEventBridgeFailedEntry(t *testing.T) {
+
+	m := &EventBridgeMock{
+		PutEventsFn: func(input *eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error) {
+			return &eventbridge.PutEventsOutput{
+				FailedEntryCount: aws.Int64(1),
+				Entries: []*eventbridge.PutEventsResultEntry{
+					{EventId: aws.String("1")},
+					{ErrorCode: aws.String("InternalFailure"), ErrorMessage: aws.String("try again")},
+				},
+			}, nil
+		},
+	}
+
+	if err := publisher.NewEventBridge(m, "todo-events").Publish(testEvents(2)...); err == nil {
+		t.Fatal("Expected an error when an entry failed")
+	}
+}
+
+func testSNSAttributes(t *testing.T) {
+
+	var published []*sns.PublishInput
+	m := &SNSMock{
+		PublishFn: func(input *sns.PublishInput) (*sns.PublishOutput, error) {
+			published = append(published, input)
+			return &sns.PublishOutput{}, nil
+		},
+	}
+
+	if err := publisher.NewSNS(m, "arn:aws:sns:us-west-2:1:todo-events").Publish(testEvents(2)...); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(published) != 2 {
+		t.Fatalf("Expected a message per event, got %d", len(published))
+	}
+
+	attrs := published[0].MessageAttributes
+	if *attrs["type"].StringValue != "todo.created" || *attrs["version"].StringValue != "1" || *attrs["owner"].StringValue != testUser {
+		t.Fatalf("Expected the type, version and owner as attributes, got %v", attrs)
+	}
+}
@@ -0,0 +1,83 @@
+package publisher
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// Source is the source of the events put on EventBridge, which rules match on
+const Source = "todo.api"
+
+// maxEventBridgeEntries is the maximum number of events of a PutEvents request
+const maxEventBridgeEntries = 10
+
+// EventBridge publishes events to an EventBridge event bus, with the event type as detail type
+type EventBridge struct {
+	eb  eventbridgeiface.EventBridgeAPI
+	bus string
+}
+
+// NewEventBridge returns a publisher putting events on the given bus
+func NewEventBridge(eb eventbridgeiface.EventBridgeAPI, bus string) *EventBridge {
+	return &EventBridge{eb, bus}
+}
+
+// Publish puts the events on the bus, in batches of up to 10 events
+func (p *EventBridge) Publish(events ...server.Event) error {
+
+	for len(events) > 0 {
+
+		n := len(events)
+		if n > maxEventBridgeEntries {
+			n = maxEventBridgeEntries
+		}
+
+		if err := p.put(events[:n]); err != nil {
+			return err
+		}
+
+		events = events[n:]
+	}
+
+	return nil
+}
+
+func (p *EventBridge) put(events []server.Event) error {
+
+	entries := make([]*eventbridge.PutEventsRequestEntry, len(events))
+	for i, e := range events {
+
+		detail, err := json.Marshal(e)
+		if err != nil {
+			return errors.Wrapf(err, "Could not marshal event %s", e.ID)
+		}
+
+		entries[i] = &eventbridge.PutEventsRequestEntry{
+			EventBusName: aws.String(p.bus),
+			Source:       aws.String(Source),
+			DetailType:   aws.String(string(e.Type)),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(e.Time),
+		}
+	}
+
+	out, err := p.eb.PutEvents(&eventbridge.PutEventsInput{Entries: entries})
+	if err != nil {
+		return errors.Wrapf(err, "Could not put events on bus %s", p.bus)
+	}
+
+	if aws.Int64Value(out.FailedEntryCount) > 0 {
+		for i, r := range out.Entries {
+			if r.ErrorCode != nil {
+				return errors.Errorf("Could not put event %s on bus %s: %s %s", events[i].ID, p.bus, aws.StringValue(r.ErrorCode), aws.StringValue(r.ErrorMessage))
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package publisher
+
+import (
+	"sync"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+)
+
+// Memory keeps the published events in memory, for tests
+type Memory struct {
+	mu     sync.Mutex
+	events []server.Event
+}
+
+// NewMemory returns a publisher keeping events in memory
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Publish appends the events to the published events
+func (p *Memory) Publish(events ...server.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// Events returns the events published so far, oldest first
+func (p *Memory) Events() []server.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]server.Event{}, p.events...)
+}
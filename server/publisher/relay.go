@@ -0,0 +1,95 @@
+package publisher
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// claimLease is how long an event is reserved for the relay publishing it, the event can be
+// claimed again once the lease expires if the relay failed before marking it published
+const claimLease = time.Minute
+
+// Relay publishes the events recorded in the outbox, either as they are received from the stream
+// of the outbox table or by polling it. Events are delivered at least once: an event is claimed
+// before it is published and marked published after, so that the stream consumer and the poller
+// do not both publish it, and it is skipped if it is received again once marked.
+type Relay struct {
+	outbox database.OutboxRepo
+	pub    server.EventPublisher
+}
+
+// NewRelay returns a relay publishing the events of the outbox with the given publisher
+func NewRelay(outbox database.OutboxRepo, pub server.EventPublisher) *Relay {
+	return &Relay{outbox, pub}
+}
+
+// Relay publishes the events which were not published yet and are not being published by
+// another relay
+func (r *Relay) Relay(events ...server.Event) error {
+
+	for _, e := range events {
+
+		claimed, err := r.outbox.Claim(e.ID, claimLease)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		if err := r.pub.Publish(e); err != nil {
+			return errors.Wrapf(err, "Could not publish event %s", e.ID)
+		}
+
+		if err := r.outbox.MarkPublished(e.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Drain publishes up to limit pending events of the outbox and returns how many were pending
+func (r *Relay) Drain(limit int) (int, error) {
+
+	pending, err := r.outbox.Pending(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(pending), r.Relay(pending...)
+}
+
+// HandleStream publishes the events inserted in the outbox table, received from its stream. The
+// batch is retried if an event cannot be published.
+func (r *Relay) HandleStream(ev events.DynamoDBEvent) error {
+
+	for _, rec := range ev.Records {
+
+		if events.DynamoDBOperationType(rec.EventName) != events.DynamoDBOperationTypeInsert {
+			continue
+		}
+
+		av, ok := rec.Change.NewImage["event"]
+		if !ok || av.DataType() != events.DataTypeString {
+			return errors.Errorf("Stream record %s has no event", rec.EventID)
+		}
+
+		var e server.Event
+		if err := json.Unmarshal([]byte(av.String()), &e); err != nil {
+			return errors.Wrapf(err, "Could not unmarshal event of stream record %s", rec.EventID)
+		}
+
+		if err := r.Relay(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
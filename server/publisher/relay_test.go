@@ -0,0 +1,140 @@
+package publisher_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/publisher"
+)
+
+func TestRelay(t *testing.T) {
+	t.Run("Drain", testRelayDrain)
+	t.Run("SkipPublished", testRelaySkipPublished)
+	t.Run("SkipClaimed", testRelaySkipClaimed)
+	t.Run("PublishFailure", testRelayPublishFailure)
+	t.Run("HandleStream", testRelayHandleStream)
+}
+
+// failingPublisher fails to publish every event
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(events ...server.Event) error {
+	return errors.New("unavailable")
+}
+
+func outbox(n int) *OutboxRepoMock {
+	return &OutboxRepoMock{Outbox: testEvents(n)}
+}
+
+func testRelayDrain(t *testing.T) {
+
+	m := outbox(3)
+	pub := publisher.NewMemory()
+	relay := publisher.NewRelay(m, pub)
+
+	n, err := relay.Drain(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 || len(pub.Events()) != 2 {
+		t.Fatalf("Expected 2 events to be published, got %d", len(pub.Events()))
+	}
+
+	if n, err = relay.Drain(2); err != nil || n != 1 {
+		t.Fatalf("Expected the last event to be pending, got %d %v", n, err)
+	}
+
+	if n, err = relay.Drain(2); err != nil || n != 0 {
+		t.Fatalf("Expected no pending event, got %d %v", n, err)
+	}
+
+	if len(pub.Events()) != 3 {
+		t.Fatalf("Expected every event to be published once, got %d", len(pub.Events()))
+	}
+}
+
+func testRelaySkipPublished(t *testing.T) {
+
+	m := outbox(2)
+	m.MarkPublished(m.Outbox[0].ID)
+	pub := publisher.NewMemory()
+
+	if err := publisher.NewRelay(m, pub).Relay(m.Outbox...); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pub.Events()) != 1 || pub.Events()[0].ID != m.Outbox[1].ID {
+		t.Fatalf("Expected only the unpublished event to be published, got %+v", pub.Events())
+	}
+}
+
+// testRelaySkipClaimed relays the same event from the stream and the poller
+func testRelaySkipClaimed(t *testing.T) {
+
+	m := outbox(1)
+	pub := publisher.NewMemory()
+
+	if _, err := m.Claim(m.Outbox[0].ID, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := publisher.NewRelay(m, pub).Drain(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pub.Events()) != 0 {
+		t.Fatalf("Expected an event claimed by another relay not to be published, got %+v", pub.Events())
+	}
+}
+
+func testRelayPublishFailure(t *testing.T) {
+
+	m := outbox(1)
+
+	if err := publisher.NewRelay(m, failingPublisher{}).Relay(m.Outbox...); err == nil {
+		t.Fatal("Expected an error when publishing fails")
+	}
+
+	if m.Published(m.Outbox[0].ID) {
+		t.Fatal("Expected an event failing to publish not to be marked published")
+	}
+}
+
+func testRelayHandleStream(t *testing.T) {
+
+	m := outbox(1)
+	pub := publisher.NewMemory()
+
+	js, err := json.Marshal(m.Outbox[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image := map[string]events.DynamoDBAttributeValue{
+		"id":    events.NewStringAttribute(m.Outbox[0].ID),
+		"event": events.NewStringAttribute(string(js)),
+	}
+
+	ev := events.DynamoDBEvent{
+		Records: []events.DynamoDBEventRecord{
+			{EventID: "1", EventName: "INSERT", Change: events.DynamoDBStreamRecord{NewImage: image}},
+			// Marking the event published modifies the record, which is not relayed again
+			{EventID: "2", EventName: "MODIFY", Change: events.DynamoDBStreamRecord{NewImage: image}},
+			{EventID: "3", EventName: "INSERT", Change: events.DynamoDBStreamRecord{NewImage: image}},
+		},
+	}
+
+	if err := publisher.NewRelay(m, pub).HandleStream(ev); err != nil {
+		t.Fatal(err)
+	}
+
+	published := pub.Events()
+	if len(published) != 1 || published[0].ID != m.Outbox[0].ID || published[0].After == nil {
+		t.Fatalf("Expected the inserted event to be published once, got %+v", published)
+	}
+}
@@ -0,0 +1,140 @@
+package publisher_test
+
+import (
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	uuid "github.com/satori/go.uuid"
+)
+
+// RepoMock is used to mock a ToDo repository keeping ToDos in a map
+type RepoMock struct {
+	ToDos map[string]server.ToDo
+}
+
+// Get returns a ToDo by its ID
+func (m *RepoMock) Get(id string) (*server.ToDo, error) {
+	t, ok := m.ToDos[id]
+	if !ok {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+// GetAll returns all ToDos
+func (m *RepoMock) GetAll() ([]server.ToDo, error) {
+	all := []server.ToDo{}
+	for _, t := range m.ToDos {
+		all = append(all, t)
+	}
+	return all, nil
+}
+
+// GetChildren is not used by the tests
+func (m *RepoMock) GetChildren(parentID string) ([]server.ToDo, error) {
+	return nil, nil
+}
+
+// GetByList is not used by the tests
+func (m *RepoMock) GetByList(listID string) ([]server.ToDo, error) {
+	return nil, nil
+}
+
+// GetByOwner is not used by the tests
+func (m *RepoMock) GetByOwner(owner string) ([]server.ToDo, error) {
+	return nil, nil
+}
+
+// ModifiedSince is not used by the tests
+func (m *RepoMock) ModifiedSince(owner string, since time.Time) ([]server.ToDo, error) {
+	return nil, nil
+}
+
+// Save creates or updates a ToDo
+func (m *RepoMock) Save(todo *server.ToDo) error {
+	if todo.ID == "" {
+		todo.ID = uuid.NewV4().String()
+	}
+	m.ToDos[todo.ID] = *todo
+	return nil
+}
+
+// Move sets the list of a ToDo
+func (m *RepoMock) Move(id, from, to string) error {
+	t := m.ToDos[id]
+	t.ListID = to
+	m.ToDos[id] = t
+	return nil
+}
+
+// Delete removes a ToDo
+func (m *RepoMock) Delete(id string) error {
+	delete(m.ToDos, id)
+	return nil
+}
+
+// OutboxRepoMock is used to mock a ToDo repository recording events in an outbox
+type OutboxRepoMock struct {
+	RepoMock
+	Outbox    []server.Event
+	published map[string]bool
+	claimed   map[string]bool
+}
+
+// SaveWithEvent creates or updates a ToDo and records its event
+func (m *OutboxRepoMock) SaveWithEvent(todo *server.ToDo, event func(*server.ToDo) server.Event) error {
+	m.RepoMock.Save(todo)
+	m.Outbox = append(m.Outbox, event(todo))
+	return nil
+}
+
+// MoveWithEvent sets the list of a ToDo and records its event
+func (m *OutboxRepoMock) MoveWithEvent(id, from, to string, event server.Event) error {
+	m.RepoMock.Move(id, from, to)
+	m.Outbox = append(m.Outbox, event)
+	return nil
+}
+
+// DeleteWithEvent removes a ToDo and records its event
+func (m *OutboxRepoMock) DeleteWithEvent(id string, event server.Event) error {
+	m.RepoMock.Delete(id)
+	m.Outbox = append(m.Outbox, event)
+	return nil
+}
+
+// Pending returns the events not marked published
+func (m *OutboxRepoMock) Pending(limit int) ([]server.Event, error) {
+	pending := []server.Event{}
+	for _, e := range m.Outbox {
+		if !m.published[e.ID] && len(pending) < limit {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+// Published reports whether an event was marked published
+func (m *OutboxRepoMock) Published(id string) bool {
+	return m.published[id]
+}
+
+// Claim claims an event neither published nor claimed, claims never expire
+func (m *OutboxRepoMock) Claim(id string, lease time.Duration) (bool, error) {
+	if m.published[id] || m.claimed[id] {
+		return false, nil
+	}
+	if m.claimed == nil {
+		m.claimed = map[string]bool{}
+	}
+	m.claimed[id] = true
+	return true, nil
+}
+
+// MarkPublished marks an event published
+func (m *OutboxRepoMock) MarkPublished(id string) error {
+	if m.published == nil {
+		m.published = map[string]bool{}
+	}
+	m.published[id] = true
+	return nil
+}
@@ -0,0 +1,51 @@
+package publisher
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/pkg/errors"
+)
+
+// SNS publishes events to an SNS topic. The type, version and owner of the events are sent as
+// message attributes, so that subscriptions can filter on them.
+type SNS struct {
+	sns   snsiface.SNSAPI
+	topic string
+}
+
+// NewSNS returns a publisher sending events to the topic with the given ARN
+func NewSNS(sns snsiface.SNSAPI, topic string) *SNS {
+	return &SNS{sns, topic}
+}
+
+// Publish sends each event as a message of the topic
+func (p *SNS) Publish(events ...server.Event) error {
+
+	for _, e := range events {
+
+		msg, err := json.Marshal(e)
+		if err != nil {
+			return errors.Wrapf(err, "Could not marshal event %s", e.ID)
+		}
+
+		_, err = p.sns.Publish(&sns.PublishInput{
+			TopicArn: aws.String(p.topic),
+			Message:  aws.String(string(msg)),
+			MessageAttributes: map[string]*sns.MessageAttributeValue{
+				"type":    {DataType: aws.String("String"), StringValue: aws.String(string(e.Type))},
+				"version": {DataType: aws.String("Number"), StringValue: aws.String(strconv.Itoa(e.Version))},
+				"owner":   {DataType: aws.String("String"), StringValue: aws.String(e.Owner)},
+			},
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Could not publish event %s to topic %s", e.ID, p.topic)
+		}
+	}
+
+	return nil
+}
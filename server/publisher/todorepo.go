@@ -0,0 +1,126 @@
+package publisher
+
+import (
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// ToDoRepo emits a domain event for every change made to a ToDo repository. The events are either
+// published right after the changes, or recorded in an outbox along with the changes.
+type ToDoRepo struct {
+	database.ToDoRepo
+	pub    server.EventPublisher
+	outbox database.OutboxToDoRepo
+	actor  string
+}
+
+// NewToDoRepo returns a ToDo repository publishing the changes actor makes through it. An event
+// is lost if publishing it fails after the change is saved.
+func NewToDoRepo(repo database.ToDoRepo, pub server.EventPublisher, actor string) *ToDoRepo {
+	return &ToDoRepo{
+		ToDoRepo: repo,
+		pub:      pub,
+		actor:    actor,
+	}
+}
+
+// NewOutboxToDoRepo returns a ToDo repository recording the events of the changes actor makes
+// through it in the outbox of the repository, from which they are published by a Relay
+func NewOutboxToDoRepo(repo database.OutboxToDoRepo, actor string) *ToDoRepo {
+	return &ToDoRepo{
+		ToDoRepo: repo,
+		outbox:   repo,
+		actor:    actor,
+	}
+}
+
+// Save creates or updates a ToDo and emits the event of the change
+func (r *ToDoRepo) Save(todo *server.ToDo) error {
+
+	var before *server.ToDo
+	if todo.ID != "" {
+		var err error
+		if before, err = r.ToDoRepo.Get(todo.ID); err != nil {
+			return err
+		}
+	}
+
+	if r.outbox != nil {
+		return r.outbox.SaveWithEvent(todo, func(after *server.ToDo) server.Event {
+			return server.NewToDoEvent(r.actor, before, after)
+		})
+	}
+
+	if err := r.ToDoRepo.Save(todo); err != nil {
+		return err
+	}
+
+	return r.publish(server.NewToDoEvent(r.actor, before, todo))
+}
+
+// Move sets the list of a ToDo and emits the event of the change
+func (r *ToDoRepo) Move(id, from, to string) error {
+
+	before, err := r.ToDoRepo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if before == nil {
+		// The move fails, there is no change to emit
+		return r.ToDoRepo.Move(id, from, to)
+	}
+
+	after := *before
+	after.ListID = to
+	after.ModTime = time.Now()
+
+	e := server.NewToDoEvent(r.actor, before, &after)
+
+	if r.outbox != nil {
+		return r.outbox.MoveWithEvent(id, from, to, e)
+	}
+
+	if err := r.ToDoRepo.Move(id, from, to); err != nil {
+		return err
+	}
+
+	return r.publish(e)
+}
+
+// Delete permanently removes a ToDo and emits the event of the change
+func (r *ToDoRepo) Delete(id string) error {
+
+	before, err := r.ToDoRepo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if before == nil {
+		return r.ToDoRepo.Delete(id)
+	}
+
+	e := server.NewToDoEvent(r.actor, before, nil)
+
+	if r.outbox != nil {
+		return r.outbox.DeleteWithEvent(id, e)
+	}
+
+	if err := r.ToDoRepo.Delete(id); err != nil {
+		return err
+	}
+
+	return r.publish(e)
+}
+
+func (r *ToDoRepo) publish(e server.Event) error {
+
+	if err := r.pub.Publish(e); err != nil {
+		return errors.Wrapf(err, "Could not publish event %s of ToDo %s", e.Type, e.ToDoID)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package publisher_test
+
+import (
+	"testing"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/publisher"
+)
+
+const testUser = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+
+func TestToDoRepo(t *testing.T) {
+	t.Run("Lifecycle", testLifecycle)
+	t.Run("Move", testMove)
+	t.Run("DeleteMissing", testDeleteMissing)
+	t.Run("Outbox", testOutbox)
+}
+
+func testLifecycle(t *testing.T) {
+
+	pub := publisher.NewMemory()
+	repo := publisher.NewToDoRepo(&RepoMock{ToDos: map[string]server.ToDo{}}, pub, testUser)
+
+	todo := &server.ToDo{Title: "Write events", Owner: testUser}
+	if err := repo.Save(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	todo.Title = "Write domain events"
+	if err := repo.Save(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	todo.Completed = true
+	if err := repo.Save(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Delete(todo.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	events := pub.Events()
+	want := []server.EventType{server.EventToDoCreated, server.EventToDoUpdated, server.EventToDoCompleted, server.EventToDoDeleted}
+
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %d", len(want), len(events))
+	}
+
+	for i, e := range events {
+		if e.Type != want[i] {
+			t.Fatalf("Expected event %d to be %s, got %s", i, want[i], e.Type)
+		}
+		if e.ID == "" || e.Version != server.EventVersion || e.ToDoID != todo.ID || e.Owner != testUser || e.Actor != testUser {
+			t.Fatalf("Expected event %d to identify the change, got %+v", i, e)
+		}
+	}
+
+	if events[0].Before != nil || events[0].After.Title != "Write events" {
+		t.Fatalf("Expected the created event to only have an after snapshot, got %+v", events[0])
+	}
+
+	if events[1].Before.Title != "Write events" || events[1].After.Title != "Write domain events" {
+		t.Fatalf("Expected the updated event to have the title before and after, got %+v", events[1])
+	}
+
+	if events[2].Before.Completed || !events[2].After.Completed {
+		t.Fatalf("Expected the completed event to have the ToDo open before and completed after, got %+v", events[2])
+	}
+
+	if events[3].Before == nil || events[3].After != nil {
+		t.Fatalf("Expected the deleted event to only have a before snapshot, got %+v", events[3])
+	}
+}
+
+func testMove(t *testing.T) {
+
+	pub := publisher.NewMemory()
+	m := &RepoMock{ToDos: map[string]server.ToDo{"t1": {ID: "t1", ListID: "l1", Owner: testUser}}}
+
+	if err := publisher.NewToDoRepo(m, pub, testUser).Move("t1", "l1", "l2"); err != nil {
+		t.Fatal(err)
+	}
+
+	events := pub.Events()
+	if len(events) != 1 || events[0].Type != server.EventToDoUpdated {
+		t.Fatalf("Expected an updated event, got %+v", events)
+	}
+
+	if events[0].Before.ListID != "l1" || events[0].After.ListID != "l2" {
+		t.Fatalf("Expected the ToDo to move from l1 to l2, got %s to %s", events[0].Before.ListID, events[0].After.ListID)
+	}
+}
+
+func testDeleteMissing(t *testing.T) {
+
+	pub := publisher.NewMemory()
+
+	if err := publisher.NewToDoRepo(&RepoMock{ToDos: map[string]server.ToDo{}}, pub, testUser).Delete("missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pub.Events()) != 0 {
+		t.Fatalf("Expected no event for a missing ToDo, got %+v", pub.Events())
+	}
+}
+
+func testOutbox(t *testing.T) {
+
+	m := &OutboxRepoMock{RepoMock: RepoMock{ToDos: map[string]server.ToDo{"t1": {ID: "t1", ListID: "l1", Owner: testUser}}}}
+	repo := publisher.NewOutboxToDoRepo(m, testUser)
+
+	todo := &server.ToDo{Title: "New", Owner: testUser}
+	if err := repo.Save(todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Move("t1", "l1", "l2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Delete(todo.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []server.EventType{server.EventToDoCreated, server.EventToDoUpdated, server.EventToDoDeleted}
+	if len(m.Outbox) != len(want) {
+		t.Fatalf("Expected %d events in the outbox, got %d", len(want), len(m.Outbox))
+	}
+
+	for i, e := range m.Outbox {
+		if e.Type != want[i] {
+			t.Fatalf("Expected event %d to be %s, got %s", i, want[i], e.Type)
+		}
+	}
+
+	if m.Outbox[0].ToDoID != todo.ID || m.Outbox[1].After.ListID != "l2" {
+		t.Fatalf("Expected the events of the changes, got %+v", m.Outbox)
+	}
+}
@@ -0,0 +1,118 @@
+// Package rank generates fractional lexicographic keys, so that an item can be moved between
+// two others by giving it a key that sorts between theirs, without renumbering the rest
+package rank
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// digits are the characters keys are made of, in ascending byte order
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// MaxLength is the length above which keys should be rebalanced with Spread
+const MaxLength = 24
+
+// ErrOrder is returned when the keys to generate a key between are not in ascending order
+var ErrOrder = errors.New("keys are not in ascending order")
+
+// ErrInvalid is returned when a key contains characters that are not digits
+var ErrInvalid = errors.New("invalid key")
+
+// ErrNoRoom is returned when no key sorts between two keys, the second one being the first one
+// followed by zeros
+var ErrNoRoom = errors.New("no key between keys")
+
+// digit returns the value of the i-th character of a key, keys being right padded with zeros
+func digit(key string, i int) int {
+
+	if i >= len(key) {
+		return 0
+	}
+
+	return strings.IndexByte(digits, key[i])
+}
+
+func valid(key string) bool {
+
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Between returns a key sorting after a and before b. An empty a is before every key and an
+// empty b is after every key. Generated keys never end with the smallest digit, so there is
+// always room for another key before them.
+func Between(a, b string) (string, error) {
+
+	if !valid(a) || !valid(b) {
+		return "", errors.Wrapf(ErrInvalid, "%q or %q", a, b)
+	}
+
+	if b != "" && a >= b {
+		return "", errors.Wrapf(ErrOrder, "%q >= %q", a, b)
+	}
+
+	var key []byte
+
+	for i := 0; ; i++ {
+
+		// the key is a prefix of a and equals b, which only has zeros left after a
+		if b != "" && i >= len(b) {
+			return "", errors.Wrapf(ErrNoRoom, "%q and %q", a, b)
+		}
+
+		lo, hi := digit(a, i), base
+		if b != "" {
+			hi = digit(b, i)
+		}
+
+		if lo == hi {
+			key = append(key, digits[lo])
+			continue
+		}
+
+		if mid := (lo + hi) / 2; mid > lo {
+			return string(append(key, digits[mid])), nil
+		}
+
+		// hi is right after lo: keep lo and look for room after the rest of a
+		key = append(key, digits[lo])
+		b = ""
+	}
+}
+
+// Spread returns n evenly spaced keys of equal length, in ascending order. Like the keys
+// returned by Between, they never end with the smallest digit.
+func Spread(n int) []string {
+
+	// slots is the number of keys of the given width not ending with the smallest digit
+	width, slots := 1, base-1
+	for slots <= n {
+		width++
+		slots *= base
+	}
+
+	step := slots / (n + 1)
+
+	keys := make([]string, n)
+	for i := range keys {
+		s := (i + 1) * step
+		v := s/(base-1)*base + s%(base-1) + 1
+		k := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			k[j] = digits[v%base]
+			v /= base
+		}
+		keys[i] = string(k)
+	}
+
+	return keys
+}
@@ -0,0 +1,125 @@
+package rank_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server/rank"
+	"github.com/pkg/errors"
+)
+
+func TestRank(t *testing.T) {
+	t.Run("Between", testBetween)
+	t.Run("BetweenUnordered", testBetweenUnordered)
+	t.Run("BetweenNoRoom", testBetweenNoRoom)
+	t.Run("RepeatedInserts", testRepeatedInserts)
+	t.Run("Spread", testSpread)
+}
+
+func testBetween(t *testing.T) {
+
+	for _, c := range []struct{ a, b string }{
+		{"", ""},
+		{"", "1"},
+		{"V", ""},
+		{"A", "B"},
+		{"A", "AB"},
+		{"Az", "B"},
+		{"0V", "1"},
+	} {
+		k, err := rank.Between(c.a, c.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if k <= c.a || (c.b != "" && k >= c.b) {
+			t.Fatalf("Expected key between %q and %q, got %q", c.a, c.b, k)
+		}
+
+		if k[len(k)-1] == '0' {
+			t.Fatalf("Expected key not ending with 0, got %q", k)
+		}
+	}
+}
+
+func testBetweenUnordered(t *testing.T) {
+
+	if _, err := rank.Between("B", "A"); errors.Cause(err) != rank.ErrOrder {
+		t.Fatalf("Expected %v, got %v", rank.ErrOrder, err)
+	}
+
+	if _, err := rank.Between("A", "A"); errors.Cause(err) != rank.ErrOrder {
+		t.Fatalf("Expected %v, got %v", rank.ErrOrder, err)
+	}
+
+	if _, err := rank.Between("a-b", ""); errors.Cause(err) != rank.ErrInvalid {
+		t.Fatalf("Expected %v, got %v", rank.ErrInvalid, err)
+	}
+}
+
+func testBetweenNoRoom(t *testing.T) {
+
+	for _, c := range []struct{ a, b string }{
+		{"1", "10"},
+		{"1", "100"},
+		{"", "0"},
+		{"A0", "A00"},
+	} {
+		if _, err := rank.Between(c.a, c.b); errors.Cause(err) != rank.ErrNoRoom {
+			t.Fatalf("Expected %v between %q and %q, got %v", rank.ErrNoRoom, c.a, c.b, err)
+		}
+	}
+}
+
+// testRepeatedInserts always inserts right after the first key, the worst case for key length
+func testRepeatedInserts(t *testing.T) {
+
+	first, last := "1", "2"
+
+	for i := 0; i < 200; i++ {
+		k, err := rank.Between(first, last)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k <= first || k >= last {
+			t.Fatalf("Expected key between %q and %q, got %q", first, last, k)
+		}
+		last = k
+	}
+
+	if len(last) <= rank.MaxLength {
+		t.Fatalf("Expected keys to grow past %d characters, got %q", rank.MaxLength, last)
+	}
+}
+
+func testSpread(t *testing.T) {
+
+	for _, n := range []int{1, 10, 61, 62, 1000} {
+
+		keys := rank.Spread(n)
+
+		if len(keys) != n {
+			t.Fatalf("Expected %d keys, got %d", n, len(keys))
+		}
+
+		if !sort.StringsAreSorted(keys) {
+			t.Fatalf("Expected keys in ascending order, got %v", keys)
+		}
+
+		for i := 1; i < n; i++ {
+			if keys[i] == keys[i-1] {
+				t.Fatalf("Expected unique keys, got %q twice", keys[i])
+			}
+		}
+
+		for _, k := range keys {
+			if k[len(k)-1] == '0' {
+				t.Fatalf("Expected key not ending with 0, got %q", k)
+			}
+		}
+
+		if _, err := rank.Between("", keys[0]); err != nil {
+			t.Fatalf("Expected room before the first key: %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidLimit is returned when a Limiter would not allow any request to be refilled
+var ErrInvalidLimit = errors.New("rate limit capacity and rate must be positive")
+
+// Result is the outcome of a rate limited request
+type Result struct {
+	// Allowed reports whether the request can proceed
+	Allowed bool
+	// Limit is the maximum number of requests that can be made in a burst
+	Limit int
+	// Remaining is the number of requests that can still be made in a burst
+	Remaining int
+	// Reset is the time until the bucket is full again, or until the next request is allowed
+	// when the request was rejected
+	Reset time.Duration
+}
+
+// Limiter is a token bucket rate limiter storing its buckets in a repository so that the
+// limit is shared by every Lambda instance
+type Limiter struct {
+	repo     database.RateLimitRepo
+	capacity int
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewLimiter returns a Limiter allowing bursts of capacity requests, refilled at rate requests
+// per second
+func NewLimiter(repo database.RateLimitRepo, capacity int, rate float64) (*Limiter, error) {
+
+	if capacity <= 0 || rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return nil, errors.Wrapf(ErrInvalidLimit, "capacity %d, rate %v", capacity, rate)
+	}
+
+	interval := time.Duration(float64(time.Second) / rate)
+	if interval <= 0 {
+		return nil, errors.Wrapf(ErrInvalidLimit, "capacity %d, rate %v", capacity, rate)
+	}
+
+	return &Limiter{
+		repo:     repo,
+		capacity: capacity,
+		interval: interval,
+		now:      time.Now,
+	}, nil
+}
+
+// Allow takes a token from the bucket of key, reporting whether the request is allowed
+func (l *Limiter) Allow(key string) (Result, error) {
+
+	now := l.now()
+
+	fullAt, err := l.repo.Take(key, l.capacity, l.interval, now)
+	if errors.Cause(err) == database.ErrLimitReached {
+		// The last token was taken at most an interval ago, so the next one is refilled within
+		// an interval
+		return Result{Limit: l.capacity, Reset: l.interval}, nil
+	} else if err != nil {
+		return Result{}, err
+	}
+
+	reset := fullAt.Sub(now)
+	missing := int((reset + l.interval - 1) / l.interval)
+
+	return Result{
+		Allowed:   true,
+		Limit:     l.capacity,
+		Remaining: l.capacity - missing,
+		Reset:     reset,
+	}, nil
+}
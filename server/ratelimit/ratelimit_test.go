@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// repoMock stores the time each bucket is full again in memory
+type repoMock struct {
+	buckets map[string]time.Time
+}
+
+func (m *repoMock) Take(key string, capacity int, interval time.Duration, now time.Time) (time.Time, error) {
+	fullAt := m.buckets[key]
+	if fullAt.Before(now) {
+		fullAt = now
+	}
+	if fullAt.After(now.Add(time.Duration(capacity-1) * interval)) {
+		return time.Time{}, database.ErrLimitReached
+	}
+	m.buckets[key] = fullAt.Add(interval)
+	return m.buckets[key], nil
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("AllowBurst", testAllowBurst)
+	t.Run("Refill", testRefill)
+	t.Run("InvalidRate", testInvalidRate)
+}
+
+func newTestLimiter(repo *repoMock, now *time.Time) *Limiter {
+	l, err := NewLimiter(repo, 3, 1)
+	if err != nil {
+		panic(err)
+	}
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func testAllowBurst(t *testing.T) {
+
+	now := time.Unix(1562917200, 0)
+	l := newTestLimiter(&repoMock{buckets: map[string]time.Time{}}, &now)
+
+	for i := 2; i >= 0; i-- {
+		res, err := l.Allow("user")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !res.Allowed || res.Remaining != i {
+			t.Fatalf("Expected request to be allowed with %d remaining, got %+v", i, res)
+		}
+	}
+
+	res, err := l.Allow("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Allowed {
+		t.Fatal("Expected request to be rejected")
+	}
+
+	if res.Reset != time.Second {
+		t.Fatalf("Expected reset in 1s, got %s", res.Reset)
+	}
+
+	now = now.Add(time.Second)
+
+	res, err = l.Allow("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("Expected refilled request to be allowed with 0 remaining, got %+v", res)
+	}
+}
+
+func testRefill(t *testing.T) {
+
+	now := time.Unix(1562917200, 0)
+	l := newTestLimiter(&repoMock{buckets: map[string]time.Time{}}, &now)
+
+	for i := 0; i < 3; i++ {
+		if _, err := l.Allow("user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now = now.Add(3 * time.Second)
+
+	res, err := l.Allow("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("Expected request to be allowed with 2 remaining, got %+v", res)
+	}
+}
+
+func testInvalidRate(t *testing.T) {
+
+	for _, rate := range []float64{0, -1} {
+		if _, err := NewLimiter(&repoMock{}, 3, rate); errors.Cause(err) != ErrInvalidLimit {
+			t.Fatalf("Expected %v for rate %v, got %v", ErrInvalidLimit, rate, err)
+		}
+	}
+}
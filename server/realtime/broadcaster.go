@@ -0,0 +1,66 @@
+package realtime
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi/apigatewaymanagementapiiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+)
+
+// Broadcaster sends the events of ToDos to the WebSocket connections of their owner
+type Broadcaster struct {
+	conns database.ConnectionRepo
+	api   apigatewaymanagementapiiface.ApiGatewayManagementApiAPI
+}
+
+// NewBroadcaster returns a broadcaster posting to connections through the given API Gateway
+// management API client, whose endpoint is the one of the WebSocket API
+func NewBroadcaster(conns database.ConnectionRepo, api apigatewaymanagementapiiface.ApiGatewayManagementApiAPI) *Broadcaster {
+	return &Broadcaster{
+		conns: conns,
+		api:   api,
+	}
+}
+
+// Broadcast sends an event to every connection of its owner, removing the connections which are
+// gone. It tries every connection, and returns the first error other than a gone connection.
+// Events may be sent again when a broadcast is retried, clients ignore the IDs they received.
+func (b *Broadcaster) Broadcast(e server.Event) error {
+
+	conns, err := b.conns.GetByOwner(e.Owner)
+	if err != nil || len(conns) == 0 {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal event %s", e.ID)
+	}
+
+	var first error
+
+	for _, c := range conns {
+
+		_, err := b.api.PostToConnection(&apigatewaymanagementapi.PostToConnectionInput{
+			ConnectionId: aws.String(c.ID),
+			Data:         data,
+		})
+
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == apigatewaymanagementapi.ErrCodeGoneException {
+			err = b.conns.Delete(c.ID)
+		} else if err != nil {
+			err = errors.Wrapf(err, "Could not post event %s to connection %s", e.ID, c.ID)
+		}
+
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,73 @@
+package realtime
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server/auth"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+)
+
+const (
+	// RouteConnect is the route API Gateway invokes when a client opens a connection
+	RouteConnect = "$connect"
+	// RouteDisconnect is the route API Gateway invokes when a connection is closed
+	RouteDisconnect = "$disconnect"
+	// RouteDefault is the route of the messages sent by clients
+	RouteDefault = "$default"
+)
+
+// Handler keeps track of the WebSocket connections of users
+type Handler struct {
+	conns database.ConnectionRepo
+}
+
+// NewHandler returns a handler storing connections in the given repository
+func NewHandler(conns database.ConnectionRepo) *Handler {
+	return &Handler{conns}
+}
+
+// Handle handles a WebSocket request from AWS API Gateway. Clients only receive changes, the
+// messages they send, such as the ones keeping idle connections open, are ignored.
+func (h *Handler) Handle(req events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	id := req.RequestContext.ConnectionID
+
+	switch req.RequestContext.RouteKey {
+	case RouteConnect:
+		values, _ := req.RequestContext.Authorizer.(map[string]interface{})
+		user := auth.Principal(values)
+		if user == "" {
+			return response(http.StatusUnauthorized), nil
+		}
+
+		conn := &database.Connection{
+			ID:    id,
+			Owner: user,
+		}
+		if at := req.RequestContext.ConnectedAt; at != 0 {
+			conn.Connected = time.Unix(0, at*int64(time.Millisecond))
+		}
+
+		if err := h.conns.Save(conn); err != nil {
+			return response(http.StatusInternalServerError), err
+		}
+
+	case RouteDisconnect:
+		if err := h.conns.Delete(id); err != nil {
+			return response(http.StatusInternalServerError), err
+		}
+
+	case RouteDefault:
+
+	default:
+		return response(http.StatusNotFound), nil
+	}
+
+	return response(http.StatusOK), nil
+}
+
+func response(code int) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{StatusCode: code}
+}
@@ -0,0 +1,53 @@
+package realtime_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi/apigatewaymanagementapiiface"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+)
+
+// ConnectionRepoMock is used to mock a connection repository
+type ConnectionRepoMock struct {
+	Conns map[string]*database.Connection
+}
+
+// GetByOwner returns the connections of a user
+func (m *ConnectionRepoMock) GetByOwner(owner string) ([]database.Connection, error) {
+	conns := []database.Connection{}
+	for _, c := range m.Conns {
+		if c.Owner == owner {
+			conns = append(conns, *c)
+		}
+	}
+	return conns, nil
+}
+
+// Save stores a connection
+func (m *ConnectionRepoMock) Save(conn *database.Connection) error {
+	c := *conn
+	m.Conns[conn.ID] = &c
+	return nil
+}
+
+// Delete removes a connection
+func (m *ConnectionRepoMock) Delete(id string) error {
+	delete(m.Conns, id)
+	return nil
+}
+
+// ManagementAPIMock is used to mock the API Gateway management API, connections in Gone are gone
+type ManagementAPIMock struct {
+	apigatewaymanagementapiiface.ApiGatewayManagementApiAPI
+	Gone   map[string]bool
+	Posted map[string][]byte
+}
+
+// PostToConnection records the data posted to a connection
+func (m *ManagementAPIMock) PostToConnection(input *apigatewaymanagementapi.PostToConnectionInput) (*apigatewaymanagementapi.PostToConnectionOutput, error) {
+	if m.Gone[*input.ConnectionId] {
+		return nil, awserr.New(apigatewaymanagementapi.ErrCodeGoneException, "gone", nil)
+	}
+	m.Posted[*input.ConnectionId] = input.Data
+	return &apigatewaymanagementapi.PostToConnectionOutput{}, nil
+}
@@ -0,0 +1,133 @@
+package realtime_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/realtime"
+)
+
+const (
+	testUser  = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+	otherUser = "9a1d2c7e-0f3b-4e8a-b6d5-7c4e2f1a0b93"
+)
+
+func TestRealtime(t *testing.T) {
+	t.Run("Connect", testConnect)
+	t.Run("ConnectUnauthenticated", testConnectUnauthenticated)
+	t.Run("Disconnect", testDisconnect)
+	t.Run("BroadcastToOwner", testBroadcastToOwner)
+	t.Run("BroadcastPrunesGone", testBroadcastPrunesGone)
+}
+
+func wsRequest(route, connID string, authorizer interface{}) events.APIGatewayWebsocketProxyRequest {
+	return events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			RouteKey:     route,
+			ConnectionID: connID,
+			ConnectedAt:  1565000000000,
+			Authorizer:   authorizer,
+		},
+	}
+}
+
+func testConnect(t *testing.T) {
+
+	m := &ConnectionRepoMock{Conns: map[string]*database.Connection{}}
+
+	resp, err := realtime.NewHandler(m).Handle(wsRequest(realtime.RouteConnect, "conn", map[string]interface{}{"principalId": testUser}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	c := m.Conns["conn"]
+	if c == nil || c.Owner != testUser || c.Connected.Unix() != 1565000000 {
+		t.Fatalf("Expected the connection of the user to be saved, got %+v", c)
+	}
+}
+
+func testConnectUnauthenticated(t *testing.T) {
+
+	m := &ConnectionRepoMock{Conns: map[string]*database.Connection{}}
+
+	resp, err := realtime.NewHandler(m).Handle(wsRequest(realtime.RouteConnect, "conn", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected %d http response code, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	if len(m.Conns) != 0 {
+		t.Fatal("Expected no connection to be saved")
+	}
+}
+
+func testDisconnect(t *testing.T) {
+
+	m := &ConnectionRepoMock{Conns: map[string]*database.Connection{
+		"conn": {ID: "conn", Owner: testUser},
+	}}
+
+	if _, err := realtime.NewHandler(m).Handle(wsRequest(realtime.RouteDisconnect, "conn", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Conns) != 0 {
+		t.Fatal("Expected the connection to be removed")
+	}
+}
+
+func testBroadcastToOwner(t *testing.T) {
+
+	m := &ConnectionRepoMock{Conns: map[string]*database.Connection{
+		"mine":   {ID: "mine", Owner: testUser},
+		"theirs": {ID: "theirs", Owner: otherUser},
+	}}
+	api := &ManagementAPIMock{Posted: map[string][]byte{}}
+
+	e := server.Event{ID: "event", Type: server.EventToDoUpdated, Owner: testUser}
+	if err := realtime.NewBroadcaster(m, api).Broadcast(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(api.Posted) != 1 || api.Posted["mine"] == nil {
+		t.Fatalf("Expected the event to be posted to the connection of the owner only, got %d posts", len(api.Posted))
+	}
+
+	var posted server.Event
+	if err := json.Unmarshal(api.Posted["mine"], &posted); err != nil || posted.ID != "event" {
+		t.Fatalf("Expected the event to be posted, got %s", api.Posted["mine"])
+	}
+}
+
+func testBroadcastPrunesGone(t *testing.T) {
+
+	m := &ConnectionRepoMock{Conns: map[string]*database.Connection{
+		"open":  {ID: "open", Owner: testUser},
+		"stale": {ID: "stale", Owner: testUser},
+	}}
+	api := &ManagementAPIMock{Gone: map[string]bool{"stale": true}, Posted: map[string][]byte{}}
+
+	e := server.Event{ID: "event", Type: server.EventToDoDeleted, Owner: testUser}
+	if err := realtime.NewBroadcaster(m, api).Broadcast(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m.Conns["stale"]; ok {
+		t.Fatal("Expected the stale connection to be pruned")
+	}
+
+	if api.Posted["open"] == nil {
+		t.Fatal("Expected the event to be posted to the open connection")
+	}
+}
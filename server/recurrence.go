@@ -0,0 +1,107 @@
+package server
+
+import (
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server/rrule"
+	"github.com/pkg/errors"
+)
+
+// ErrNoDueDate is returned when a recurring ToDo has no due date to start from
+var ErrNoDueDate = errors.New("recurring ToDos must have a due date")
+
+// Location returns the time zone of the ToDo
+func (t *ToDo) Location() (*time.Location, error) {
+	return time.LoadLocation(t.TimeZone)
+}
+
+// Rule parses the recurrence of the ToDo, it returns nil if the ToDo does not repeat
+func (t *ToDo) Rule() (*rrule.Rule, error) {
+
+	if t.Recurrence == "" {
+		return nil, nil
+	}
+
+	if t.Due == nil {
+		return nil, ErrNoDueDate
+	}
+
+	return rrule.Parse(t.Recurrence)
+}
+
+// Occurrences returns up to n due dates following the one of the ToDo. Dates are computed in
+// the time zone of the ToDo, so a ToDo due at 9:00 stays due at 9:00 local time.
+func (t *ToDo) Occurrences(n int) ([]time.Time, error) {
+
+	rule, err := t.Rule()
+	if err != nil || rule == nil {
+		return nil, err
+	}
+
+	loc, err := t.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	start := t.Due.In(loc)
+	return rule.Occurrences(start, start, n), nil
+}
+
+// NextOccurrence returns a new open ToDo due at the next occurrence of the ToDo, or nil if
+// the ToDo does not repeat or its last occurrence has been reached
+func (t *ToDo) NextOccurrence() (*ToDo, error) {
+
+	rule, err := t.Rule()
+	if err != nil || rule == nil {
+		return nil, err
+	}
+
+	if rule.Count == 1 {
+		return nil, nil
+	}
+
+	o, err := t.Occurrences(1)
+	if err != nil || len(o) == 0 {
+		return nil, err
+	}
+
+	next := *t
+	next.ID = ""
+	next.Completed = false
+	next.CompletedAt = nil
+	next.ModTime = time.Time{}
+	next.ItemsProgress = nil
+	// Blobs belong to a single ToDo and are deleted with it
+	next.Attachments = nil
+	// The new occurrence is ranked like any new ToDo, it keeps the owner of the series
+	next.Rank = ""
+
+	due := o[0].UTC()
+	next.Due = &due
+
+	// Reminders keep their offset from the due date
+	next.Reminders = make([]time.Time, len(t.Reminders))
+	for i, r := range t.Reminders {
+		next.Reminders[i] = r.Add(due.Sub(*t.Due))
+	}
+	if len(next.Reminders) == 0 {
+		next.Reminders = nil
+	}
+
+	if rule.Count > 1 {
+		// The next ToDo starts the rest of the series
+		next.Recurrence = rule.WithCount(rule.Count - 1)
+	}
+
+	next.Items = make([]ChecklistItem, len(t.Items))
+	for i, item := range t.Items {
+		item.ID = ""
+		item.Completed = false
+		next.Items[i] = item
+	}
+	if len(next.Items) == 0 {
+		next.Items = nil
+	}
+
+	return &next, nil
+}
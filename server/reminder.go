@@ -0,0 +1,24 @@
+package server
+
+import "time"
+
+// MaxReminders is the maximum number of reminders of a ToDo
+const MaxReminders = 10
+
+// NextReminder returns the earliest reminder of the ToDo after the given time, or nil if there
+// is none or the ToDo is completed
+func (t *ToDo) NextReminder(after time.Time) *time.Time {
+
+	if t.Completed {
+		return nil
+	}
+
+	var next *time.Time
+	for i, r := range t.Reminders {
+		if r.After(after) && (next == nil || r.Before(*next)) {
+			next = &t.Reminders[i]
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,66 @@
+package reminder_test
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+)
+
+// ReminderRepoMock is used to mock a reminder repository
+type ReminderRepoMock struct {
+	Reminders []database.DueReminder
+	Sent      map[string]bool
+	Advanced  []string
+}
+
+func sentKey(todoID string, at time.Time) string {
+	return todoID + "#" + strconv.FormatInt(at.UnixNano(), 10)
+}
+
+// Due returns the due reminders
+func (m *ReminderRepoMock) Due(now time.Time, limit int) ([]database.DueReminder, error) {
+	return m.Reminders, nil
+}
+
+// MarkSent marks a reminder sent
+func (m *ReminderRepoMock) MarkSent(todoID string, at time.Time) error {
+	if m.Sent[sentKey(todoID, at)] {
+		return database.ErrExists
+	}
+	m.Sent[sentKey(todoID, at)] = true
+	return nil
+}
+
+// Unmark removes the sent marker of a reminder
+func (m *ReminderRepoMock) Unmark(todoID string, at time.Time) error {
+	delete(m.Sent, sentKey(todoID, at))
+	return nil
+}
+
+// Advance records the ToDos whose reminders were advanced
+func (m *ReminderRepoMock) Advance(todo *server.ToDo, at time.Time) error {
+	m.Advanced = append(m.Advanced, todo.ID)
+	return nil
+}
+
+// NotifierMock records the notifications sent, or fails with Err. Like the email notifier, it
+// fails with ErrNoContact for users without an email address.
+type NotifierMock struct {
+	Err  error
+	Sent []notify.Message
+}
+
+// Notify records a notification
+func (m *NotifierMock) Notify(to *server.Profile, msg notify.Message) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	if to.Email == "" {
+		return notify.ErrNoContact
+	}
+	m.Sent = append(m.Sent, msg)
+	return nil
+}
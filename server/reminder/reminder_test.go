@@ -0,0 +1,150 @@
+package reminder_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database/databasetest"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/massimoselvi/serverless-todo-api-go/server/reminder"
+)
+
+const testUser = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+
+var (
+	testNow = time.Date(2019, 8, 5, 9, 0, 0, 0, time.UTC)
+	testDue = time.Date(2019, 8, 5, 17, 30, 0, 0, time.UTC)
+)
+
+func TestReminder(t *testing.T) {
+	t.Run("Send", testSend)
+	t.Run("SendOnce", testSendOnce)
+	t.Run("SendFailed", testSendFailed)
+	t.Run("SendNoContact", testSendNoContact)
+	t.Run("SendUnverified", testSendUnverified)
+}
+
+func setup(n *NotifierMock) (*reminder.Sender, *ReminderRepoMock, *databasetest.ProfileRepoMock) {
+
+	due := testDue
+	reminders := &ReminderRepoMock{
+		Reminders: []database.DueReminder{{
+			ToDo: server.ToDo{ID: "todo", Title: "Pay rent", Owner: testUser, Due: &due, Reminders: []time.Time{testNow}},
+			At:   testNow,
+		}},
+		Sent: map[string]bool{},
+	}
+	profiles := &databasetest.ProfileRepoMock{Profiles: map[string]*server.Profile{
+		testUser: {User: testUser, Email: "user@example.com", EmailVerified: true},
+	}}
+
+	return reminder.NewSender(reminders, profiles, n), reminders, profiles
+}
+
+func testSend(t *testing.T) {
+
+	n := &NotifierMock{}
+	s, reminders, _ := setup(n)
+
+	sent, err := s.Send(testNow, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != 1 || len(n.Sent) != 1 {
+		t.Fatalf("Expected 1 reminder to be sent, got %d", len(n.Sent))
+	}
+
+	if n.Sent[0].Subject != "Reminder: Pay rent" || !strings.Contains(n.Sent[0].Text, "Mon Aug 5 17:30 UTC") {
+		t.Fatalf("Expected the reminder of the ToDo with its due date, got %+v", n.Sent[0])
+	}
+
+	if len(reminders.Advanced) != 1 {
+		t.Fatalf("Expected the reminders of the ToDo to be advanced, got %v", reminders.Advanced)
+	}
+}
+
+func testSendOnce(t *testing.T) {
+
+	n := &NotifierMock{}
+	s, reminders, _ := setup(n)
+
+	if _, err := s.Send(testNow, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reminder is still due when advancing it failed or raced with another sender
+	sent, err := s.Send(testNow, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != 0 || len(n.Sent) != 1 {
+		t.Fatalf("Expected the reminder to be sent once, got %d notifications", len(n.Sent))
+	}
+
+	if len(reminders.Advanced) != 2 {
+		t.Fatalf("Expected the reminders of the ToDo to be advanced again, got %v", reminders.Advanced)
+	}
+}
+
+func testSendFailed(t *testing.T) {
+
+	n := &NotifierMock{Err: errors.New("throttled")}
+	s, reminders, _ := setup(n)
+
+	if _, err := s.Send(testNow, 10); err == nil {
+		t.Fatal("Expected an error when the notification fails")
+	}
+
+	if len(reminders.Sent) != 0 {
+		t.Fatalf("Expected the reminder to be unmarked, got %v", reminders.Sent)
+	}
+
+	if len(reminders.Advanced) != 0 {
+		t.Fatalf("Expected the reminder to stay due, got %v", reminders.Advanced)
+	}
+
+	n.Err = nil
+	if sent, err := s.Send(testNow, 10); err != nil || sent != 1 {
+		t.Fatalf("Expected the reminder to be sent when retried, got %d, %v", sent, err)
+	}
+}
+
+func testSendNoContact(t *testing.T) {
+
+	n := &NotifierMock{Err: notify.ErrNoContact}
+	s, reminders, _ := setup(n)
+
+	sent, err := s.Send(testNow, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != 0 {
+		t.Fatalf("Expected no reminder to be sent, got %d", sent)
+	}
+
+	if len(reminders.Advanced) != 1 {
+		t.Fatalf("Expected the reminder to be skipped, got %v", reminders.Advanced)
+	}
+}
+
+func testSendUnverified(t *testing.T) {
+
+	n := &NotifierMock{}
+	s, reminders, profiles := setup(n)
+	profiles.Profiles[testUser].EmailVerified = false
+
+	if _, err := s.Send(testNow, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(n.Sent) != 0 || len(reminders.Advanced) != 1 {
+		t.Fatalf("Expected the reminder not to be emailed to an unverified address, got %d", len(n.Sent))
+	}
+}
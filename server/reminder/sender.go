@@ -0,0 +1,129 @@
+// Package reminder sends the reminders of ToDos to their owners
+package reminder
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/massimoselvi/serverless-todo-api-go/server/notify"
+	"github.com/pkg/errors"
+)
+
+// dueLayout formats the due date of a ToDo in reminders
+const dueLayout = "Mon Jan 2 15:04 MST"
+
+// Sender sends the due reminders of ToDos to their owners
+type Sender struct {
+	reminders database.ReminderRepo
+	profiles  database.ProfileRepo
+	notifier  notify.Notifier
+}
+
+// NewSender returns a sender notifying the owners of ToDos with the given notifier
+func NewSender(reminders database.ReminderRepo, profiles database.ProfileRepo, notifier notify.Notifier) *Sender {
+	return &Sender{
+		reminders: reminders,
+		profiles:  profiles,
+		notifier:  notifier,
+	}
+}
+
+// Send sends up to limit reminders due at now and returns how many were sent. A reminder is
+// marked sent before it is sent, so it is never sent twice, and unmarked if it could not be sent
+// so that it is retried. Reminders of owners without contact details are skipped.
+func (s *Sender) Send(now time.Time, limit int) (int, error) {
+
+	due, err := s.reminders.Due(now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var first error
+
+	for i := range due {
+
+		ok, err := s.send(&due[i])
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+
+		if ok {
+			sent++
+		}
+
+		if err := s.reminders.Advance(&due[i].ToDo, due[i].At); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return sent, first
+}
+
+// send sends a reminder unless it was already sent, and reports whether it sent it
+func (s *Sender) send(r *database.DueReminder) (bool, error) {
+
+	todo := &r.ToDo
+	if todo.Owner == "" {
+		return false, nil
+	}
+
+	if err := s.reminders.MarkSent(todo.ID, r.At); err != nil {
+		if errors.Cause(err) == database.ErrExists {
+			return false, nil
+		}
+		return false, err
+	}
+
+	profile, err := s.profiles.Get(todo.Owner)
+	if err != nil || profile == nil {
+		return false, s.unmark(r, err)
+	}
+
+	err = s.notifier.Notify(profile.Contact(), message(todo))
+	if errors.Cause(err) == notify.ErrNoContact {
+		return false, nil
+	}
+	if err != nil {
+		return false, s.unmark(r, err)
+	}
+
+	return true, nil
+}
+
+// unmark removes the sent marker of a reminder which could not be sent because of err, if any
+func (s *Sender) unmark(r *database.DueReminder, err error) error {
+
+	if err == nil {
+		return nil
+	}
+
+	if uerr := s.reminders.Unmark(r.ToDo.ID, r.At); uerr != nil {
+		return errors.Wrapf(uerr, "Could not unmark reminder after error %v", err)
+	}
+
+	return err
+}
+
+// message returns the reminder of a ToDo
+func message(todo *server.ToDo) notify.Message {
+
+	text := todo.Title
+	if todo.Due != nil {
+		loc, err := todo.Location()
+		if err != nil {
+			loc = time.UTC
+		}
+		text = fmt.Sprintf("%s is due %s", todo.Title, todo.Due.In(loc).Format(dueLayout))
+	}
+
+	return notify.Message{
+		Subject: "Reminder: " + todo.Title,
+		Text:    text,
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+// Role is the level of access a user has been granted on a resource
+type Role string
+
+const (
+	// RoleOwner can read, update, delete and share a resource
+	RoleOwner Role = "owner"
+	// RoleEditor can read and update a resource
+	RoleEditor Role = "editor"
+	// RoleViewer can only read a resource
+	RoleViewer Role = "viewer"
+)
+
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	switch r {
+	case RoleOwner, RoleEditor, RoleViewer:
+		return true
+	default:
+		return false
+	}
+}
+
+// Enum returns the known roles
+func (r Role) Enum() []string {
+	return []string{string(RoleOwner), string(RoleEditor), string(RoleViewer)}
+}
@@ -0,0 +1,481 @@
+package rrule
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxPeriods bounds the number of periods searched for an occurrence, so that rules matching
+// no date (e.g. the 30th of February) do not loop forever
+const maxPeriods = 10000
+
+// ErrInvalid is returned when a rule cannot be parsed or uses unsupported parts
+var ErrInvalid = errors.New("invalid recurrence rule")
+
+// Frequency is the base period of a rule
+type Frequency string
+
+const (
+	// Daily repeats every INTERVAL days
+	Daily Frequency = "DAILY"
+	// Weekly repeats every INTERVAL weeks
+	Weekly Frequency = "WEEKLY"
+	// Monthly repeats every INTERVAL months
+	Monthly Frequency = "MONTHLY"
+	// Yearly repeats every INTERVAL years
+	Yearly Frequency = "YEARLY"
+)
+
+// WeekdayNum is a BYDAY value, N is the occurrence of the weekday within the month (1 for the
+// first, -1 for the last) or 0 for every occurrence
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a recurrence rule as defined by RFC 5545. The FREQ, INTERVAL, COUNT, UNTIL, BYDAY,
+// BYMONTHDAY, BYMONTH and WKST parts are supported.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+	// FloatingUntil reports that UNTIL has no time zone, its wall clock is then read in the
+	// location of DTSTART
+	FloatingUntil bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse parses a recurrence rule such as "FREQ=WEEKLY;BYDAY=MO,WE". An optional "RRULE:"
+// prefix is ignored.
+func Parse(s string) (*Rule, error) {
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+
+	for _, part := range strings.Split(s, ";") {
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, errors.Wrapf(ErrInvalid, "malformed part %q", part)
+		}
+
+		var err error
+		switch name, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1]); name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+		case "INTERVAL":
+			r.Interval, err = positive(value)
+		case "COUNT":
+			r.Count, err = positive(value)
+		case "UNTIL":
+			r.Until, r.FloatingUntil, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseByMonthDay(value)
+		case "BYMONTH":
+			r.ByMonth, err = parseByMonth(value)
+		case "WKST":
+			d, ok := weekdays[value]
+			if !ok {
+				err = errors.Errorf("unknown weekday %s", value)
+			}
+			r.WeekStart = d
+		default:
+			err = errors.Errorf("unsupported part %s", name)
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalid, err.Error())
+		}
+	}
+
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return nil, errors.Wrap(ErrInvalid, "FREQ is required")
+	default:
+		return nil, errors.Wrapf(ErrInvalid, "unsupported frequency %s", r.Freq)
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, errors.Wrap(ErrInvalid, "COUNT and UNTIL cannot be used together")
+	}
+
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != Monthly && (r.Freq != Yearly || len(r.ByMonth) == 0) {
+			return nil, errors.Wrap(ErrInvalid, "numbered BYDAY values are only supported in monthly rules or yearly rules with BYMONTH")
+		}
+	}
+
+	return r, nil
+}
+
+func positive(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, errors.Errorf("%s is not a positive integer", s)
+	}
+	return n, nil
+}
+
+// parseUntil returns the time of an UNTIL value, and whether it is a floating time whose wall
+// clock is parsed as UTC
+func parseUntil(s string) (time.Time, bool, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			if layout == "20060102" {
+				// A date includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, layout != "20060102T150405Z", nil
+		}
+	}
+	return time.Time{}, false, errors.Errorf("invalid UNTIL %s", s)
+}
+
+// until returns the last time an occurrence can start at, reading a floating UNTIL in loc
+func (r *Rule) until(loc *time.Location) time.Time {
+
+	if r.Until.IsZero() || !r.FloatingUntil {
+		return r.Until
+	}
+
+	u := r.Until
+	return time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc)
+}
+
+func parseByDay(s string) ([]WeekdayNum, error) {
+
+	var days []WeekdayNum
+
+	for _, v := range strings.Split(s, ",") {
+
+		if len(v) < 2 {
+			return nil, errors.Errorf("invalid BYDAY %s", v)
+		}
+
+		d, ok := weekdays[v[len(v)-2:]]
+		if !ok {
+			return nil, errors.Errorf("invalid BYDAY %s", v)
+		}
+
+		n := 0
+		if prefix := v[:len(v)-2]; prefix != "" {
+			var err error
+			if n, err = strconv.Atoi(prefix); err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, errors.Errorf("invalid BYDAY %s", v)
+			}
+		}
+
+		days = append(days, WeekdayNum{N: n, Weekday: d})
+	}
+
+	return days, nil
+}
+
+func parseByMonthDay(s string) ([]int, error) {
+
+	var days []int
+
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(v)
+		if err != nil || n == 0 || n < -31 || n > 31 {
+			return nil, errors.Errorf("invalid BYMONTHDAY %s", v)
+		}
+		days = append(days, n)
+	}
+
+	return days, nil
+}
+
+func parseByMonth(s string) ([]time.Month, error) {
+
+	var months []time.Month
+
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 12 {
+			return nil, errors.Errorf("invalid BYMONTH %s", v)
+		}
+		months = append(months, time.Month(n))
+	}
+
+	return months, nil
+}
+
+// Occurrences returns up to n occurrences of the rule starting at dtstart that are after the
+// given time. Occurrences are computed using the wall clock of the location of dtstart, so
+// that they keep the same local time across daylight saving time changes.
+func (r *Rule) Occurrences(dtstart, after time.Time, n int) []time.Time {
+
+	var out []time.Time
+	count := 0
+	until := r.until(dtstart.Location())
+
+	for period := 0; period < maxPeriods && len(out) < n; period++ {
+
+		for _, t := range r.expand(dtstart, period*r.Interval) {
+
+			if t.Before(dtstart) {
+				continue
+			}
+
+			if !until.IsZero() && t.After(until) {
+				return out
+			}
+
+			count++
+			if r.Count > 0 && count > r.Count {
+				return out
+			}
+
+			if t.After(after) {
+				out = append(out, t)
+				if len(out) == n {
+					return out
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// Next returns the first occurrence after the given time, or false if there is none
+func (r *Rule) Next(dtstart, after time.Time) (time.Time, bool) {
+	o := r.Occurrences(dtstart, after, 1)
+	if len(o) == 0 {
+		return time.Time{}, false
+	}
+	return o[0], true
+}
+
+// expand returns the sorted candidate occurrences in the period offset periods after the one
+// containing dtstart
+func (r *Rule) expand(dtstart time.Time, offset int) []time.Time {
+
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, 0, loc)
+	}
+
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{at(y, m, d+offset)}
+
+	case Weekly:
+		// Move to the first day of the week containing dtstart
+		start := d - (int(dtstart.Weekday())-int(r.WeekStart)+7)%7 + 7*offset
+		if len(r.ByDay) == 0 {
+			days = []time.Time{at(y, m, d+7*offset)}
+			break
+		}
+		for i := 0; i < 7; i++ {
+			t := at(y, m, start+i)
+			if r.matchesWeekday(t) {
+				days = append(days, t)
+			}
+		}
+
+	case Monthly:
+		first := at(y, m+time.Month(offset), 1)
+		days = r.expandMonth(first.Year(), first.Month(), d, at)
+
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{m}
+		}
+		for _, month := range months {
+			days = append(days, r.expandMonth(y+offset, month, d, at)...)
+		}
+	}
+
+	var out []time.Time
+	for _, t := range days {
+		if r.matchesMonth(t) && (r.Freq != Daily || (r.matchesWeekday(t) && r.matchesMonthDay(t))) {
+			out = append(out, t)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+
+	return out
+}
+
+// expandMonth returns the days of a month matching BYMONTHDAY or BYDAY, or the given day of
+// the month if neither is set. Days that do not exist in the month are skipped.
+func (r *Rule) expandMonth(y int, m time.Month, day int, at func(int, time.Month, int) time.Time) []time.Time {
+
+	last := time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var days []time.Time
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, md := range r.ByMonthDay {
+			if md < 0 {
+				md = last + md + 1
+			}
+			if md >= 1 && md <= last {
+				t := at(y, m, md)
+				if r.matchesWeekday(t) {
+					days = append(days, t)
+				}
+			}
+		}
+
+	case len(r.ByDay) > 0:
+		for _, wd := range r.ByDay {
+			var matches []int
+			for md := 1; md <= last; md++ {
+				if time.Date(y, m, md, 0, 0, 0, 0, time.UTC).Weekday() == wd.Weekday {
+					matches = append(matches, md)
+				}
+			}
+			switch {
+			case wd.N == 0:
+				for _, md := range matches {
+					days = append(days, at(y, m, md))
+				}
+			case wd.N > 0 && wd.N <= len(matches):
+				days = append(days, at(y, m, matches[wd.N-1]))
+			case wd.N < 0 && -wd.N <= len(matches):
+				days = append(days, at(y, m, matches[len(matches)+wd.N]))
+			}
+		}
+
+	case day <= last:
+		days = []time.Time{at(y, m, day)}
+	}
+
+	return days
+}
+
+func (r *Rule) matchesWeekday(t time.Time) bool {
+
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	for _, d := range r.ByDay {
+		if d.Weekday == t.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchesMonthDay(t time.Time) bool {
+
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	for _, md := range r.ByMonthDay {
+		if md == t.Day() || last+md+1 == t.Day() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchesMonth(t time.Time) bool {
+
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+
+	for _, m := range r.ByMonth {
+		if m == t.Month() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithCount returns the rule as a string with its COUNT replaced, used to continue a series
+// from one of its occurrences
+func (r *Rule) WithCount(count int) string {
+	c := *r
+	c.Count = count
+	return c.String()
+}
+
+// String formats the rule as an RFC 5545 RRULE value
+func (r *Rule) String() string {
+
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.FloatingUntil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+	} else if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, d := range r.ByDay {
+			s := strings.ToUpper(d.Weekday.String()[:2])
+			if d.N != 0 {
+				s = strconv.Itoa(d.N) + s
+			}
+			days = append(days, s)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		var days []string
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonth) > 0 {
+		var months []string
+		for _, m := range r.ByMonth {
+			months = append(months, strconv.Itoa(int(m)))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.WeekStart.String()[:2]))
+	}
+
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,186 @@
+package rrule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server/rrule"
+)
+
+func TestRule(t *testing.T) {
+	t.Run("Parse", testParse)
+	t.Run("ParseInvalid", testParseInvalid)
+	t.Run("Daily", testDaily)
+	t.Run("WeeklyByDay", testWeeklyByDay)
+	t.Run("MonthlyLastFriday", testMonthlyLastFriday)
+	t.Run("MonthlySkipsShortMonths", testMonthlySkipsShortMonths)
+	t.Run("YearlyByMonth", testYearlyByMonth)
+	t.Run("Count", testCount)
+	t.Run("Until", testUntil)
+	t.Run("FloatingUntil", testFloatingUntil)
+	t.Run("DaylightSavingTime", testDaylightSavingTime)
+}
+
+func mustParse(t *testing.T, s string) *rrule.Rule {
+	r, err := rrule.Parse(s)
+	if err != nil {
+		t.Fatalf("Could not parse %s: %v", s, err)
+	}
+	return r
+}
+
+func assertDates(t *testing.T, got []time.Time, want ...string) {
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d occurrences, got %d: %v", len(want), len(got), got)
+	}
+
+	for i, w := range want {
+		if d := got[i].Format("2006-01-02"); d != w {
+			t.Fatalf("Expected occurrence %d on %s, got %s", i, w, d)
+		}
+	}
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 9, 0, 0, 0, time.UTC)
+}
+
+func testParse(t *testing.T) {
+
+	r := mustParse(t, "RRULE:FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR;COUNT=3")
+
+	if r.Freq != rrule.Monthly || r.Interval != 2 || r.Count != 3 {
+		t.Fatalf("Unexpected rule %+v", r)
+	}
+
+	if len(r.ByDay) != 1 || r.ByDay[0].N != -1 || r.ByDay[0].Weekday != time.Friday {
+		t.Fatalf("Unexpected BYDAY %+v", r.ByDay)
+	}
+
+	if s := r.String(); s != "FREQ=MONTHLY;INTERVAL=2;COUNT=3;BYDAY=-1FR" {
+		t.Fatalf("Unexpected string %s", s)
+	}
+}
+
+func testParseInvalid(t *testing.T) {
+
+	for _, s := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20200101",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYSETPOS=1",
+	} {
+		if _, err := rrule.Parse(s); err == nil {
+			t.Fatalf("Expected %q to be invalid", s)
+		}
+	}
+}
+
+func testDaily(t *testing.T) {
+
+	r := mustParse(t, "FREQ=DAILY;INTERVAL=3")
+	start := date(2019, time.January, 30)
+
+	assertDates(t, r.Occurrences(start, start, 3), "2019-02-02", "2019-02-05", "2019-02-08")
+}
+
+func testWeeklyByDay(t *testing.T) {
+
+	r := mustParse(t, "FREQ=WEEKLY;BYDAY=MO,FR")
+	// A Wednesday
+	start := date(2019, time.July, 10)
+
+	assertDates(t, r.Occurrences(start, start, 4), "2019-07-12", "2019-07-15", "2019-07-19", "2019-07-22")
+}
+
+func testMonthlyLastFriday(t *testing.T) {
+
+	r := mustParse(t, "FREQ=MONTHLY;BYDAY=-1FR")
+	start := date(2019, time.July, 26)
+
+	assertDates(t, r.Occurrences(start, start, 3), "2019-08-30", "2019-09-27", "2019-10-25")
+}
+
+func testMonthlySkipsShortMonths(t *testing.T) {
+
+	r := mustParse(t, "FREQ=MONTHLY")
+	start := date(2019, time.January, 31)
+
+	assertDates(t, r.Occurrences(start, start, 3), "2019-03-31", "2019-05-31", "2019-07-31")
+}
+
+func testYearlyByMonth(t *testing.T) {
+
+	r := mustParse(t, "FREQ=YEARLY;BYMONTH=3,9;BYMONTHDAY=1")
+	start := date(2019, time.March, 1)
+
+	assertDates(t, r.Occurrences(start, start, 3), "2019-09-01", "2020-03-01", "2020-09-01")
+}
+
+func testCount(t *testing.T) {
+
+	r := mustParse(t, "FREQ=DAILY;COUNT=3")
+	start := date(2019, time.July, 1)
+
+	assertDates(t, r.Occurrences(start, start, 10), "2019-07-02", "2019-07-03")
+
+	if _, ok := r.Next(start, date(2019, time.July, 3)); ok {
+		t.Fatal("Expected no occurrence after the last one")
+	}
+}
+
+func testUntil(t *testing.T) {
+
+	r := mustParse(t, "FREQ=WEEKLY;UNTIL=20190722")
+	start := date(2019, time.July, 1)
+
+	assertDates(t, r.Occurrences(start, start, 10), "2019-07-08", "2019-07-15", "2019-07-22")
+}
+
+func testFloatingUntil(t *testing.T) {
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// UNTIL without a zone is 9:00 in New York, which is 13:00 UTC
+	r := mustParse(t, "FREQ=DAILY;UNTIL=20190703T090000")
+	start := time.Date(2019, time.July, 1, 9, 0, 0, 0, ny)
+
+	assertDates(t, r.Occurrences(start, start, 10), "2019-07-02", "2019-07-03")
+
+	if s := r.String(); s != "FREQ=DAILY;UNTIL=20190703T090000" {
+		t.Fatalf("Expected UNTIL to stay floating, got %s", s)
+	}
+}
+
+func testDaylightSavingTime(t *testing.T) {
+
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	r := mustParse(t, "FREQ=DAILY")
+	// The day before clocks go forward
+	start := time.Date(2019, time.March, 30, 9, 0, 0, 0, rome)
+
+	next, ok := r.Next(start, start)
+	if !ok {
+		t.Fatal("Expected an occurrence")
+	}
+
+	if h := next.In(rome).Hour(); h != 9 {
+		t.Fatalf("Expected occurrence at 9:00 local time, got %d:00", h)
+	}
+
+	if d := next.Sub(start); d != 23*time.Hour {
+		t.Fatalf("Expected occurrence 23 hours later, got %s", d)
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+// Stats counts the ToDos of a user
+type Stats struct {
+	Owner     string `json:"owner"`
+	Total     int    `json:"total"`
+	Completed int    `json:"completed"`
+}
+
+// StatsOf returns the contribution of a ToDo to the stats of its owner, nothing for a nil ToDo
+func StatsOf(t *ToDo) Stats {
+
+	if t == nil {
+		return Stats{}
+	}
+
+	s := Stats{Owner: t.Owner, Total: 1}
+	if t.Completed {
+		s.Completed = 1
+	}
+
+	return s
+}
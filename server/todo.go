@@ -4,8 +4,60 @@ import "time"
 
 // ToDo represents details of a "todo" task to be compelted
 type ToDo struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Completed bool      `json:"completed"`
-	ModTime   time.Time `json:"modTime"`
+	ID        string          `json:"id" schema:"format=uuid"`
+	Title     string          `json:"title" schema:"required,minLength=1,maxLength=500"`
+	Completed bool            `json:"completed"`
+	ModTime   time.Time       `json:"modTime" schema:"readonly"`
+	Owner     string          `json:"owner,omitempty" schema:"readonly"`
+	Members   map[string]Role `json:"members,omitempty"`
+	Items     []ChecklistItem `json:"items,omitempty"`
+	ParentID  string          `json:"parentId,omitempty" schema:"format=uuid"`
+	ListID    string          `json:"listId,omitempty" schema:"format=uuid"`
+	BlockedBy []string        `json:"blockedBy,omitempty"`
+	Tags      []string        `json:"tags,omitempty"`
+	// Attachments are added and removed through the attachments of the ToDo
+	Attachments []Attachment `json:"attachments,omitempty" schema:"readonly"`
+	Due         *time.Time   `json:"due,omitempty"`
+	// Reminders are the times the owner is notified of the ToDo at, until it is completed
+	Reminders []time.Time `json:"reminders,omitempty"`
+	// Recurrence is an RFC 5545 RRULE repeating the ToDo from its due date
+	Recurrence string `json:"recurrence,omitempty" schema:"maxLength=500"`
+	// TimeZone is the IANA time zone occurrences are computed in, UTC if empty
+	TimeZone string `json:"timeZone,omitempty" schema:"maxLength=64"`
+	// Rank orders the ToDos of a list, it is set by moving the ToDo
+	Rank string `json:"rank,omitempty" schema:"readonly"`
+	// CompletedAt is when the ToDo was completed, it is set by the server
+	CompletedAt *time.Time `json:"completedAt,omitempty" schema:"readonly"`
+
+	// ItemsProgress is computed from Items when the ToDo is sent to clients
+	ItemsProgress *Progress `json:"progress,omitempty" dynamodbav:"-" schema:"readonly"`
+}
+
+// StampCompletion sets the time the ToDo was completed at, given its previous version, nil for
+// a new ToDo. The time is kept while the ToDo stays completed and cleared when it is reopened.
+func (t *ToDo) StampCompletion(before *ToDo, now time.Time) {
+	switch {
+	case !t.Completed:
+		t.CompletedAt = nil
+	case before != nil && before.Completed:
+		t.CompletedAt = before.CompletedAt
+	default:
+		completed := now.UTC()
+		t.CompletedAt = &completed
+	}
+}
+
+// RoleOf returns the role the given user has on the ToDo. ToDos created before
+// roles were introduced have no owner, every user can read them but none can change
+// them until a migration gives them an owner.
+func (t *ToDo) RoleOf(user string) Role {
+	if t.Owner == "" {
+		return RoleViewer
+	}
+
+	if t.Owner == user {
+		return RoleOwner
+	}
+
+	return t.Members[user]
 }
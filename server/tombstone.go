@@ -0,0 +1,9 @@
+package server
+
+import "time"
+
+// Tombstone records the deletion of a ToDo, so that clients syncing their changes remove it
+type Tombstone struct {
+	ID      string    `json:"id"`
+	Deleted time.Time `json:"deleted"`
+}
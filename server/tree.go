@@ -0,0 +1,7 @@
+package server
+
+// ToDoNode is a ToDo along with its sub-tasks
+type ToDoNode struct {
+	ToDo
+	Children []*ToDoNode `json:"children"`
+}
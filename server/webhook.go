@@ -0,0 +1,63 @@
+package server
+
+import "time"
+
+// Webhook subscribes a URL to the events of the ToDos of its owner. Deliveries are signed with
+// the secret of the webhook.
+type Webhook struct {
+	ID  string `json:"id" schema:"readonly"`
+	URL string `json:"url" schema:"required,format=uri,maxLength=2000"`
+	// Events filters the delivered events, all events are delivered if empty
+	Events []EventType `json:"events,omitempty"`
+	// Secret is generated if empty, it is only sent back when the webhook is created
+	Secret  string    `json:"secret,omitempty" schema:"minLength=16,maxLength=128"`
+	Owner   string    `json:"owner,omitempty" schema:"readonly"`
+	Created time.Time `json:"created" schema:"readonly"`
+}
+
+// Subscribed reports whether events of the given type are delivered to the webhook
+func (w *Webhook) Subscribed(t EventType) bool {
+
+	if len(w.Events) == 0 || t == EventPing {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeliveryStatus is the state of the delivery of an event to a webhook
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is the status of deliveries waiting for their next attempt
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded is the status of deliveries the webhook accepted
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed is the status of failed deliveries which are not retried, such as pings
+	DeliveryFailed DeliveryStatus = "failed"
+	// DeliveryDead is the status of deliveries which failed every attempt, they are moved to the
+	// dead-letter store
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Delivery is the delivery of an event to a webhook, with the outcome of its last attempt
+type Delivery struct {
+	WebhookID   string         `json:"webhookId"`
+	ID          string         `json:"id"`
+	EventID     string         `json:"eventId"`
+	EventType   EventType      `json:"eventType"`
+	Payload     string         `json:"payload"`
+	Status      DeliveryStatus `json:"status"`
+	Attempts    int            `json:"attempts"`
+	StatusCode  int            `json:"statusCode,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Created     time.Time      `json:"created"`
+	LastAttempt *time.Time     `json:"lastAttempt,omitempty"`
+	NextAttempt *time.Time     `json:"nextAttempt,omitempty"`
+}
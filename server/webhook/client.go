@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrForbiddenAddress is returned when a webhook resolves to an address which is not public
+var ErrForbiddenAddress = errors.New("address is not public")
+
+// privateNetworks are the ranges of addresses which are not reachable from the internet, on
+// top of the loopback, link-local and unspecified addresses. They are listed rather than checked
+// with net.IP.IsPrivate, which needs Go 1.17.
+var privateNetworks = parseNetworks(
+	// "This" network, which some stacks route to the host itself
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	// Carrier-grade NAT
+	"100.64.0.0/10",
+	// Benchmarking
+	"198.18.0.0/15",
+	// Multicast, then reserved up to the broadcast address
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"fc00::/7",
+	// IPv6 multicast
+	"ff00::/8",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = n
+	}
+
+	return networks
+}
+
+// NewClient returns an HTTP client delivering events to webhooks. It does not follow redirects
+// and only connects to public addresses, checked once the host is resolved, so that webhooks
+// cannot reach the network of the service or its metadata endpoint.
+func NewClient(timeout time.Duration) *http.Client {
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: checkAddress,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkAddress refuses to connect to an address which is not public
+func checkAddress(network, address string, _ syscall.RawConn) error {
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !public(ip) {
+		return errors.Wrap(ErrForbiddenAddress, host)
+	}
+
+	return nil
+}
+
+// public reports whether an IP address is reachable from the internet
+func public(ip net.IP) bool {
+
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return false
+	}
+
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
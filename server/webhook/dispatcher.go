@@ -0,0 +1,243 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/database"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// MaxAttempts is the number of attempts made to deliver an event before it is dead-lettered
+	MaxAttempts = 8
+	// baseBackoff is the delay before the second attempt, it doubles after each failed attempt
+	baseBackoff = 30 * time.Second
+	// maxBackoff is the longest delay between two attempts
+	maxBackoff = 6 * time.Hour
+	// maxErrorLength is the length of the response body kept as the error of a failed attempt
+	maxErrorLength = 512
+)
+
+// Backoff returns the delay before the next attempt of a delivery which failed attempts times
+func Backoff(attempts int) time.Duration {
+
+	d := baseBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+
+	return d
+}
+
+// Dispatcher delivers events to the webhooks subscribed to them, retries failed deliveries with
+// exponential backoff and moves them to the dead-letter store after MaxAttempts attempts
+type Dispatcher struct {
+	hooks       database.WebhookRepo
+	deliveries  database.DeliveryRepo
+	deadLetters database.DeadLetterRepo
+	client      *http.Client
+}
+
+// NewDispatcher returns a dispatcher sending deliveries with the given HTTP client
+func NewDispatcher(hooks database.WebhookRepo, deliveries database.DeliveryRepo, deadLetters database.DeadLetterRepo, client *http.Client) *Dispatcher {
+	return &Dispatcher{
+		hooks:       hooks,
+		deliveries:  deliveries,
+		deadLetters: deadLetters,
+		client:      client,
+	}
+}
+
+// Dispatch makes a first attempt to deliver an event to each webhook of its owner subscribed to it
+func (d *Dispatcher) Dispatch(e server.Event) error {
+
+	hooks, err := d.hooks.GetByOwner(e.Owner)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrapf(err, "Could not marshal event %s", e.ID)
+	}
+
+	for i := range hooks {
+
+		if !hooks[i].Subscribed(e.Type) {
+			continue
+		}
+
+		delivery := &server.Delivery{
+			WebhookID: hooks[i].ID,
+			EventID:   e.ID,
+			EventType: e.Type,
+			Payload:   string(payload),
+		}
+
+		if err := d.attempt(&hooks[i], delivery, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Retry attempts up to limit deliveries whose next attempt is due, and returns how many were due
+func (d *Dispatcher) Retry(limit int) (int, error) {
+
+	due, err := d.deliveries.Due(time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range due {
+
+		hook, err := d.hooks.Get(due[i].WebhookID)
+		if err != nil {
+			return 0, err
+		}
+
+		if hook == nil {
+			due[i].Status = server.DeliveryFailed
+			due[i].Error = "webhook was deleted"
+			due[i].NextAttempt = nil
+			if err := d.deliveries.Save(&due[i]); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if err := d.attempt(hook, &due[i], true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(due), nil
+}
+
+// Ping delivers a ping event to a webhook once, without retrying it
+func (d *Dispatcher) Ping(hook *server.Webhook) (*server.Delivery, error) {
+
+	e := server.Event{
+		ID:      uuid.NewV4().String(),
+		Type:    server.EventPing,
+		Version: server.EventVersion,
+		Time:    time.Now(),
+		Owner:   hook.Owner,
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not marshal event %s", e.ID)
+	}
+
+	delivery := &server.Delivery{
+		WebhookID: hook.ID,
+		EventID:   e.ID,
+		EventType: e.Type,
+		Payload:   string(payload),
+	}
+
+	if err := d.attempt(hook, delivery, false); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// attempt sends a delivery and records the outcome. A failed delivery is scheduled for another
+// attempt if retry is set, and dead-lettered after its last attempt.
+func (d *Dispatcher) attempt(hook *server.Webhook, delivery *server.Delivery, retry bool) error {
+
+	now := time.Now()
+
+	// New deliveries are saved before the first attempt to get their ID, and are retried if the
+	// outcome of the attempt cannot be recorded
+	if delivery.ID == "" {
+		delivery.Status = server.DeliveryPending
+		if retry {
+			next := now.Add(Backoff(1))
+			delivery.NextAttempt = &next
+		}
+		if err := d.deliveries.Save(delivery); err != nil {
+			return err
+		}
+	}
+
+	delivery.Attempts++
+	delivery.LastAttempt = &now
+	delivery.NextAttempt = nil
+	delivery.Error = ""
+
+	code, err := d.send(hook, delivery, now)
+	delivery.StatusCode = code
+
+	switch {
+	case err == nil:
+		delivery.Status = server.DeliverySucceeded
+	case !retry:
+		delivery.Status = server.DeliveryFailed
+		delivery.Error = err.Error()
+	case delivery.Attempts >= MaxAttempts:
+		delivery.Status = server.DeliveryDead
+		delivery.Error = err.Error()
+	default:
+		next := now.Add(Backoff(delivery.Attempts))
+		delivery.Status = server.DeliveryPending
+		delivery.Error = err.Error()
+		delivery.NextAttempt = &next
+	}
+
+	if err := d.deliveries.Save(delivery); err != nil {
+		return err
+	}
+
+	if delivery.Status == server.DeliveryDead {
+		return d.deadLetters.Save(delivery)
+	}
+
+	return nil
+}
+
+// send posts the payload of a delivery to a webhook and returns the status code of the response
+func (d *Dispatcher) send(hook *server.Webhook, delivery *server.Delivery, now time.Time) (int, error) {
+
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return resp.StatusCode, errors.Errorf("webhook responded %d: %s", resp.StatusCode, msg)
+	}
+
+	return resp.StatusCode, nil
+}
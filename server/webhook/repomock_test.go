@@ -0,0 +1,85 @@
+package webhook_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+)
+
+// WebhookRepoMock is used to mock a webhook repository
+type WebhookRepoMock struct {
+	Hooks map[string]*server.Webhook
+}
+
+// Get returns a webhook by its ID
+func (m *WebhookRepoMock) Get(id string) (*server.Webhook, error) {
+	w, ok := m.Hooks[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *w
+	return &c, nil
+}
+
+// GetByOwner returns the webhooks of a user
+func (m *WebhookRepoMock) GetByOwner(owner string) ([]server.Webhook, error) {
+	hooks := []server.Webhook{}
+	for _, w := range m.Hooks {
+		if w.Owner == owner {
+			hooks = append(hooks, *w)
+		}
+	}
+	return hooks, nil
+}
+
+// Save creates or updates a webhook
+func (m *WebhookRepoMock) Save(webhook *server.Webhook) error {
+	c := *webhook
+	m.Hooks[webhook.ID] = &c
+	return nil
+}
+
+// Delete permanently removes a webhook
+func (m *WebhookRepoMock) Delete(id string) error {
+	delete(m.Hooks, id)
+	return nil
+}
+
+// DeliveryRepoMock is used to mock a delivery and a dead-letter repository
+type DeliveryRepoMock struct {
+	Deliveries map[string]*server.Delivery
+}
+
+// List returns all the deliveries of a webhook
+func (m *DeliveryRepoMock) List(webhookID, cursor string, limit int) ([]server.Delivery, string, error) {
+	page := []server.Delivery{}
+	for _, d := range m.Deliveries {
+		if d.WebhookID == webhookID {
+			page = append(page, *d)
+		}
+	}
+	return page, "", nil
+}
+
+// Due returns the pending deliveries due at now
+func (m *DeliveryRepoMock) Due(now time.Time, limit int) ([]server.Delivery, error) {
+	due := []server.Delivery{}
+	for _, d := range m.Deliveries {
+		if d.Status == server.DeliveryPending && !d.NextAttempt.After(now) {
+			due = append(due, *d)
+		}
+	}
+	return due, nil
+}
+
+// Save creates or updates a delivery
+func (m *DeliveryRepoMock) Save(delivery *server.Delivery) error {
+	if delivery.ID == "" {
+		delivery.ID = fmt.Sprintf("d%03d", len(m.Deliveries))
+		delivery.Created = time.Now()
+	}
+	c := *delivery
+	m.Deliveries[delivery.ID] = &c
+	return nil
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader holds the signature of a delivery, sha256=<hex HMAC-SHA256>
+	SignatureHeader = "X-Todo-Signature"
+	// TimestampHeader holds the Unix time a delivery was signed at
+	TimestampHeader = "X-Todo-Timestamp"
+	// EventHeader holds the type of the delivered event
+	EventHeader = "X-Todo-Event"
+	// DeliveryHeader holds the ID of the delivery, which is the same for every attempt
+	DeliveryHeader = "X-Todo-Delivery"
+)
+
+// Sign returns the signature of a delivery, the HMAC-SHA256 of the timestamp and the body joined
+// by a dot, keyed by the secret of the webhook. Signing the timestamp lets receivers reject
+// replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether a delivery was signed with the secret less than tolerance before now
+func Verify(secret, signature string, timestamp int64, body []byte, now time.Time, tolerance time.Duration) bool {
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age < -tolerance || age > tolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
@@ -0,0 +1,295 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massimoselvi/serverless-todo-api-go/server"
+	"github.com/massimoselvi/serverless-todo-api-go/server/webhook"
+)
+
+const (
+	testOwner  = "3b0f3a3e-6a43-4c55-8e0e-2b2a1c9d7f10"
+	testSecret = "0123456789abcdef0123456789abcdef"
+)
+
+func TestWebhook(t *testing.T) {
+	t.Run("VerifySignature", testVerifySignature)
+	t.Run("Backoff", testBackoff)
+	t.Run("DispatchSigned", testDispatchSigned)
+	t.Run("DispatchFiltersEvents", testDispatchFiltersEvents)
+	t.Run("DispatchFailureScheduled", testDispatchFailureScheduled)
+	t.Run("RetryDeadLetters", testRetryDeadLetters)
+	t.Run("PingNotRetried", testPingNotRetried)
+	t.Run("ClientRefusesPrivateAddresses", testClientRefusesPrivateAddresses)
+	t.Run("ClientDoesNotFollowRedirects", testClientDoesNotFollowRedirects)
+}
+
+// receiver is a webhook endpoint recording the requests it receives
+type receiver struct {
+	*httptest.Server
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(status int) *receiver {
+
+	r := &receiver{status: status}
+	r.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		w.WriteHeader(r.status)
+	}))
+
+	return r
+}
+
+func newDispatcher(r *receiver, events ...server.EventType) (*webhook.Dispatcher, *DeliveryRepoMock, *DeliveryRepoMock) {
+
+	hooks := &WebhookRepoMock{Hooks: map[string]*server.Webhook{
+		"hook": {ID: "hook", URL: r.URL, Events: events, Secret: testSecret, Owner: testOwner},
+	}}
+	deliveries := &DeliveryRepoMock{Deliveries: map[string]*server.Delivery{}}
+	dead := &DeliveryRepoMock{Deliveries: map[string]*server.Delivery{}}
+
+	return webhook.NewDispatcher(hooks, deliveries, dead, r.Client()), deliveries, dead
+}
+
+func created() server.Event {
+	return server.Event{ID: "event", Type: server.EventToDoCreated, Version: server.EventVersion, Time: time.Now(), Owner: testOwner}
+}
+
+func only(t *testing.T, m *DeliveryRepoMock) *server.Delivery {
+
+	if len(m.Deliveries) != 1 {
+		t.Fatalf("Expected a delivery, got %d", len(m.Deliveries))
+	}
+
+	for _, d := range m.Deliveries {
+		return d
+	}
+
+	return nil
+}
+
+func testVerifySignature(t *testing.T) {
+
+	now := time.Now()
+	body := []byte(`{"type":"todo.created"}`)
+	sig := webhook.Sign(testSecret, now.Unix(), body)
+
+	if !webhook.Verify(testSecret, sig, now.Unix(), body, now, time.Minute) {
+		t.Fatal("Expected the signature to be valid")
+	}
+
+	if webhook.Verify(testSecret, sig, now.Unix(), []byte(`{}`), now, time.Minute) {
+		t.Fatal("Expected the signature of another body to be invalid")
+	}
+
+	if webhook.Verify(testSecret, sig, now.Unix(), body, now.Add(time.Hour), time.Minute) {
+		t.Fatal("Expected an old signature to be invalid")
+	}
+}
+
+func testBackoff(t *testing.T) {
+
+	if d := webhook.Backoff(1); d != 30*time.Second {
+		t.Fatalf("Expected 30s after the first attempt, got %s", d)
+	}
+
+	if d := webhook.Backoff(3); d != 2*time.Minute {
+		t.Fatalf("Expected 2m after the third attempt, got %s", d)
+	}
+
+	if d := webhook.Backoff(20); d != 6*time.Hour {
+		t.Fatalf("Expected the backoff capped at 6h, got %s", d)
+	}
+}
+
+func testDispatchSigned(t *testing.T) {
+
+	r := newReceiver(http.StatusNoContent)
+	defer r.Close()
+
+	d, deliveries, _ := newDispatcher(r)
+
+	if err := d.Dispatch(created()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.requests) != 1 {
+		t.Fatalf("Expected a request, got %d", len(r.requests))
+	}
+
+	req := r.requests[0]
+	ts, err := strconv.ParseInt(req.Header.Get(webhook.TimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !webhook.Verify(testSecret, req.Header.Get(webhook.SignatureHeader), ts, r.bodies[0], time.Now(), time.Minute) {
+		t.Fatal("Expected a valid signature")
+	}
+
+	var e server.Event
+	if err := json.Unmarshal(r.bodies[0], &e); err != nil || e.ID != "event" {
+		t.Fatalf("Expected the event as body, got %s", r.bodies[0])
+	}
+
+	delivery := only(t, deliveries)
+	if delivery.Status != server.DeliverySucceeded || delivery.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected a succeeded delivery, got %s with %d", delivery.Status, delivery.StatusCode)
+	}
+
+	if req.Header.Get(webhook.DeliveryHeader) != delivery.ID {
+		t.Fatalf("Expected the delivery ID header %s, got %s", delivery.ID, req.Header.Get(webhook.DeliveryHeader))
+	}
+}
+
+func testDispatchFiltersEvents(t *testing.T) {
+
+	r := newReceiver(http.StatusOK)
+	defer r.Close()
+
+	d, deliveries, _ := newDispatcher(r, server.EventToDoDeleted)
+
+	if err := d.Dispatch(created()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.requests) != 0 || len(deliveries.Deliveries) != 0 {
+		t.Fatal("Expected no delivery of an unsubscribed event")
+	}
+}
+
+func testDispatchFailureScheduled(t *testing.T) {
+
+	r := newReceiver(http.StatusInternalServerError)
+	defer r.Close()
+
+	d, deliveries, _ := newDispatcher(r)
+
+	before := time.Now()
+	if err := d.Dispatch(created()); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := only(t, deliveries)
+	if delivery.Status != server.DeliveryPending || delivery.Attempts != 1 || delivery.Error == "" {
+		t.Fatalf("Expected a pending delivery with an error, got %+v", delivery)
+	}
+
+	if delivery.NextAttempt == nil || delivery.NextAttempt.Before(before.Add(30*time.Second)) {
+		t.Fatalf("Expected the next attempt in 30s, got %v", delivery.NextAttempt)
+	}
+}
+
+func testRetryDeadLetters(t *testing.T) {
+
+	r := newReceiver(http.StatusBadGateway)
+	defer r.Close()
+
+	d, deliveries, dead := newDispatcher(r)
+
+	past := time.Now().Add(-time.Second)
+	deliveries.Deliveries["d"] = &server.Delivery{
+		WebhookID:   "hook",
+		ID:          "d",
+		EventType:   server.EventToDoCreated,
+		Payload:     "{}",
+		Status:      server.DeliveryPending,
+		Attempts:    webhook.MaxAttempts - 1,
+		NextAttempt: &past,
+	}
+
+	n, err := d.Retry(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 || len(r.requests) != 1 {
+		t.Fatalf("Expected a due delivery to be attempted, got %d due and %d requests", n, len(r.requests))
+	}
+
+	if s := deliveries.Deliveries["d"].Status; s != server.DeliveryDead {
+		t.Fatalf("Expected the delivery to be dead, got %s", s)
+	}
+
+	if only(t, dead).ID != "d" {
+		t.Fatal("Expected the delivery in the dead-letter store")
+	}
+}
+
+func testPingNotRetried(t *testing.T) {
+
+	r := newReceiver(http.StatusNotFound)
+	defer r.Close()
+
+	d, _, _ := newDispatcher(r, server.EventToDoDeleted)
+
+	delivery, err := d.Ping(&server.Webhook{ID: "hook", URL: r.URL, Secret: testSecret, Owner: testOwner})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.requests[0].Header.Get(webhook.EventHeader) != string(server.EventPing) {
+		t.Fatal("Expected a ping event")
+	}
+
+	if delivery.Status != server.DeliveryFailed || delivery.StatusCode != http.StatusNotFound || delivery.NextAttempt != nil {
+		t.Fatalf("Expected a failed delivery which is not retried, got %+v", delivery)
+	}
+}
+
+func testClientRefusesPrivateAddresses(t *testing.T) {
+
+	r := newReceiver(http.StatusOK)
+	defer r.Close()
+
+	client := webhook.NewClient(time.Second)
+
+	for _, u := range []string{
+		r.URL,
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://192.168.1.1/",
+		"http://0.1.2.3/",
+		"http://100.64.0.1/",
+		"http://198.18.0.1/",
+		"http://224.0.0.251/",
+		"http://255.255.255.255/",
+		"http://[::1]:8080/",
+		"http://[ff0e::1]:8080/",
+	} {
+		resp, err := client.Get(u)
+		if err == nil {
+			resp.Body.Close()
+			t.Fatalf("Expected request to %s to be refused", u)
+		}
+
+		if !strings.Contains(err.Error(), webhook.ErrForbiddenAddress.Error()) {
+			t.Fatalf("Expected %v for %s, got %v", webhook.ErrForbiddenAddress, u, err)
+		}
+	}
+
+	if len(r.requests) != 0 {
+		t.Fatalf("Expected no request to be received, got %d", len(r.requests))
+	}
+}
+
+func testClientDoesNotFollowRedirects(t *testing.T) {
+
+	client := webhook.NewClient(time.Second)
+
+	if err := client.CheckRedirect(nil, nil); err != http.ErrUseLastResponse {
+		t.Fatalf("Expected redirects not to be followed, got %v", err)
+	}
+}